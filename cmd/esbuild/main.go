@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"runtime/debug"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/evanw/esbuild/internal/api_helpers"
 	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/pkg/api"
 	"github.com/evanw/esbuild/pkg/cli"
 )
 
@@ -37,41 +39,61 @@ var helpText = func(colors logger.Colors) string {
   --bundle              Bundle all dependencies into the output files
   --define:K=V          Substitute K with V while parsing
   --external:M          Exclude module M from the bundle (can use * wildcards)
-  --format=...          Output format (iife | cjs | esm, no default when not
-                        bundling, otherwise default is iife when platform
-                        is browser and cjs when platform is node)
+  --format=...          Output format (iife | cjs | esm | umd | system, no
+                        default when not bundling, otherwise default is iife
+                        when platform is browser and cjs when platform is
+                        node)
   --loader:X=L          Use loader L to load file extension X, where L is
-                        one of: js | jsx | ts | tsx | json | text | base64 |
-                        file | dataurl | binary
+                        one of: js | jsx | ts | tsx | html | json | text |
+                        base64 | file | dataurl | binary
   --minify              Minify the output (sets all --minify-* flags)
   --outdir=...          The output directory (for multiple entry points)
   --outfile=...         The output file (for one entry point)
+  --packages=external   Exclude all package imports from the bundle
   --platform=...        Platform target (browser | node | neutral,
                         default browser)
   --serve=...           Start a local HTTP server on this host:port for outputs
   --sourcemap           Emit a source map
-  --splitting           Enable code splitting (currently only for esm)
+  --splitting           Enable code splitting (only for esm and system)
   --target=...          Environment target (e.g. es2017, chrome58, firefox57,
                         safari11, edge16, node10, default esnext)
   --watch               Watch mode: rebuild on file system changes
 
 ` + colors.Bold + `Advanced options:` + colors.Reset + `
+  --alias:X=Y               Import the package path Y instead of the package X
+                            (and X/path becomes Y/path)
   --allow-overwrite         Allow output files to overwrite input files
+  --asset-inline-limit=...  Inline "file" loader files smaller than this many
+                            bytes as data URLs (default 0, i.e. never)
   --asset-names=...         Path template to use for "file" loader files
                             (default "[name]-[hash]")
   --banner:T=...            Text to be prepended to each output file of type T
                             where T is one of: css | js
   --charset=utf8            Do not escape UTF-8 code points
+  --chunk-merge-budget=...  Most bytes of unused code that merging small chunks
+                            may add to an entry point (default 0)
   --chunk-names=...         Path template to use for code splitting chunks
                             (default "[name]-[hash]")
   --color=...               Force use of color terminal escapes (true | false)
+  --config=...              Read options from a JSON file whose keys match the
+                            JS API (command-line flags take precedence, and
+                            repeated flags such as --external: add to it)
   --entry-names=...         Path template to use for entry point output paths
                             (default "[dir]/[name]", can also use "[hash]")
+  --external-global:M=G     Use the global G for the external module M in the
+                            IIFE format and when the UMD format is loaded as
+                            a script
   --footer:T=...            Text to be appended to each output file of type T
                             where T is one of: css | js
-  --global-name=...         The name of the global for the IIFE format
+  --global-name=...         The name of the global for the IIFE and UMD formats
+  --infer-pure-functions    Remove unused calls to functions and classes that
+                            are inferred to have no side effects when bundling
   --inject:F                Import the file F into all input files and
                             automatically replace matching globals with imports
+  --integrity=...           Add Subresource Integrity digests of output files
+                            to the metafile (sha256 | sha384 | sha512)
+  --integrity-map           Also add the digests of chunks loaded with import()
+                            to the metafile outputs that load them
   --jsx-factory=...         What to use for JSX instead of React.createElement
   --jsx-fragment=...        What to use for JSX instead of React.Fragment
   --jsx=...                 Set to "preserve" to disable transforming JSX to JS
@@ -79,16 +101,29 @@ var helpText = func(colors logger.Colors) string {
   --legal-comments=...      Where to place license comments (none | inline |
                             eof | linked | external, default eof when bundling
                             and inline otherwise)
+  --log-format=...          Log message format (text | json, default text)
   --log-level=...           Disable logging (verbose | debug | info | warning |
                             error | silent, default info)
   --log-limit=...           Maximum message count or 0 to disable (default 10)
+  --log-override:X=Y        Use log level Y for messages with ID X (e.g.
+                            --log-override:direct-eval=silent)
   --main-fields=...         Override the main file order in package.json
                             (default "browser,module,main" when platform is
                             browser and "main,module" when platform is node)
+  --manifest                Write "manifest.json" to the output directory with
+                            each entry point's output and dependencies
+  --manual-chunk:N=P        Put files matching the comma-separated globs or
+                            package names P in the code splitting chunk N
+  --max-chunk-size=...      Split larger code splitting chunks along module
+                            boundaries (estimated from input sizes)
   --metafile=...            Write metadata about the build to a JSON file
+  --min-chunk-size=...      Merge smaller code splitting chunks into other
+                            chunks (estimated from input sizes)
   --minify-whitespace       Remove whitespace in output files
   --minify-identifiers      Shorten identifiers in output files
   --minify-syntax           Use equivalent but shorter syntax in output files
+  --minify-inline           Inline single-use constants and small functions
+                            when bundling
   --out-extension:.js=.mjs  Use a custom output extension instead of ".js"
   --outbase=...             The base path used to determine entry point output
                             paths (for multiple entry points)
@@ -98,6 +133,9 @@ var helpText = func(colors logger.Colors) string {
   --resolve-extensions=...  A comma-separated list of implicit extensions
                             (default ".tsx,.ts,.jsx,.js,.css,.json")
   --servedir=...            What to serve in addition to generated output files
+  --size-budget:G=L         Fail the build if output files matching the glob G
+                            are too large (e.g. L = "gzip:30000,brotli:25000")
+  --size-budget-warnings    Make exceeded size budgets warnings, not errors
   --source-root=...         Sets the "sourceRoot" field in generated source maps
   --sourcefile=...          Set the source file for the source map (for stdin)
   --sourcemap=external      Do not link to the source map with a comment
@@ -127,6 +165,9 @@ var helpText = func(colors logger.Colors) string {
   ` + colors.Dim + `# Start a local HTTP server for everything in "www"` + colors.Reset + `
   esbuild app.ts --bundle --servedir=www --outdir=www/js
 
+  ` + colors.Dim + `# Show which files make each output file large (add "--verbose" for why)` + colors.Reset + `
+  esbuild analyze meta.json
+
 `
 }
 
@@ -201,6 +242,11 @@ func main() {
 		return
 	}
 
+	// The "analyze" command prints a report about an existing metafile
+	if len(osArgs) > 0 && osArgs[0] == "analyze" {
+		os.Exit(runAnalyze(osArgs[1:]))
+	}
+
 	// Print help text when there are no arguments
 	isStdinTTY := logger.GetTerminalInfo(os.Stdin).IsTTY
 	if len(osArgs) == 0 && isStdinTTY {
@@ -303,3 +349,61 @@ func main() {
 
 	os.Exit(exitCode)
 }
+
+// This reads a metafile from the path in the arguments, or from stdin if there
+// is no path, and prints a report about the size of each output file
+func runAnalyze(osArgs []string) int {
+	verbose := false
+	path := ""
+	for _, arg := range osArgs {
+		switch {
+		case arg == "--verbose":
+			verbose = true
+
+		case strings.HasPrefix(arg, "--color="), strings.HasPrefix(arg, "--log-level="):
+			// These are handled by the logger
+
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			logger.PrintErrorToStderr(osArgs, fmt.Sprintf("Invalid analyze flag: %q", arg))
+			return 1
+
+		case path == "":
+			path = arg
+
+		default:
+			logger.PrintErrorToStderr(osArgs, "Only one metafile can be analyzed at a time")
+			return 1
+		}
+	}
+
+	var bytes []byte
+	var err error
+	if path == "" || path == "-" {
+		bytes, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		bytes, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		logger.PrintErrorToStderr(osArgs, fmt.Sprintf("Could not read metafile: %s", err.Error()))
+		return 1
+	}
+
+	// The report is only empty if the metafile couldn't be parsed
+	isEmpty := false
+	logger.PrintText(os.Stdout, logger.LevelSilent, osArgs, func(colors logger.Colors) string {
+		text := api.AnalyzeMetafile(string(bytes), api.AnalyzeMetafileOptions{
+			Color:   colors.Reset != "",
+			Verbose: verbose,
+		})
+		if text == "" {
+			isEmpty = true
+			return ""
+		}
+		return text + "\n"
+	})
+	if isEmpty {
+		logger.PrintErrorToStderr(osArgs, "The metafile is not valid or does not contain any outputs")
+		return 1
+	}
+	return 0
+}
@@ -952,6 +952,7 @@ func encodeMessages(msgs []api.Message) []interface{} {
 	values := make([]interface{}, len(msgs))
 	for i, msg := range msgs {
 		value := map[string]interface{}{
+			"id":         msg.ID,
 			"pluginName": msg.PluginName,
 			"text":       msg.Text,
 			"location":   encodeLocation(msg.Location),
@@ -1001,7 +1002,9 @@ func decodeMessages(values []interface{}) []api.Message {
 	msgs := make([]api.Message, len(values))
 	for i, value := range values {
 		obj := value.(map[string]interface{})
+		id, _ := obj["id"].(string)
 		msg := api.Message{
+			ID:         id,
 			PluginName: obj["pluginName"].(string),
 			Text:       obj["text"].(string),
 			Location:   decodeLocation(obj["location"]),
@@ -1040,7 +1043,10 @@ func decodeLocationToPrivate(value interface{}) *logger.MsgLocation {
 }
 
 func decodeMessageToPrivate(obj map[string]interface{}) logger.Msg {
+	id, _ := obj["id"].(string)
+	msgID, _ := logger.StringToMsgID(id)
 	msg := logger.Msg{
+		ID:         msgID,
 		PluginName: obj["pluginName"].(string),
 		Data: logger.MsgData{
 			Text:       obj["text"].(string),
@@ -0,0 +1,615 @@
+// Package brotli implements a compressor for the brotli format described in
+// RFC 7932. Go's standard library only comes with a decompressor for gzip and
+// friends, and this is used to measure the brotli-compressed size of output
+// files for size budgets.
+//
+// This is deliberately simple compared to the reference encoder: it uses a
+// hash chain to find LZ77 matches and a single prefix code per meta-block for
+// literals, commands, and distances. It doesn't use context modeling, block
+// splitting, or the built-in static dictionary, so compressed files are
+// somewhat larger than what "brotli --best" produces. The output is a valid
+// brotli stream that any brotli decompressor can read.
+package brotli
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// The sliding window is 4mb, which is the reference encoder's default
+	windowBits  = 22
+	maxDistance = (1 << windowBits) - 16
+
+	// Each meta-block gets its own prefix codes, which lets them adapt to
+	// changes in the data. This must be at most 2^24 bytes.
+	maxBlockSize = 1 << 18
+
+	hashBits   = 17
+	minMatch   = 4
+	maxChain   = 64
+	niceLength = 258
+
+	literalAlphabetSize  = 256
+	commandAlphabetSize  = 704
+	distanceAlphabetSize = 64 // 16 short codes, no direct codes, 48 long codes
+
+	maxCodeLength     = 15
+	maxCodeLengthCode = 5
+	repeatNonZeroCode = 16
+	repeatZeroCode    = 17
+	numCodeLengthCode = 18
+)
+
+// Compress returns the brotli-compressed form of the data
+func Compress(data []byte) []byte {
+	w := bitWriter{}
+
+	// Stream header: the window size is 2^(17 + 5) - 16
+	w.writeBits(4, 1|((windowBits-17)<<1))
+
+	if len(data) == 0 {
+		w.writeBits(2, 3) // ISLAST and ISLASTEMPTY
+		return w.finish()
+	}
+
+	m := newMatcher(data)
+	lastDistance := 4 // The last entry of the initial distance ring buffer
+	for start := 0; start < len(data); start += maxBlockSize {
+		end := start + maxBlockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		commands := m.commands(start, end)
+		lastDistance = writeMetaBlock(&w, data, start, end, commands, lastDistance, end == len(data))
+	}
+	return w.finish()
+}
+
+type command struct {
+	insertLength int
+	copyLength   int // Zero for a final command that only inserts literals
+	distance     int
+}
+
+type matcher struct {
+	data []byte
+	head []int32
+	prev []int32
+	next int // Positions before this have been added to the hash chains
+}
+
+func newMatcher(data []byte) *matcher {
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	return &matcher{data: data, head: head, prev: make([]int32, len(data))}
+}
+
+func (m *matcher) hash(i int) uint32 {
+	return (binary.LittleEndian.Uint32(m.data[i:]) * 0x1E35A7BD) >> (32 - hashBits)
+}
+
+// Adds all positions up to "end" to the hash chains
+func (m *matcher) insertUpTo(end int) {
+	if limit := len(m.data) - minMatch + 1; end > limit {
+		end = limit
+	}
+	for ; m.next < end; m.next++ {
+		h := m.hash(m.next)
+		m.prev[m.next] = m.head[h]
+		m.head[h] = int32(m.next)
+	}
+}
+
+// Returns the longest match for the data at "i" that doesn't go past "end"
+func (m *matcher) longestMatch(i int, end int) (length int, distance int) {
+	if end-i < minMatch {
+		return 0, 0
+	}
+	m.insertUpTo(i)
+	data := m.data
+	chain := maxChain
+	for candidate := m.head[m.hash(i)]; candidate >= 0 && chain > 0; candidate = m.prev[candidate] {
+		c := int(candidate)
+		if i-c > maxDistance {
+			break
+		}
+		chain--
+
+		// Quickly reject candidates that can't be longer than the best match
+		if length > 0 && data[c+length] != data[i+length] {
+			continue
+		}
+		n := 0
+		for i+n < end && data[c+n] == data[i+n] {
+			n++
+		}
+		if n > length {
+			length, distance = n, i-c
+			if n >= niceLength || i+n == end {
+				break
+			}
+		}
+	}
+	if length < minMatch {
+		return 0, 0
+	}
+	return
+}
+
+// Splits the data from "start" to "end" into commands using lazy matching,
+// which delays a match by one byte if the next byte has a longer match
+func (m *matcher) commands(start int, end int) []command {
+	var commands []command
+	literalStart := start
+	i := start
+	length, distance := m.longestMatch(i, end)
+	for i < end {
+		if length == 0 {
+			i++
+			length, distance = m.longestMatch(i, end)
+			continue
+		}
+		if length < niceLength {
+			if nextLength, nextDistance := m.longestMatch(i+1, end); nextLength > length {
+				i++
+				length, distance = nextLength, nextDistance
+				continue
+			}
+		}
+		commands = append(commands, command{insertLength: i - literalStart, copyLength: length, distance: distance})
+		i += length
+		literalStart = i
+		length, distance = m.longestMatch(i, end)
+	}
+	if literalStart < end {
+		commands = append(commands, command{insertLength: end - literalStart})
+	}
+	return commands
+}
+
+// The bases and extra bit counts for insert length codes (RFC 7932, section 5)
+var insertLengthBase = [24]int{0, 1, 2, 3, 4, 5, 6, 8, 10, 14, 18, 26, 34, 50, 66, 98, 130, 194, 322, 578, 1090, 2114, 6210, 22594}
+var insertLengthExtra = [24]uint{0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 7, 8, 9, 10, 12, 14, 24}
+
+// The bases and extra bit counts for copy length codes (RFC 7932, section 5)
+var copyLengthBase = [24]int{2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 14, 18, 22, 30, 38, 54, 70, 102, 134, 198, 326, 582, 1094, 2118}
+var copyLengthExtra = [24]uint{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 7, 8, 9, 10, 24}
+
+func lengthCode(bases *[24]int, length int) int {
+	code := 23
+	for bases[code] > length {
+		code--
+	}
+	return code
+}
+
+// Combines an insert length code and a copy length code into a command code
+// using the table in RFC 7932, section 5. The first 128 command codes also
+// mean that the command reuses the last distance.
+func commandCode(insertCode int, copyCode int, useLastDistance bool) int {
+	low := (copyCode & 7) | ((insertCode & 7) << 3)
+	if useLastDistance && insertCode < 8 && copyCode < 16 {
+		if copyCode < 8 {
+			return low
+		}
+		return low | 64
+	}
+	cell := [3][3]int{{128, 192, 384}, {256, 320, 512}, {448, 576, 640}}[insertCode>>3][copyCode>>3]
+	return cell | low
+}
+
+// Returns the distance code and its extra bits for a distance that doesn't
+// use the distance ring buffer, assuming no postfix bits and no direct codes
+func distanceCode(distance int) (code int, extraCount uint, extra int) {
+	d := distance + 3
+	bucket := uint(bits.Len(uint(d))) - 2
+	prefix := (d >> bucket) & 1
+	return 16 + 2*(int(bucket)-1) + prefix, bucket, d - ((2 + prefix) << bucket)
+}
+
+type encodedCommand struct {
+	code          int
+	insertCode    int
+	copyCode      int
+	distanceCode  int // -1 if no distance is written
+	distanceCount uint
+	distanceExtra int
+}
+
+func writeMetaBlock(w *bitWriter, data []byte, start int, end int, commands []command, lastDistance int, isLast bool) int {
+	literalHistogram := make([]uint32, literalAlphabetSize)
+	commandHistogram := make([]uint32, commandAlphabetSize)
+	distanceHistogram := make([]uint32, distanceAlphabetSize)
+
+	encoded := make([]encodedCommand, len(commands))
+	pos := start
+	for i, cmd := range commands {
+		for _, c := range data[pos : pos+cmd.insertLength] {
+			literalHistogram[c]++
+		}
+		pos += cmd.insertLength + cmd.copyLength
+
+		e := encodedCommand{insertCode: lengthCode(&insertLengthBase, cmd.insertLength), distanceCode: -1}
+		if cmd.copyLength == 0 {
+			// The decoder stops at the end of the meta-block before copying, so
+			// this uses copy length code 0, which has no extra bits
+			e.code = commandCode(e.insertCode, 0, true)
+		} else {
+			e.copyCode = lengthCode(&copyLengthBase, cmd.copyLength)
+			if cmd.distance == lastDistance {
+				e.code = commandCode(e.insertCode, e.copyCode, true)
+				if e.code >= 128 {
+					e.distanceCode = 0
+				}
+			} else {
+				e.code = commandCode(e.insertCode, e.copyCode, false)
+				e.distanceCode, e.distanceCount, e.distanceExtra = distanceCode(cmd.distance)
+				lastDistance = cmd.distance
+			}
+			if e.distanceCode >= 0 {
+				distanceHistogram[e.distanceCode]++
+			}
+		}
+		commandHistogram[e.code]++
+		encoded[i] = e
+	}
+
+	// Meta-block header
+	length := end - start
+	nibbles := 4
+	for nibbles < 6 && (length-1)>>(4*nibbles) != 0 {
+		nibbles++
+	}
+	if isLast {
+		w.writeBits(2, 1) // ISLAST but not ISLASTEMPTY
+	} else {
+		w.writeBits(1, 0)
+	}
+	w.writeBits(2, uint64(nibbles-4))
+	w.writeBits(uint(4*nibbles), uint64(length-1))
+	if !isLast {
+		w.writeBits(1, 0) // Not ISUNCOMPRESSED
+	}
+	w.writeBits(3, 0) // One block type each for literals, commands, and distances
+	w.writeBits(6, 0) // NPOSTFIX and NDIRECT are both zero
+	w.writeBits(2, 0) // The context mode for literals doesn't matter with one tree
+	w.writeBits(2, 0) // One prefix code each for literals and distances
+
+	literalCode := buildAndStorePrefixCode(w, literalHistogram)
+	commandPrefixCode := buildAndStorePrefixCode(w, commandHistogram)
+	distancePrefixCode := buildAndStorePrefixCode(w, distanceHistogram)
+
+	pos = start
+	for i, cmd := range commands {
+		e := encoded[i]
+		commandPrefixCode.write(w, e.code)
+		w.writeBits(insertLengthExtra[e.insertCode], uint64(cmd.insertLength-insertLengthBase[e.insertCode]))
+		if cmd.copyLength > 0 {
+			w.writeBits(copyLengthExtra[e.copyCode], uint64(cmd.copyLength-copyLengthBase[e.copyCode]))
+		}
+		for _, c := range data[pos : pos+cmd.insertLength] {
+			literalCode.write(w, int(c))
+		}
+		if e.distanceCode >= 0 {
+			distancePrefixCode.write(w, e.distanceCode)
+			w.writeBits(e.distanceCount, uint64(e.distanceExtra))
+		}
+		pos += cmd.insertLength + cmd.copyLength
+	}
+	return lastDistance
+}
+
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16 // Bit-reversed since prefix codes are read starting with the most significant bit
+}
+
+func (c prefixCode) write(w *bitWriter, symbol int) {
+	w.writeBits(uint(c.lengths[symbol]), uint64(c.codes[symbol]))
+}
+
+func newPrefixCode(lengths []uint8) prefixCode {
+	var count [maxCodeLength + 1]uint16
+	for _, length := range lengths {
+		count[length]++
+	}
+	count[0] = 0
+	var next [maxCodeLength + 1]uint16
+	code := uint16(0)
+	for length := 1; length <= maxCodeLength; length++ {
+		code = (code + count[length-1]) << 1
+		next[length] = code
+	}
+	codes := make([]uint16, len(lengths))
+	for symbol, length := range lengths {
+		if length > 0 {
+			codes[symbol] = bits.Reverse16(next[length]) >> (16 - length)
+			next[length]++
+		}
+	}
+	return prefixCode{lengths: lengths, codes: codes}
+}
+
+// Computes the code lengths of a prefix code with at most "maxLength" bits
+// per symbol. If the result is too deep, small counts are raised and the tree
+// is built again, which is what the reference encoder does.
+func codeLengths(histogram []uint32, maxLength uint8) []uint8 {
+	type node struct {
+		count       uint32
+		left, right int // Children for internal nodes, or -1 and the symbol for leaves
+	}
+
+	lengths := make([]uint8, len(histogram))
+	for minCount := uint32(1); ; minCount *= 2 {
+		var nodes []node
+		for symbol, count := range histogram {
+			if count > 0 {
+				if count < minCount {
+					count = minCount
+				}
+				nodes = append(nodes, node{count: count, left: -1, right: symbol})
+			}
+		}
+		if len(nodes) == 1 {
+			lengths[nodes[0].right] = 0
+			return lengths
+		}
+		sort.SliceStable(nodes, func(i int, j int) bool {
+			return nodes[i].count < nodes[j].count
+		})
+
+		// Merge the two smallest nodes until one is left. Leaves are sorted and
+		// internal nodes are created in order, so both queues stay sorted.
+		leafCount := len(nodes)
+		leaf, internal := 0, leafCount
+		smallest := func() int {
+			if leaf < leafCount && (internal == len(nodes) || nodes[leaf].count <= nodes[internal].count) {
+				leaf++
+				return leaf - 1
+			}
+			internal++
+			return internal - 1
+		}
+		for len(nodes) < 2*leafCount-1 {
+			a := smallest()
+			b := smallest()
+			nodes = append(nodes, node{count: nodes[a].count + nodes[b].count, left: a, right: b})
+		}
+
+		// Walk down from the root, which is the last node
+		depths := make([]uint8, len(nodes))
+		tooDeep := false
+		for i := len(nodes) - 1; i >= leafCount; i-- {
+			n := nodes[i]
+			depths[n.left] = depths[i] + 1
+			depths[n.right] = depths[i] + 1
+		}
+		for i := 0; i < leafCount; i++ {
+			if depths[i] > maxLength {
+				tooDeep = true
+				break
+			}
+			lengths[nodes[i].right] = depths[i]
+		}
+		if !tooDeep {
+			return lengths
+		}
+	}
+}
+
+// The order that code length code lengths are stored in, and the fixed code
+// used to store them (RFC 7932, section 3.5)
+var codeLengthCodeOrder = [numCodeLengthCode]int{1, 2, 3, 4, 0, 5, 17, 6, 16, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+var codeLengthCodeLengthBits = [6]uint{2, 4, 3, 2, 2, 4}
+var codeLengthCodeLengthSymbols = [6]uint64{0, 7, 3, 2, 1, 15}
+
+// Builds a prefix code for the histogram and writes it to the stream
+func buildAndStorePrefixCode(w *bitWriter, histogram []uint32) prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) == 0 {
+		// The code is never used, but it still has to be stored
+		used = append(used, 0)
+		histogram = append([]uint32{}, histogram...)
+		histogram[0] = 1
+	}
+	lengths := codeLengths(histogram, maxCodeLength)
+
+	// Simple prefix codes list up to four symbols with implied lengths
+	if len(used) <= 4 {
+		alphabetBits := uint(bits.Len(uint(len(histogram) - 1)))
+		sort.SliceStable(used, func(i int, j int) bool {
+			return lengths[used[i]] < lengths[used[j]]
+		})
+		w.writeBits(2, 1)
+		w.writeBits(2, uint64(len(used)-1))
+		for _, symbol := range used {
+			w.writeBits(alphabetBits, uint64(symbol))
+		}
+		if len(used) == 4 {
+			if lengths[used[0]] == 1 {
+				w.writeBits(1, 1) // The lengths are 1, 2, 3, 3
+			} else {
+				w.writeBits(1, 0) // The lengths are 2, 2, 2, 2
+			}
+		}
+		return newPrefixCode(lengths)
+	}
+
+	// Otherwise the lengths are run-length encoded using another prefix code
+	var tokens []uint8
+	var extras []uint8
+	end := len(lengths)
+	for end > 0 && lengths[end-1] == 0 {
+		end--
+	}
+	previous := uint8(8) // The initial value for repeating non-zero lengths
+	for i := 0; i < end; {
+		value := lengths[i]
+		repeat := 1
+		for i+repeat < end && lengths[i+repeat] == value {
+			repeat++
+		}
+		if value == 0 {
+			tokens, extras = appendZeroRepeats(tokens, extras, repeat)
+		} else {
+			tokens, extras = appendNonZeroRepeats(tokens, extras, previous, value, repeat)
+			previous = value
+		}
+		i += repeat
+	}
+
+	tokenHistogram := make([]uint32, numCodeLengthCode)
+	for _, token := range tokens {
+		tokenHistogram[token]++
+	}
+	tokenLengths := codeLengths(tokenHistogram, maxCodeLengthCode)
+	tokenCount := 0
+	for _, count := range tokenHistogram {
+		if count > 0 {
+			tokenCount++
+		}
+	}
+
+	// With a single token the decoder reads all 18 code length code lengths
+	// and then reads no bits for each token, so give it any non-zero length
+	stored := numCodeLengthCode
+	if tokenCount == 1 {
+		for token, count := range tokenHistogram {
+			if count > 0 {
+				tokenLengths[token] = 1
+			}
+		}
+	} else {
+		for stored > 0 && tokenLengths[codeLengthCodeOrder[stored-1]] == 0 {
+			stored--
+		}
+	}
+	skip := 0
+	if tokenLengths[codeLengthCodeOrder[0]] == 0 && tokenLengths[codeLengthCodeOrder[1]] == 0 {
+		skip = 2
+		if tokenLengths[codeLengthCodeOrder[2]] == 0 {
+			skip = 3
+		}
+	}
+	w.writeBits(2, uint64(skip))
+	for _, token := range codeLengthCodeOrder[skip:stored] {
+		length := tokenLengths[token]
+		w.writeBits(codeLengthCodeLengthBits[length], codeLengthCodeLengthSymbols[length])
+	}
+	if tokenCount == 1 {
+		for token := range tokenLengths {
+			tokenLengths[token] = 0
+		}
+	}
+
+	tokenCode := newPrefixCode(tokenLengths)
+	for i, token := range tokens {
+		tokenCode.write(w, int(token))
+		switch token {
+		case repeatNonZeroCode:
+			w.writeBits(2, uint64(extras[i]))
+		case repeatZeroCode:
+			w.writeBits(3, uint64(extras[i]))
+		}
+	}
+	return newPrefixCode(lengths)
+}
+
+// Repeat codes that follow each other combine their counts, so a long run is
+// written as several repeat codes with the most significant digit first
+func appendZeroRepeats(tokens []uint8, extras []uint8, repeat int) ([]uint8, []uint8) {
+	if repeat == 11 {
+		tokens, extras = append(tokens, 0), append(extras, 0)
+		repeat--
+	}
+	if repeat < 3 {
+		for ; repeat > 0; repeat-- {
+			tokens, extras = append(tokens, 0), append(extras, 0)
+		}
+		return tokens, extras
+	}
+	start := len(tokens)
+	repeat -= 3
+	for {
+		tokens, extras = append(tokens, repeatZeroCode), append(extras, uint8(repeat&7))
+		repeat >>= 3
+		if repeat == 0 {
+			break
+		}
+		repeat--
+	}
+	reverse(tokens[start:])
+	reverse(extras[start:])
+	return tokens, extras
+}
+
+func appendNonZeroRepeats(tokens []uint8, extras []uint8, previous uint8, value uint8, repeat int) ([]uint8, []uint8) {
+	if previous != value {
+		tokens, extras = append(tokens, value), append(extras, 0)
+		repeat--
+	}
+	if repeat == 7 {
+		tokens, extras = append(tokens, value), append(extras, 0)
+		repeat--
+	}
+	if repeat < 3 {
+		for ; repeat > 0; repeat-- {
+			tokens, extras = append(tokens, value), append(extras, 0)
+		}
+		return tokens, extras
+	}
+	start := len(tokens)
+	repeat -= 3
+	for {
+		tokens, extras = append(tokens, repeatNonZeroCode), append(extras, uint8(repeat&3))
+		repeat >>= 2
+		if repeat == 0 {
+			break
+		}
+		repeat--
+	}
+	reverse(tokens[start:])
+	reverse(extras[start:])
+	return tokens, extras
+}
+
+func reverse(slice []uint8) {
+	for i, j := 0, len(slice)-1; i < j; i, j = i+1, j-1 {
+		slice[i], slice[j] = slice[j], slice[i]
+	}
+}
+
+// Brotli packs bits starting with the least significant bit of each byte
+type bitWriter struct {
+	bytes []byte
+	bits  uint64
+	count uint
+}
+
+func (w *bitWriter) writeBits(count uint, value uint64) {
+	w.bits |= value << w.count
+	w.count += count
+	for w.count >= 8 {
+		w.bytes = append(w.bytes, byte(w.bits))
+		w.bits >>= 8
+		w.count -= 8
+	}
+}
+
+func (w *bitWriter) finish() []byte {
+	if w.count > 0 {
+		w.bytes = append(w.bytes, byte(w.bits))
+		w.bits = 0
+		w.count = 0
+	}
+	return w.bytes
+}
@@ -0,0 +1,60 @@
+package brotli
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectCompressed(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		test.AssertEqual(t, hex.EncodeToString(Compress([]byte(contents))), expected)
+	})
+}
+
+// These were checked against the brotli decompressor in node's "zlib" module
+func TestCompress(t *testing.T) {
+	expectCompressed(t, "", "3b")
+	expectCompressed(t, "a", "1b00000020c202810000")
+	expectCompressed(t, "hello hello hello hello", "1b1600000036c6d6ed9fc02118f28464ae2d")
+	expectCompressed(t, "abcabcabcabcabcabcabcabc", "1b17000020c7c2c4629b886e00")
+}
+
+func TestCodeLengths(t *testing.T) {
+	// Fibonacci counts make the deepest possible tree, which must be limited
+	histogram := make([]uint32, 30)
+	a, b := uint32(1), uint32(1)
+	for i := range histogram {
+		histogram[i] = a
+		a, b = b, a+b
+	}
+	lengths := codeLengths(histogram, maxCodeLength)
+
+	// The code must be complete for the decoder to accept it
+	space := 0
+	for _, length := range lengths {
+		if length < 1 || length > maxCodeLength {
+			t.Fatalf("Invalid code length %d", length)
+		}
+		space += 1 << (maxCodeLength - length)
+	}
+	test.AssertEqual(t, space, 1<<maxCodeLength)
+}
+
+func TestDistanceCode(t *testing.T) {
+	// Check that the decoder's formula from RFC 7932, section 4 gives back the
+	// original distance
+	for distance := 1; distance <= maxDistance; distance += 1 + distance/7 {
+		code, extraCount, extra := distanceCode(distance)
+		if code < 16 || code >= distanceAlphabetSize || extra < 0 || extra >= 1<<extraCount {
+			t.Fatalf("Invalid distance code %d with %d extra bits %d for distance %d", code, extraCount, extra, distance)
+		}
+		ndistbits := 1 + ((code - 16) >> 1)
+		offset := ((2 + ((code - 16) & 1)) << ndistbits) - 4
+		test.AssertEqual(t, uint(ndistbits), extraCount)
+		test.AssertEqual(t, offset+extra+1, distance)
+	}
+}
@@ -2,6 +2,8 @@ package bundler
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base32"
 	"encoding/base64"
 	"fmt"
@@ -23,6 +25,7 @@ import (
 	"github.com/evanw/esbuild/internal/fs"
 	"github.com/evanw/esbuild/internal/graph"
 	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/html_parser"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/js_lexer"
 	"github.com/evanw/esbuild/internal/js_parser"
@@ -41,6 +44,11 @@ type scannerFile struct {
 	// about this file in JSON format. This is a partial JSON file that will be
 	// fully assembled later.
 	jsonMetadataChunk string
+
+	// If this is a simple CommonJS module that was scope-hoisted like ESM, these
+	// are the options it was parsed with. It's parsed again without hoisting if
+	// another file turns out to be able to observe its exports object.
+	hoistedCommonJSOptions *config.Options
 }
 
 // This is data related to source maps. It's computed in parallel with linking
@@ -159,12 +167,20 @@ func parseFile(args parseArgs) {
 		loader = loaderFromFileExtension(args.options.ExtensionToLoader, base+ext)
 	}
 
+	// Small files from the "file" loader are inlined as data URLs instead
+	isInlinedAsset := false
+	if loader == config.LoaderFile && len(source.Contents) < args.options.AssetInlineLimit {
+		loader = config.LoaderDataURL
+		isInlinedAsset = true
+	}
+
 	result := parseResult{
 		file: scannerFile{
 			inputFile: graph.InputFile{
-				Source:      source,
-				Loader:      loader,
-				SideEffects: args.sideEffects,
+				Source:         source,
+				Loader:         loader,
+				SideEffects:    args.sideEffects,
+				IsInlinedAsset: isInlinedAsset,
 			},
 			pluginData: pluginData,
 		},
@@ -175,18 +191,27 @@ func parseFile(args parseArgs) {
 		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
+		if ast.IsHoistedCommonJS {
+			result.file.hoistedCommonJSOptions = &args.options
+		}
 
 	case config.LoaderJSX:
 		args.options.JSX.Parse = true
 		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
+		if ast.IsHoistedCommonJS {
+			result.file.hoistedCommonJSOptions = &args.options
+		}
 
 	case config.LoaderTS:
 		args.options.TS.Parse = true
 		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
+		if ast.IsHoistedCommonJS {
+			result.file.hoistedCommonJSOptions = &args.options
+		}
 
 	case config.LoaderTSX:
 		args.options.TS.Parse = true
@@ -194,6 +219,9 @@ func parseFile(args parseArgs) {
 		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
+		if ast.IsHoistedCommonJS {
+			result.file.hoistedCommonJSOptions = &args.options
+		}
 
 	case config.LoaderCSS:
 		ast := args.caches.CSSCache.Parse(args.log, source, css_parser.Options{
@@ -204,6 +232,19 @@ func parseFile(args parseArgs) {
 		result.file.inputFile.Repr = &graph.CSSRepr{AST: ast}
 		result.ok = true
 
+	case config.LoaderHTML:
+		// The files that HTML files reference become separate output files, so
+		// there must be an output directory to put them in
+		if args.options.Mode != config.ModeBundle || args.options.AbsOutputFile != "" || args.options.WriteToStdout {
+			tracker := logger.MakeLineColumnTracker(args.importSource)
+			args.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, args.importPathRange,
+				fmt.Sprintf("Cannot load %q without bundling to an output directory", source.PrettyPath))
+			break
+		}
+		ast := html_parser.Parse(args.log, source)
+		result.file.inputFile.Repr = &graph.HTMLRepr{AST: ast}
+		result.ok = true
+
 	case config.LoaderJSON:
 		expr, ok := args.caches.JSONCache.Parse(args.log, source, js_parser.JSONOptions{})
 		ast := js_parser.LazyExportAST(args.log, source, js_parser.OptionsFromConfig(&args.options), expr, "")
@@ -299,7 +340,7 @@ func parseFile(args parseArgs) {
 			message = fmt.Sprintf("Do not know how to load path: %s", source.PrettyPath)
 		}
 		tracker := logger.MakeLineColumnTracker(args.importSource)
-		args.log.AddRangeError(&tracker, args.importPathRange, message)
+		args.log.AddID(logger.MsgID_Bundler_NoLoader, logger.Error, &tracker, args.importPathRange, message)
 	}
 
 	// This must come before we send on the "results" channel to avoid deadlock
@@ -369,7 +410,7 @@ func parseFile(args parseArgs) {
 				}
 
 				// Run the resolver and log an error if the path couldn't be resolved
-				resolveResult, didLogError, debug := runOnResolvePlugins(
+				resolveResult, didLogError, debug := RunOnResolvePlugins(
 					args.options.Plugins,
 					args.res,
 					args.log,
@@ -377,7 +418,7 @@ func parseFile(args parseArgs) {
 					&args.caches.FSCache,
 					&source,
 					record.Range,
-					source.KeyPath.Namespace,
+					source.KeyPath,
 					record.Path.Text,
 					record.Kind,
 					absResolveDir,
@@ -389,7 +430,7 @@ func parseFile(args parseArgs) {
 				// want to waste effort traversing into them
 				if record.Kind == ast.ImportRequireResolve {
 					if !record.HandlesImportErrors && (resolveResult == nil || !resolveResult.IsExternal) {
-						args.log.AddRangeWarning(&tracker, record.Range,
+						args.log.AddID(logger.MsgID_Bundler_RequireResolveNotExternal, logger.Warning, &tracker, record.Range,
 							fmt.Sprintf("%q should be marked as external for use with \"require.resolve\"", record.Path.Text))
 					}
 					continue
@@ -432,7 +473,7 @@ func parseFile(args parseArgs) {
 						}
 						debug.LogErrorMsg(args.log, &source, record.Range, fmt.Sprintf("Could not resolve %q%s", record.Path.Text, hint))
 					} else if args.log.Level <= logger.LevelDebug && !didLogError && record.HandlesImportErrors {
-						args.log.AddRangeDebug(&tracker, record.Range,
+						args.log.AddID(logger.MsgID_Bundler_RequireResolveNotExternal, logger.Debug, &tracker, record.Range,
 							fmt.Sprintf("Importing %q was allowed even though it could not be resolved because dynamic import failures appear to be handled here",
 								record.Path.Text))
 					}
@@ -525,7 +566,7 @@ func extractSourceMapFromComment(
 		if contents, err := parsed.DecodeData(); err == nil {
 			return logger.Path{Text: source.PrettyPath, IgnoredSuffix: "#sourceMappingURL"}, &contents
 		} else {
-			log.AddRangeWarning(&tracker, comment.Range, fmt.Sprintf("Unsupported source map comment: %s", err.Error()))
+			log.AddID(logger.MsgID_SourceMap_UnsupportedSourceMapComment, logger.Warning, &tracker, comment.Range, fmt.Sprintf("Unsupported source map comment: %s", err.Error()))
 			return logger.Path{}, nil
 		}
 	}
@@ -536,14 +577,14 @@ func extractSourceMapFromComment(
 		path := logger.Path{Text: absPath, Namespace: "file"}
 		contents, err, originalError := fsCache.ReadFile(fs, absPath)
 		if log.Level <= logger.LevelDebug && originalError != nil {
-			log.AddRangeDebug(&tracker, comment.Range, fmt.Sprintf("Failed to read file %q: %s", res.PrettyPath(path), originalError.Error()))
+			log.AddID(logger.MsgID_SourceMap_MissingSourceMap, logger.Debug, &tracker, comment.Range, fmt.Sprintf("Failed to read file %q: %s", res.PrettyPath(path), originalError.Error()))
 		}
 		if err != nil {
 			if err == syscall.ENOENT {
 				// Don't report a warning because this is likely unactionable
 				return logger.Path{}, nil
 			}
-			log.AddRangeWarning(&tracker, comment.Range, fmt.Sprintf("Cannot read file %q: %s", res.PrettyPath(path), err.Error()))
+			log.AddID(logger.MsgID_SourceMap_MissingSourceMap, logger.Warning, &tracker, comment.Range, fmt.Sprintf("Cannot read file %q: %s", res.PrettyPath(path), err.Error()))
 			return logger.Path{}, nil
 		}
 		return path, &contents
@@ -623,7 +664,10 @@ func logPluginMessages(
 	return didLogError
 }
 
-func runOnResolvePlugins(
+// This is exported because plugins can also call this using the "Resolve"
+// function in the plugin API. The import source is only used for locating
+// log messages and may be nil even if the importer is present.
+func RunOnResolvePlugins(
 	plugins []config.Plugin,
 	res resolver.Resolver,
 	log logger.Log,
@@ -631,7 +675,7 @@ func runOnResolvePlugins(
 	fsCache *cache.FSCache,
 	importSource *logger.Source,
 	importPathRange logger.Range,
-	importNamespace string,
+	importer logger.Path,
 	path string,
 	kind ast.ImportKind,
 	absResolveDir string,
@@ -639,18 +683,14 @@ func runOnResolvePlugins(
 ) (*resolver.ResolveResult, bool, resolver.DebugMeta) {
 	resolverArgs := config.OnResolveArgs{
 		Path:       path,
+		Importer:   importer,
 		ResolveDir: absResolveDir,
 		Kind:       kind,
 		PluginData: pluginData,
 	}
 	applyPath := logger.Path{
 		Text:      path,
-		Namespace: importNamespace,
-	}
-	if importSource != nil {
-		resolverArgs.Importer = importSource.KeyPath
-	} else {
-		resolverArgs.Importer.Namespace = importNamespace
+		Namespace: importer.Namespace,
 	}
 	tracker := logger.MakeLineColumnTracker(importSource)
 
@@ -701,10 +741,10 @@ func runOnResolvePlugins(
 			// Paths in the file namespace must be absolute paths
 			if result.Path.Namespace == "file" && !fs.IsAbs(result.Path.Text) {
 				if nsFromPlugin == "file" {
-					log.AddRangeError(&tracker, importPathRange,
+					log.AddID(logger.MsgID_Bundler_UnresolvedImport, logger.Error, &tracker, importPathRange,
 						fmt.Sprintf("Plugin %q returned a path in the \"file\" namespace that is not an absolute path: %s", pluginName, result.Path.Text))
 				} else {
-					log.AddRangeError(&tracker, importPathRange,
+					log.AddID(logger.MsgID_Bundler_UnresolvedImport, logger.Error, &tracker, importPathRange,
 						fmt.Sprintf("Plugin %q returned a non-absolute path: %s (set a namespace if this is not a file path)", pluginName, result.Path.Text))
 				}
 				return nil, true, resolver.DebugMeta{}
@@ -734,7 +774,7 @@ func runOnResolvePlugins(
 	// Warn when the case used for importing differs from the actual file name
 	if result != nil && result.DifferentCase != nil && !helpers.IsInsideNodeModules(absResolveDir) {
 		diffCase := *result.DifferentCase
-		log.AddRangeWarning(&tracker, importPathRange, fmt.Sprintf(
+		log.AddID(logger.MsgID_Bundler_DifferentPathCase, logger.Warning, &tracker, importPathRange, fmt.Sprintf(
 			"Use %q instead of %q to avoid issues with case-sensitive file systems",
 			res.PrettyPath(logger.Path{Text: fs.Join(diffCase.Dir, diffCase.Actual), Namespace: "file"}),
 			res.PrettyPath(logger.Path{Text: fs.Join(diffCase.Dir, diffCase.Query), Namespace: "file"}),
@@ -839,14 +879,14 @@ func runOnLoadPlugins(
 			}, true
 		} else {
 			if log.Level <= logger.LevelDebug && originalError != nil {
-				log.AddDebug(nil, logger.Loc{}, fmt.Sprintf("Failed to read file %q: %s", source.KeyPath.Text, originalError.Error()))
+				log.AddID(logger.MsgID_Bundler_ReadError, logger.Debug, nil, logger.Range{}, fmt.Sprintf("Failed to read file %q: %s", source.KeyPath.Text, originalError.Error()))
 			}
 			if err == syscall.ENOENT {
-				log.AddRangeError(&tracker, importPathRange,
+				log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, &tracker, importPathRange,
 					fmt.Sprintf("Could not read from file: %s", source.KeyPath.Text))
 				return loaderPluginResult{}, false
 			} else {
-				log.AddRangeError(&tracker, importPathRange,
+				log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, &tracker, importPathRange,
 					fmt.Sprintf("Cannot read file %q: %s", res.PrettyPath(source.KeyPath), err.Error()))
 				return loaderPluginResult{}, false
 			}
@@ -859,7 +899,7 @@ func runOnLoadPlugins(
 		if parsed, ok := resolver.ParseDataURL(source.KeyPath.Text); ok {
 			if mimeType := parsed.DecodeMIMEType(); mimeType != resolver.MIMETypeUnsupported {
 				if contents, err := parsed.DecodeData(); err != nil {
-					log.AddRangeError(&tracker, importPathRange,
+					log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, &tracker, importPathRange,
 						fmt.Sprintf("Could not load data URL: %s", err.Error()))
 					return loaderPluginResult{loader: config.LoaderNone}, true
 				} else {
@@ -975,7 +1015,7 @@ func ScanBundle(
 	// Each bundling operation gets a separate unique key
 	uniqueKeyPrefix, err := generateUniqueKeyPrefix()
 	if err != nil {
-		log.AddError(nil, logger.Loc{}, fmt.Sprintf("Failed to read from randomness source: %s", err.Error()))
+		log.AddID(logger.MsgID_Bundler_InternalError, logger.Error, nil, logger.Range{}, fmt.Sprintf("Failed to read from randomness source: %s", err.Error()))
 	}
 
 	s := scanner{
@@ -1070,11 +1110,18 @@ func (s *scanner) maybeParseFile(
 	if resolveResult.UseDefineForClassFieldsTS != config.Unspecified {
 		optionsClone.UseDefineForClassFields = resolveResult.UseDefineForClassFieldsTS
 	}
+	if resolveResult.ExperimentalDecoratorsTS != config.Unspecified {
+		optionsClone.ExperimentalDecorators = resolveResult.ExperimentalDecoratorsTS
+	}
 	if resolveResult.PreserveUnusedImportsTS {
 		optionsClone.PreserveUnusedImportsTS = true
 	}
 	optionsClone.TSTarget = resolveResult.TSTarget
 
+	// Simple CommonJS modules can be scope-hoisted like ESM, but not when they
+	// are entry points since that would change the shape of their exports
+	optionsClone.HoistSimpleCommonJS = kind == inputKindNormal
+
 	// Set the module type preference using node's module type rules
 	if strings.HasSuffix(path.Text, ".mjs") {
 		optionsClone.ModuleType = config.ModuleESM
@@ -1213,7 +1260,7 @@ func (s *scanner) preprocessInjectedFiles() {
 		absPathKey := canonicalFileSystemPathForWindows(absPath)
 
 		if duplicateInjectedFiles[absPathKey] {
-			s.log.AddError(nil, logger.Loc{}, fmt.Sprintf("Duplicate injected file %q", prettyPath))
+			s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, nil, logger.Range{}, fmt.Sprintf("Duplicate injected file %q", prettyPath))
 			continue
 		}
 
@@ -1221,7 +1268,7 @@ func (s *scanner) preprocessInjectedFiles() {
 		resolveResult := s.res.ResolveAbs(absPath)
 
 		if resolveResult == nil {
-			s.log.AddError(nil, logger.Loc{}, fmt.Sprintf("Could not resolve %q", prettyPath))
+			s.log.AddID(logger.MsgID_Bundler_UnresolvedImport, logger.Error, nil, logger.Range{}, fmt.Sprintf("Could not resolve %q", prettyPath))
 			continue
 		}
 
@@ -1302,7 +1349,7 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 				}
 			}
 		} else if s.log.Level <= logger.LevelDebug && originalError != nil {
-			s.log.AddDebug(nil, logger.Loc{}, fmt.Sprintf("Failed to read directory %q: %s", absPath, originalError.Error()))
+			s.log.AddID(logger.MsgID_Bundler_ReadError, logger.Debug, nil, logger.Range{}, fmt.Sprintf("Failed to read directory %q: %s", absPath, originalError.Error()))
 		}
 	}
 
@@ -1320,7 +1367,7 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 			}
 
 			// Run the resolver and log an error if the path couldn't be resolved
-			resolveResult, didLogError, debug := runOnResolvePlugins(
+			resolveResult, didLogError, debug := RunOnResolvePlugins(
 				s.options.Plugins,
 				s.res,
 				s.log,
@@ -1328,7 +1375,7 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 				&s.caches.FSCache,
 				nil,
 				logger.Range{},
-				namespace,
+				logger.Path{Namespace: namespace},
 				entryPoint.InputPath,
 				ast.ImportEntryPoint,
 				entryPointAbsResolveDir,
@@ -1336,7 +1383,7 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 			)
 			if resolveResult != nil {
 				if resolveResult.IsExternal {
-					s.log.AddError(nil, logger.Loc{}, fmt.Sprintf("The entry point %q cannot be marked as external", entryPoint.InputPath))
+					s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, nil, logger.Range{}, fmt.Sprintf("The entry point %q cannot be marked as external", entryPoint.InputPath))
 				} else {
 					entryPointResolveResults[i] = resolveResult
 				}
@@ -1564,11 +1611,19 @@ func (s *scanner) processScannedFiles() []scannerFile {
 
 		sb := strings.Builder{}
 		isFirstImport := true
+		var importedNames map[uint32][]string
 
 		// Begin the metadata chunk
 		if s.options.NeedsMetafile {
+			if repr, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok {
+				importedNames = importedNamesForMetafile(repr)
+			}
 			sb.Write(js_printer.QuoteForJSON(result.file.inputFile.Source.PrettyPath, s.options.ASCIIOnly))
-			sb.WriteString(fmt.Sprintf(": {\n      \"bytes\": %d,\n      \"imports\": [", len(result.file.inputFile.Source.Contents)))
+			sb.WriteString(fmt.Sprintf(": {\n      \"bytes\": %d,\n      ", len(result.file.inputFile.Source.Contents)))
+			if s.options.AssetInlineLimit > 0 && (result.file.inputFile.IsInlinedAsset || result.file.inputFile.UniqueKeyForFileLoader != "") {
+				sb.WriteString(fmt.Sprintf("\"inlined\": %v,\n      ", result.file.inputFile.IsInlinedAsset))
+			}
+			sb.WriteString("\"imports\": [")
 		}
 
 		// Don't try to resolve paths if we're not bundling
@@ -1581,7 +1636,22 @@ func (s *scanner) processScannedFiles() []scannerFile {
 
 				// Skip this import record if the previous resolver call failed
 				resolveResult := result.resolveResults[importRecordIndex]
-				if resolveResult == nil || !record.SourceIndex.IsValid() {
+				if resolveResult == nil {
+					continue
+				}
+
+				// External imports are only mentioned in the metadata
+				if !record.SourceIndex.IsValid() {
+					if s.options.NeedsMetafile && resolveResult.IsExternal {
+						if isFirstImport {
+							isFirstImport = false
+							sb.WriteString("\n        ")
+						} else {
+							sb.WriteString(",\n        ")
+						}
+						sb.WriteString(importMetadataJSON(record.Path.Text, record.Kind,
+							importedNames[uint32(importRecordIndex)], true, resolveResult.OriginalImportPath, s.options.ASCIIOnly))
+					}
 					continue
 				}
 
@@ -1612,37 +1682,48 @@ func (s *scanner) processScannedFiles() []scannerFile {
 					} else {
 						sb.WriteString(",\n        ")
 					}
-					sb.WriteString(fmt.Sprintf("{\n          \"path\": %s,\n          \"kind\": %s\n        }",
-						js_printer.QuoteForJSON(s.results[record.SourceIndex.GetIndex()].file.inputFile.Source.PrettyPath, s.options.ASCIIOnly),
-						js_printer.QuoteForJSON(record.Kind.StringForMetafile(), s.options.ASCIIOnly)))
+					sb.WriteString(importMetadataJSON(s.results[record.SourceIndex.GetIndex()].file.inputFile.Source.PrettyPath,
+						record.Kind, importedNames[uint32(importRecordIndex)], false, resolveResult.OriginalImportPath, s.options.ASCIIOnly))
 				}
 
 				switch record.Kind {
 				case ast.ImportAt, ast.ImportAtConditional:
-					// Using a JavaScript file with CSS "@import" is not allowed
+					// Using a non-CSS file with CSS "@import" is not allowed
 					otherFile := &s.results[record.SourceIndex.GetIndex()].file
-					if _, ok := otherFile.inputFile.Repr.(*graph.JSRepr); ok {
-						s.log.AddRangeError(&tracker, record.Range,
+					switch otherFile.inputFile.Repr.(type) {
+					case *graph.JSRepr, *graph.HTMLRepr:
+						s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
 							fmt.Sprintf("Cannot import %q into a CSS file", otherFile.inputFile.Source.PrettyPath))
-					} else if record.Kind == ast.ImportAtConditional {
-						s.log.AddRangeError(&tracker, record.Range,
-							"Bundling with conditional \"@import\" rules is not currently supported")
+
+					default:
+						if record.Kind == ast.ImportAtConditional {
+							s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
+								"Bundling with conditional \"@import\" rules is not currently supported")
+						}
 					}
 
 				case ast.ImportURL:
 					// Using a JavaScript or CSS file with CSS "url()" is not allowed
 					otherFile := &s.results[record.SourceIndex.GetIndex()].file
 					switch otherRepr := otherFile.inputFile.Repr.(type) {
-					case *graph.CSSRepr:
-						s.log.AddRangeError(&tracker, record.Range,
+					case *graph.CSSRepr, *graph.HTMLRepr:
+						s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
 							fmt.Sprintf("Cannot use %q as a URL", otherFile.inputFile.Source.PrettyPath))
 
 					case *graph.JSRepr:
 						if otherRepr.AST.URLForCSS == "" {
-							s.log.AddRangeError(&tracker, record.Range,
+							s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
 								fmt.Sprintf("Cannot use %q as a URL", otherFile.inputFile.Source.PrettyPath))
 						}
 					}
+
+				case ast.ImportEntryPoint:
+					// HTML files can reference scripts and stylesheets but not other HTML files
+					otherFile := &s.results[record.SourceIndex.GetIndex()].file
+					if _, ok := otherFile.inputFile.Repr.(*graph.HTMLRepr); ok {
+						s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
+							fmt.Sprintf("Cannot use %q as a script or stylesheet", otherFile.inputFile.Source.PrettyPath))
+					}
 				}
 
 				// If an import from a JavaScript file targets a CSS file, generate a
@@ -1650,9 +1731,14 @@ func (s *scanner) processScannedFiles() []scannerFile {
 				// other JavaScript files.
 				if _, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok {
 					otherFile := &s.results[record.SourceIndex.GetIndex()].file
+					if _, ok := otherFile.inputFile.Repr.(*graph.HTMLRepr); ok {
+						s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
+							fmt.Sprintf("Cannot import %q into a JavaScript file", otherFile.inputFile.Source.PrettyPath))
+						continue
+					}
 					if css, ok := otherFile.inputFile.Repr.(*graph.CSSRepr); ok {
 						if s.options.WriteToStdout {
-							s.log.AddRangeError(&tracker, record.Range,
+							s.log.AddID(logger.MsgID_Bundler_InvalidImport, logger.Error, &tracker, record.Range,
 								fmt.Sprintf("Cannot import %q into a JavaScript file without an output path configured", otherFile.inputFile.Source.PrettyPath))
 						} else if !css.JSSourceIndex.IsValid() {
 							stubKey := otherFile.inputFile.Source.KeyPath
@@ -1718,7 +1804,7 @@ func (s *scanner) processScannedFiles() []scannerFile {
 								notes = append(notes, logger.RangeData(&tracker, data.Range, text))
 							}
 						}
-						s.log.AddRangeWarningWithNotes(&tracker, record.Range,
+						s.log.AddIDWithNotes(logger.MsgID_Bundler_IgnoredBareImport, logger.Warning, &tracker, record.Range,
 							fmt.Sprintf("Ignoring this import because %q was marked as having no side effects%s",
 								otherModule.Source.PrettyPath, by), notes)
 					}
@@ -1792,6 +1878,8 @@ func (s *scanner) processScannedFiles() []scannerFile {
 		s.results[i] = result
 	}
 
+	s.unhoistObservedCommonJS()
+
 	// The linker operates on an array of files, so construct that now. This
 	// can't be constructed earlier because we generate new parse results for
 	// JavaScript stub files for CSS imports above.
@@ -1805,6 +1893,143 @@ func (s *scanner) processScannedFiles() []scannerFile {
 	return files
 }
 
+// Simple CommonJS modules are scope-hoisted like ESM when they are parsed, but
+// that's only correct if nothing observes the exports object itself. It would
+// otherwise gain an "__esModule" marker and getters, and missing imports would
+// become errors instead of being undefined. Now that every importer is known,
+// parse any module that is used in one of these ways again as CommonJS.
+func (s *scanner) unhoistObservedCommonJS() {
+	var observed map[uint32]bool
+	for _, result := range s.results {
+		if !result.ok {
+			continue
+		}
+		repr, ok := result.file.inputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			continue
+		}
+		isHoisted := func(record *ast.ImportRecord) bool {
+			if !record.SourceIndex.IsValid() {
+				return false
+			}
+			other := &s.results[record.SourceIndex.GetIndex()]
+			return other.ok && other.file.hoistedCommonJSOptions != nil
+		}
+		observe := func(record *ast.ImportRecord) {
+			if observed == nil {
+				observed = make(map[uint32]bool)
+			}
+			observed[record.SourceIndex.GetIndex()] = true
+		}
+
+		// Anything other than an import statement can observe the exports
+		// object, and so can "import * as ns" and "export * from"
+		for i := range repr.AST.ImportRecords {
+			if record := &repr.AST.ImportRecords[i]; isHoisted(record) && (record.Kind != ast.ImportStmt || record.ContainsImportStar) {
+				observe(record)
+			}
+		}
+		for _, i := range repr.AST.ExportStarImportRecords {
+			if record := &repr.AST.ImportRecords[i]; isHoisted(record) {
+				observe(record)
+			}
+		}
+
+		// The default import is the exports object unless there is an export
+		// named "default", and missing imports must still be undefined. This
+		// also handles "export * as ns from".
+		for _, namedImport := range repr.AST.NamedImports {
+			if record := &repr.AST.ImportRecords[namedImport.ImportRecordIndex]; isHoisted(record) {
+				otherRepr := s.results[record.SourceIndex.GetIndex()].file.inputFile.Repr.(*graph.JSRepr)
+				if _, ok := otherRepr.AST.NamedExports[namedImport.Alias]; !ok || namedImport.AliasIsStar {
+					observe(record)
+				}
+			}
+		}
+	}
+
+	for sourceIndex := range observed {
+		result := &s.results[sourceIndex]
+		options := *result.file.hoistedCommonJSOptions
+		options.HoistSimpleCommonJS = false
+
+		// Any messages were already reported when this file was first parsed
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		ast, ok := js_parser.Parse(log, result.file.inputFile.Source, js_parser.OptionsFromConfig(&options))
+		if !ok {
+			continue
+		}
+
+		// Parsing is deterministic, so the import records are the same as before
+		// and can keep the paths that were already resolved for them
+		repr := result.file.inputFile.Repr.(*graph.JSRepr)
+		ast.ImportRecords = repr.AST.ImportRecords
+		repr.AST = ast
+		result.file.hoistedCommonJSOptions = nil
+	}
+}
+
+// Returns the names that a JavaScript file imports through each of its import
+// records in sorted order. Namespace imports and "export * from" statements
+// use every export, which is represented by "*".
+func importedNamesForMetafile(repr *graph.JSRepr) map[uint32][]string {
+	seen := make(map[uint32]map[string]bool)
+	add := func(importRecordIndex uint32, name string) {
+		names := seen[importRecordIndex]
+		if names == nil {
+			names = make(map[string]bool)
+			seen[importRecordIndex] = names
+		}
+		names[name] = true
+	}
+	for _, namedImport := range repr.AST.NamedImports {
+		if namedImport.AliasIsStar {
+			add(namedImport.ImportRecordIndex, "*")
+		} else {
+			add(namedImport.ImportRecordIndex, namedImport.Alias)
+		}
+	}
+	for _, importRecordIndex := range repr.AST.ExportStarImportRecords {
+		add(importRecordIndex, "*")
+	}
+
+	result := make(map[uint32][]string, len(seen))
+	for importRecordIndex, names := range seen {
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+		result[importRecordIndex] = sorted
+	}
+	return result
+}
+
+func importMetadataJSON(path string, kind ast.ImportKind, names []string, isExternal bool, original string, asciiOnly bool) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("{\n          \"path\": %s,\n          \"kind\": %s",
+		js_printer.QuoteForJSON(path, asciiOnly),
+		js_printer.QuoteForJSON(kind.StringForMetafile(), asciiOnly)))
+	if isExternal {
+		sb.WriteString(",\n          \"external\": true")
+	}
+	if len(names) > 0 {
+		sb.WriteString(",\n          \"uses\": [")
+		for i, name := range names {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.Write(js_printer.QuoteForJSON(name, asciiOnly))
+		}
+		sb.WriteString("]")
+	}
+	if original != "" {
+		sb.WriteString(fmt.Sprintf(",\n          \"original\": %s", js_printer.QuoteForJSON(original, asciiOnly)))
+	}
+	sb.WriteString("\n        }")
+	return sb.String()
+}
+
 func (s *scanner) validateTLA(sourceIndex uint32) tlaCheck {
 	result := &s.results[sourceIndex]
 
@@ -1875,7 +2100,7 @@ func (s *scanner) validateTLA(sourceIndex uint32) tlaCheck {
 						}
 
 						tracker := logger.MakeLineColumnTracker(&result.file.inputFile.Source)
-						s.log.AddRangeErrorWithNotes(&tracker, record.Range, text, notes)
+						s.log.AddIDWithNotes(logger.MsgID_Bundler_UnresolvedImport, logger.Error, &tracker, record.Range, text, notes)
 					}
 				}
 			}
@@ -1901,6 +2126,7 @@ func DefaultExtensionToLoaderMap() map[string]config.Loader {
 		".ts":   config.LoaderTS,
 		".tsx":  config.LoaderTSX,
 		".css":  config.LoaderCSS,
+		".html": config.LoaderHTML,
 		".json": config.LoaderJSON,
 		".txt":  config.LoaderText,
 	}
@@ -1998,10 +2224,16 @@ func (b *Bundle) Compile(log logger.Log, options config.Options, timer *helpers.
 	var metafileJSON string
 	if options.NeedsMetafile {
 		timer.Begin("Generate metadata JSON")
-		metafileJSON = b.generateMetadataJSON(outputFiles, allReachableFiles, options.ASCIIOnly)
+		metafileJSON = b.generateMetadataJSON(outputFiles, allReachableFiles, &options)
 		timer.End("Generate metadata JSON")
 	}
 
+	// Also generate the manifest file if necessary. This is an output file so it
+	// must come before the checks for output files that overwrite other files.
+	if options.NeedsManifest {
+		outputFiles = append(outputFiles, b.generateManifestJSON(outputFiles, &options))
+	}
+
 	if !options.WriteToStdout {
 		// Make sure an output file never overwrites an input file
 		if !options.AllowOverwrite {
@@ -2025,7 +2257,7 @@ func (b *Bundle) Compile(log logger.Log, options config.Options, timer *helpers.
 					case logger.GoAPI:
 						hint = " (use \"AllowOverwrite: true\" to allow this)"
 					}
-					log.AddError(nil, logger.Loc{},
+					log.AddID(logger.MsgID_Bundler_OutputCollision, logger.Error, nil, logger.Range{},
 						fmt.Sprintf("Refusing to overwrite input file %q%s",
 							b.files[sourceIndex].inputFile.Source.PrettyPath, hint))
 				}
@@ -2062,7 +2294,7 @@ func (b *Bundle) Compile(log logger.Log, options config.Options, timer *helpers.
 			if relPath, ok := b.fs.Rel(b.fs.Cwd(), outputPath); ok {
 				outputPath = relPath
 			}
-			log.AddError(nil, logger.Loc{}, "Two output files share the same path but have different contents: "+outputPath)
+			log.AddID(logger.MsgID_Bundler_OutputCollision, logger.Error, nil, logger.Range{}, "Two output files share the same path but have different contents: "+outputPath)
 		}
 		outputFiles = outputFiles[:end]
 	}
@@ -2175,7 +2407,9 @@ func (b *Bundle) computeDataForSourceMapsInParallel(options *config.Options, rea
 	}
 }
 
-func (b *Bundle) generateMetadataJSON(results []graph.OutputFile, allReachableFiles []uint32, asciiOnly bool) string {
+func (b *Bundle) generateMetadataJSON(results []graph.OutputFile, allReachableFiles []uint32, options *config.Options) string {
+	asciiOnly := options.ASCIIOnly
+	integrity := computeIntegrityInParallel(results, options.Integrity)
 	sb := strings.Builder{}
 	sb.WriteString("{\n  \"inputs\": {")
 
@@ -2216,7 +2450,27 @@ func (b *Bundle) generateMetadataJSON(results []graph.OutputFile, allReachableFi
 			}
 			paths[path] = true
 			sb.WriteString(fmt.Sprintf("%s: ", js_printer.QuoteForJSON(path, asciiOnly)))
-			sb.WriteString(result.JSONMetadataChunk)
+			if integrity == nil {
+				sb.WriteString(result.JSONMetadataChunk)
+				continue
+			}
+
+			// Add the integrity fields to the end of the JSON object
+			sb.WriteString(strings.TrimSuffix(result.JSONMetadataChunk, "\n    }"))
+			sb.WriteString(fmt.Sprintf(",\n      \"integrity\": %s", js_printer.QuoteForJSON(integrity[result.AbsPath], asciiOnly)))
+			if options.IntegrityMap && len(result.DynamicImportAbsPaths) > 0 {
+				sb.WriteString(",\n      \"integrityMap\": {")
+				for i, absPath := range result.DynamicImportAbsPaths {
+					if i > 0 {
+						sb.WriteString(",")
+					}
+					sb.WriteString(fmt.Sprintf("\n        %s: %s",
+						js_printer.QuoteForJSON(b.res.PrettyPath(logger.Path{Text: absPath, Namespace: "file"}), asciiOnly),
+						js_printer.QuoteForJSON(integrity[absPath], asciiOnly)))
+				}
+				sb.WriteString("\n      }")
+			}
+			sb.WriteString("\n    }")
 		}
 	}
 
@@ -2224,6 +2478,80 @@ func (b *Bundle) generateMetadataJSON(results []graph.OutputFile, allReachableFi
 	return sb.String()
 }
 
+func (b *Bundle) generateManifestJSON(results []graph.OutputFile, options *config.Options) graph.OutputFile {
+	sb := strings.Builder{}
+	sb.WriteString("{")
+
+	isFirst := true
+	entries := make(map[string]bool)
+	for _, result := range results {
+		if len(result.JSONManifestChunk) > 0 {
+			if entries[result.JSONManifestChunk] {
+				// Don't write out the same entry point twice (can happen when an entry
+				// point is also referenced from an HTML file and each is linked alone)
+				continue
+			}
+			if isFirst {
+				isFirst = false
+				sb.WriteString("\n  ")
+			} else {
+				sb.WriteString(",\n  ")
+			}
+			entries[result.JSONManifestChunk] = true
+			sb.WriteString(result.JSONManifestChunk)
+		}
+	}
+
+	if !isFirst {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+
+	return graph.OutputFile{
+		AbsPath:  b.fs.Join(options.AbsOutputDir, "manifest.json"),
+		Contents: []byte(sb.String()),
+	}
+}
+
+// Returns the Subresource Integrity digest of each output file keyed by the
+// absolute path of the file, or nil if integrity digests are disabled. See
+// https://www.w3.org/TR/SRI/ for the format.
+func computeIntegrityInParallel(results []graph.OutputFile, algorithm config.Integrity) map[string]string {
+	if algorithm == config.IntegrityNone {
+		return nil
+	}
+
+	digests := make([]string, len(results))
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(len(results))
+	for i, result := range results {
+		go func(i int, contents []byte) {
+			var prefix string
+			var sum []byte
+			switch algorithm {
+			case config.IntegritySHA256:
+				hash := sha256.Sum256(contents)
+				prefix, sum = "sha256-", hash[:]
+			case config.IntegritySHA384:
+				hash := sha512.Sum384(contents)
+				prefix, sum = "sha384-", hash[:]
+			case config.IntegritySHA512:
+				hash := sha512.Sum512(contents)
+				prefix, sum = "sha512-", hash[:]
+			}
+			digests[i] = prefix + base64.StdEncoding.EncodeToString(sum)
+			waitGroup.Done()
+		}(i, result.Contents)
+	}
+	waitGroup.Wait()
+
+	integrity := make(map[string]string, len(results))
+	for i, result := range results {
+		integrity[result.AbsPath] = digests[i]
+	}
+	return integrity
+}
+
 type runtimeCacheKey struct {
 	MangleSyntax      bool
 	MinifyIdentifiers bool
@@ -1655,3 +1655,231 @@ func TestTreeShakingInESMWrapper(t *testing.T) {
 		},
 	})
 }
+
+func TestTreeShakingSimpleCommonJS(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {keep1} from './assign'
+				import {keep2} from './object'
+				import {keep3} from './marker'
+				import {keep4} from './unsafe'
+				console.log(keep1, keep2, keep3, keep4)
+			`,
+			"/assign.js": `
+				exports.keep1 = 1
+				exports.REMOVE = 2
+			`,
+			"/object.js": `
+				module.exports = {
+					keep2: 1,
+					REMOVE: 2,
+				}
+			`,
+			"/marker.js": `
+				Object.defineProperty(exports, "__esModule", { value: true })
+				exports.keep3 = 1
+				exports.REMOVE = 2
+				exports.default = 3
+			`,
+			"/unsafe.js": `
+				exports.keep4 = 1
+				exports.other = 2
+				console.log(this)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingPureFunctionCalls(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {createThing, Thing, impure, callsImpure, recursive} from './lib'
+				const unusedThing = createThing(1)
+				const unusedInstance = new Thing(1)
+				const unusedRecursive = recursive(1)
+				const usedThing = createThing(2)
+				const keptImpure = impure()
+				const keptCallsImpure = callsImpure()
+				createThing(3)
+				console.log(usedThing)
+			`,
+			"/lib.js": `
+				let counter = 0
+				function helper(x) {
+					let y = x
+					if (x) y = { x }
+					return y
+				}
+				export function createThing(x) {
+					return helper(x)
+				}
+				export class Thing {
+					value = null
+					constructor(x) {
+						this.x = helper(x)
+					}
+				}
+				export function impure() {
+					counter++
+				}
+				export const callsImpure = () => impure()
+				export function recursive(x) {
+					return x ? recursive(null) : x
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			InferPureFunctions: true,
+			AbsOutputFile:      "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingPureFunctionCallsReassigned(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {pure, reset} from './lib'
+				const unused = pure()
+				reset()
+			`,
+			"/lib.js": `
+				export function pure() {
+					return 1
+				}
+				export function reset() {
+					pure = () => console.log('side effect')
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			InferPureFunctions: true,
+			AbsOutputFile:      "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingPureFunctionCallsIgnoreAnnotations(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				function pure() {
+					return 1
+				}
+				const unused = pure()
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                 config.ModeBundle,
+			IgnoreDCEAnnotations: true,
+			InferPureFunctions:   true,
+			AbsOutputFile:        "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingSimpleCommonJSObserved(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {keep1} from './required'
+				import def from './default'
+				import * as ns from './namespace'
+				import {missing} from './missing'
+				import {keep5} from './dynamic'
+				console.log(keep1, require('./required'), def, ns, missing, keep5, import('./dynamic'))
+			`,
+			"/required.js": `
+				exports.keep1 = 1
+			`,
+			"/default.js": `
+				exports.keep2 = 2
+			`,
+			"/namespace.js": `
+				exports.keep3 = 3
+			`,
+			"/missing.js": `
+				exports.keep4 = 4
+			`,
+			"/dynamic.js": `
+				exports.keep5 = 5
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			InferPureFunctions: true,
+			AbsOutputFile:      "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingPureFunctionCallsDisabled(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				function pure() {
+					return 1
+				}
+				const unused = pure()
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTreeShakingPureClassAccessors(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				class Pure {
+					constructor(x) { this.x = x }
+					get y() { return this.x }
+				}
+				class Setter {
+					constructor(x) { this.x = x }
+					set x(v) { console.log(v) }
+				}
+				class FieldSetter {
+					x = 1
+					set x(v) { console.log(v) }
+				}
+				class Computed {
+					constructor(x) { this.x = x }
+					set [name](v) { console.log(v) }
+				}
+				class Base {}
+				class Derived extends Base {
+					constructor(x) { super(); this.x = x }
+				}
+				new Pure(1)
+				new Setter(1)
+				new FieldSetter()
+				new Computed(1)
+				new Derived(1)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			InferPureFunctions: true,
+			AbsOutputFile:      "/out.js",
+		},
+	})
+}
@@ -239,6 +239,116 @@ func TestExportFormsIIFE(t *testing.T) {
 	})
 }
 
+func TestExportFormsUMD(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import React, {useState} from 'react'
+				import * as lodash from 'lodash'
+				export default 123
+				export function Fn() {
+					return [React, useState, lodash]
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatUMD,
+			GlobalName:    []string{"my", "lib"},
+			AbsOutputFile: "/out.js",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"react":  true,
+					"lodash": true,
+				},
+			},
+			ExternalGlobals: map[string][]string{
+				"react": {"React"},
+			},
+		},
+		expectedCompileLog: `warning: The external module "lodash" has no global name, so importing it will fail when the UMD output is loaded as a browser global
+note: You can use "--external-global:M=G" to use the global G for the external module M.
+`,
+	})
+}
+
+func TestExportFormsUMDMinified(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				const React = require('react')
+				module.exports = { version: React.version }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			OutputFormat:     config.FormatUMD,
+			GlobalName:       []string{"my-lib"},
+			RemoveWhitespace: true,
+			AbsOutputFile:    "/out.js",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"react": true,
+				},
+			},
+			ExternalGlobals: map[string][]string{
+				"react": {"window", "React"},
+			},
+		},
+	})
+}
+
+func TestExportFormsUMDNoGlobalName(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				export const foo = 123
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatUMD,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestExternalGlobalsIIFE(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import React, {createElement} from "react"
+				import * as ReactDOM from "react-dom"
+				import {debounce} from "lodash"
+				let window = 123
+				console.log(React, createElement, ReactDOM.render, debounce, window)
+				console.log(require("lodash"))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatIIFE,
+			AbsOutputFile: "/out.js",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"react":     true,
+					"react-dom": true,
+					"lodash":    true,
+				},
+			},
+			ExternalGlobals: map[string][]string{
+				"react":     {"window", "React"},
+				"react-dom": {"ReactDOM"},
+				"lodash":    {"window", "lodash-es"},
+			},
+		},
+	})
+}
+
 func TestExportFormsWithMinifyIdentifiersAndNoBundle(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1589,6 +1699,95 @@ func TestMinifiedBundleCommonJS(t *testing.T) {
 	})
 }
 
+func TestMinifyInlineBundle(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {PREFIX, id, add, getX, arrow, keep, notSimple, reassigned} from './lib'
+				let x = 1
+				console.log(id(x), add(x, 2), getX(x), arrow(x, 'y'), PREFIX + x, id())
+				console.log(keep, keep(x))
+				console.log(notSimple(foo()))
+				console.log(reassigned(x))
+			`,
+			"/lib.js": `
+				export const PREFIX = 'prefix:'
+				export function id(a) { return a }
+				export function add(a, b) { return a + b }
+				export function getX(o) { return o.x }
+				export const arrow = (a, b) => [a, b]
+				export function keep(a) { return a }
+				export function notSimple(a) { return a }
+				export let reassigned = a => a
+				export function reset() { reassigned = null }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			MangleInline:  true,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestMinifyInlineBundleExported(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {id} from './lib'
+				export const VALUE = 123
+				export function exported(a) { return a }
+				console.log(id(VALUE), exported(VALUE))
+			`,
+			"/lib.js": `
+				export function id(a) { return a }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			MangleInline:  true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestMinifyInlineBundleValues(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {label, version, log, twice, notStable, notPrimitive, obj} from './lib'
+				console.log(label, version)
+				log(twice)
+				console.log(notStable, notPrimitive, obj)
+			`,
+			"/lib.js": `
+				const NAME = 'lib'
+				const MAJOR = 1
+				export const version = MAJOR + '.' + (MAJOR * 2)
+				export const label = ` + "`${NAME}@${version}`" + `
+				function helper(x) { console.log(x) }
+				export const log = helper
+				export const twice = typeof MAJOR === 'number' ? !NAME : void 0
+				let counter = 0
+				export const notStable = counter + 1
+				export const notPrimitive = MAJOR in globalThis
+				export const obj = { NAME }
+				export function reset() { counter = 0 }
+				console.log(MAJOR, NAME)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			MangleInline:  true,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
 func TestMinifiedBundleEndingWithImportantSemicolon(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -4677,3 +4876,202 @@ func TestRequireShimSubstitution(t *testing.T) {
 		},
 	})
 }
+
+func TestMetafileImportDetails(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {a, b as c} from './named.js'
+				import * as ns from './namespace.js'
+				import 'external-pkg'
+				import {x} from 'external-pkg'
+				export * from './star.js'
+				console.log(a, c, ns, x)
+			`,
+			"/named.js": `
+				import {helper} from './helper.js'
+				export let a = helper(1), b = 2
+			`,
+			"/namespace.js": `export let y = 3`,
+			"/star.js":      `export let z = 4`,
+			"/helper.js":    `export function helper(x) { return x }`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			NeedsMetafile: true,
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"external-pkg": true,
+				},
+			},
+		},
+	})
+}
+
+func TestPackageAlias(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.js": `
+				import a from 'pkg1'
+				import b from 'pkg1/sub'
+				import c from '@scope/pkg2'
+				import d from '@scope/pkg2/sub/file'
+				import e from 'pkg3'
+				const f = require('pkg1')
+				import g from 'pkg1-not-aliased'
+				console.log(a, b, c, d, e, f, g)
+			`,
+			"/node_modules/alias1/index.js":              `export default 'alias1'`,
+			"/node_modules/alias1/sub.js":                `export default 'alias1/sub'`,
+			"/node_modules/alias2/index.js":              `export default 'alias2'`,
+			"/node_modules/alias2/sub/file.js":           `export default 'alias2/sub/file'`,
+			"/node_modules/pkg1-not-aliased/index.js":    `export default 'pkg1-not-aliased'`,
+			"/node_modules/@scope/pkg2/sub/file.js":      `export default 'this should not be used'`,
+			"/shims/pkg3.js":                             `export default 'shim'`,
+			"/src/node_modules/alias1/index.js":          `export default 'this should not be used'`,
+			"/src/node_modules/@scope/pkg2/sub/index.js": `export default 'this should not be used'`,
+		},
+		entryPaths: []string{"/src/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			PackageAliases: map[string]string{
+				"pkg1":        "alias1",
+				"@scope/pkg2": "alias2",
+				"pkg3":        "./shims/pkg3.js",
+			},
+		},
+	})
+}
+
+func TestPackageAliasCSS(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/entry.css": `
+				@import "pkg/theme.css";
+				a { color: red }
+			`,
+			"/node_modules/alias/theme.css": `b { color: blue }`,
+		},
+		entryPaths: []string{"/src/entry.css"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.css",
+			PackageAliases: map[string]string{
+				"pkg": "alias",
+			},
+		},
+	})
+}
+
+func TestPackageAliasMetafile(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from 'pkg'
+				import b from 'ext'
+				console.log(a, b)
+			`,
+			"/node_modules/alias/index.js": `export default 123`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			NeedsMetafile: true,
+			PackageAliases: map[string]string{
+				"pkg": "alias",
+				"ext": "external-pkg",
+			},
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"external-pkg": true,
+				},
+			},
+		},
+	})
+}
+
+func TestPackageAliasMissing(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import 'pkg/file'
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			PackageAliases: map[string]string{
+				"pkg": "alias",
+			},
+		},
+		expectedScanLog: `entry.js: error: Could not resolve "pkg/file" (mark it as external to exclude it from the bundle)
+note: The import path "pkg/file" was rewritten to "alias/file" by the "alias" setting
+`,
+	})
+}
+
+func TestPackagesExternal(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import a from 'pkg'
+				import b from 'pkg/sub'
+				import c from '@scope/pkg'
+				import d from './local'
+				import e from '#utils/helper'
+				import f from 'lib/shared'
+				const g = require('other-pkg')
+				console.log(a, b, c, d, e, f, g)
+			`,
+			"/Users/user/project/src/local.js":        `export default 'local'`,
+			"/Users/user/project/src/utils/helper.js": `export default 'helper'`,
+			"/Users/user/project/src/lib/shared.js":   `export default 'shared'`,
+			"/Users/user/project/tsconfig.json": `
+				{
+					"compilerOptions": {
+						"baseUrl": "./src",
+						"paths": {
+							"#utils/*": ["./utils/*"]
+						}
+					}
+				}
+			`,
+			"/Users/user/project/node_modules/pkg/index.js":        `export default 'this should not be used'`,
+			"/Users/user/project/node_modules/other-pkg/index.js":  `module.exports = 'this should not be used'`,
+			"/Users/user/project/node_modules/@scope/pkg/index.js": `export default 'this should not be used'`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			OutputFormat:     config.FormatESModule,
+			AbsOutputFile:    "/Users/user/project/out.js",
+			ExternalPackages: true,
+		},
+	})
+}
+
+func TestPackagesExternalEntryPoint(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/node_modules/pkg/index.js": `
+				import dep from 'dep'
+				console.log(dep)
+			`,
+			"/node_modules/dep/index.js": `export default 'this should not be used'`,
+		},
+		entryPaths: []string{"pkg"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			OutputFormat:     config.FormatESModule,
+			AbsOutputDir:     "/out",
+			ExternalPackages: true,
+		},
+	})
+}
@@ -0,0 +1,183 @@
+package bundler
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/config"
+)
+
+var html_suite = suite{
+	name: "html",
+}
+
+func TestHTMLEntryPoint(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `<!DOCTYPE html>
+<html>
+  <head>
+    <link rel="icon" href="favicon.png">
+    <link rel="stylesheet" href="./style.css">
+    <script type="module" src="app.js"></script>
+    <script src="classic.js"></script>
+  </head>
+  <body>
+    <img src="https://example.com/logo.png">
+    <img src="./logo.png">
+  </body>
+</html>
+`,
+			"/app.js": `
+				import './app.css'
+				console.log('app')
+			`,
+			"/app.css":     `body { color: red }`,
+			"/style.css":   `body { margin: 0 }`,
+			"/favicon.png": `favicon`,
+			"/logo.png":    `logo`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputDir:  "/out",
+			OutputFormat:  config.FormatESModule,
+			NeedsMetafile: true,
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".js":   config.LoaderJS,
+				".css":  config.LoaderCSS,
+				".png":  config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestHTMLTextAndSrcset(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `<!DOCTYPE html>
+<title>Uses <link rel="stylesheet" href="./nope.css"></title>
+<textarea><link rel="stylesheet" href="./nope.css"></textarea>
+<picture>
+  <source srcset="a.webp 1x, b.webp 2x" type="image/webp">
+  <img srcset="b.png 2x, https://example.com/c.png 3x" src="a.png">
+</picture>
+<video poster="a.png" src="a.mp4"></video>
+`,
+			"/a.webp": `a.webp`,
+			"/b.webp": `b.webp`,
+			"/a.png":  `a.png`,
+			"/b.png":  `b.png`,
+			"/a.mp4":  `a.mp4`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".webp": config.LoaderFile,
+				".png":  config.LoaderFile,
+				".mp4":  config.LoaderFile,
+			},
+		},
+	})
+}
+
+func TestHTMLEntryPointSplitting(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `
+				<script type="module" src="a.js"></script>
+				<script type="module" src="b.js"></script>
+			`,
+			"/a.js": `
+				import { shared } from './shared.js'
+				shared('a')
+				import('./lazy.js')
+			`,
+			"/b.js": `
+				import { shared } from './shared.js'
+				shared('b')
+			`,
+			"/shared.js": `export function shared(x) { console.log(x) }`,
+			"/lazy.js":   `console.log('lazy')`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputDir:  "/out",
+			OutputFormat:  config.FormatESModule,
+			CodeSplitting: true,
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".js":   config.LoaderJS,
+			},
+		},
+	})
+}
+
+func TestHTMLImportFromJS(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js":  `import './page.html'`,
+			"/page.html": `<p>page</p>`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".js":   config.LoaderJS,
+			},
+		},
+		expectedScanLog: `entry.js: error: Cannot import "page.html" into a JavaScript file
+`,
+	})
+}
+
+func TestHTMLBadReferences(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `
+				<script type="module" src="other.html"></script>
+				<img src="image.css">
+			`,
+			"/other.html": `<p>other</p>`,
+			"/image.css":  `a { color: red }`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".css":  config.LoaderCSS,
+			},
+		},
+		expectedScanLog: `index.html: error: Cannot use "other.html" as a script or stylesheet
+index.html: error: Cannot use "image.css" as a URL
+`,
+	})
+}
+
+func TestHTMLWithoutOutputDirectory(t *testing.T) {
+	html_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/index.html": `<script type="module" src="app.js"></script>`,
+			"/app.js":     `console.log('app')`,
+		},
+		entryPaths: []string{"/index.html"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.html",
+			ExtensionToLoader: map[string]config.Loader{
+				".html": config.LoaderHTML,
+				".js":   config.LoaderJS,
+			},
+		},
+		expectedScanLog: `error: Cannot load "index.html" without bundling to an output directory
+`,
+	})
+}
@@ -929,3 +929,34 @@ func TestLoaderDataURLUnknownMIME(t *testing.T) {
 		},
 	})
 }
+
+func TestLoaderFileAssetInlineLimit(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import small from './small.png'
+				import large from './large.png'
+				import './style.css'
+				console.log(small, large)
+			`,
+			"/style.css": `
+				a { background: url(small.png) }
+				b { background: url(large.png) }
+			`,
+			"/small.png": "small",
+			"/large.png": "this file is too large to inline",
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			AbsOutputDir:     "/out",
+			NeedsMetafile:    true,
+			AssetInlineLimit: 10,
+			ExtensionToLoader: map[string]config.Loader{
+				".js":  config.LoaderJS,
+				".css": config.LoaderCSS,
+				".png": config.LoaderFile,
+			},
+		},
+	})
+}
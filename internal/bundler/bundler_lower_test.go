@@ -268,6 +268,47 @@ func TestLowerPrivateFieldAssignmentsNextNoBundle(t *testing.T) {
 	})
 }
 
+func TestLowerAutoAccessorSetter2020NoBundle(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				class Foo {
+					accessor a = 1
+					static accessor b = 2
+				}
+				new Foo().a = 3
+				Foo.b = 4
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			UnsupportedJSFeatures: es(2020),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerAutoAccessorSetter2020(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				class Foo {
+					accessor a = 1
+					static accessor b = 2
+				}
+				new Foo().a = 3
+				Foo.b = 4
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(2020),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
 func TestLowerPrivateFieldOptionalChain2019NoBundle(t *testing.T) {
 	lower_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1929,3 +1929,152 @@ Users/user/project/src/entry.js: note: Import from "pkg/extra/other/file.js" to
 `,
 	})
 }
+
+func TestPackageJsonYarnPnP(t *testing.T) {
+	packagejson_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import left from 'left-pad'
+				import { pad } from 'pad-user'
+				console.log(left(), pad())
+			`,
+			"/Users/user/project/.pnp.data.json": `
+				{
+					"enableTopLevelFallback": false,
+					"fallbackExclusionList": [],
+					"fallbackPool": [],
+					"packageRegistryData": [
+						[null, [[null, {
+							"packageLocation": "./",
+							"packageDependencies": [["left-pad", "npm:1.3.0"], ["pad-user", "npm:1.0.0"]]
+						}]]],
+						["left-pad", [["npm:1.3.0", {
+							"packageLocation": "./.yarn/unplugged/left-pad-npm-1.3.0/node_modules/left-pad/",
+							"packageDependencies": [["left-pad", "npm:1.3.0"]]
+						}]]],
+						["pad-user", [["npm:1.0.0", {
+							"packageLocation": "./.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/",
+							"packageDependencies": [["pad-user", "npm:1.0.0"], ["lp", ["left-pad", "npm:1.3.0"]]]
+						}]]]
+					]
+				}
+			`,
+			"/Users/user/project/.yarn/unplugged/left-pad-npm-1.3.0/node_modules/left-pad/index.js": `
+				module.exports = function() {
+					return 123
+				}
+			`,
+			"/Users/user/project/.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/package.json": `
+				{
+					"exports": { ".": "./lib/main.js" }
+				}
+			`,
+			"/Users/user/project/.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/lib/main.js": `
+				export { default as pad } from 'lp'
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestPackageJsonYarnPnPUndeclaredDependency(t *testing.T) {
+	packagejson_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import 'pad-user'
+			`,
+			"/Users/user/project/.pnp.data.json": `
+				{
+					"enableTopLevelFallback": false,
+					"packageRegistryData": [
+						[null, [[null, {
+							"packageLocation": "./",
+							"packageDependencies": [["pad-user", "npm:1.0.0"]]
+						}]]],
+						["pad-user", [["npm:1.0.0", {
+							"packageLocation": "./.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/",
+							"packageDependencies": [["pad-user", "npm:1.0.0"], ["react", null]]
+						}]]]
+					]
+				}
+			`,
+			"/Users/user/project/.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/index.js": `
+				import 'left-pad'
+				import 'react'
+			`,
+			"/Users/user/project/node_modules/left-pad/index.js": `
+				console.log('this should not be used')
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+		expectedScanLog: `Users/user/project/.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/index.js: error: Could not resolve "left-pad" (mark it as external to exclude it from the bundle)
+note: The Yarn Plug'n'Play manifest forbids importing "left-pad" here because it's not listed as a dependency of package "pad-user"
+Users/user/project/.yarn/unplugged/pad-user-npm-1.0.0/node_modules/pad-user/index.js: error: Could not resolve "react" (mark it as external to exclude it from the bundle)
+note: The package "react" is a peer dependency of package "pad-user", but it was not provided by the package that depends on it
+`,
+	})
+}
+
+func TestPackageJsonYarnPnPIgnorePattern(t *testing.T) {
+	packagejson_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				import '../.yarn/sdks/tool/index.js'
+			`,
+			"/Users/user/project/.pnp.data.json": `
+				{
+					"enableTopLevelFallback": false,
+					"ignorePatternData": "(^(?:\\.yarn\\/sdks(?:\\/(?!\\.{1,2}(?:\\/|$))(?:(?:(?!(?:^|\\/)\\.{1,2}(?:\\/|$)).)*?)|$))$)",
+					"packageRegistryData": [
+						[null, [[null, {
+							"packageLocation": "./",
+							"packageDependencies": []
+						}]]]
+					]
+				}
+			`,
+			"/Users/user/project/.yarn/sdks/tool/index.js": `
+				import 'left-pad'
+			`,
+			"/Users/user/project/.yarn/sdks/tool/node_modules/left-pad/index.js": `
+				console.log('this should be used')
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestPackageJsonYarnPnPUnsupportedIgnorePattern(t *testing.T) {
+	packagejson_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.js": `
+				console.log('entry')
+			`,
+			"/Users/user/project/.pnp.data.json": `
+				{
+					"ignorePatternData": "^(?!src)",
+					"packageRegistryData": []
+				}
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+		expectedScanLog: `warning: Ignoring the unsupported "ignorePatternData" regular expression in the Yarn PnP manifest "Users/user/project/.pnp.data.json": error parsing regexp: invalid or unsupported Perl syntax: ` + "`(?!`" + `
+`,
+	})
+}
@@ -1,6 +1,7 @@
 package bundler
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/evanw/esbuild/internal/config"
@@ -525,3 +526,473 @@ func TestSplittingPublicPathEntryName(t *testing.T) {
 		},
 	})
 }
+
+func TestSplittingMinifyInline(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {lookup} from "./shared.js"
+				console.log(lookup('a'))
+			`,
+			"/b.js": `
+				import {lookup} from "./shared.js"
+				console.log(lookup('b'))
+			`,
+			"/shared.js": `
+				const table = {a: 1, b: 2}
+				export function lookup(key) { return table[key] }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			MangleInline:  true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingSystemFormat(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {counter, increment} from "./shared.js"
+				import React, {render} from "react"
+				import * as path from "path"
+				increment()
+				console.log(counter, React, render, path.join)
+				import("./c.js").then(c => console.log(c.default, import.meta.url))
+			`,
+			"/b.js": `
+				import {counter} from "./shared.js"
+				export {counter}
+				export * from "lodash"
+				export let value = 1
+				export function setValue(v) { value = v; value++ }
+			`,
+			"/c.js": `
+				export default await 123
+			`,
+			"/shared.js": `
+				export let counter = 0
+				export function increment() { counter += 1 }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatSystem,
+			AbsOutputDir:  "/out",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"react":  true,
+					"path":   true,
+					"lodash": true,
+				},
+			},
+		},
+	})
+}
+
+func TestSplittingSystemFormatMinified(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {value} from "./shared.js"
+				import {render} from "react"
+				render(value)
+			`,
+			"/b.js": `
+				import {value} from "./shared.js"
+				export {value as v}
+			`,
+			"/shared.js": `
+				export let value = 0
+				export function update() { value++ }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			CodeSplitting:     true,
+			OutputFormat:      config.FormatSystem,
+			RemoveWhitespace:  true,
+			MinifyIdentifiers: true,
+			AbsOutputDir:      "/out",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"react": true,
+				},
+			},
+		},
+	})
+}
+
+func TestSplittingSystemFormatHoisting(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {b} from "./b.js"
+				export let x = 1, y
+				export function a() { return b() }
+				;[x, {y}] = [2, {y: 3}]
+				for (x of [4]) if (x) y = x
+				for (y in {z: 5}) ;
+			`,
+			"/b.js": `
+				import {a} from "./a.js"
+				export function b() { return a }
+				export class C {}
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatSystem,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingSystemFormatUpdates(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				export let big = 0n, str = "5", num = 1
+				big++
+				str--
+				export let old = num++
+				export function next() { return [num--, ++num, big++] }
+				export let _t = "this is renamed"
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatSystem,
+			AbsOutputDir:  "/out",
+		},
+	})
+}
+
+func TestSplittingSystemFormatReExportImports(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import ext, {named} from "ext"
+				import * as ns from "ext"
+				export {ext, named, ns}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatSystem,
+			AbsOutputDir:  "/out",
+			ExternalModules: config.ExternalModules{
+				NodeModules: map[string]bool{
+					"ext": true,
+				},
+			},
+		},
+	})
+}
+
+func TestSplittingManualChunks(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {render} from "react-dom"
+				import {createElement} from "react"
+				import {util} from "./util.js"
+				render(createElement("a"), util)
+			`,
+			"/b.js": `
+				import {createElement} from "react"
+				import {util} from "./util.js"
+				console.log(createElement("b"), util)
+			`,
+			"/util.js": `
+				export let util = 123
+			`,
+			"/node_modules/react/index.js": `
+				import {helper} from "shared-helper"
+				export function createElement(tag) { return helper(tag) }
+			`,
+			"/node_modules/react-dom/index.js": `
+				import {createElement} from "react"
+				export function render(el, root) { console.log(el, root, createElement) }
+			`,
+			"/node_modules/shared-helper/index.js": `
+				export function helper(x) { return [x] }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			ManualChunks: []config.ManualChunk{{
+				Name: "vendor",
+				Filters: []*regexp.Regexp{
+					regexp.MustCompile("(^|/)node_modules/react[^/]*(/|$)"),
+				},
+			}},
+		},
+	})
+}
+
+func TestSplittingManualChunksFunc(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {render} from "react-dom"
+				import {util} from "./util.js"
+				render(util)
+			`,
+			"/b.js": `
+				import {util} from "./util.js"
+				import {other} from "./other.js"
+				console.log(util, other)
+			`,
+			"/util.js": `
+				export let util = 123
+			`,
+			"/other.js": `
+				export let other = 234
+			`,
+			"/node_modules/react-dom/index.js": `
+				export function render(root) { console.log(root) }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			ManualChunksFunc: func(path string) string {
+				if path == "/node_modules/react-dom/index.js" {
+					return "vendor"
+				}
+				return ""
+			},
+		},
+	})
+}
+
+func TestSplittingManualChunksFuncInvalidName(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {util} from "./util.js"
+				console.log(util)
+			`,
+			"/b.js": `
+				import {util} from "./util.js"
+				console.log(util)
+			`,
+			"/util.js": `
+				export let util = 123
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			CodeSplitting:    true,
+			OutputFormat:     config.FormatESModule,
+			AbsOutputDir:     "/out",
+			ManualChunksFunc: func(path string) string { return "../util" },
+		},
+		expectedCompileLog: `error: Invalid manual chunk name: "../util"
+`,
+	})
+}
+
+func TestSplittingManualChunksCircular(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {lib} from "./lib.js"
+				console.log(lib)
+			`,
+			"/b.js": `
+				import {lib} from "./lib.js"
+				export let b = lib
+			`,
+			"/lib.js": `
+				import {b} from "./b.js"
+				export function lib() { return b }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			ManualChunks: []config.ManualChunk{{
+				Name:    "lib",
+				Filters: []*regexp.Regexp{regexp.MustCompile("(^|/)lib\\.js(/|$)")},
+			}},
+		},
+		expectedCompileLog: `error: The manual chunk "lib" cannot be generated because it is part of a circular import between chunks
+`,
+	})
+}
+
+func TestSplittingMinChunkSize(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {ab} from "./ab.js"
+				import {abc} from "./abc.js"
+				console.log(ab, abc)
+			`,
+			"/b.js": `
+				import {ab} from "./ab.js"
+				import {abc} from "./abc.js"
+				import {bc} from "./bc.js"
+				console.log(ab, abc, bc)
+			`,
+			"/c.js": `
+				import {abc} from "./abc.js"
+				import {bc} from "./bc.js"
+				console.log(abc, bc)
+			`,
+			"/ab.js": `
+				import {abc} from "./abc.js"
+				export function ab() { return abc }
+			`,
+			"/abc.js": `
+				export let abc = 3
+			`,
+			"/bc.js": `
+				import {abc} from "./abc.js"
+				export let bc = abc
+				console.log("bc has side effects")
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js", "/c.js"},
+		options: config.Options{
+			Mode:             config.ModeBundle,
+			CodeSplitting:    true,
+			OutputFormat:     config.FormatESModule,
+			AbsOutputDir:     "/out",
+			NeedsMetafile:    true,
+			MinChunkSize:     50,
+			ChunkMergeBudget: 100,
+		},
+	})
+}
+
+func TestSplittingMaxChunkSize(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {one} from "./one.js"
+				import {two} from "./two.js"
+				import {three} from "./three.js"
+				console.log(one, two, three)
+			`,
+			"/b.js": `
+				import {one} from "./one.js"
+				import {two} from "./two.js"
+				import {three} from "./three.js"
+				console.log(one, two, three)
+			`,
+			"/one.js": `
+				export function one() { return "the first module in the shared chunk" }
+			`,
+			"/two.js": `
+				import {one} from "./one.js"
+				export function two() { return one() + "the second module in the chunk" }
+			`,
+			"/three.js": `
+				import {cycle} from "./cycle.js"
+				export function three() { return cycle() }
+			`,
+			"/cycle.js": `
+				import {three} from "./three.js"
+				export function cycle() { return three }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			NeedsMetafile: true,
+			MaxChunkSize:  100,
+		},
+	})
+}
+
+func TestSplittingManifest(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/src/a.js": `
+				import {shared} from "./shared.js"
+				import "./a.css"
+				shared()
+				import("./lazy.js")
+			`,
+			"/src/b.js": `
+				import {shared} from "./shared.js"
+				shared()
+			`,
+			"/src/shared.js": `
+				import "./shared.css"
+				export function shared() { console.log("shared") }
+			`,
+			"/src/lazy.js":    `console.log("lazy")`,
+			"/src/a.css":      `a { color: red }`,
+			"/src/shared.css": `b { color: blue }`,
+		},
+		entryPaths: []string{"/src/a.js", "/src/b.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			CodeSplitting:     true,
+			OutputFormat:      config.FormatESModule,
+			AbsOutputDir:      "/out",
+			EntryPathTemplate: []config.PathTemplate{{Data: "./entries/", Placeholder: config.NamePlaceholder}},
+			NeedsManifest:     true,
+		},
+	})
+}
+
+func TestSplittingIntegrity(t *testing.T) {
+	splitting_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import {shared} from "./shared.js"
+				shared()
+				import("./lazy.js")
+			`,
+			"/b.js": `
+				import {shared} from "./shared.js"
+				shared()
+			`,
+			"/shared.js": `export function shared() { console.log("shared") }`,
+			"/lazy.js":   `console.log("lazy")`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			CodeSplitting: true,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputDir:  "/out",
+			NeedsMetafile: true,
+			Integrity:     config.IntegritySHA384,
+			IntegrityMap:  true,
+		},
+	})
+}
@@ -95,7 +95,7 @@ func (s *suite) expectBundled(t *testing.T, args bundled) {
 
 		log = logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
 		args.options.OmitRuntimeForTests = true
-		results, _ := bundle.Compile(log, args.options, nil)
+		results, metafileJSON := bundle.Compile(log, args.options, nil)
 		msgs = log.Done()
 		assertLog(t, msgs, args.expectedCompileLog)
 
@@ -116,6 +116,9 @@ func (s *suite) expectBundled(t *testing.T, args bundled) {
 				generated += fmt.Sprintf("---------- %s ----------\n%s", result.AbsPath, string(result.Contents))
 			}
 		}
+		if args.options.NeedsMetafile {
+			generated += fmt.Sprintf("\n---------- metafile.json ----------\n%s", metafileJSON)
+		}
 		s.compareSnapshot(t, testName, generated)
 	})
 }
@@ -863,6 +863,222 @@ func TestTsconfigJsonExtendsPackage(t *testing.T) {
 	})
 }
 
+func TestTsconfigJsonExtendsPackageExports(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/app/entry.jsx": `
+				console.log(<div/>)
+			`,
+			"/Users/user/project/src/tsconfig.json": `
+				{
+					"extends": "@package/foo/react"
+				}
+			`,
+			"/Users/user/project/node_modules/@package/foo/package.json": `
+				{
+					"exports": {
+						"./react": {
+							"import": "./bad.json",
+							"require": "./configs/react.json"
+						}
+					}
+				}
+			`,
+			"/Users/user/project/node_modules/@package/foo/react/tsconfig.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "bad"
+					}
+				}
+			`,
+			"/Users/user/project/node_modules/@package/foo/configs/react.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "worked"
+					}
+				}
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/app/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigJsonExtendsPackageTsconfigField(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/app/entry.jsx": `
+				console.log(<div/>)
+			`,
+			"/Users/user/project/src/tsconfig.json": `
+				{
+					"extends": "@package/foo"
+				}
+			`,
+			"/Users/user/project/node_modules/@package/foo/package.json": `
+				{
+					"tsconfig": "./configs/base.json"
+				}
+			`,
+			"/Users/user/project/node_modules/@package/foo/tsconfig.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "bad"
+					}
+				}
+			`,
+			"/Users/user/project/node_modules/@package/foo/configs/base.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "worked"
+					}
+				}
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/app/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigJsonExtendsArray(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.jsx": `
+				console.log(<div/>, <></>)
+			`,
+			"/Users/user/project/tsconfig.json": `
+				{
+					"extends": ["./configs/a", "@package/b"],
+					"compilerOptions": {
+						"jsxFragmentFactory": "ownFragment"
+					}
+				}
+			`,
+			"/Users/user/project/configs/a.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "aFactory",
+						"jsxFragmentFactory": "aFragment"
+					}
+				}
+			`,
+			"/Users/user/project/node_modules/@package/b/tsconfig.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "bFactory"
+					}
+				}
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigJsonReferences(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.tsx": `
+				import value from '@/value'
+				import config from '../vite.config'
+				console.log(<div/>, value, config)
+			`,
+			"/Users/user/project/src/value.ts": `
+				export default 123
+			`,
+			"/Users/user/project/vite.config.tsx": `
+				export default <div/>
+			`,
+			"/Users/user/project/tsconfig.json": `
+				{
+					"files": [],
+					"references": [
+						{ "path": "./tsconfig.app.json" },
+						{ "path": "./tsconfig.node.json" }
+					]
+				}
+			`,
+			"/Users/user/project/tsconfig.app.json": `
+				{
+					"include": ["src"],
+					"compilerOptions": {
+						"jsxFactory": "app",
+						"paths": {
+							"@/*": ["./src/*"]
+						}
+					}
+				}
+			`,
+			"/Users/user/project/tsconfig.node.json": `
+				{
+					"include": ["vite.config.tsx"],
+					"compilerOptions": {
+						"jsxFactory": "node"
+					}
+				}
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.tsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigJsonReferencesDirectory(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/entry.tsx": `
+				import '@/lib'
+				console.log(<div/>)
+			`,
+			"/Users/user/project/tsconfig.json": `
+				{
+					"compilerOptions": {
+						"jsxFactory": "root",
+						"paths": {
+							"@/*": ["./packages/*"]
+						}
+					},
+					"references": [
+						{ "path": "./packages" },
+						{ "path": "./missing" }
+					]
+				}
+			`,
+			"/Users/user/project/packages/lib.tsx": `
+				console.log(<div/>)
+			`,
+			"/Users/user/project/packages/tsconfig.json": `
+				{
+					"include": ["**/*.tsx"],
+					"exclude": ["excluded"],
+					"compilerOptions": {
+						"jsxFactory": "packages"
+					}
+				}
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/entry.tsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+		expectedScanLog: `warning: Cannot find the project "Users/user/project/missing/tsconfig.json" referenced by "Users/user/project/tsconfig.json"
+`,
+	})
+}
+
 func TestTsconfigJsonOverrideMissing(t *testing.T) {
 	tsconfig_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1269,3 +1485,39 @@ func TestTsconfigUseDefineForClassFieldsESNext(t *testing.T) {
 		},
 	})
 }
+
+func TestTsconfigExperimentalDecoratorsFalse(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import { legacy } from './legacy/foo'
+				declare let dec: any
+				@dec class Foo {
+					@dec method() {}
+				}
+				console.log(Foo, legacy)
+			`,
+			"/Users/user/project/src/tsconfig.json": `{
+				"compilerOptions": {
+					"experimentalDecorators": false
+				}
+			}`,
+			"/Users/user/project/src/legacy/foo.ts": `
+				declare let dec: any
+				@dec export class legacy {
+					@dec method() {}
+				}
+			`,
+			"/Users/user/project/src/legacy/tsconfig.json": `{
+				"compilerOptions": {
+					"experimentalDecorators": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
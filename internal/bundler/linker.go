@@ -19,6 +19,7 @@ import (
 	"github.com/evanw/esbuild/internal/fs"
 	"github.com/evanw/esbuild/internal/graph"
 	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/html_ast"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/js_lexer"
 	"github.com/evanw/esbuild/internal/js_printer"
@@ -48,6 +49,16 @@ type linkerContext struct {
 	cjsRuntimeRef js_ast.Ref
 	esmRuntimeRef js_ast.Ref
 
+	// These are the results of the optional inlining pass. They are passed to
+	// the printer, which does the actual substitution.
+	constValues      map[js_ast.Ref]js_ast.Expr
+	inlinedFunctions map[js_ast.Ref]*js_printer.InlinedFunction
+
+	// If the metafile is needed, this holds the files that caused each file to
+	// be included by tree shaking, indexed by source index. This is used to
+	// explain why each file is in the bundle.
+	includedBy [][]uint32
+
 	// This represents the parallel computation of source map related data.
 	// Calling this will block until the computation is done. The resulting value
 	// is shared between threads and must be treated as immutable.
@@ -77,6 +88,20 @@ type chunkInfo struct {
 	sourceIndex   uint32 // An index into "c.sources"
 	entryPointBit uint   // An index into "c.graph.EntryPoints"
 
+	// This is only set for chunks created by the "ManualChunks" option. The
+	// entry bits of these chunks are the union of the entry bits of all files
+	// in the chunk.
+	manualChunkName string
+
+	// If this chunk is one of the pieces of a chunk that was split because of
+	// the "MaxChunkSize" option, this is the previous piece. That piece must be
+	// evaluated before this one.
+	previousPieceIndex ast.Index32
+
+	// The chunks that were merged into this chunk or the way it was split due
+	// to the "MinChunkSize" and "MaxChunkSize" options. This is for the metafile.
+	sizeDecisions []chunkSizeDecision
+
 	// For code splitting
 	crossChunkImports []chunkImport
 
@@ -154,8 +179,9 @@ type intermediateOutput struct {
 
 type chunkRepr interface{ isChunk() }
 
-func (*chunkReprJS) isChunk()  {}
-func (*chunkReprCSS) isChunk() {}
+func (*chunkReprJS) isChunk()   {}
+func (*chunkReprCSS) isChunk()  {}
+func (*chunkReprHTML) isChunk() {}
 
 type chunkReprJS struct {
 	filesInChunkInOrder []uint32
@@ -173,6 +199,11 @@ type chunkReprCSS struct {
 	filesInChunkInOrder    []uint32
 }
 
+// HTML chunks are always entry points. The only file in the chunk is the HTML
+// file itself, which is copied through with the URLs of referenced files
+// replaced by the paths of their output files.
+type chunkReprHTML struct{}
+
 type externalImportCSS struct {
 	path       logger.Path
 	conditions []css_ast.Token
@@ -265,7 +296,7 @@ func link(
 			// when the global name is present, since that's the only way the exports
 			// can actually be observed externally.
 			if repr.AST.ExportKeyword.Len > 0 && (options.OutputFormat == config.FormatCommonJS ||
-				(options.OutputFormat == config.FormatIIFE && len(options.GlobalName) > 0)) {
+				options.OutputFormat == config.FormatUMD || (options.OutputFormat == config.FormatIIFE && len(options.GlobalName) > 0)) {
 				repr.AST.UsesExportsRef = true
 				repr.Meta.ForceIncludeExportsForEntryPoint = true
 			}
@@ -288,6 +319,10 @@ func link(
 
 	c.treeShakingAndCodeSplitting()
 
+	if c.options.MangleInline && c.options.Mode == config.ModeBundle {
+		c.inlineConstantsAndFunctions()
+	}
+
 	if c.options.Mode == config.ModePassThrough {
 		for _, entryPoint := range c.graph.EntryPoints() {
 			c.preventExportsFromBeingRenamed(entryPoint.SourceIndex)
@@ -308,19 +343,32 @@ func link(
 // never generate chunks that import each other since files are allocated to
 // chunks based on which entry points they are reachable from.
 //
-// This will change in the future when we allow manual chunk labels. But before
-// we allow manual chunk labels, we'll need to rework module initialization to
-// allow code splitting chunks to be lazily-initialized.
+// This isn't true for manual chunks, which can be made to import each other
+// by the way files are assigned to them. But we'll need to rework module
+// initialization to allow code splitting chunks to be lazily-initialized
+// before these cycles can be supported.
 //
 // Since that work hasn't been finished yet, cycles in the chunk import graph
 // can cause initialization bugs. So let's forbid these cycles for now to guard
 // against code splitting bugs that could cause us to generate buggy chunks.
 func (c *linkerContext) enforceNoCyclicChunkImports(chunks []chunkInfo) {
+	reportedManualChunks := make(map[string]bool)
 	var validate func(int, []int)
 	validate = func(chunkIndex int, path []int) {
-		for _, otherChunkIndex := range path {
+		for i, otherChunkIndex := range path {
 			if chunkIndex == otherChunkIndex {
-				c.log.AddError(nil, logger.Loc{}, "Internal error: generated chunks contain a circular import")
+				// Blame the manual chunk if there is one in the cycle
+				for _, cycleChunkIndex := range path[i:] {
+					if name := chunks[cycleChunkIndex].manualChunkName; name != "" {
+						if !reportedManualChunks[name] {
+							reportedManualChunks[name] = true
+							c.log.AddID(logger.MsgID_Bundler_ManualChunkCycle, logger.Error, nil, logger.Range{}, fmt.Sprintf(
+								"The manual chunk %q cannot be generated because it is part of a circular import between chunks", name))
+						}
+						return
+					}
+				}
+				c.log.AddID(logger.MsgID_Bundler_InternalError, logger.Error, nil, logger.Range{}, "Internal error: generated chunks contain a circular import")
 				return
 			}
 		}
@@ -354,6 +402,8 @@ func (c *linkerContext) generateChunksInParallel(chunks []chunkInfo) []graph.Out
 			go c.generateChunkJS(chunks, chunkIndex, &generateWaitGroup)
 		case *chunkReprCSS:
 			go c.generateChunkCSS(chunks, chunkIndex, &generateWaitGroup)
+		case *chunkReprHTML:
+			go c.generateChunkHTML(chunks, chunkIndex, &generateWaitGroup)
 		}
 	}
 	c.enforceNoCyclicChunkImports(chunks)
@@ -405,6 +455,9 @@ func (c *linkerContext) generateChunksInParallel(chunks []chunkInfo) []graph.Out
 				for _, sourceIndex := range chunkRepr.filesInChunkInOrder {
 					outputFiles = append(outputFiles, c.graph.Files[sourceIndex].InputFile.AdditionalFiles...)
 				}
+
+			case *chunkReprHTML:
+				outputFiles = append(outputFiles, c.graph.Files[chunk.sourceIndex].InputFile.AdditionalFiles...)
 			}
 
 			// Path substitution for the chunk itself
@@ -484,12 +537,33 @@ func (c *linkerContext) generateChunksInParallel(chunks []chunkInfo) []graph.Out
 				jsonMetadataChunk = string(jsonMetadataChunkBytes.Done())
 			}
 
+			// Generate the manifest entry for this chunk. JS entry points that import
+			// CSS files also generate a CSS chunk, but that's included in the entry
+			// for the JS chunk instead of being an entry of its own.
+			var jsonManifestChunk string
+			if c.options.NeedsManifest && chunk.isEntryPoint && c.graph.Files[chunk.sourceIndex].EntryPointChunkIndex == uint32(chunkIndex) {
+				jsonManifestChunk = c.generateManifestChunk(chunks, uint32(chunkIndex))
+			}
+
+			// Remember which chunks this chunk loads lazily for the integrity map
+			var dynamicImportAbsPaths []string
+			if c.options.IntegrityMap {
+				for _, chunkImport := range chunk.crossChunkImports {
+					if chunkImport.importKind == ast.ImportDynamic {
+						dynamicImportAbsPaths = append(dynamicImportAbsPaths,
+							c.fs.Join(c.options.AbsOutputDir, chunks[chunkImport.chunkIndex].finalRelPath))
+					}
+				}
+			}
+
 			// Generate the output file for this chunk
 			outputFiles = append(outputFiles, graph.OutputFile{
-				AbsPath:           c.fs.Join(c.options.AbsOutputDir, chunk.finalRelPath),
-				Contents:          outputContents,
-				JSONMetadataChunk: jsonMetadataChunk,
-				IsExecutable:      chunk.isExecutable,
+				AbsPath:               c.fs.Join(c.options.AbsOutputDir, chunk.finalRelPath),
+				Contents:              outputContents,
+				JSONMetadataChunk:     jsonMetadataChunk,
+				JSONManifestChunk:     jsonManifestChunk,
+				DynamicImportAbsPaths: dynamicImportAbsPaths,
+				IsExecutable:          chunk.isExecutable,
 			})
 
 			results[chunkIndex] = outputFiles
@@ -576,7 +650,7 @@ func (c *linkerContext) pathBetweenChunks(fromRelDir string, toRelPath string) s
 	// Otherwise, return a relative path
 	relPath, ok := c.fs.Rel(fromRelDir, toRelPath)
 	if !ok {
-		c.log.AddError(nil, logger.Loc{},
+		c.log.AddID(logger.MsgID_Bundler_InternalError, logger.Error, nil, logger.Range{},
 			fmt.Sprintf("Cannot traverse from directory %q to chunk %q", fromRelDir, toRelPath))
 		return ""
 	}
@@ -690,6 +764,21 @@ func (c *linkerContext) computeCrossChunkDependencies(chunks []chunkInfo) {
 	c.timer.Begin("Compute cross-chunk dependencies")
 	defer c.timer.End("Compute cross-chunk dependencies")
 
+	// HTML chunks depend on the chunks for the scripts and stylesheets that they
+	// reference. This is needed to include their hashes in the hash of the HTML
+	// chunk, and to include them in the metafile.
+	for chunkIndex := range chunks {
+		chunk := &chunks[chunkIndex]
+		if _, ok := chunk.chunkRepr.(*chunkReprHTML); ok {
+			for _, otherChunkIndex := range c.chunksReferencedByHTML(chunks, chunk.sourceIndex) {
+				chunk.crossChunkImports = append(chunk.crossChunkImports, chunkImport{
+					chunkIndex: otherChunkIndex,
+					importKind: ast.ImportEntryPoint,
+				})
+			}
+		}
+	}
+
 	jsChunks := 0
 	for _, chunk := range chunks {
 		if _, ok := chunk.chunkRepr.(*chunkReprJS); ok {
@@ -872,6 +961,13 @@ func (c *linkerContext) computeCrossChunkDependencies(chunks []chunkInfo) {
 			}
 		}
 
+		// The pieces of a split chunk must be evaluated in order, even if this
+		// piece doesn't use anything from the previous piece
+		if chunk.previousPieceIndex.IsValid() {
+			otherChunkIndex := chunk.previousPieceIndex.GetIndex()
+			chunkRepr.importsFromOtherChunks[otherChunkIndex] = chunkRepr.importsFromOtherChunks[otherChunkIndex]
+		}
+
 		// If this is an entry point, make sure we import all chunks belonging to
 		// this entry point, even if there are no imports. We need to make sure
 		// these chunks are evaluated for their side effects too.
@@ -914,7 +1010,7 @@ func (c *linkerContext) computeCrossChunkDependencies(chunks []chunkInfo) {
 
 		chunkRepr.exportsToOtherChunks = make(map[js_ast.Ref]string)
 		switch c.options.OutputFormat {
-		case config.FormatESModule:
+		case config.FormatESModule, config.FormatSystem:
 			r := renamer.ExportRenamer{}
 			var items []js_ast.ClauseItem
 			for _, export := range c.sortedCrossChunkExportItems(chunkMetas[chunkIndex].exports) {
@@ -952,7 +1048,7 @@ func (c *linkerContext) computeCrossChunkDependencies(chunks []chunkInfo) {
 
 		for _, crossChunkImport := range c.sortedCrossChunkImports(chunks, chunkRepr.importsFromOtherChunks) {
 			switch c.options.OutputFormat {
-			case config.FormatESModule:
+			case config.FormatESModule, config.FormatSystem:
 				var items []js_ast.ClauseItem
 				for _, item := range crossChunkImport.sortedImportItems {
 					items = append(items, js_ast.ClauseItem{Name: js_ast.LocRef{Ref: item.ref}, Alias: item.exportAlias})
@@ -1099,6 +1195,19 @@ func (c *linkerContext) scanImportsAndExports() {
 			}
 			file.InputFile.AdditionalFiles = additionalFiles
 
+		case *graph.HTMLRepr:
+			// Copy the additional files for assets to the output directory. The
+			// URLs themselves are substituted when the HTML chunk is generated.
+			var additionalFiles []graph.OutputFile
+			visited := make(map[uint32]bool)
+			for _, record := range repr.AST.ImportRecords {
+				if record.Kind == ast.ImportURL && record.SourceIndex.IsValid() && !visited[record.SourceIndex.GetIndex()] {
+					visited[record.SourceIndex.GetIndex()] = true
+					additionalFiles = append(additionalFiles, c.graph.Files[record.SourceIndex.GetIndex()].InputFile.AdditionalFiles...)
+				}
+			}
+			file.InputFile.AdditionalFiles = additionalFiles
+
 		case *graph.JSRepr:
 			for importRecordIndex := range repr.AST.ImportRecords {
 				record := &repr.AST.ImportRecords[importRecordIndex]
@@ -1164,7 +1273,8 @@ func (c *linkerContext) scanImportsAndExports() {
 			// resulting wrapper won't be invoked by other files. An exception is made
 			// for entry point files in CommonJS format (or when in pass-through mode).
 			if repr.AST.ExportsKind == js_ast.ExportsCommonJS && (!file.IsEntryPoint() ||
-				c.options.OutputFormat == config.FormatIIFE || c.options.OutputFormat == config.FormatESModule) {
+				c.options.OutputFormat == config.FormatIIFE || c.options.OutputFormat == config.FormatESModule ||
+				c.options.OutputFormat == config.FormatUMD || c.options.OutputFormat == config.FormatSystem) {
 				repr.Meta.Wrap = graph.WrapCJS
 			}
 		}
@@ -1347,7 +1457,7 @@ func (c *linkerContext) scanImportsAndExports() {
 		// Pre-generate symbols for re-exports CommonJS symbols in case they
 		// are necessary later. This is done now because the symbols map cannot be
 		// mutated later due to parallelism.
-		if file.IsEntryPoint() && c.options.OutputFormat == config.FormatESModule {
+		if file.IsEntryPoint() && (c.options.OutputFormat == config.FormatESModule || c.options.OutputFormat == config.FormatSystem) {
 			copies := make([]js_ast.Ref, len(repr.Meta.SortedAndFilteredExportAliases))
 			for i, alias := range repr.Meta.SortedAndFilteredExportAliases {
 				copies[i] = c.graph.GenerateNewSymbol(sourceIndex, js_ast.SymbolOther, "export_"+alias)
@@ -1966,7 +2076,7 @@ func (c *linkerContext) matchImportsWithExportsForFile(sourceIndex uint32) {
 
 		case matchImportCycle:
 			namedImport := repr.AST.NamedImports[importRef]
-			c.log.AddRangeError(file.LineColumnTracker(), js_lexer.RangeOfIdentifier(file.InputFile.Source, namedImport.AliasLoc),
+			c.log.AddID(logger.MsgID_Bundler_MissingExport, logger.Error, file.LineColumnTracker(), js_lexer.RangeOfIdentifier(file.InputFile.Source, namedImport.AliasLoc),
 				fmt.Sprintf("Detected cycle while resolving import %q", namedImport.Alias))
 
 		case matchImportProbablyTypeScriptType:
@@ -2000,10 +2110,10 @@ func (c *linkerContext) matchImportsWithExportsForFile(sourceIndex uint32) {
 				// "undefined" instead of emitting an error.
 				symbol.ImportItemStatus = js_ast.ImportItemMissing
 				msg := fmt.Sprintf("Import %q will always be undefined because there are multiple matching exports", namedImport.Alias)
-				c.log.AddRangeWarningWithNotes(file.LineColumnTracker(), r, msg, notes)
+				c.log.AddIDWithNotes(logger.MsgID_Bundler_AmbiguousReexport, logger.Warning, file.LineColumnTracker(), r, msg, notes)
 			} else {
 				msg := fmt.Sprintf("Ambiguous import %q has multiple matching exports", namedImport.Alias)
-				c.log.AddRangeErrorWithNotes(file.LineColumnTracker(), r, msg, notes)
+				c.log.AddIDWithNotes(logger.MsgID_Bundler_MissingExport, logger.Error, file.LineColumnTracker(), r, msg, notes)
 			}
 		}
 	}
@@ -2105,7 +2215,9 @@ loop:
 			if status == importCommonJSWithoutExports {
 				symbol := c.graph.Symbols.Get(tracker.importRef)
 				symbol.ImportItemStatus = js_ast.ImportItemMissing
-				c.log.AddRangeWarning(
+				c.log.AddID(
+					logger.MsgID_Bundler_ImportIsUndefined,
+					logger.Warning,
 					trackerFile.LineColumnTracker(),
 					js_lexer.RangeOfIdentifier(trackerFile.InputFile.Source, namedImport.AliasLoc),
 					fmt.Sprintf("Import %q will always be undefined because the file %q has no exports",
@@ -2144,10 +2256,10 @@ loop:
 				// time, so we emit a warning and rewrite the value to the literal
 				// "undefined" instead of emitting an error.
 				symbol.ImportItemStatus = js_ast.ImportItemMissing
-				c.log.AddRangeWarning(trackerFile.LineColumnTracker(), r, fmt.Sprintf(
+				c.log.AddID(logger.MsgID_Bundler_ImportIsUndefined, logger.Warning, trackerFile.LineColumnTracker(), r, fmt.Sprintf(
 					"Import %q will always be undefined because there is no matching export", namedImport.Alias))
 			} else {
-				c.log.AddRangeError(trackerFile.LineColumnTracker(), r, fmt.Sprintf("No matching export in %q for import %q",
+				c.log.AddID(logger.MsgID_Bundler_MissingExport, logger.Error, trackerFile.LineColumnTracker(), r, fmt.Sprintf("No matching export in %q for import %q",
 					c.graph.Files[nextTracker.sourceIndex].InputFile.Source.PrettyPath, namedImport.Alias))
 			}
 
@@ -2434,7 +2546,7 @@ func (c *linkerContext) advanceImportTracker(tracker importTracker) (importTrack
 	otherRepr := c.graph.Files[otherSourceIndex].InputFile.Repr.(*graph.JSRepr)
 	if !namedImport.AliasIsStar && !otherRepr.AST.HasLazyExport &&
 		// CommonJS exports
-		otherRepr.AST.ExportKeyword.Len == 0 && namedImport.Alias != "default" &&
+		otherRepr.AST.ExportKeyword.Len == 0 && !otherRepr.AST.IsHoistedCommonJS && namedImport.Alias != "default" &&
 		// ESM exports
 		!otherRepr.AST.UsesExportsRef && !otherRepr.AST.UsesModuleRef {
 		// Just warn about it and replace the import with "undefined"
@@ -2480,8 +2592,17 @@ func (c *linkerContext) advanceImportTracker(tracker importTracker) (importTrack
 }
 
 func (c *linkerContext) treeShakingAndCodeSplitting() {
+	// Purity analysis: Code that only calls pure functions can be removed if
+	// unused, so this must be done before tree shaking
+	if c.options.InferPureFunctions && !c.options.IgnoreDCEAnnotations {
+		c.markCodeWithPureCallsAsRemovable()
+	}
+
 	// Tree shaking: Each entry point marks all files reachable from itself
 	c.timer.Begin("Tree shaking")
+	if c.options.NeedsMetafile {
+		c.includedBy = make([][]uint32, len(c.graph.Files))
+	}
 	for _, entryPoint := range c.graph.EntryPoints() {
 		c.markFileLiveForTreeShaking(entryPoint.SourceIndex)
 	}
@@ -2498,6 +2619,89 @@ func (c *linkerContext) treeShakingAndCodeSplitting() {
 	c.timer.End("Code splitting")
 }
 
+// The parser records which top-level functions and classes are pure as long
+// as the functions they call are pure too, but it can't see across files. This
+// resolves those calls across the whole bundle and then allows parts whose only
+// side effects are calls to pure functions to be removed if they are unused.
+func (c *linkerContext) markCodeWithPureCallsAsRemovable() {
+	c.timer.Begin("Purity analysis")
+	defer c.timer.End("Purity analysis")
+
+	// Collect the candidates along with the calls they make
+	candidates := make(map[js_ast.Ref][]js_ast.Ref)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+
+		// Don't trust code that could reassign top-level symbols using direct eval
+		if !ok || sourceIndex == runtime.SourceIndex || repr.AST.ModuleScope.ContainsDirectEval {
+			continue
+		}
+
+		for _, part := range repr.AST.Parts {
+			if !part.IsPureFunction {
+				continue
+			}
+			for _, declared := range part.DeclaredSymbols {
+				// The symbol must never refer to anything else
+				if !declared.IsTopLevel || len(repr.TopLevelSymbolToParts(declared.Ref)) != 1 {
+					continue
+				}
+				ref := js_ast.FollowSymbols(c.graph.Symbols, declared.Ref)
+				if !c.graph.Symbols.Get(ref).CouldPotentiallyBeMutated {
+					candidates[ref] = part.PureCallees
+				}
+			}
+		}
+	}
+
+	pure := make(map[js_ast.Ref]bool)
+	isPure := func(ref js_ast.Ref) bool {
+		return pure[js_ast.FollowSymbols(c.graph.Symbols, ref)]
+	}
+
+	// Then repeatedly mark the candidates that only call pure functions as pure
+	// until nothing changes anymore. Nothing is assumed to be pure up front, so
+	// recursive and mutually-recursive functions are never considered pure. This
+	// matches how loops are handled since neither is guaranteed to terminate.
+	for changed := true; changed; {
+		changed = false
+	nextCandidate:
+		for ref, callees := range candidates {
+			if pure[ref] {
+				continue
+			}
+			for _, callee := range callees {
+				if !isPure(callee) {
+					continue nextCandidate
+				}
+			}
+			pure[ref] = true
+			changed = true
+		}
+	}
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			continue
+		}
+
+	nextPart:
+		for partIndex := range repr.AST.Parts {
+			part := &repr.AST.Parts[partIndex]
+			if !part.CanBeRemovedIfCalleesArePure {
+				continue
+			}
+			for _, callee := range part.PureCallees {
+				if !isPure(callee) {
+					continue nextPart
+				}
+			}
+			part.CanBeRemovedIfUnused = true
+		}
+	}
+}
+
 func (c *linkerContext) markFileReachableForCodeSplitting(sourceIndex uint32, entryPointBit uint, distanceFromEntryPoint uint32) {
 	file := &c.graph.Files[sourceIndex]
 	if !file.IsLive {
@@ -2589,6 +2793,7 @@ func (c *linkerContext) markFileLiveForTreeShaking(sourceIndex uint32) {
 					}
 
 					// Otherwise, include this module for its side effects
+					c.recordInclusion(sourceIndex, otherSourceIndex)
 					c.markFileLiveForTreeShaking(otherSourceIndex)
 				}
 
@@ -2609,12 +2814,50 @@ func (c *linkerContext) markFileLiveForTreeShaking(sourceIndex uint32) {
 		// Include all "@import" rules
 		for _, record := range repr.AST.ImportRecords {
 			if record.SourceIndex.IsValid() {
+				c.recordInclusion(sourceIndex, record.SourceIndex.GetIndex())
 				c.markFileLiveForTreeShaking(record.SourceIndex.GetIndex())
 			}
 		}
 	}
 }
 
+// This remembers that "importer" caused "imported" to be included so that the
+// metafile can explain why each file is in the bundle
+func (c *linkerContext) recordInclusion(importer uint32, imported uint32) {
+	if c.includedBy == nil || importer == imported {
+		return
+	}
+	for _, other := range c.includedBy[imported] {
+		if other == importer {
+			return
+		}
+	}
+	c.includedBy[imported] = append(c.includedBy[imported], importer)
+
+	// Importing the JavaScript stub for a CSS file also includes the CSS file
+	if repr, ok := c.graph.Files[imported].InputFile.Repr.(*graph.JSRepr); ok && repr.CSSSourceIndex.IsValid() {
+		c.recordInclusion(importer, repr.CSSSourceIndex.GetIndex())
+	}
+}
+
+func (c *linkerContext) generateIncludedByJSON(sourceIndex uint32) string {
+	if c.includedBy == nil || len(c.includedBy[sourceIndex]) == 0 {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(",\n          \"includedBy\": [")
+	for i, importer := range c.includedBy[sourceIndex] {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n            ")
+		sb.Write(js_printer.QuoteForJSON(c.graph.Files[importer].InputFile.Source.PrettyPath, c.options.ASCIIOnly))
+	}
+	sb.WriteString("\n          ]")
+	return sb.String()
+}
+
 func (c *linkerContext) isExternalDynamicImport(record *ast.ImportRecord, sourceIndex uint32) bool {
 	return record.Kind == ast.ImportDynamic && c.graph.Files[record.SourceIndex.GetIndex()].IsEntryPoint() && record.SourceIndex.GetIndex() != sourceIndex
 }
@@ -2635,138 +2878,674 @@ func (c *linkerContext) markPartLiveForTreeShaking(sourceIndex uint32, partIndex
 
 	// Also include any dependencies
 	for _, dep := range part.Dependencies {
+		c.recordInclusion(sourceIndex, dep.SourceIndex)
 		c.markPartLiveForTreeShaking(dep.SourceIndex, dep.PartIndex)
 	}
 }
 
-func sanitizeFilePathForVirtualModulePath(path string) string {
-	// Convert it to a safe file path. See: https://stackoverflow.com/a/31976060
-	sb := strings.Builder{}
-	needsGap := false
-	for _, c := range path {
-		switch c {
-		case 0:
-			// These characters are forbidden on Unix and Windows
+type inlinedCall struct {
+	fn    *js_printer.InlinedFunction
+	ref   js_ast.Ref
+	count uint32
+}
 
-		case '<', '>', ':', '"', '|', '?', '*':
-			// These characters are forbidden on Windows
+// A top-level declaration that other inlined values may reference. The value
+// is nil for function declarations.
+type inlineDecl struct {
+	value       js_ast.Expr
+	sourceIndex uint32
+}
 
-		default:
-			if c < 0x20 {
-				// These characters are forbidden on Windows
-				break
+// Function bodies larger than this (measured in expression nodes) are never
+// inlined. Inlining is only beneficial for tiny functions since the function
+// body is duplicated at every call site.
+const maxInlinedFunctionBodySize = 10
+
+// This is an optional pass that runs after tree shaking. It replaces top-level
+// symbols that are only used once with their values and replaces calls to
+// small functions with the function body. This runs after linking so that it
+// also works across modules. The actual substitution is done by the printer
+// so that the AST, which may be shared with other builds, isn't modified.
+//
+// Only values that evaluate to the same thing no matter where they are
+// evaluated are substituted: primitive literals, expressions on primitive
+// literals and constants (e.g. "`${PREFIX}:${VERSION}`"), and aliases of
+// constants and function declarations (e.g. "const log = console_log").
+func (c *linkerContext) inlineConstantsAndFunctions() {
+	c.timer.Begin("Inline constants and functions")
+	defer c.timer.End("Inline constants and functions")
+
+	// Count the uses of each symbol in all live code
+	uses := make(map[js_ast.Ref]uint32)
+	calls := make(map[js_ast.Ref]uint32)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		if repr, ok := file.InputFile.Repr.(*graph.JSRepr); ok && file.IsLive {
+			for _, part := range repr.AST.Parts {
+				if !part.IsLive {
+					continue
+				}
+				for ref, use := range part.SymbolUses {
+					uses[js_ast.FollowSymbols(c.graph.Symbols, ref)] += use.CountEstimate
+				}
+				for ref, use := range part.SymbolCallUses {
+					calls[js_ast.FollowSymbols(c.graph.Symbols, ref)] += use.CallCountEstimate
+				}
 			}
+		}
+	}
 
-			// Turn runs of invalid characters into a '_'
-			if needsGap {
-				sb.WriteByte('_')
-				needsGap = false
+	// Symbols exported from entry points must be kept around
+	isExportedFromEntryPoint := make(map[js_ast.Ref]bool)
+	for _, entryPoint := range c.graph.EntryPoints() {
+		if repr, ok := c.graph.Files[entryPoint.SourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			for _, export := range repr.Meta.ResolvedExports {
+				isExportedFromEntryPoint[js_ast.FollowSymbols(c.graph.Symbols, export.Ref)] = true
 			}
+		}
+	}
 
-			sb.WriteRune(c)
+	// Collect the top-level declarations that inlined values may reference.
+	// Only files that aren't wrapped are considered since the top-level
+	// symbols of a wrapped file may not be initialized yet at the use site.
+	decls := make(map[js_ast.Ref]inlineDecl)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		repr, ok := file.InputFile.Repr.(*graph.JSRepr)
+		if !ok || !file.IsLive || sourceIndex == runtime.SourceIndex || repr.AST.ModuleScope.ContainsDirectEval || repr.Meta.Wrap != graph.WrapNone {
 			continue
 		}
+		for _, part := range repr.AST.Parts {
+			if !part.IsLive || len(part.Stmts) != 1 {
+				continue
+			}
+			switch s := part.Stmts[0].Data.(type) {
+			case *js_ast.SFunction:
+				decls[js_ast.FollowSymbols(c.graph.Symbols, s.Fn.Name.Ref)] = inlineDecl{sourceIndex: sourceIndex}
 
-		if sb.Len() > 0 {
-			needsGap = true
+			case *js_ast.SLocal:
+				if len(s.Decls) == 1 && s.Decls[0].ValueOrNil.Data != nil {
+					if id, ok := s.Decls[0].Binding.Data.(*js_ast.BIdentifier); ok {
+						decls[js_ast.FollowSymbols(c.graph.Symbols, id.Ref)] = inlineDecl{value: s.Decls[0].ValueOrNil, sourceIndex: sourceIndex}
+					}
+				}
+			}
 		}
 	}
 
-	// Make sure the name isn't empty
-	if sb.Len() == 0 {
-		return "_"
+	// Returns the declaration for this symbol if it's a constant or a function
+	// declaration that is never reassigned, so it's safe to reference anywhere
+	stableDecl := func(ref js_ast.Ref) (inlineDecl, bool) {
+		ref = js_ast.FollowSymbols(c.graph.Symbols, ref)
+		decl, ok := decls[ref]
+		if !ok {
+			return inlineDecl{}, false
+		}
+		symbol := c.graph.Symbols.Get(ref)
+		if symbol.CouldPotentiallyBeMutated || (symbol.Kind != js_ast.SymbolConst && symbol.Kind != js_ast.SymbolHoistedFunction) {
+			return inlineDecl{}, false
+		}
+		return decl, true
 	}
 
-	// Note: An extension will be added to this base name, so there is no need to
-	// avoid forbidden file names such as ".." since ".js" is a valid file name.
-	return sb.String()
-}
+	// Returns true if this expression always evaluates to the same primitive
+	// value and can't throw. Constants are followed so that expressions built
+	// from other constants qualify too.
+	isPrimitive := make(map[js_ast.Ref]bool)
+	isVisiting := make(map[js_ast.Ref]bool)
+	var isStablePrimitive func(expr js_ast.Expr) bool
+	isStablePrimitive = func(expr js_ast.Expr) bool {
+		switch e := expr.Data.(type) {
+		case *js_ast.ENull, *js_ast.EUndefined, *js_ast.EBoolean, *js_ast.ENumber, *js_ast.EString:
+			return true
 
-// JavaScript modules are traversed in depth-first postorder. This is the
-// order that JavaScript modules were evaluated in before the top-level await
-// feature was introduced.
-//
-//     A
-//    / \
-//   B   C
-//    \ /
-//     D
-//
-// If A imports B and then C, B imports D, and C imports D, then the JavaScript
-// traversal order is D B C A.
-//
-// This function may deviate from ESM import order for dynamic imports (both
-// "require()" and "import()"). This is because the import order is impossible
-// to determine since the imports happen at run-time instead of compile-time.
-// In this case we just pick an arbitrary but consistent order.
-func (c *linkerContext) findImportedCSSFilesInJSOrder(entryPoint uint32) (order []uint32) {
-	visited := make(map[uint32]bool)
-	var visit func(uint32, ast.Index32)
+		case *js_ast.EIdentifier, *js_ast.EImportIdentifier:
+			var ref js_ast.Ref
+			if id, ok := e.(*js_ast.EIdentifier); ok {
+				ref = id.Ref
+			} else {
+				ref = e.(*js_ast.EImportIdentifier).Ref
+			}
+			ref = js_ast.FollowSymbols(c.graph.Symbols, ref)
+			if result, ok := isPrimitive[ref]; ok {
+				return result
+			}
+			decl, ok := stableDecl(ref)
+			if !ok || decl.value.Data == nil || isVisiting[ref] {
+				return false
+			}
+			isVisiting[ref] = true
+			result := isStablePrimitive(decl.value)
+			delete(isVisiting, ref)
+			isPrimitive[ref] = result
+			return result
 
-	// Include this file and all files it imports
-	visit = func(sourceIndex uint32, importerIndex ast.Index32) {
-		if visited[sourceIndex] {
-			return
-		}
-		visited[sourceIndex] = true
-		file := &c.graph.Files[sourceIndex]
-		repr := file.InputFile.Repr.(*graph.JSRepr)
+		case *js_ast.EUnary:
+			switch e.Op {
+			case js_ast.UnOpPos, js_ast.UnOpNeg, js_ast.UnOpCpl, js_ast.UnOpNot, js_ast.UnOpVoid, js_ast.UnOpTypeof:
+				return isStablePrimitive(e.Value)
+			}
 
-		// Iterate over each part in the file in order
-		for _, part := range repr.AST.Parts {
-			// Ignore dead code that has been removed from the bundle. Any code
-			// that's reachable from the entry point, even through lazy dynamic
-			// imports, could end up being activated by the bundle and needs its
-			// CSS to be included. This may change if/when code splitting is
-			// supported for CSS.
-			if !part.IsLive {
-				continue
+		case *js_ast.EBinary:
+			// "in" and "instanceof" throw when the right operand is a primitive
+			if e.Op.BinaryAssignTarget() == js_ast.AssignTargetNone && e.Op != js_ast.BinOpComma &&
+				e.Op != js_ast.BinOpIn && e.Op != js_ast.BinOpInstanceof {
+				return isStablePrimitive(e.Left) && isStablePrimitive(e.Right)
 			}
 
-			// Traverse any files imported by this part. Note that CommonJS calls
-			// to "require()" count as imports too, sort of as if the part has an
-			// ESM "import" statement in it. This may seem weird because ESM imports
-			// are a compile-time concept while CommonJS imports are a run-time
-			// concept. But we don't want to manipulate <style> tags at run-time so
-			// this is the only way to do it.
-			for _, importRecordIndex := range part.ImportRecordIndices {
-				if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() {
-					visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex))
+		case *js_ast.EIf:
+			return isStablePrimitive(e.Test) && isStablePrimitive(e.Yes) && isStablePrimitive(e.No)
+
+		case *js_ast.ETemplate:
+			if e.TagOrNil.Data != nil {
+				return false
+			}
+			for _, part := range e.Parts {
+				if !isStablePrimitive(part.Value) {
+					return false
 				}
 			}
+			return true
 		}
 
-		// Iterate over the associated CSS imports in postorder
-		if repr.CSSSourceIndex.IsValid() {
-			order = append(order, repr.CSSSourceIndex.GetIndex())
+		return false
+	}
+
+	// Returns true if this expression is a reference to a constant or to a
+	// function declaration. Aliases of aliases aren't allowed so that two
+	// aliases can't end up substituting each other forever.
+	isStableAlias := func(expr js_ast.Expr, declRef js_ast.Ref) bool {
+		var ref js_ast.Ref
+		switch e := expr.Data.(type) {
+		case *js_ast.EIdentifier:
+			ref = e.Ref
+		case *js_ast.EImportIdentifier:
+			ref = e.Ref
+		default:
+			return false
+		}
+		ref = js_ast.FollowSymbols(c.graph.Symbols, ref)
+		if ref == declRef {
+			return false
+		}
+		decl, ok := stableDecl(ref)
+		if !ok {
+			return false
+		}
+		switch decl.value.Data.(type) {
+		case *js_ast.EIdentifier, *js_ast.EImportIdentifier:
+			return false
 		}
+		return true
 	}
 
-	// Include all files reachable from the entry point
-	visit(entryPoint, ast.Index32{})
+	constValues := make(map[js_ast.Ref]js_ast.Expr)
+	constValueSourceIndices := make(map[js_ast.Ref]uint32)
+	inlinedFunctions := make(map[js_ast.Ref]*js_printer.InlinedFunction)
+	inlinedFunctionSourceIndices := make(map[js_ast.Ref]uint32)
 
-	return
-}
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		repr, ok := file.InputFile.Repr.(*graph.JSRepr)
 
-// CSS files are traversed in depth-first reversed reverse preorder. This is
-// because unlike JavaScript import statements, CSS "@import" rules are
-// evaluated every time instead of just the first time. However, evaluating a
-// CSS file multiple times is equivalent to evaluating it once at the last
-// location. So we drop all but the last evaluation in the order.
-//
-//     A
-//    / \
-//   B   C
-//    \ /
-//     D
-//
-// If A imports B and then C, B imports D, and C imports D, then the CSS
-// traversal order is B D C A.
-func (c *linkerContext) findImportedFilesInCSSOrder(entryPoints []uint32) (externalOrder []externalImportCSS, internalOrder []uint32) {
-	type externalImportsCSS struct {
-		unconditional bool
-		conditions    [][]css_ast.Token
-	}
+		// Don't touch the runtime since the linker generates references to it
+		// that aren't tracked as symbol uses. Also don't touch code that could
+		// reference top-level symbols using direct eval.
+		if !ok || !file.IsLive || sourceIndex == runtime.SourceIndex || repr.AST.ModuleScope.ContainsDirectEval {
+			continue
+		}
+
+		for partIndex := range repr.AST.Parts {
+			part := &repr.AST.Parts[partIndex]
+			if !part.IsLive || len(part.Stmts) != 1 {
+				continue
+			}
+
+			// Only consider parts that declare a single symbol
+			var declRef js_ast.Ref
+			var fn *js_printer.InlinedFunction
+			var value js_ast.Expr
+			switch s := part.Stmts[0].Data.(type) {
+			case *js_ast.SFunction:
+				declRef = s.Fn.Name.Ref
+				if !s.Fn.IsAsync && !s.Fn.IsGenerator && !s.Fn.HasRestArg {
+					fn = c.inlinedFunctionFor(repr, declRef, s.Fn.Args, s.Fn.Body)
+				}
+
+			case *js_ast.SLocal:
+				if len(s.Decls) != 1 || s.Decls[0].ValueOrNil.Data == nil {
+					continue
+				}
+				id, ok := s.Decls[0].Binding.Data.(*js_ast.BIdentifier)
+				if !ok {
+					continue
+				}
+				declRef = id.Ref
+				switch e := s.Decls[0].ValueOrNil.Data.(type) {
+				case *js_ast.EArrow:
+					if !e.IsAsync && !e.HasRestArg {
+						fn = c.inlinedFunctionFor(repr, declRef, e.Args, e.Body)
+					}
+
+				case *js_ast.EFunction:
+					if !e.Fn.IsAsync && !e.Fn.IsGenerator && !e.Fn.HasRestArg {
+						fn = c.inlinedFunctionFor(repr, declRef, e.Fn.Args, e.Fn.Body)
+					}
+
+				default:
+					// Don't inline constants when preserving JSX since there's no way to
+					// substitute a value for a JSX tag
+					if !c.options.JSX.Preserve {
+						ref := js_ast.FollowSymbols(c.graph.Symbols, declRef)
+						if _, ok := e.(*js_ast.EBigInt); ok || isStableAlias(s.Decls[0].ValueOrNil, ref) {
+							value = s.Decls[0].ValueOrNil
+						} else {
+							isVisiting[ref] = true
+							if isStablePrimitive(s.Decls[0].ValueOrNil) {
+								value = s.Decls[0].ValueOrNil
+							}
+							delete(isVisiting, ref)
+						}
+					}
+				}
+
+			default:
+				continue
+			}
+
+			// The checks above guarantee that substituted values have no side
+			// effects, even when the parser couldn't tell (e.g. "a + b" where
+			// both are constants). Everything else must be removable.
+			if value.Data == nil && !part.CanBeRemovedIfUnused {
+				continue
+			}
+
+			// The symbol must never change and must not be referenced in any way
+			// that we aren't aware of
+			if len(repr.TopLevelSymbolToParts(declRef)) != 1 {
+				continue
+			}
+			ref := js_ast.FollowSymbols(c.graph.Symbols, declRef)
+			if symbol := c.graph.Symbols.Get(ref); symbol.CouldPotentiallyBeMutated || symbol.MustNotBeRenamed || isExportedFromEntryPoint[ref] {
+				continue
+			}
+
+			if value.Data != nil && uses[ref] == 1 {
+				// Substitute values that are only used once
+				constValues[ref] = value
+				constValueSourceIndices[ref] = sourceIndex
+				part.IsLive = false
+			} else if fn != nil && uses[ref] > 0 && uses[ref] == calls[ref] {
+				// Inline functions that are only ever called. Every call must be
+				// inlined since the function declaration is removed.
+				inlinedFunctions[ref] = fn
+				inlinedFunctionSourceIndices[ref] = sourceIndex
+				part.IsLive = false
+			}
+		}
+	}
+
+	if len(constValues) == 0 && len(inlinedFunctions) == 0 {
+		return
+	}
+
+	// Update the symbol uses for the parts that reference inlined symbols. This
+	// is important for code splitting since cross-chunk imports are generated
+	// from the symbols that each part uses.
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		repr, ok := file.InputFile.Repr.(*graph.JSRepr)
+		if !ok || !file.IsLive {
+			continue
+		}
+
+		for partIndex := range repr.AST.Parts {
+			part := &repr.AST.Parts[partIndex]
+			if !part.IsLive {
+				continue
+			}
+
+			// The inlined expressions now reference the symbols that they used to
+			// reference. Substituted values may contain other substituted values
+			// since the printer substitutes recursively, so follow those too.
+			var generateUses func(exprSourceIndex uint32, refs []js_ast.Ref, count uint32)
+			generateUses = func(exprSourceIndex uint32, refs []js_ast.Ref, count uint32) {
+				exprRepr := c.graph.Files[exprSourceIndex].InputFile.Repr.(*graph.JSRepr)
+				for _, ref := range refs {
+					targetRef := js_ast.FollowSymbols(c.graph.Symbols, ref)
+					if value, ok := constValues[targetRef]; ok {
+						generateUses(constValueSourceIndices[targetRef], symbolsReferencedByInlinedExpr(value, nil), count)
+						continue
+					}
+					if importData, ok := exprRepr.Meta.ImportsToBind[ref]; ok {
+						c.graph.GenerateSymbolImportAndUse(sourceIndex, uint32(partIndex), importData.Ref, count, importData.SourceIndex)
+					} else {
+						c.graph.GenerateSymbolImportAndUse(sourceIndex, uint32(partIndex), ref, count, exprSourceIndex)
+					}
+				}
+			}
+
+			var inlinedCalls []inlinedCall
+			var inlinedValues []inlinedCall
+			for ref, use := range part.SymbolUses {
+				targetRef := js_ast.FollowSymbols(c.graph.Symbols, ref)
+				if _, ok := constValues[targetRef]; ok {
+					delete(part.SymbolUses, ref)
+					inlinedValues = append(inlinedValues, inlinedCall{ref: targetRef, count: use.CountEstimate})
+				} else if fn, ok := inlinedFunctions[targetRef]; ok {
+					delete(part.SymbolUses, ref)
+					inlinedCalls = append(inlinedCalls, inlinedCall{fn: fn, ref: targetRef, count: use.CountEstimate})
+				}
+			}
+			for _, value := range inlinedValues {
+				generateUses(sourceIndex, []js_ast.Ref{value.ref}, value.count)
+			}
+			for _, call := range inlinedCalls {
+				generateUses(inlinedFunctionSourceIndices[call.ref], symbolsReferencedByInlinedExpr(call.fn.Body, call.fn.Params), call.count)
+			}
+		}
+	}
+
+	c.constValues = constValues
+	c.inlinedFunctions = inlinedFunctions
+}
+
+// Returns a description of this function for the printer if it's small enough
+// to be inlined, or nil otherwise. The body must be a single return statement
+// with an expression that has no side effects and that only references the
+// function's parameters and top-level symbols.
+func (c *linkerContext) inlinedFunctionFor(repr *graph.JSRepr, fnRef js_ast.Ref, args []js_ast.Arg, body js_ast.FnBody) *js_printer.InlinedFunction {
+	params := make([]js_ast.Ref, 0, len(args))
+	isParam := make(map[js_ast.Ref]bool, len(args))
+	for _, arg := range args {
+		id, ok := arg.Binding.Data.(*js_ast.BIdentifier)
+		if !ok || arg.DefaultOrNil.Data != nil || len(arg.TSDecorators) > 0 {
+			return nil
+		}
+		params = append(params, id.Ref)
+		isParam[id.Ref] = true
+	}
+
+	var value js_ast.Expr
+	switch len(body.Stmts) {
+	case 0:
+		value = js_ast.Expr{Loc: body.Loc, Data: js_ast.EUndefinedShared}
+
+	case 1:
+		s, ok := body.Stmts[0].Data.(*js_ast.SReturn)
+		if !ok {
+			return nil
+		}
+		if s.ValueOrNil.Data != nil {
+			value = s.ValueOrNil
+		} else {
+			value = js_ast.Expr{Loc: body.Stmts[0].Loc, Data: js_ast.EUndefinedShared}
+		}
+
+	default:
+		return nil
+	}
+
+	isTopLevelSymbol := func(ref js_ast.Ref) bool {
+		if _, ok := repr.AST.NamedImports[ref]; ok {
+			return true
+		}
+		return ref != fnRef && len(repr.TopLevelSymbolToParts(ref)) > 0
+	}
+
+	budget := maxInlinedFunctionBodySize
+	var visit func(expr js_ast.Expr) bool
+	visit = func(expr js_ast.Expr) bool {
+		if budget--; budget < 0 {
+			return false
+		}
+
+		switch e := expr.Data.(type) {
+		case *js_ast.ENull, *js_ast.EUndefined, *js_ast.EBoolean, *js_ast.ENumber, *js_ast.EBigInt, *js_ast.EString:
+			return true
+
+		case *js_ast.EIdentifier:
+			return isParam[e.Ref] || isTopLevelSymbol(e.Ref)
+
+		case *js_ast.EImportIdentifier:
+			return isTopLevelSymbol(e.Ref)
+
+		case *js_ast.EUnary:
+			return e.Op.UnaryAssignTarget() == js_ast.AssignTargetNone && e.Op != js_ast.UnOpDelete && visit(e.Value)
+
+		case *js_ast.EBinary:
+			return e.Op.BinaryAssignTarget() == js_ast.AssignTargetNone && visit(e.Left) && visit(e.Right)
+
+		case *js_ast.EIf:
+			return visit(e.Test) && visit(e.Yes) && visit(e.No)
+
+		case *js_ast.EDot:
+			return e.OptionalChain == js_ast.OptionalChainNone && visit(e.Target)
+
+		case *js_ast.EIndex:
+			if _, ok := e.Index.Data.(*js_ast.EPrivateIdentifier); ok {
+				return false
+			}
+			return e.OptionalChain == js_ast.OptionalChainNone && visit(e.Target) && visit(e.Index)
+
+		case *js_ast.EArray:
+			for _, item := range e.Items {
+				if _, ok := item.Data.(*js_ast.ESpread); ok || !visit(item) {
+					return false
+				}
+			}
+			return true
+
+		case *js_ast.EObject:
+			for _, property := range e.Properties {
+				if property.Kind != js_ast.PropertyNormal || property.IsMethod || property.IsComputed ||
+					property.ValueOrNil.Data == nil || property.InitializerOrNil.Data != nil || !visit(property.ValueOrNil) {
+					return false
+				}
+			}
+			return true
+
+		case *js_ast.ETemplate:
+			if e.TagOrNil.Data != nil {
+				return false
+			}
+			for _, part := range e.Parts {
+				if !visit(part.Value) {
+					return false
+				}
+			}
+			return true
+		}
+
+		return false
+	}
+
+	if !visit(value) {
+		return nil
+	}
+	return &js_printer.InlinedFunction{Params: params, Body: value}
+}
+
+// Returns all symbols other than parameters that are referenced by an inlined
+// expression. This relies on the expression only containing the kinds of
+// expressions that are allowed by "inlinedFunctionFor" and by the checks for
+// substituted values in "inlineConstantsAndFunctions".
+func symbolsReferencedByInlinedExpr(expr js_ast.Expr, params []js_ast.Ref) (refs []js_ast.Ref) {
+	isParam := make(map[js_ast.Ref]bool, len(params))
+	for _, param := range params {
+		isParam[param] = true
+	}
+
+	var visit func(expr js_ast.Expr)
+	visit = func(expr js_ast.Expr) {
+		switch e := expr.Data.(type) {
+		case *js_ast.EIdentifier:
+			if !isParam[e.Ref] {
+				refs = append(refs, e.Ref)
+			}
+		case *js_ast.EImportIdentifier:
+			refs = append(refs, e.Ref)
+		case *js_ast.EUnary:
+			visit(e.Value)
+		case *js_ast.EBinary:
+			visit(e.Left)
+			visit(e.Right)
+		case *js_ast.EIf:
+			visit(e.Test)
+			visit(e.Yes)
+			visit(e.No)
+		case *js_ast.EDot:
+			visit(e.Target)
+		case *js_ast.EIndex:
+			visit(e.Target)
+			visit(e.Index)
+		case *js_ast.EArray:
+			for _, item := range e.Items {
+				visit(item)
+			}
+		case *js_ast.EObject:
+			for _, property := range e.Properties {
+				visit(property.ValueOrNil)
+			}
+		case *js_ast.ETemplate:
+			for _, part := range e.Parts {
+				visit(part.Value)
+			}
+		}
+	}
+
+	visit(expr)
+	return
+}
+
+func sanitizeFilePathForVirtualModulePath(path string) string {
+	// Convert it to a safe file path. See: https://stackoverflow.com/a/31976060
+	sb := strings.Builder{}
+	needsGap := false
+	for _, c := range path {
+		switch c {
+		case 0:
+			// These characters are forbidden on Unix and Windows
+
+		case '<', '>', ':', '"', '|', '?', '*':
+			// These characters are forbidden on Windows
+
+		default:
+			if c < 0x20 {
+				// These characters are forbidden on Windows
+				break
+			}
+
+			// Turn runs of invalid characters into a '_'
+			if needsGap {
+				sb.WriteByte('_')
+				needsGap = false
+			}
+
+			sb.WriteRune(c)
+			continue
+		}
+
+		if sb.Len() > 0 {
+			needsGap = true
+		}
+	}
+
+	// Make sure the name isn't empty
+	if sb.Len() == 0 {
+		return "_"
+	}
+
+	// Note: An extension will be added to this base name, so there is no need to
+	// avoid forbidden file names such as ".." since ".js" is a valid file name.
+	return sb.String()
+}
+
+// JavaScript modules are traversed in depth-first postorder. This is the
+// order that JavaScript modules were evaluated in before the top-level await
+// feature was introduced.
+//
+//     A
+//    / \
+//   B   C
+//    \ /
+//     D
+//
+// If A imports B and then C, B imports D, and C imports D, then the JavaScript
+// traversal order is D B C A.
+//
+// This function may deviate from ESM import order for dynamic imports (both
+// "require()" and "import()"). This is because the import order is impossible
+// to determine since the imports happen at run-time instead of compile-time.
+// In this case we just pick an arbitrary but consistent order.
+func (c *linkerContext) findImportedCSSFilesInJSOrder(entryPoint uint32) (order []uint32) {
+	visited := make(map[uint32]bool)
+	var visit func(uint32, ast.Index32)
+
+	// Include this file and all files it imports
+	visit = func(sourceIndex uint32, importerIndex ast.Index32) {
+		if visited[sourceIndex] {
+			return
+		}
+		visited[sourceIndex] = true
+		file := &c.graph.Files[sourceIndex]
+		repr := file.InputFile.Repr.(*graph.JSRepr)
+
+		// Iterate over each part in the file in order
+		for _, part := range repr.AST.Parts {
+			// Ignore dead code that has been removed from the bundle. Any code
+			// that's reachable from the entry point, even through lazy dynamic
+			// imports, could end up being activated by the bundle and needs its
+			// CSS to be included. This may change if/when code splitting is
+			// supported for CSS.
+			if !part.IsLive {
+				continue
+			}
+
+			// Traverse any files imported by this part. Note that CommonJS calls
+			// to "require()" count as imports too, sort of as if the part has an
+			// ESM "import" statement in it. This may seem weird because ESM imports
+			// are a compile-time concept while CommonJS imports are a run-time
+			// concept. But we don't want to manipulate <style> tags at run-time so
+			// this is the only way to do it.
+			for _, importRecordIndex := range part.ImportRecordIndices {
+				if record := &repr.AST.ImportRecords[importRecordIndex]; record.SourceIndex.IsValid() {
+					visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex))
+				}
+			}
+		}
+
+		// Iterate over the associated CSS imports in postorder
+		if repr.CSSSourceIndex.IsValid() {
+			order = append(order, repr.CSSSourceIndex.GetIndex())
+		}
+	}
+
+	// Include all files reachable from the entry point
+	visit(entryPoint, ast.Index32{})
+
+	return
+}
+
+// CSS files are traversed in depth-first reversed reverse preorder. This is
+// because unlike JavaScript import statements, CSS "@import" rules are
+// evaluated every time instead of just the first time. However, evaluating a
+// CSS file multiple times is equivalent to evaluating it once at the last
+// location. So we drop all but the last evaluation in the order.
+//
+//     A
+//    / \
+//   B   C
+//    \ /
+//     D
+//
+// If A imports B and then C, B imports D, and C imports D, then the CSS
+// traversal order is B D C A.
+func (c *linkerContext) findImportedFilesInCSSOrder(entryPoints []uint32) (externalOrder []externalImportCSS, internalOrder []uint32) {
+	type externalImportsCSS struct {
+		unconditional bool
+		conditions    [][]css_ast.Token
+	}
 
 	visited := make(map[uint32]bool)
 	externals := make(map[logger.Path]externalImportsCSS)
@@ -2782,63 +3561,542 @@ func (c *linkerContext) findImportedFilesInCSSOrder(entryPoints []uint32) (exter
 			// Iterate in reverse preorder (will be reversed again later)
 			internalOrder = append(internalOrder, sourceIndex)
 
-			// Iterate in the inverse order of top-level "@import" rules
-		outer:
-			for i := len(topLevelRules) - 1; i >= 0; i-- {
-				if atImport, ok := topLevelRules[i].(*css_ast.RAtImport); ok {
-					if record := &repr.AST.ImportRecords[atImport.ImportRecordIndex]; record.SourceIndex.IsValid() {
-						// Follow internal dependencies
-						visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex))
-					} else {
-						// Record external dependencies
-						external := externals[record.Path]
+			// Iterate in the inverse order of top-level "@import" rules
+		outer:
+			for i := len(topLevelRules) - 1; i >= 0; i-- {
+				if atImport, ok := topLevelRules[i].(*css_ast.RAtImport); ok {
+					if record := &repr.AST.ImportRecords[atImport.ImportRecordIndex]; record.SourceIndex.IsValid() {
+						// Follow internal dependencies
+						visit(record.SourceIndex.GetIndex(), ast.MakeIndex32(sourceIndex))
+					} else {
+						// Record external dependencies
+						external := externals[record.Path]
+
+						// Check for an unconditional import. An unconditional import
+						// should always mask all conditional imports that are overridden
+						// by the unconditional import.
+						if external.unconditional {
+							continue
+						}
+
+						if len(atImport.ImportConditions) == 0 {
+							external.unconditional = true
+						} else {
+							// Check for a conditional import. A conditional import does not
+							// mask an earlier unconditional import because re-evaluating a
+							// CSS file can have observable results.
+							for _, tokens := range external.conditions {
+								if css_ast.TokensEqualIgnoringWhitespace(tokens, atImport.ImportConditions) {
+									continue outer
+								}
+							}
+							external.conditions = append(external.conditions, atImport.ImportConditions)
+						}
+
+						externals[record.Path] = external
+						externalOrder = append(externalOrder, externalImportCSS{
+							path:       record.Path,
+							conditions: atImport.ImportConditions,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	// Include all files reachable from any entry point
+	for i := len(entryPoints) - 1; i >= 0; i-- {
+		visit(entryPoints[i], ast.Index32{})
+	}
+
+	// Reverse the order afterward when traversing in CSS order
+	for i, j := 0, len(internalOrder)-1; i < j; i, j = i+1, j-1 {
+		internalOrder[i], internalOrder[j] = internalOrder[j], internalOrder[i]
+	}
+	for i, j := 0, len(externalOrder)-1; i < j; i, j = i+1, j-1 {
+		externalOrder[i], externalOrder[j] = externalOrder[j], externalOrder[i]
+	}
+
+	return
+}
+
+// Assigns files to the chunks configured by the "ManualChunks" option. Files
+// imported by these files are pulled into the same chunk so that a manual
+// chunk doesn't import from an automatically-generated chunk, which would
+// otherwise easily cause a circular import between the two chunks.
+func (c *linkerContext) computeManualChunkNames() map[uint32]string {
+	if !c.options.CodeSplitting || (len(c.options.ManualChunks) == 0 && c.options.ManualChunksFunc == nil) {
+		return nil
+	}
+
+	canBeAssigned := func(sourceIndex uint32) bool {
+		file := &c.graph.Files[sourceIndex]
+		_, isJS := file.InputFile.Repr.(*graph.JSRepr)
+		return isJS && file.IsLive && !file.IsEntryPoint() && sourceIndex != runtime.SourceIndex
+	}
+
+	names := make(map[uint32]string)
+	invalidNames := make(map[string]bool)
+	var queue []uint32
+
+	// Explicitly-matched files take precedence
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if !canBeAssigned(sourceIndex) {
+			continue
+		}
+		source := &c.graph.Files[sourceIndex].InputFile.Source
+	nextFile:
+		for _, manualChunk := range c.options.ManualChunks {
+			for _, filter := range manualChunk.Filters {
+				if filter.MatchString(source.PrettyPath) {
+					names[sourceIndex] = manualChunk.Name
+					queue = append(queue, sourceIndex)
+					break nextFile
+				}
+			}
+		}
+
+		// Otherwise ask the callback, which gets the same path that plugins see
+		if _, ok := names[sourceIndex]; !ok && c.options.ManualChunksFunc != nil {
+			if name := c.options.ManualChunksFunc(source.KeyPath.Text); name != "" {
+				if strings.ContainsAny(name, "/\\") {
+					if !invalidNames[name] {
+						invalidNames[name] = true
+						c.log.AddID(logger.MsgID_Bundler_InvalidManualChunkName, logger.Error, nil, logger.Range{}, fmt.Sprintf("Invalid manual chunk name: %q", name))
+					}
+					continue
+				}
+				names[sourceIndex] = name
+				queue = append(queue, sourceIndex)
+			}
+		}
+	}
+
+	// Then pull in everything they import that isn't already assigned
+	for len(queue) > 0 {
+		sourceIndex := queue[0]
+		queue = queue[1:]
+		repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		for _, part := range repr.AST.Parts {
+			if !part.IsLive {
+				continue
+			}
+			for _, importRecordIndex := range part.ImportRecordIndices {
+				record := &repr.AST.ImportRecords[importRecordIndex]
+				if !record.SourceIndex.IsValid() || c.isExternalDynamicImport(record, sourceIndex) {
+					continue
+				}
+				otherSourceIndex := record.SourceIndex.GetIndex()
+				if _, ok := names[otherSourceIndex]; !ok && canBeAssigned(otherSourceIndex) {
+					names[otherSourceIndex] = names[sourceIndex]
+					queue = append(queue, otherSourceIndex)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+type chunkSizeDecisionKind uint8
+
+const (
+	// Another chunk was merged into this one. The inputs are the files from the
+	// other chunk and "addedBytes" is the most code that this adds to any one
+	// entry point that didn't load that code before.
+	chunkSizeMerged chunkSizeDecisionKind = iota
+
+	// This chunk is under "MinChunkSize" but there was no other chunk that it
+	// could be merged into without changing which side effects are run or
+	// exceeding the "ChunkMergeBudget" option.
+	chunkSizeNotMerged
+
+	// This chunk is one of the pieces of a chunk that was over "MaxChunkSize"
+	chunkSizeSplit
+
+	// This chunk is over "MaxChunkSize" but it contains a single file or files
+	// that import each other, so it can't be split along module boundaries
+	chunkSizeNotSplit
+)
+
+type chunkSizeDecision struct {
+	kind           chunkSizeDecisionKind
+	inputs         []uint32
+	estimatedBytes int
+	addedBytes     int
+	piece          int
+	pieceCount     int
+}
+
+// Chunk sizes are estimated from the size of the input files since the final
+// output hasn't been generated yet when chunks are computed. The runtime isn't
+// counted since only the used parts of it are included.
+func (c *linkerContext) estimatedChunkSize(files map[uint32]bool) (size int) {
+	for sourceIndex := range files {
+		if sourceIndex != runtime.SourceIndex {
+			size += len(c.graph.Files[sourceIndex].InputFile.Source.Contents)
+		}
+	}
+	return
+}
+
+func (c *linkerContext) chunkHasSideEffects(files map[uint32]bool) bool {
+	for sourceIndex := range files {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			for _, part := range repr.AST.Parts {
+				if part.IsLive && !part.CanBeRemovedIfUnused && len(part.Stmts) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
 
-						// Check for an unconditional import. An unconditional import
-						// should always mask all conditional imports that are overridden
-						// by the unconditional import.
-						if external.unconditional {
-							continue
-						}
+func sortedSourceIndices(files map[uint32]bool) []uint32 {
+	result := make([]uint32, 0, len(files))
+	for sourceIndex := range files {
+		result = append(result, sourceIndex)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
 
-						if len(atImport.ImportConditions) == 0 {
-							external.unconditional = true
-						} else {
-							// Check for a conditional import. A conditional import does not
-							// mask an earlier unconditional import because re-evaluating a
-							// CSS file can have observable results.
-							for _, tokens := range external.conditions {
-								if css_ast.TokensEqualIgnoringWhitespace(tokens, atImport.ImportConditions) {
-									continue outer
-								}
-							}
-							external.conditions = append(external.conditions, atImport.ImportConditions)
-						}
+// Chunks smaller than "MinChunkSize" are merged into a chunk that imports
+// them. The merged chunk is loaded by the entry points of both chunks, so this
+// is only done if the chunk that would be loaded by additional entry points
+// has no side effects and is no larger than the "ChunkMergeBudget" option.
+// Entry point chunks and manual chunks are never merged.
+func (c *linkerContext) mergeSmallChunks(jsChunks map[string]chunkInfo) {
+	canBeMerged := func(chunk *chunkInfo) bool {
+		return !chunk.isEntryPoint && chunk.manualChunkName == ""
+	}
 
-						externals[record.Path] = external
-						externalOrder = append(externalOrder, externalImportCSS{
-							path:       record.Path,
-							conditions: atImport.ImportConditions,
-						})
+	// Build a graph of the static imports between chunks
+	chunkKeyForFile := make(map[uint32]string)
+	for key, chunk := range jsChunks {
+		for sourceIndex := range chunk.filesWithPartsInChunk {
+			chunkKeyForFile[sourceIndex] = key
+		}
+	}
+	chunkDeps := make(map[string]map[string]bool)
+	for key, chunk := range jsChunks {
+		deps := make(map[string]bool)
+		for sourceIndex := range chunk.filesWithPartsInChunk {
+			repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+			for _, part := range repr.AST.Parts {
+				if !part.IsLive {
+					continue
+				}
+				for _, importRecordIndex := range part.ImportRecordIndices {
+					record := &repr.AST.ImportRecords[importRecordIndex]
+					if !record.SourceIndex.IsValid() || c.isExternalDynamicImport(record, sourceIndex) {
+						continue
+					}
+					if otherKey, ok := chunkKeyForFile[record.SourceIndex.GetIndex()]; ok && otherKey != key {
+						deps[otherKey] = true
 					}
 				}
 			}
 		}
+		chunkDeps[key] = deps
 	}
 
-	// Include all files reachable from any entry point
-	for i := len(entryPoints) - 1; i >= 0; i-- {
-		visit(entryPoints[i], ast.Index32{})
+	// Merging two chunks creates a cycle if there's a path between them that
+	// goes through another chunk
+	hasIndirectPath := func(from string, to string) bool {
+		visited := map[string]bool{from: true}
+		var stack []string
+		for dep := range chunkDeps[from] {
+			if dep != to {
+				stack = append(stack, dep)
+			}
+		}
+		for len(stack) > 0 {
+			key := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if key == to {
+				return true
+			}
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			for dep := range chunkDeps[key] {
+				stack = append(stack, dep)
+			}
+		}
+		return false
 	}
 
-	// Reverse the order afterward when traversing in CSS order
-	for i, j := 0, len(internalOrder)-1; i < j; i, j = i+1, j-1 {
-		internalOrder[i], internalOrder[j] = internalOrder[j], internalOrder[i]
+	// Returns true if some bit in "a" isn't in "b"
+	hasExtraBits := func(a helpers.BitSet, b helpers.BitSet) bool {
+		for bit := uint(0); bit < uint(len(c.graph.EntryPoints())); bit++ {
+			if a.HasBit(bit) && !b.HasBit(bit) {
+				return true
+			}
+		}
+		return false
 	}
-	for i, j := 0, len(externalOrder)-1; i < j; i, j = i+1, j-1 {
-		externalOrder[i], externalOrder[j] = externalOrder[j], externalOrder[i]
+
+	sizes := make(map[string]int)
+	for key, chunk := range jsChunks {
+		sizes[key] = c.estimatedChunkSize(chunk.filesWithPartsInChunk)
 	}
+	notMerged := make(map[string]bool)
 
-	return
+	for {
+		// Handle the smallest chunk first
+		smallKey := ""
+		for key, chunk := range jsChunks {
+			if canBeMerged(&chunk) && !notMerged[key] && sizes[key] < c.options.MinChunkSize &&
+				(smallKey == "" || sizes[key] < sizes[smallKey] || (sizes[key] == sizes[smallKey] && key < smallKey)) {
+				smallKey = key
+			}
+		}
+		if smallKey == "" {
+			break
+		}
+		small := jsChunks[smallKey]
+		smallHasSideEffects := c.chunkHasSideEffects(small.filesWithPartsInChunk)
+
+		// Find the other chunk that adds the least code to entry points
+		otherKey := ""
+		otherAddedBytes := 0
+		for key, chunk := range jsChunks {
+			if key == smallKey || !canBeMerged(&chunk) || !chunkDeps[key][smallKey] {
+				continue
+			}
+			addedBytes := 0
+			if hasExtraBits(chunk.entryBits, small.entryBits) {
+				if smallHasSideEffects {
+					continue
+				}
+				addedBytes = sizes[smallKey]
+			}
+			if hasExtraBits(small.entryBits, chunk.entryBits) {
+				if c.chunkHasSideEffects(chunk.filesWithPartsInChunk) {
+					continue
+				}
+				if sizes[key] > addedBytes {
+					addedBytes = sizes[key]
+				}
+			}
+			if addedBytes > c.options.ChunkMergeBudget || hasIndirectPath(smallKey, key) || hasIndirectPath(key, smallKey) {
+				continue
+			}
+			if otherKey == "" || addedBytes < otherAddedBytes || (addedBytes == otherAddedBytes && key < otherKey) {
+				otherKey = key
+				otherAddedBytes = addedBytes
+			}
+		}
+
+		if otherKey == "" {
+			notMerged[smallKey] = true
+			small.sizeDecisions = append(small.sizeDecisions, chunkSizeDecision{
+				kind:           chunkSizeNotMerged,
+				estimatedBytes: sizes[smallKey],
+			})
+			jsChunks[smallKey] = small
+			continue
+		}
+
+		// Move everything from the small chunk into the other chunk
+		other := jsChunks[otherKey]
+		entryBits := helpers.NewBitSet(uint(len(c.graph.EntryPoints())))
+		entryBits.SetBits(other.entryBits)
+		entryBits.SetBits(small.entryBits)
+		other.entryBits = entryBits
+		for sourceIndex := range small.filesWithPartsInChunk {
+			other.filesWithPartsInChunk[sourceIndex] = true
+		}
+		other.sizeDecisions = append(other.sizeDecisions, small.sizeDecisions...)
+		other.sizeDecisions = append(other.sizeDecisions, chunkSizeDecision{
+			kind:           chunkSizeMerged,
+			inputs:         sortedSourceIndices(small.filesWithPartsInChunk),
+			estimatedBytes: sizes[smallKey],
+			addedBytes:     otherAddedBytes,
+		})
+		jsChunks[otherKey] = other
+		sizes[otherKey] += sizes[smallKey]
+		delete(jsChunks, smallKey)
+		delete(sizes, smallKey)
+
+		// Update the import graph
+		for dep := range chunkDeps[smallKey] {
+			if dep != otherKey {
+				chunkDeps[otherKey][dep] = true
+			}
+		}
+		delete(chunkDeps, smallKey)
+		for key, deps := range chunkDeps {
+			if deps[smallKey] {
+				delete(deps, smallKey)
+				if key != otherKey {
+					deps[otherKey] = true
+				}
+			}
+		}
+	}
+}
+
+// Chunks larger than "MaxChunkSize" are split into pieces along module
+// boundaries. Files are assigned to pieces in evaluation order and each piece
+// imports the previous piece, so the code is still evaluated in the same order.
+// A split is only done between two files if no file before the split imports
+// a file after it, since that would cause a cycle between the pieces.
+func (c *linkerContext) splitLargeChunks(chunks []chunkInfo) []chunkInfo {
+	var result []chunkInfo
+
+	for _, chunk := range chunks {
+		chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS)
+		size := 0
+		if ok && chunk.manualChunkName == "" {
+			size = c.estimatedChunkSize(chunk.filesWithPartsInChunk)
+		}
+		if size <= c.options.MaxChunkSize {
+			result = append(result, chunk)
+			continue
+		}
+
+		// Find where each file is in the chunk
+		var files []uint32
+		for _, sourceIndex := range chunkRepr.filesInChunkInOrder {
+			if sourceIndex != runtime.SourceIndex {
+				files = append(files, sourceIndex)
+			}
+		}
+		order := make(map[uint32]int)
+		for i, sourceIndex := range files {
+			order[sourceIndex] = i
+		}
+
+		// The entry point file must stay in the last piece, which is the chunk
+		// for the entry point
+		splitLimit := len(files)
+		if chunk.isEntryPoint {
+			if i, ok := order[chunk.sourceIndex]; ok {
+				splitLimit = i
+			}
+		}
+
+		// Determine the piece for each file
+		pieceForFile := make(map[uint32]int)
+		pieceSizes := []int{0}
+		latestImport := 0
+		for i, sourceIndex := range files {
+			fileSize := len(c.graph.Files[sourceIndex].InputFile.Source.Contents)
+			current := len(pieceSizes) - 1
+			if i > 0 && i <= splitLimit && latestImport < i && pieceSizes[current] > 0 &&
+				pieceSizes[current]+fileSize > c.options.MaxChunkSize {
+				pieceSizes = append(pieceSizes, 0)
+				current++
+			}
+			pieceForFile[sourceIndex] = current
+			pieceSizes[current] += fileSize
+
+			// Track the latest file in this chunk that has been imported so far
+			repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+			for _, part := range repr.AST.Parts {
+				if !part.IsLive {
+					continue
+				}
+				for _, importRecordIndex := range part.ImportRecordIndices {
+					record := &repr.AST.ImportRecords[importRecordIndex]
+					if record.SourceIndex.IsValid() && !c.isExternalDynamicImport(record, sourceIndex) {
+						if other, ok := order[record.SourceIndex.GetIndex()]; ok && other > latestImport {
+							latestImport = other
+						}
+					}
+				}
+			}
+		}
+
+		if len(pieceSizes) == 1 {
+			chunk.sizeDecisions = append(chunk.sizeDecisions, chunkSizeDecision{
+				kind:           chunkSizeNotSplit,
+				estimatedBytes: size,
+			})
+			result = append(result, chunk)
+			continue
+		}
+
+		// Create a chunk for each piece. The last piece reuses the original chunk
+		// so that it's still the chunk for the entry point.
+		pieces := make([]chunkInfo, len(pieceSizes))
+		for i := range pieces {
+			piece := &pieces[i]
+			if i+1 == len(pieces) {
+				*piece = chunk
+			} else {
+				piece.entryBits = chunk.entryBits
+			}
+			piece.filesWithPartsInChunk = make(map[uint32]bool)
+			piece.chunkRepr = &chunkReprJS{}
+			if i > 0 {
+				piece.previousPieceIndex = ast.MakeIndex32(uint32(len(result) + i - 1))
+			}
+			piece.sizeDecisions = append(piece.sizeDecisions, chunkSizeDecision{
+				kind:           chunkSizeSplit,
+				estimatedBytes: pieceSizes[i],
+				piece:          i + 1,
+				pieceCount:     len(pieces),
+			})
+		}
+		for _, sourceIndex := range chunkRepr.filesInChunkInOrder {
+			piece := &pieces[pieceForFile[sourceIndex]]
+			pieceRepr := piece.chunkRepr.(*chunkReprJS)
+			pieceRepr.filesInChunkInOrder = append(pieceRepr.filesInChunkInOrder, sourceIndex)
+			if chunk.filesWithPartsInChunk[sourceIndex] {
+				piece.filesWithPartsInChunk[sourceIndex] = true
+			}
+		}
+		for _, partRange := range chunkRepr.partsInChunkInOrder {
+			pieceRepr := pieces[pieceForFile[partRange.sourceIndex]].chunkRepr.(*chunkReprJS)
+			pieceRepr.partsInChunkInOrder = append(pieceRepr.partsInChunkInOrder, partRange)
+		}
+		result = append(result, pieces...)
+	}
+
+	return result
+}
+
+// Returns the ",\n"chunkSizeDecisions": [...]" metafile field for a chunk
+func (c *linkerContext) generateChunkSizeDecisionsJSON(decisions []chunkSizeDecision) string {
+	if len(decisions) == 0 {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(",\n      \"chunkSizeDecisions\": [")
+	for i, decision := range decisions {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n        {\n")
+		switch decision.kind {
+		case chunkSizeMerged:
+			sb.WriteString("          \"kind\": \"merged\",\n          \"inputs\": [")
+			for j, sourceIndex := range decision.inputs {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				sb.Write(js_printer.QuoteForJSON(c.graph.Files[sourceIndex].InputFile.Source.PrettyPath, c.options.ASCIIOnly))
+			}
+			sb.WriteString(fmt.Sprintf("],\n          \"estimatedBytes\": %d,\n          \"addedBytes\": %d\n",
+				decision.estimatedBytes, decision.addedBytes))
+		case chunkSizeNotMerged:
+			sb.WriteString(fmt.Sprintf("          \"kind\": \"notMerged\",\n          \"estimatedBytes\": %d\n", decision.estimatedBytes))
+		case chunkSizeSplit:
+			sb.WriteString(fmt.Sprintf("          \"kind\": \"split\",\n          \"piece\": %d,\n          \"pieceCount\": %d,\n          \"estimatedBytes\": %d\n",
+				decision.piece, decision.pieceCount, decision.estimatedBytes))
+		case chunkSizeNotSplit:
+			sb.WriteString(fmt.Sprintf("          \"kind\": \"notSplit\",\n          \"estimatedBytes\": %d\n", decision.estimatedBytes))
+		}
+		sb.WriteString("        }")
+	}
+	sb.WriteString("\n      ]")
+	return sb.String()
 }
 
 func (c *linkerContext) computeChunks() []chunkInfo {
@@ -2847,6 +4105,7 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 
 	jsChunks := make(map[string]chunkInfo)
 	cssChunks := make(map[string]chunkInfo)
+	htmlChunks := make(map[string]chunkInfo)
 
 	// Create chunks for entry points
 	for i, entryPoint := range c.graph.EntryPoints() {
@@ -2905,13 +4164,33 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 				filesInChunkInOrder:    internalOrder,
 			}
 			cssChunks[key] = chunk
+
+		case *graph.HTMLRepr:
+			chunk.chunkRepr = &chunkReprHTML{}
+			htmlChunks[key] = chunk
 		}
 	}
 
 	// Figure out which JS files are in which chunk
+	manualChunkNames := c.computeManualChunkNames()
 	for _, sourceIndex := range c.graph.ReachableFiles {
 		if file := &c.graph.Files[sourceIndex]; file.IsLive {
 			if _, ok := file.InputFile.Repr.(*graph.JSRepr); ok {
+				if name, ok := manualChunkNames[sourceIndex]; ok {
+					key := "manual:" + name
+					chunk, ok := jsChunks[key]
+					if !ok {
+						chunk.entryBits = helpers.NewBitSet(uint(len(c.graph.EntryPoints())))
+						chunk.manualChunkName = name
+						chunk.filesWithPartsInChunk = make(map[uint32]bool)
+						chunk.chunkRepr = &chunkReprJS{}
+						jsChunks[key] = chunk
+					}
+					chunk.entryBits.SetBits(file.EntryBits)
+					chunk.filesWithPartsInChunk[uint32(sourceIndex)] = true
+					continue
+				}
+
 				key := file.EntryBits.String()
 				chunk, ok := jsChunks[key]
 				if !ok {
@@ -2925,10 +4204,15 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 		}
 	}
 
+	// Optionally merge small chunks into other chunks
+	if c.options.CodeSplitting && c.options.MinChunkSize > 0 {
+		c.mergeSmallChunks(jsChunks)
+	}
+
 	// Sort the chunks for determinism. This matters because we use chunk indices
 	// as sorting keys in a few places.
-	sortedChunks := make([]chunkInfo, 0, len(jsChunks)+len(cssChunks))
-	sortedKeys := make([]string, 0, len(jsChunks)+len(cssChunks))
+	sortedChunks := make([]chunkInfo, 0, len(jsChunks)+len(cssChunks)+len(htmlChunks))
+	sortedKeys := make([]string, 0, len(jsChunks)+len(cssChunks)+len(htmlChunks))
 	for key := range jsChunks {
 		sortedKeys = append(sortedKeys, key)
 	}
@@ -2944,6 +4228,27 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 	for _, key := range sortedKeys {
 		sortedChunks = append(sortedChunks, cssChunks[key])
 	}
+	sortedKeys = sortedKeys[:0]
+	for key := range htmlChunks {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	for _, key := range sortedKeys {
+		sortedChunks = append(sortedChunks, htmlChunks[key])
+	}
+
+	// Determine the order of JS files (and parts) within the chunk ahead of time
+	for _, chunk := range sortedChunks {
+		if chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS); ok {
+			chunkRepr.filesInChunkInOrder, chunkRepr.partsInChunkInOrder = c.findImportedPartsInJSOrder(&chunk)
+		}
+	}
+
+	// Optionally split large chunks into multiple chunks. This must be done
+	// after the file order is known since pieces are split off in that order.
+	if c.options.CodeSplitting && c.options.MaxChunkSize > 0 {
+		sortedChunks = c.splitLargeChunks(sortedChunks)
+	}
 
 	// Map from the entry point file to this chunk. We will need this later if
 	// a file contains a dynamic import to this entry point, since we'll need
@@ -2965,13 +4270,6 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 		}
 	}
 
-	// Determine the order of JS files (and parts) within the chunk ahead of time
-	for _, chunk := range sortedChunks {
-		if chunkRepr, ok := chunk.chunkRepr.(*chunkReprJS); ok {
-			chunkRepr.filesInChunkInOrder, chunkRepr.partsInChunkInOrder = c.findImportedPartsInJSOrder(&chunk)
-		}
-	}
-
 	// Assign general information to each chunk
 	for chunkIndex := range sortedChunks {
 		chunk := &sortedChunks[chunkIndex]
@@ -2988,6 +4286,8 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 			stdExt = c.options.OutputExtensionJS
 		case *chunkReprCSS:
 			stdExt = c.options.OutputExtensionCSS
+		case *chunkReprHTML:
+			stdExt = ".html"
 		}
 
 		// Compute the template substitutions
@@ -3031,6 +4331,9 @@ func (c *linkerContext) computeChunks() []chunkInfo {
 		} else {
 			dir = "/"
 			base = "chunk"
+			if chunk.manualChunkName != "" {
+				base = chunk.manualChunkName
+			}
 			ext = stdExt
 			template = c.options.ChunkPathTemplate
 		}
@@ -3127,7 +4430,7 @@ func (c *linkerContext) findImportedPartsInJSOrder(chunk *chunkInfo) (js []uint3
 		file := &c.graph.Files[sourceIndex]
 
 		if repr, ok := file.InputFile.Repr.(*graph.JSRepr); ok {
-			isFileInThisChunk := chunk.entryBits.Equals(file.EntryBits)
+			isFileInThisChunk := chunk.filesWithPartsInChunk[sourceIndex]
 
 			// Wrapped files can't be split because they are all inside the wrapper
 			canFileBeSplit := repr.Meta.Wrap == graph.WrapNone
@@ -3544,6 +4847,13 @@ type compileResultJS struct {
 
 	sourceIndex uint32
 
+	// The SystemJS format moves function declarations into the declaration
+	// prologue and declares all top-level variables there. These are the
+	// printed functions and the hoisted variables.
+	systemHoistedFunctions    *compileResultJS
+	systemHoistedFunctionRefs []js_ast.Ref
+	systemHoistedVarRefs      []js_ast.Ref
+
 	// This is the line and column offset since the previous JavaScript string
 	// or the start of the file if this is the first JavaScript string.
 	generatedOffset sourcemap.LineColumnOffset
@@ -3569,6 +4879,7 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 	chunkAbsDir string,
 	toModuleRef js_ast.Ref,
 	runtimeRequireRef js_ast.Ref,
+	systemExportAliases map[js_ast.Ref][]string,
 	result *compileResultJS,
 	dataForSourceMaps []dataForSourceMap,
 ) {
@@ -3748,6 +5059,28 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		}
 	}
 
+	// The SystemJS format hoists function declarations and top-level variables
+	// out of the "execute" function into the declaration function. That way
+	// exported functions can be passed to "_export()" before any code runs,
+	// which lets modules that import this one in a cycle call them.
+	var hoistedFunctions []js_ast.Stmt
+	var hoistedFunctionRefs []js_ast.Ref
+	var hoistedVarRefs []js_ast.Ref
+	var systemHoistedExports map[js_ast.Ref]bool
+	if c.options.OutputFormat == config.FormatSystem {
+		stmts, hoistedFunctions, hoistedVarRefs = hoistSystemDecls(stmts)
+		for _, stmt := range hoistedFunctions {
+			ref := stmt.Data.(*js_ast.SFunction).Fn.Name.Ref
+			hoistedFunctionRefs = append(hoistedFunctionRefs, ref)
+			if _, ok := systemExportAliases[js_ast.FollowSymbols(c.graph.Symbols, ref)]; ok {
+				if systemHoistedExports == nil {
+					systemHoistedExports = make(map[js_ast.Ref]bool)
+				}
+				systemHoistedExports[js_ast.FollowSymbols(c.graph.Symbols, ref)] = true
+			}
+		}
+	}
+
 	// Only generate a source map if needed
 	var addSourceMappings bool
 	var inputSourceMap *sourcemap.SourceMap
@@ -3758,10 +5091,14 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		lineOffsetTables = dataForSourceMaps[partRange.sourceIndex].lineOffsetTables
 	}
 
-	// Indent the file if everything is wrapped in an IIFE
-	indent := 0
+	// Indent the file if everything is wrapped in a function
+	indent := c.indentForOutputFormat()
+
+	// Externals are read from globals instead of being imported in the IIFE
+	// format. The UMD format handles these in its preamble instead.
+	var externalGlobals map[string][]string
 	if c.options.OutputFormat == config.FormatIIFE {
-		indent++
+		externalGlobals = c.options.ExternalGlobals
 	}
 
 	// Convert the AST to JavaScript code
@@ -3773,28 +5110,118 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		ASCIIOnly:                    c.options.ASCIIOnly,
 		ToModuleRef:                  toModuleRef,
 		RuntimeRequireRef:            runtimeRequireRef,
+		ExternalGlobals:              externalGlobals,
 		LegalComments:                c.options.LegalComments,
 		UnsupportedFeatures:          c.options.UnsupportedJSFeatures,
 		AddSourceMappings:            addSourceMappings,
 		InputSourceMap:               inputSourceMap,
 		LineOffsetTables:             lineOffsetTables,
 		RequireOrImportMetaForSource: c.requireOrImportMetaForSource,
+		ConstValues:                  c.constValues,
+		InlinedFunctions:             c.inlinedFunctions,
+		SystemExportAliases:          systemExportAliases,
+		SystemHoistedExports:         systemHoistedExports,
 	}
 	tree := repr.AST
 	tree.Directive = "" // This is handled elsewhere
 	tree.Parts = []js_ast.Part{{Stmts: stmts}}
 	*result = compileResultJS{
-		PrintResult: js_printer.Print(tree, c.graph.Symbols, r, printOptions),
-		sourceIndex: partRange.sourceIndex,
+		PrintResult:               js_printer.Print(tree, c.graph.Symbols, r, printOptions),
+		sourceIndex:               partRange.sourceIndex,
+		systemHoistedFunctionRefs: hoistedFunctionRefs,
+		systemHoistedVarRefs:      hoistedVarRefs,
+	}
+
+	// Hoisted functions are indented one level inside the declaration function
+	if len(hoistedFunctions) > 0 {
+		printOptions.Indent = 1
+		tree.Parts = []js_ast.Part{{Stmts: hoistedFunctions}}
+		result.systemHoistedFunctions = &compileResultJS{
+			PrintResult: js_printer.Print(tree, c.graph.Symbols, r, printOptions),
+			sourceIndex: partRange.sourceIndex,
+		}
 	}
 
 	waitGroup.Done()
 }
 
+// Splits the top-level statements of a file for the SystemJS format. Function
+// declarations are returned separately so they can be moved into the
+// declaration function. Variable and class declarations are converted into
+// assignments and the declared symbols are returned so they can be declared in
+// the declaration function too, where the moved functions can see them.
+func hoistSystemDecls(stmts []js_ast.Stmt) (rest []js_ast.Stmt, functions []js_ast.Stmt, vars []js_ast.Ref) {
+	rest = make([]js_ast.Stmt, 0, len(stmts))
+	wrapIdentifier := func(loc logger.Loc, ref js_ast.Ref) js_ast.Expr {
+		vars = append(vars, ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+
+	for _, stmt := range stmts {
+		switch s := stmt.Data.(type) {
+		case *js_ast.SFunction:
+			clone := *s
+			clone.IsExport = false
+			functions = append(functions, js_ast.Stmt{Loc: stmt.Loc, Data: &clone})
+			continue
+
+		case *js_ast.SLocal:
+			var value js_ast.Expr
+			for _, decl := range s.Decls {
+				binding := js_ast.ConvertBindingToExpr(decl.Binding, wrapIdentifier)
+				if decl.ValueOrNil.Data != nil {
+					value = js_ast.JoinWithComma(value, js_ast.Assign(binding, decl.ValueOrNil))
+				}
+			}
+			if value.Data == nil {
+				continue
+			}
+			stmt = js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}}
+
+		case *js_ast.SClass:
+			// "class Foo {}" => "Foo = class Foo {}"
+			name := s.Class.Name
+			vars = append(vars, name.Ref)
+			stmt = js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: js_ast.Assign(
+				js_ast.Expr{Loc: name.Loc, Data: &js_ast.EIdentifier{Ref: name.Ref}},
+				js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EClass{Class: s.Class}},
+			)}}
+
+		case *js_ast.SExportDefault:
+			switch s2 := s.Value.Data.(type) {
+			case *js_ast.SFunction:
+				clone := *s2
+				if clone.Fn.Name == nil {
+					clone.Fn.Name = &js_ast.LocRef{Loc: s.DefaultName.Loc, Ref: s.DefaultName.Ref}
+				}
+				functions = append(functions, js_ast.Stmt{Loc: s.Value.Loc, Data: &clone})
+				continue
+
+			case *js_ast.SClass:
+				// Anonymous classes stay where they are since they can't be referenced
+				if name := s2.Class.Name; name != nil {
+					vars = append(vars, name.Ref)
+					rest = append(rest, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: js_ast.Assign(
+						js_ast.Expr{Loc: name.Loc, Data: &js_ast.EIdentifier{Ref: name.Ref}},
+						js_ast.Expr{Loc: s.Value.Loc, Data: &js_ast.EClass{Class: s2.Class}},
+					)}})
+					stmt = js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExportDefault{DefaultName: s.DefaultName, Value: js_ast.Stmt{
+						Loc: s.Value.Loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: name.Loc, Data: &js_ast.EIdentifier{Ref: name.Ref}}}}}}
+				}
+			}
+		}
+
+		rest = append(rest, stmt)
+	}
+
+	return
+}
+
 func (c *linkerContext) generateEntryPointTailJS(
 	r renamer.Renamer,
 	toModuleRef js_ast.Ref,
 	sourceIndex uint32,
+	systemHoistedExports map[js_ast.Ref]bool,
 ) (result compileResultJS) {
 	file := &c.graph.Files[sourceIndex]
 	repr := file.InputFile.Repr.(*graph.JSRepr)
@@ -3810,9 +5237,13 @@ func (c *linkerContext) generateEntryPointTailJS(
 			}}}})
 		}
 
-	case config.FormatIIFE:
+	case config.FormatIIFE, config.FormatUMD:
+		// The UMD format always returns the exports since they are observable
+		// through AMD and CommonJS even if there is no global name
+		returnsExports := len(c.options.GlobalName) > 0 || c.options.OutputFormat == config.FormatUMD
+
 		if repr.Meta.Wrap == graph.WrapCJS {
-			if len(c.options.GlobalName) > 0 {
+			if returnsExports {
 				// "return require_foo();"
 				stmts = append(stmts, js_ast.Stmt{Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Data: &js_ast.ECall{
 					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
@@ -3830,7 +5261,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
 				}}}})
 			}
-			if repr.Meta.ForceIncludeExportsForEntryPoint && len(c.options.GlobalName) > 0 {
+			if repr.Meta.ForceIncludeExportsForEntryPoint && returnsExports {
 				// "return exports;"
 				stmts = append(stmts, js_ast.Stmt{Data: &js_ast.SReturn{
 					ValueOrNil: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.ExportsRef}},
@@ -3929,7 +5360,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 			stmts = append(stmts, js_ast.Stmt{Data: &js_ast.SExpr{Value: expr}})
 		}
 
-	case config.FormatESModule:
+	case config.FormatESModule, config.FormatSystem:
 		if repr.Meta.Wrap == graph.WrapCJS {
 			// "export default require_foo();"
 			stmts = append(stmts, js_ast.Stmt{
@@ -4067,11 +5498,8 @@ func (c *linkerContext) generateEntryPointTailJS(
 	tree := repr.AST
 	tree.Parts = []js_ast.Part{{Stmts: stmts}}
 
-	// Indent the file if everything is wrapped in an IIFE
-	indent := 0
-	if c.options.OutputFormat == config.FormatIIFE {
-		indent++
-	}
+	// Indent the file if everything is wrapped in a function
+	indent := c.indentForOutputFormat()
 
 	// Convert the AST to JavaScript code
 	printOptions := js_printer.Options{
@@ -4084,6 +5512,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 		LegalComments:                c.options.LegalComments,
 		UnsupportedFeatures:          c.options.UnsupportedJSFeatures,
 		RequireOrImportMetaForSource: c.requireOrImportMetaForSource,
+		SystemHoistedExports:         systemHoistedExports,
 	}
 	result.PrintResult = js_printer.Print(tree, c.graph.Symbols, r, printOptions)
 	return
@@ -4111,6 +5540,22 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 		reservedNames["require"] = 1
 		reservedNames["Promise"] = 1
 	}
+
+	// Local variables must not shadow the globals that externals are read from
+	if c.options.OutputFormat == config.FormatIIFE {
+		for _, name := range c.options.ExternalGlobals {
+			reservedNames[name[0]] = 1
+		}
+	}
+
+	// These are used by the SystemJS wrapper around the code
+	if c.options.OutputFormat == config.FormatSystem {
+		reservedNames["_export"] = 1
+		reservedNames["_context"] = 1
+		reservedNames["_m"] = 1
+		reservedNames["_k"] = 1
+		reservedNames[js_printer.SystemUpdateTempName] = 1
+	}
 	timer.End("Compute reserved names")
 
 	// Make sure imports get a chance to be renamed too
@@ -4351,6 +5796,13 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 	// never change the "../" count.
 	chunkAbsDir := c.fs.Dir(c.fs.Join(c.options.AbsOutputDir, config.TemplateToString(chunk.finalTemplate)))
 
+	// Assignments to exported symbols must update importers in the SystemJS
+	// format since it doesn't have live bindings
+	var systemExportAliases map[js_ast.Ref][]string
+	if c.options.OutputFormat == config.FormatSystem {
+		systemExportAliases = c.systemExportAliasesForChunk(chunk)
+	}
+
 	// Generate JavaScript for each file in parallel
 	timer.Begin("Print JavaScript files")
 	waitGroup := sync.WaitGroup{}
@@ -4372,6 +5824,7 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 			chunkAbsDir,
 			toModuleRef,
 			runtimeRequireRef,
+			systemExportAliases,
 			compileResult,
 			dataForSourceMaps,
 		)
@@ -4380,18 +5833,17 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 	// Also generate the cross-chunk binding code
 	var crossChunkPrefix []byte
 	var crossChunkSuffix []byte
+	var crossChunkSystemImports []js_printer.SystemImport
+
+	// Indent the file if everything is wrapped in a function
+	crossChunkPrintOptions := js_printer.Options{
+		Indent:              c.indentForOutputFormat(),
+		OutputFormat:        c.options.OutputFormat,
+		RemoveWhitespace:    c.options.RemoveWhitespace,
+		MangleSyntax:        c.options.MangleSyntax,
+		SystemExportAliases: systemExportAliases,
+	}
 	{
-		// Indent the file if everything is wrapped in an IIFE
-		indent := 0
-		if c.options.OutputFormat == config.FormatIIFE {
-			indent++
-		}
-		printOptions := js_printer.Options{
-			Indent:           indent,
-			OutputFormat:     c.options.OutputFormat,
-			RemoveWhitespace: c.options.RemoveWhitespace,
-			MangleSyntax:     c.options.MangleSyntax,
-		}
 		crossChunkImportRecords := make([]ast.ImportRecord, len(chunk.crossChunkImports))
 		for i, chunkImport := range chunk.crossChunkImports {
 			crossChunkImportRecords[i] = ast.ImportRecord{
@@ -4399,15 +5851,57 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 				Path: logger.Path{Text: chunks[chunkImport.chunkIndex].uniqueKey},
 			}
 		}
-		crossChunkPrefix = js_printer.Print(js_ast.AST{
-			ImportRecords: crossChunkImportRecords,
-			Parts:         []js_ast.Part{{Stmts: chunkRepr.crossChunkPrefixStmts}},
-		}, c.graph.Symbols, r, printOptions).JS
-		crossChunkSuffix = js_printer.Print(js_ast.AST{
-			Parts: []js_ast.Part{{Stmts: chunkRepr.crossChunkSuffixStmts}},
-		}, c.graph.Symbols, r, printOptions).JS
+		crossChunkPrefixResult := js_printer.Print(js_ast.AST{
+			ImportRecords: crossChunkImportRecords,
+			Parts:         []js_ast.Part{{Stmts: chunkRepr.crossChunkPrefixStmts}},
+		}, c.graph.Symbols, r, crossChunkPrintOptions)
+		crossChunkPrefix = crossChunkPrefixResult.JS
+		crossChunkSystemImports = crossChunkPrefixResult.SystemImports
+	}
+
+	waitGroup.Wait()
+
+	// The exports of functions that were hoisted into the SystemJS declaration
+	// prologue must be omitted from the exports at the end of the chunk. So
+	// these are only printed once all files have been printed.
+	var systemHoistedExports map[js_ast.Ref]bool
+	var systemHoistedFunctions []compileResultJS
+	var systemHoistedExportItems []js_ast.ClauseItem
+	var systemHoistedVars []string
+	if c.options.OutputFormat == config.FormatSystem {
+		seenVars := make(map[string]bool)
+		for _, compileResult := range compileResults {
+			if compileResult.systemHoistedFunctions != nil {
+				systemHoistedFunctions = append(systemHoistedFunctions, *compileResult.systemHoistedFunctions)
+			}
+			for _, ref := range compileResult.systemHoistedFunctionRefs {
+				ref = js_ast.FollowSymbols(c.graph.Symbols, ref)
+				for _, alias := range systemExportAliases[ref] {
+					if systemHoistedExports == nil {
+						systemHoistedExports = make(map[js_ast.Ref]bool)
+					}
+					systemHoistedExports[ref] = true
+					systemHoistedExportItems = append(systemHoistedExportItems, js_ast.ClauseItem{Alias: alias, Name: js_ast.LocRef{Ref: ref}})
+				}
+			}
+			for _, ref := range compileResult.systemHoistedVarRefs {
+				if name := r.NameForSymbol(ref); !seenVars[name] {
+					seenVars[name] = true
+					systemHoistedVars = append(systemHoistedVars, name)
+				}
+			}
+			if compileResult.SystemUpdateTempIsUsed && !seenVars[js_printer.SystemUpdateTempName] {
+				seenVars[js_printer.SystemUpdateTempName] = true
+				systemHoistedVars = append(systemHoistedVars, js_printer.SystemUpdateTempName)
+			}
+		}
 	}
 
+	crossChunkPrintOptions.SystemHoistedExports = systemHoistedExports
+	crossChunkSuffix = js_printer.Print(js_ast.AST{
+		Parts: []js_ast.Part{{Stmts: chunkRepr.crossChunkSuffixStmts}},
+	}, c.graph.Symbols, r, crossChunkPrintOptions).JS
+
 	// Generate the exports for the entry point, if there are any
 	var entryPointTail compileResultJS
 	if chunk.isEntryPoint {
@@ -4415,10 +5909,10 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 			r,
 			toModuleRef,
 			chunk.sourceIndex,
+			systemHoistedExports,
 		)
 	}
 
-	waitGroup.Wait()
 	timer.End("Print JavaScript files")
 	timer.Begin("Join JavaScript files")
 
@@ -4481,11 +5975,13 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 		newlineBeforeComment = false
 	}
 
-	// Put the cross-chunk prefix inside the IIFE
-	if len(crossChunkPrefix) > 0 {
-		newlineBeforeComment = true
-		prevOffset.AdvanceBytes(crossChunkPrefix)
-		j.AddBytes(crossChunkPrefix)
+	// Optionally wrap with a UMD factory
+	if c.options.OutputFormat == config.FormatUMD {
+		indent = "  "
+		text := c.generateUMDPrefix(c.externalImportPathsInChunk(chunk))
+		prevOffset.AdvanceString(text)
+		j.AddString(text)
+		newlineBeforeComment = false
 	}
 
 	// Start the metadata
@@ -4562,7 +6058,7 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 		metaOrder = make([]uint32, 0, len(compileResults))
 		metaByteCount = make(map[string]int, len(compileResults))
 	}
-	for _, compileResult := range compileResults {
+	addCompileResult := func(compileResult compileResultJS) {
 		isRuntime := compileResult.sourceIndex == runtime.SourceIndex
 		for text := range compileResult.ExtractedLegalComments {
 			if !legalCommentSet[text] {
@@ -4635,6 +6131,58 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 		}
 	}
 
+	// Optionally wrap with a SystemJS registration
+	if c.options.OutputFormat == config.FormatSystem {
+		systemImports := crossChunkSystemImports
+		for _, compileResult := range compileResults {
+			systemImports = append(systemImports, compileResult.SystemImports...)
+		}
+		head, tail := c.generateSystemPrefix(systemImports, systemHoistedVars, c.chunkHasTopLevelAwait(chunk))
+		prevOffset.AdvanceString(head)
+		j.AddString(head)
+		newlineBeforeComment = false
+
+		// Hoisted functions go in the declaration function so they can be
+		// exported before any module in the same cycle executes
+		if len(systemHoistedFunctions) > 0 {
+			indent = "  "
+			for _, compileResult := range systemHoistedFunctions {
+				addCompileResult(compileResult)
+			}
+			if len(systemHoistedExportItems) > 0 {
+				exportOptions := crossChunkPrintOptions
+				exportOptions.Indent = 1
+				exportOptions.SystemHoistedExports = nil
+				text := js_printer.Print(js_ast.AST{
+					Parts: []js_ast.Part{{Stmts: []js_ast.Stmt{{Data: &js_ast.SExportClause{Items: systemHoistedExportItems}}}}},
+				}, c.graph.Symbols, r, exportOptions).JS
+				if newlineBeforeComment {
+					prevOffset.AdvanceString(newline)
+					j.AddString(newline)
+				}
+				prevOffset.AdvanceBytes(text)
+				j.AddBytes(text)
+			}
+			prevFileNameComment = 0
+			newlineBeforeComment = false
+		}
+
+		indent = "      "
+		prevOffset.AdvanceString(tail)
+		j.AddString(tail)
+	}
+
+	// Put the cross-chunk prefix inside the IIFE
+	if len(crossChunkPrefix) > 0 {
+		newlineBeforeComment = true
+		prevOffset.AdvanceBytes(crossChunkPrefix)
+		j.AddBytes(crossChunkPrefix)
+	}
+
+	for _, compileResult := range compileResults {
+		addCompileResult(compileResult)
+	}
+
 	// Stick the entry point tail at the end of the file. Deliberately don't
 	// include any source mapping information for this because it's automatically
 	// generated and doesn't correspond to a location in the input file.
@@ -4653,6 +6201,20 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 		j.AddString("})();" + newline)
 	}
 
+	// Optionally wrap with a UMD factory
+	if c.options.OutputFormat == config.FormatUMD {
+		j.AddString("});" + newline)
+	}
+
+	// Optionally wrap with a SystemJS registration
+	if c.options.OutputFormat == config.FormatSystem {
+		if c.options.RemoveWhitespace {
+			j.AddString("}}});")
+		} else {
+			j.AddString("    }\n  };\n});\n")
+		}
+	}
+
 	// Make sure the file ends with a newline
 	j.EnsureNewlineAtEnd()
 
@@ -4710,13 +6272,15 @@ func (c *linkerContext) generateChunkJS(chunks []chunkInfo, chunkIndex int, chun
 				}
 				path := c.graph.Files[sourceIndex].InputFile.Source.PrettyPath
 				extra := c.generateExtraDataForFileJS(sourceIndex)
-				jMeta.AddString(fmt.Sprintf("\n        %s: {\n          \"bytesInOutput\": %d\n        %s}",
-					js_printer.QuoteForJSON(path, c.options.ASCIIOnly), metaByteCount[path], extra))
+				jMeta.AddString(fmt.Sprintf("\n        %s: {\n          \"bytesInOutput\": %d%s\n        %s}",
+					js_printer.QuoteForJSON(path, c.options.ASCIIOnly), metaByteCount[path], c.generateIncludedByJSON(sourceIndex), extra))
 			}
 			if !isFirstMeta {
 				jMeta.AddString("\n      ")
 			}
-			jMeta.AddString(fmt.Sprintf("},\n      \"bytes\": %d\n    }", finalOutputSize))
+			jMeta.AddString(fmt.Sprintf("},\n      \"bytes\": %d", finalOutputSize))
+			jMeta.AddString(c.generateChunkSizeDecisionsJSON(chunk.sizeDecisions))
+			jMeta.AddString("\n    }")
 			return jMeta
 		}
 	}
@@ -4763,6 +6327,284 @@ func (c *linkerContext) generateGlobalNamePrefix() string {
 	return text
 }
 
+// Everything is indented by one level for formats that wrap the code in a
+// function. The SystemJS format puts the code inside an "execute" function
+// inside an object literal inside the registration function.
+func (c *linkerContext) indentForOutputFormat() int {
+	switch c.options.OutputFormat {
+	case config.FormatIIFE, config.FormatUMD:
+		return 1
+	case config.FormatSystem:
+		return 3
+	}
+	return 0
+}
+
+// Returns the paths of all external modules that are imported by live code in
+// this chunk, in the order that they are first imported
+func (c *linkerContext) externalImportPathsInChunk(chunk *chunkInfo) (paths []string) {
+	chunkRepr := chunk.chunkRepr.(*chunkReprJS)
+	visited := make(map[string]bool)
+
+	for _, partRange := range chunkRepr.partsInChunkInOrder {
+		repr := c.graph.Files[partRange.sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		for partIndex := partRange.partIndexBegin; partIndex < partRange.partIndexEnd; partIndex++ {
+			for _, importRecordIndex := range repr.AST.Parts[partIndex].ImportRecordIndices {
+				record := &repr.AST.ImportRecords[importRecordIndex]
+				if record.SourceIndex.IsValid() || record.IsUnused || (record.Kind != ast.ImportStmt && record.Kind != ast.ImportRequire) {
+					continue
+				}
+				if !visited[record.Path.Text] {
+					visited[record.Path.Text] = true
+					paths = append(paths, record.Path.Text)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// This generates the UMD preamble. The factory function receives a "require"
+// function that is used by the bundled code to import external modules. AMD
+// loaders provide one that can synchronously return any of the listed
+// dependencies, and CommonJS has one already. When running as a script, the
+// externals are mapped to globals using the "ExternalGlobals" option.
+func (c *linkerContext) generateUMDPrefix(externals []string) string {
+	space := " "
+	newline := "\n"
+	indent := "  "
+	if c.options.RemoveWhitespace {
+		space = ""
+		newline = ""
+		indent = ""
+	}
+	quote := func(text string) string {
+		return string(js_printer.QuoteForJSON(text, c.options.ASCIIOnly))
+	}
+
+	// "define(["require", "foo"], factory);"
+	deps := []string{quote("require")}
+	for _, path := range externals {
+		deps = append(deps, quote(path))
+	}
+
+	// "factory(function(id) { return { "foo": root.Foo }[id]; })"
+	var globals []string
+	var missing []string
+	for _, path := range externals {
+		if name, ok := c.options.ExternalGlobals[path]; ok {
+			globals = append(globals, fmt.Sprintf("%s:%s%s", quote(path), space, c.globalPropertyChain("root", name)))
+		} else {
+			missing = append(missing, quote(path))
+		}
+	}
+
+	// Imports of external modules without a global can't work when the code is
+	// loaded using a script tag, so warn about them
+	if len(missing) > 0 {
+		what := "module " + missing[0] + " has"
+		if len(missing) > 1 {
+			what = "modules " + strings.Join(missing, ", ") + " have"
+		}
+		c.log.AddIDWithNotes(logger.MsgID_Bundler_MissingExternalGlobal, logger.Warning, nil, logger.Range{},
+			fmt.Sprintf("The external %s no global name, so importing it will fail when the UMD output is loaded as a browser global", what),
+			[]logger.MsgData{{Text: "You can use \"--external-global:M=G\" to use the global G for the external module M."}})
+	}
+	callFactory := "factory()"
+	if len(globals) > 0 {
+		callFactory = fmt.Sprintf("factory(function(id)%s{%s%s%s%sreturn%s{%s%s%s}[id];%s%s%s})",
+			space, newline, indent, indent, indent, space, space, strings.Join(globals, ","+space), space, newline, indent, indent)
+	}
+
+	// "root.globalName = factory(...);"
+	var assignGlobal string
+	if len(c.options.GlobalName) > 0 {
+		for i := range c.options.GlobalName[:len(c.options.GlobalName)-1] {
+			prefix := c.globalPropertyChain("root", c.options.GlobalName[:i+1])
+			assignGlobal += fmt.Sprintf("%s%s%s%s=%s%s%s||%s{};%s", indent, indent, prefix, space, space, prefix, space, space, newline)
+		}
+		assignGlobal += fmt.Sprintf("%s%s%s%s=%s%s;%s", indent, indent, c.globalPropertyChain("root", c.options.GlobalName), space, space, callFactory, newline)
+	} else {
+		assignGlobal = fmt.Sprintf("%s%s%s;%s", indent, indent, callFactory, newline)
+	}
+
+	text := "(function(root," + space + "factory)" + space + "{" + newline +
+		indent + "if" + space + "(typeof define" + space + "===" + space + "\"function\"" + space + "&&" + space + "define.amd)" + space + "{" + newline +
+		indent + indent + "define([" + strings.Join(deps, ","+space) + "]," + space + "factory);" + newline +
+		indent + "}" + space + "else if" + space + "(typeof module" + space + "===" + space + "\"object\"" + space + "&&" + space + "module.exports)" + space + "{" + newline +
+		indent + indent + "module.exports" + space + "=" + space + "factory(require);" + newline +
+		indent + "}" + space + "else" + space + "{" + newline +
+		assignGlobal +
+		indent + "}" + newline +
+		"})(typeof self" + space + "!==" + space + "\"undefined\"" + space + "?" + space + "self" + space + ":" + space + "this," + space
+
+	if c.options.UnsupportedJSFeatures.Has(compat.Arrow) {
+		text += "function(require)" + space + "{" + newline
+	} else {
+		text += "(require)" + space + "=>" + space + "{" + newline
+	}
+	return text
+}
+
+// Returns the names that assignments to each exported symbol must be
+// forwarded to. These are the exports of the entry point and the exports to
+// other chunks.
+func (c *linkerContext) systemExportAliasesForChunk(chunk *chunkInfo) map[js_ast.Ref][]string {
+	aliases := make(map[js_ast.Ref][]string)
+	chunkRepr := chunk.chunkRepr.(*chunkReprJS)
+
+	for ref, alias := range chunkRepr.exportsToOtherChunks {
+		ref = js_ast.FollowSymbols(c.graph.Symbols, ref)
+		aliases[ref] = append(aliases[ref], alias)
+	}
+
+	if chunk.isEntryPoint {
+		if repr := c.graph.Files[chunk.sourceIndex].InputFile.Repr.(*graph.JSRepr); repr.Meta.Wrap != graph.WrapCJS {
+			for _, alias := range repr.Meta.SortedAndFilteredExportAliases {
+				export := repr.Meta.ResolvedExports[alias]
+				if importData, ok := c.graph.Files[export.SourceIndex].InputFile.Repr.(*graph.JSRepr).Meta.ImportsToBind[export.Ref]; ok {
+					export.Ref = importData.Ref
+				}
+
+				// Exports of CommonJS properties are copied into a temporary variable
+				// first, so they don't need to be updated
+				if c.graph.Symbols.Get(export.Ref).NamespaceAlias == nil {
+					ref := js_ast.FollowSymbols(c.graph.Symbols, export.Ref)
+					aliases[ref] = append(aliases[ref], alias)
+				}
+			}
+		}
+	}
+
+	for _, list := range aliases {
+		sort.Strings(list)
+	}
+	return aliases
+}
+
+func (c *linkerContext) chunkHasTopLevelAwait(chunk *chunkInfo) bool {
+	for _, sourceIndex := range chunk.chunkRepr.(*chunkReprJS).filesInChunkInOrder {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok && repr.AST.TopLevelAwaitKeyword.Len > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// This generates everything in the SystemJS wrapper before the code. Each
+// import statement becomes a dependency with a setter function that assigns
+// to the imported bindings whenever the imported module updates its exports.
+// Returns the start of the SystemJS wrapper in two parts. The declaration
+// function body goes in between the two parts, and the "execute" function body
+// comes after the second part.
+func (c *linkerContext) generateSystemPrefix(imports []js_printer.SystemImport, hoistedVars []string, isAsync bool) (head string, tail string) {
+	space := " "
+	newline := "\n"
+	indent := "  "
+	if c.options.RemoveWhitespace {
+		space = ""
+		newline = ""
+		indent = ""
+	}
+	quote := func(text string) string {
+		return string(js_printer.QuoteForJSON(text, c.options.ASCIIOnly))
+	}
+	property := func(name string) string {
+		if name == "*" {
+			return "_m"
+		}
+		return c.globalPropertyChain("_m", []string{name})
+	}
+
+	// Merge imports of the same path together
+	var paths []string
+	setters := make(map[string][]string)
+	var locals []string
+	for _, item := range imports {
+		if _, ok := setters[item.Path]; !ok {
+			paths = append(paths, item.Path)
+			setters[item.Path] = []string{}
+		}
+		lines := setters[item.Path]
+		for _, binding := range item.Bindings {
+			locals = append(locals, binding.Name)
+			lines = append(lines, fmt.Sprintf("%s%s=%s%s", binding.Name, space, space, property(binding.Imported)))
+		}
+		for _, binding := range item.ReExports {
+			lines = append(lines, fmt.Sprintf("_export(%s,%s%s)", quote(binding.Name), space, property(binding.Imported)))
+		}
+		if item.ExportStar {
+			lines = append(lines, fmt.Sprintf("for%s(var _k in _m)%sif%s(_k%s!==%s\"default\")%s_export(_k,%s_m[_k])",
+				space, space, space, space, space, space, space))
+		}
+		setters[item.Path] = lines
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("System.register([")
+	for i, path := range paths {
+		if i > 0 {
+			sb.WriteString("," + space)
+		}
+		sb.WriteString(quote(path))
+	}
+	sb.WriteString("]," + space + "function(_export," + space + "_context)" + space + "{" + newline)
+	sb.WriteString(indent + "\"use strict\";" + newline)
+	locals = append(locals, hoistedVars...)
+	if len(locals) > 0 {
+		sb.WriteString(indent + "var " + strings.Join(locals, ","+space) + ";" + newline)
+	}
+	head = sb.String()
+	sb.Reset()
+	sb.WriteString(indent + "return" + space + "{" + newline)
+	sb.WriteString(indent + indent + "setters:" + space + "[")
+	for i, path := range paths {
+		if i > 0 {
+			sb.WriteString("," + space)
+		}
+		lines := setters[path]
+		if len(lines) == 0 {
+			sb.WriteString("null")
+			continue
+		}
+		sb.WriteString("function(_m)" + space + "{" + newline)
+		for k, line := range lines {
+			sb.WriteString(indent + indent + indent + line)
+			if k+1 < len(lines) || !c.options.RemoveWhitespace {
+				sb.WriteString(";")
+			}
+			sb.WriteString(newline)
+		}
+		sb.WriteString(indent + indent + "}")
+	}
+	sb.WriteString("]," + newline)
+	sb.WriteString(indent + indent + "execute:" + space)
+	if isAsync {
+		sb.WriteString("async ")
+	}
+	sb.WriteString("function()" + space + "{" + newline)
+	tail = sb.String()
+	return
+}
+
+// Returns "root.a.b" for the global name "a.b", using an index expression for
+// names that aren't valid identifiers
+func (c *linkerContext) globalPropertyChain(root string, names []string) string {
+	text := root
+	for _, name := range names {
+		if js_printer.CanEscapeIdentifier(name, c.options.UnsupportedJSFeatures, c.options.ASCIIOnly) {
+			if c.options.ASCIIOnly {
+				name = string(js_printer.QuoteIdentifier(nil, name, c.options.UnsupportedJSFeatures))
+			}
+			text = fmt.Sprintf("%s.%s", text, name)
+		} else {
+			text = fmt.Sprintf("%s[%s]", text, js_printer.QuoteForJSON(name, c.options.ASCIIOnly))
+		}
+	}
+	return text
+}
+
 type compileResultCSS struct {
 	printedCSS  string
 	sourceIndex uint32
@@ -4921,9 +6763,9 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 			} else {
 				jMeta.AddString(",")
 			}
-			jMeta.AddString(fmt.Sprintf("\n        %s: {\n          \"bytesInOutput\": %d\n        }",
+			jMeta.AddString(fmt.Sprintf("\n        %s: {\n          \"bytesInOutput\": %d%s\n        }",
 				js_printer.QuoteForJSON(c.graph.Files[compileResult.sourceIndex].InputFile.Source.PrettyPath, c.options.ASCIIOnly),
-				len(compileResult.printedCSS)))
+				len(compileResult.printedCSS), c.generateIncludedByJSON(compileResult.sourceIndex)))
 		}
 	}
 
@@ -4955,6 +6797,254 @@ func (c *linkerContext) generateChunkCSS(chunks []chunkInfo, chunkIndex int, chu
 	chunkWaitGroup.Done()
 }
 
+// Returns the chunk for each script and stylesheet referenced by an HTML file,
+// followed by the CSS chunk generated for each referenced script. Each chunk is
+// only returned once.
+func (c *linkerContext) chunksReferencedByHTML(chunks []chunkInfo, sourceIndex uint32) (chunkIndices []uint32) {
+	repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.HTMLRepr)
+	visited := make(map[uint32]bool)
+	visit := func(chunkIndex uint32) {
+		if !visited[chunkIndex] {
+			visited[chunkIndex] = true
+			chunkIndices = append(chunkIndices, chunkIndex)
+		}
+	}
+	for _, url := range repr.AST.URLs {
+		if record := &repr.AST.ImportRecords[url.ImportRecordIndex]; record.SourceIndex.IsValid() && url.Kind != html_ast.URLAsset {
+			otherSourceIndex := record.SourceIndex.GetIndex()
+			visit(c.graph.Files[otherSourceIndex].EntryPointChunkIndex)
+			if url.Kind == html_ast.URLScript {
+				if cssChunkIndex, ok := cssChunkIndexForEntryPoint(chunks, otherSourceIndex); ok {
+					visit(cssChunkIndex)
+				}
+			}
+		}
+	}
+	return
+}
+
+// JS entry points that import CSS files generate a secondary CSS chunk. This
+// chunk isn't the one in "EntryPointChunkIndex" so it has to be searched for.
+func cssChunkIndexForEntryPoint(chunks []chunkInfo, sourceIndex uint32) (uint32, bool) {
+	for chunkIndex, chunk := range chunks {
+		if _, ok := chunk.chunkRepr.(*chunkReprCSS); ok && chunk.isEntryPoint && chunk.sourceIndex == sourceIndex {
+			return uint32(chunkIndex), true
+		}
+	}
+	return 0, false
+}
+
+// Returns the chunks that should be loaded along with the chunk for a module
+// script: its CSS chunk followed by the chunks that it imports directly or
+// indirectly. Dynamically-imported chunks aren't included since they may
+// never be loaded.
+func (c *linkerContext) chunksToLinkBeforeScript(chunks []chunkInfo, sourceIndex uint32) (cssChunks []uint32, jsChunks []uint32) {
+	if cssChunkIndex, ok := cssChunkIndexForEntryPoint(chunks, sourceIndex); ok {
+		cssChunks = append(cssChunks, cssChunkIndex)
+	}
+
+	// Preloading only makes sense if the chunks are loaded as modules
+	if c.options.OutputFormat == config.FormatESModule {
+		jsChunks = staticallyImportedChunks(chunks, c.graph.Files[sourceIndex].EntryPointChunkIndex)
+	}
+	return
+}
+
+// Returns the chunks that a chunk imports directly or indirectly in the order
+// they are first encountered, not including the chunk itself. Chunks that are
+// only imported with "import()" are not included.
+func staticallyImportedChunks(chunks []chunkInfo, chunkIndex uint32) (chunkIndices []uint32) {
+	visited := make(map[uint32]bool)
+	var visit func(uint32)
+	visit = func(chunkIndex uint32) {
+		for _, chunkImport := range chunks[chunkIndex].crossChunkImports {
+			if chunkImport.importKind != ast.ImportDynamic && !visited[chunkImport.chunkIndex] {
+				visited[chunkImport.chunkIndex] = true
+				chunkIndices = append(chunkIndices, chunkImport.chunkIndex)
+				visit(chunkImport.chunkIndex)
+			}
+		}
+	}
+	visited[chunkIndex] = true
+	visit(chunkIndex)
+	return
+}
+
+// Generates the manifest entry for an entry point chunk. The paths in it are
+// relative to the output directory, so it can only be generated once the final
+// paths of all chunks are known.
+func (c *linkerContext) generateManifestChunk(chunks []chunkInfo, chunkIndex uint32) string {
+	chunk := &chunks[chunkIndex]
+	relPath := func(chunkIndex uint32) string {
+		absPath := c.fs.Join(c.options.AbsOutputDir, chunks[chunkIndex].finalRelPath)
+		relPath, _ := c.fs.Rel(c.options.AbsOutputDir, absPath)
+
+		// Make sure to always use forward slashes, even on Windows
+		return strings.ReplaceAll(relPath, "\\", "/")
+	}
+	pathArray := func(chunkIndices []uint32) string {
+		if len(chunkIndices) == 0 {
+			return "[]"
+		}
+		sb := strings.Builder{}
+		sb.WriteString("[")
+		for i, chunkIndex := range chunkIndices {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("\n      ")
+			sb.Write(js_printer.QuoteForJSON(relPath(chunkIndex), c.options.ASCIIOnly))
+		}
+		sb.WriteString("\n    ]")
+		return sb.String()
+	}
+
+	// Scripts need their CSS and their imports to be loaded along with them
+	var css []uint32
+	if cssChunkIndex, ok := cssChunkIndexForEntryPoint(chunks, chunk.sourceIndex); ok && cssChunkIndex != chunkIndex {
+		css = append(css, cssChunkIndex)
+	}
+	imports := staticallyImportedChunks(chunks, chunkIndex)
+
+	// Dynamic imports in any of the loaded chunks may be loaded later
+	var dynamicImports []uint32
+	visited := make(map[uint32]bool)
+	for _, otherChunkIndex := range append([]uint32{chunkIndex}, imports...) {
+		for _, chunkImport := range chunks[otherChunkIndex].crossChunkImports {
+			if chunkImport.importKind == ast.ImportDynamic && !visited[chunkImport.chunkIndex] {
+				visited[chunkImport.chunkIndex] = true
+				dynamicImports = append(dynamicImports, chunkImport.chunkIndex)
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s: {\n    \"file\": %s,\n    \"css\": %s,\n    \"imports\": %s,\n    \"dynamicImports\": %s\n  }",
+		js_printer.QuoteForJSON(c.graph.Files[chunk.sourceIndex].InputFile.Source.PrettyPath, c.options.ASCIIOnly),
+		js_printer.QuoteForJSON(relPath(chunkIndex), c.options.ASCIIOnly),
+		pathArray(css),
+		pathArray(imports),
+		pathArray(dynamicImports))
+}
+
+func (c *linkerContext) generateChunkHTML(chunks []chunkInfo, chunkIndex int, chunkWaitGroup *sync.WaitGroup) {
+	chunk := &chunks[chunkIndex]
+
+	timer := c.timer.Fork()
+	if timer != nil {
+		timeName := fmt.Sprintf("Generate chunk %q", path.Clean(config.TemplateToString(chunk.finalTemplate)))
+		timer.Begin(timeName)
+		defer c.timer.Join(timer)
+		defer timer.End(timeName)
+	}
+
+	file := &c.graph.Files[chunk.sourceIndex]
+	repr := file.InputFile.Repr.(*graph.HTMLRepr)
+	contents := file.InputFile.Source.Contents
+	linkedChunks := make(map[uint32]bool)
+	j := helpers.Joiner{}
+	end := 0
+
+	for _, url := range repr.AST.URLs {
+		// Leave URLs for external files alone
+		record := &repr.AST.ImportRecords[url.ImportRecordIndex]
+		if !record.SourceIndex.IsValid() {
+			continue
+		}
+		otherSourceIndex := record.SourceIndex.GetIndex()
+
+		var newURL string
+		switch url.Kind {
+		case html_ast.URLAsset:
+			if otherRepr, ok := c.graph.Files[otherSourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+				newURL = otherRepr.AST.URLForCSS
+			}
+
+		case html_ast.URLStylesheet:
+			otherChunkIndex := c.graph.Files[otherSourceIndex].EntryPointChunkIndex
+			linkedChunks[otherChunkIndex] = true
+			newURL = chunks[otherChunkIndex].uniqueKey
+
+		case html_ast.URLScript:
+			otherChunkIndex := c.graph.Files[otherSourceIndex].EntryPointChunkIndex
+			linkedChunks[otherChunkIndex] = true
+			newURL = chunks[otherChunkIndex].uniqueKey
+
+			// Link to the CSS generated for this script and preload the chunks that
+			// it imports. These are inserted before the "<script>" tag using the
+			// same indentation as the tag.
+			tagStart := int(url.TagLoc.Start)
+			indent := tagStart
+			for indent > 0 && (contents[indent-1] == ' ' || contents[indent-1] == '\t') {
+				indent--
+			}
+			separator := "\n"
+			if indent == 0 || contents[indent-1] == '\n' {
+				separator += contents[indent:tagStart]
+			}
+			j.AddString(contents[end:tagStart])
+			end = tagStart
+			cssChunks, jsChunks := c.chunksToLinkBeforeScript(chunks, otherSourceIndex)
+			for _, cssChunkIndex := range cssChunks {
+				if !linkedChunks[cssChunkIndex] {
+					linkedChunks[cssChunkIndex] = true
+					j.AddString(fmt.Sprintf("<link rel=\"stylesheet\" href=\"%s\">%s", chunks[cssChunkIndex].uniqueKey, separator))
+				}
+			}
+			for _, jsChunkIndex := range jsChunks {
+				if !linkedChunks[jsChunkIndex] {
+					linkedChunks[jsChunkIndex] = true
+					j.AddString(fmt.Sprintf("<link rel=\"modulepreload\" href=\"%s\">%s", chunks[jsChunkIndex].uniqueKey, separator))
+				}
+			}
+		}
+
+		if newURL != "" {
+			j.AddString(contents[end:url.Range.Loc.Start])
+			j.AddString(newURL)
+			end = int(url.Range.End())
+		}
+	}
+	j.AddString(contents[end:])
+
+	// Start the metadata
+	jMeta := helpers.Joiner{}
+	if c.options.NeedsMetafile {
+		isFirstMeta := true
+		jMeta.AddString("{\n      \"imports\": [")
+		for _, chunkImport := range chunk.crossChunkImports {
+			if isFirstMeta {
+				isFirstMeta = false
+			} else {
+				jMeta.AddString(",")
+			}
+			jMeta.AddString(fmt.Sprintf("\n        {\n          \"path\": %s,\n          \"kind\": %s\n        }",
+				js_printer.QuoteForJSON(c.res.PrettyPath(logger.Path{Text: chunks[chunkImport.chunkIndex].uniqueKey, Namespace: "file"}), c.options.ASCIIOnly),
+				js_printer.QuoteForJSON(chunkImport.importKind.StringForMetafile(), c.options.ASCIIOnly)))
+		}
+		if !isFirstMeta {
+			jMeta.AddString("\n      ")
+		}
+		jMeta.AddString(fmt.Sprintf("],\n      \"entryPoint\": %s,\n      \"inputs\": {\n        %s: {\n          \"bytesInOutput\": %d\n        }\n      }",
+			js_printer.QuoteForJSON(file.InputFile.Source.PrettyPath, c.options.ASCIIOnly),
+			js_printer.QuoteForJSON(file.InputFile.Source.PrettyPath, c.options.ASCIIOnly),
+			len(contents)))
+	}
+
+	chunk.intermediateOutput = c.breakOutputIntoPieces(j, uint32(len(chunks)))
+
+	// End the metadata lazily. The final output size is not known until the
+	// final import paths are substituted into the output pieces generated below.
+	if c.options.NeedsMetafile {
+		chunk.jsonMetadataChunkCallback = func(finalOutputSize int) helpers.Joiner {
+			jMeta.AddString(fmt.Sprintf(",\n      \"bytes\": %d\n    }", finalOutputSize))
+			return jMeta
+		}
+	}
+
+	c.generateIsolatedHashInParallel(chunk)
+	chunkWaitGroup.Done()
+}
+
 func appendIsolatedHashesForImportedChunks(
 	hash hash.Hash,
 	chunks []chunkInfo,
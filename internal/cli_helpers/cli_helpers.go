@@ -21,6 +21,8 @@ func ParseLoader(text string) (api.Loader, error) {
 		return api.LoaderTSX, nil
 	case "css":
 		return api.LoaderCSS, nil
+	case "html":
+		return api.LoaderHTML, nil
 	case "json":
 		return api.LoaderJSON, nil
 	case "text":
@@ -37,6 +39,6 @@ func ParseLoader(text string) (api.Loader, error) {
 		return api.LoaderDefault, nil
 	default:
 		return api.LoaderNone, fmt.Errorf("Invalid loader: %q (valid: "+
-			"js, jsx, ts, tsx, css, json, text, base64, dataurl, file, binary)", text)
+			"js, jsx, ts, tsx, css, html, json, text, base64, dataurl, file, binary)", text)
 	}
 }
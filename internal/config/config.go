@@ -67,6 +67,15 @@ const (
 	LegalCommentsExternalWithoutComment
 )
 
+type Integrity uint8
+
+const (
+	IntegrityNone Integrity = iota
+	IntegritySHA256
+	IntegritySHA384
+	IntegritySHA512
+)
+
 func (lc LegalComments) HasExternalFile() bool {
 	return lc == LegalCommentsLinkedWithComment || lc == LegalCommentsExternalWithoutComment
 }
@@ -86,6 +95,7 @@ const (
 	LoaderFile
 	LoaderBinary
 	LoaderCSS
+	LoaderHTML
 	LoaderDefault
 )
 
@@ -134,10 +144,47 @@ const (
 	//   export {...};
 	//
 	FormatESModule
+
+	// The UMD format looks like this:
+	//
+	//   (function(root, factory) {
+	//     if (typeof define === "function" && define.amd) {
+	//       define(["require", ...externals], factory);
+	//     } else if (typeof module === "object" && module.exports) {
+	//       module.exports = factory(require);
+	//     } else {
+	//       root.globalName = factory(...);
+	//     }
+	//   })(typeof self !== "undefined" ? self : this, (require) => {
+	//     ... bundled code ...
+	//     return exports;
+	//   });
+	//
+	// External imports use the "require" function passed to the factory. In
+	// the global case, that function returns the global configured for each
+	// external in ExternalGlobals.
+	FormatUMD
+
+	// The SystemJS format looks like this:
+	//
+	//   System.register([...deps], function(_export, _context) {
+	//     var ...imports;
+	//     return {
+	//       setters: [...],
+	//       execute: function() {
+	//         ... bundled code ...
+	//         _export({...});
+	//       }
+	//     };
+	//   });
+	//
+	// The linker handles this format like the ES module format. The printer
+	// converts the import and export statements at the very end.
+	FormatSystem
 )
 
 func (f Format) KeepES6ImportExportSyntax() bool {
-	return f == FormatPreserve || f == FormatESModule
+	return f == FormatPreserve || f == FormatESModule || f == FormatSystem
 }
 
 func (f Format) String() string {
@@ -148,6 +195,10 @@ func (f Format) String() string {
 		return "cjs"
 	case FormatESModule:
 		return "esm"
+	case FormatUMD:
+		return "umd"
+	case FormatSystem:
+		return "system"
 	}
 	return ""
 }
@@ -170,6 +221,16 @@ type ExternalModules struct {
 	Patterns    []WildcardPattern
 }
 
+// When code splitting, files with a path that matches one of the filters are
+// put in the chunk with this name instead of in the chunk for the entry points
+// that they are reachable from. The filters are matched against the path
+// relative to the current working directory. Files imported by these files
+// are put in the same chunk too.
+type ManualChunk struct {
+	Name    string
+	Filters []*regexp.Regexp
+}
+
 type Mode uint8
 
 const (
@@ -201,6 +262,7 @@ type Options struct {
 	RemoveWhitespace  bool
 	MinifyIdentifiers bool
 	MangleSyntax      bool
+	MangleInline      bool
 	ProfilerNames     bool
 	CodeSplitting     bool
 	WatchMode         bool
@@ -213,9 +275,12 @@ type Options struct {
 	OmitRuntimeForTests     bool
 	PreserveUnusedImportsTS bool
 	UseDefineForClassFields MaybeBool
+	ExperimentalDecorators  MaybeBool
+	HoistSimpleCommonJS     bool // Set per-file by the bundler for non-entry points
 	ASCIIOnly               bool
 	KeepNames               bool
 	IgnoreDCEAnnotations    bool
+	InferPureFunctions      bool
 
 	Defines  *ProcessedDefines
 	TS       TSOptions
@@ -236,6 +301,11 @@ type Options struct {
 	Conditions      []string
 	AbsNodePaths    []string // The "NODE_PATH" variable from Node.js
 	ExternalModules ExternalModules
+	PackageAliases  map[string]string // Maps package paths to substitute import paths
+
+	// If true, all package paths are external except for those that are
+	// mapped to local files using "tsconfig.json"
+	ExternalPackages bool
 
 	AbsOutputFile      string
 	AbsOutputDir       string
@@ -243,6 +313,7 @@ type Options struct {
 	OutputExtensionJS  string
 	OutputExtensionCSS string
 	GlobalName         []string
+	ExternalGlobals    map[string][]string
 	TsConfigOverride   string
 	ExtensionToLoader  map[string]Loader
 	OutputFormat       Format
@@ -259,11 +330,42 @@ type Options struct {
 	EntryPathTemplate []PathTemplate
 	ChunkPathTemplate []PathTemplate
 	AssetPathTemplate []PathTemplate
+	ManualChunks      []ManualChunk
+
+	// This is called with the path of each file that isn't matched by one of
+	// the "ManualChunks" filters. It returns the name of the manual chunk for
+	// that file or an empty string to let the file be assigned automatically.
+	ManualChunksFunc func(path string) string
+
+	// Files from the "file" loader that are smaller than this many bytes are
+	// inlined as data URLs instead of being copied to the output directory.
+	// Zero means no files are inlined.
+	AssetInlineLimit int
+
+	// When code splitting, chunks smaller than "MinChunkSize" bytes are merged
+	// into other chunks and chunks larger than "MaxChunkSize" bytes are split
+	// along module boundaries. Sizes are estimated from the input files. A merge
+	// may make entry points load up to "ChunkMergeBudget" bytes of code that
+	// they didn't load before. Zero means there is no limit for the sizes and
+	// that no extra code may be loaded for the budget.
+	MinChunkSize     int
+	MaxChunkSize     int
+	ChunkMergeBudget int
 
 	Plugins []Plugin
 
 	NeedsMetafile bool
 
+	// If not "IntegrityNone", each output file in the metafile includes a
+	// Subresource Integrity digest. With "IntegrityMap", outputs that load
+	// other chunks with "import()" also include the digests of those chunks.
+	Integrity    Integrity
+	IntegrityMap bool
+
+	// If true, a "manifest.json" file is written to the output directory that
+	// maps each entry point to its output file and the files it depends on
+	NeedsManifest bool
+
 	SourceMap             SourceMap
 	SourceRoot            string
 	ExcludeSourcesContent bool
@@ -380,7 +482,7 @@ func SubstituteTemplate(template []PathTemplate, placeholders PathPlaceholders)
 }
 
 func IsTreeShakingEnabled(mode Mode, outputFormat Format) bool {
-	return mode == ModeBundle || (mode == ModeConvertFormat && outputFormat == FormatIIFE)
+	return mode == ModeBundle || (mode == ModeConvertFormat && (outputFormat == FormatIIFE || outputFormat == FormatUMD))
 }
 
 func ShouldCallRuntimeRequire(mode Mode, outputFormat Format) bool {
@@ -849,6 +849,11 @@ type DotDefine struct {
 type ProcessedDefines struct {
 	IdentifierDefines map[string]DefineData
 	DotDefines        map[string][]DotDefine
+
+	// Builds that share a cache compare this to tell whether their defines are
+	// the same. It describes the options the defines were created from. It's
+	// empty if that's unknown, in which case only the same object is equal.
+	InputKey string
 }
 
 // This transformation is expensive, so we only want to do it once. Make sure
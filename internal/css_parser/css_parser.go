@@ -126,7 +126,7 @@ func (p *parser) expect(kind css_lexer.T) bool {
 		}
 	}
 	if t.Range.Loc.Start > p.prevError.Start {
-		p.log.AddRangeWarning(&p.tracker, t.Range, text)
+		p.log.AddID(logger.MsgID_CSS_SyntaxError, logger.Warning, &p.tracker, t.Range, text)
 		p.prevError = t.Range.Loc
 	}
 	return false
@@ -144,7 +144,7 @@ func (p *parser) unexpected() {
 		default:
 			text = fmt.Sprintf("Unexpected %q", p.raw())
 		}
-		p.log.AddRangeWarning(&p.tracker, t.Range, text)
+		p.log.AddID(logger.MsgID_CSS_SyntaxError, logger.Warning, &p.tracker, t.Range, text)
 		p.prevError = t.Range.Loc
 	}
 }
@@ -179,7 +179,7 @@ loop:
 				switch rule.(type) {
 				case *css_ast.RAtCharset:
 					if !didWarnAboutCharset && len(rules) > 0 {
-						p.log.AddRangeWarningWithNotes(&p.tracker, first, "\"@charset\" must be the first rule in the file",
+						p.log.AddIDWithNotes(logger.MsgID_CSS_InvalidAtCharset, logger.Warning, &p.tracker, first, "\"@charset\" must be the first rule in the file",
 							[]logger.MsgData{logger.RangeData(&p.tracker, logger.Range{Loc: locs[len(locs)-1]},
 								"This rule cannot come before a \"@charset\" rule")})
 						didWarnAboutCharset = true
@@ -192,7 +192,7 @@ loop:
 							switch before.(type) {
 							case *css_ast.RAtCharset, *css_ast.RAtImport:
 							default:
-								p.log.AddRangeWarningWithNotes(&p.tracker, first, "All \"@import\" rules must come first",
+								p.log.AddIDWithNotes(logger.MsgID_CSS_InvalidAtImport, logger.Warning, &p.tracker, first, "All \"@import\" rules must come first",
 									[]logger.MsgData{logger.RangeData(&p.tracker, logger.Range{Loc: locs[i]},
 										"This rule cannot come before an \"@import\" rule")})
 								didWarnAboutImport = true
@@ -424,7 +424,7 @@ func (p *parser) parseAtRule(context atRuleContext) css_ast.R {
 		if p.peek(css_lexer.TString) {
 			encoding := p.decoded()
 			if !strings.EqualFold(encoding, "UTF-8") {
-				p.log.AddRangeWarning(&p.tracker, p.current().Range,
+				p.log.AddID(logger.MsgID_CSS_UnsupportedAtCharset, logger.Warning, &p.tracker, p.current().Range,
 					fmt.Sprintf("\"UTF-8\" will be used instead of unsupported charset %q", encoding))
 			}
 			p.advance()
@@ -581,7 +581,7 @@ func (p *parser) parseAtRule(context atRuleContext) css_ast.R {
 			//
 			// Instead of implementing all of that for an extremely obscure feature,
 			// CSS namespaces are just explicitly not supported.
-			p.log.AddRangeWarning(&p.tracker, atRange, "\"@namespace\" rules are not supported")
+			p.log.AddID(logger.MsgID_CSS_UnsupportedAtNamespace, logger.Warning, &p.tracker, atRange, "\"@namespace\" rules are not supported")
 		}
 	}
 
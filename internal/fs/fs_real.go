@@ -108,12 +108,40 @@ func RealFS(options RealFSOptions) (FS, error) {
 		watchData = make(map[string]privateWatchData)
 	}
 
-	return &realFS{
+	var fs FS = &realFS{
 		entries:           make(map[string]entriesOrErr),
 		fp:                fp,
 		watchData:         watchData,
 		doNotCacheEntries: options.DoNotCache,
-	}, nil
+	}
+
+	// Add support for reading files inside Yarn PnP zip archives. This is only
+	// done when there is a Yarn PnP manifest somewhere above the working
+	// directory so that other projects don't pay for the extra path checks.
+	if hasYarnPnPManifest(fp) {
+		fs = &zipFS{
+			inner:    fs,
+			zipFiles: make(map[string]*zipFile),
+		}
+	}
+
+	return fs, nil
+}
+
+func hasYarnPnPManifest(fp goFilepath) bool {
+	dir := fp.cwd
+	for {
+		for _, base := range []string{".pnp.data.json", ".pnp.cjs", ".pnp.js"} {
+			if info, err := os.Stat(fp.join([]string{dir, base})); err == nil && !info.IsDir() {
+				return true
+			}
+		}
+		parent := fp.dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
 }
 
 func (fs *realFS) ReadDirectory(dir string) (entries DirEntries, canonicalError error, originalError error) {
@@ -0,0 +1,319 @@
+package fs
+
+// The Yarn package manager (https://yarnpkg.com/) has a custom installation
+// strategy called "Plug'n'Play" where it doesn't create a "node_modules"
+// folder. Instead it stores packages as zip files in ".yarn/cache" and adds
+// a manifest that maps import paths to locations inside those zip files. This
+// file system implementation makes it possible to read files from inside
+// these zip files as if they were regular directories. It also handles the
+// "__virtual__" paths that Yarn uses for packages with peer dependencies.
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+type zipFS struct {
+	inner FS
+
+	zipFilesMutex sync.Mutex
+	zipFiles      map[string]*zipFile
+}
+
+type zipFile struct {
+	// This is used to wait for the archive to be read by another goroutine
+	wait sync.WaitGroup
+
+	// These are nil if reading the archive failed
+	dirs  map[string]*compressedDir
+	files map[string]*zip.File
+	err   error
+}
+
+type compressedDir struct {
+	entries map[string]EntryKind
+
+	// Directory entries are created lazily
+	mutex      sync.Mutex
+	dirEntries DirEntries
+}
+
+// Paths containing a ".zip" path segment are read from inside the zip archive
+// if they don't exist as-is in the inner file system
+func (fs *zipFS) checkForZip(path string) (archive *zipFile, zipPath string, pathTail string) {
+	// Find a path segment that ends in ".zip"
+	for i := 0; ; {
+		dotZip := strings.Index(path[i:], ".zip")
+		if dotZip == -1 {
+			return nil, "", ""
+		}
+		end := i + dotZip + len(".zip")
+		if end == len(path) {
+			zipPath = path
+			break
+		}
+		if c := path[end]; c == '/' || c == '\\' {
+			zipPath = path[:end]
+			pathTail = strings.ReplaceAll(path[end+1:], "\\", "/")
+			break
+		}
+		i = end
+	}
+
+	// If there is one, then check whether it's a zip file in the file system
+	fs.zipFilesMutex.Lock()
+	archive = fs.zipFiles[zipPath]
+	if archive != nil {
+		fs.zipFilesMutex.Unlock()
+		archive.wait.Wait()
+	} else {
+		archive = &zipFile{}
+		archive.wait.Add(1)
+		fs.zipFiles[zipPath] = archive
+		fs.zipFilesMutex.Unlock()
+		defer archive.wait.Done()
+		archive.read(fs.inner, zipPath)
+	}
+
+	if archive.err != nil {
+		return nil, "", ""
+	}
+	return archive, zipPath, strings.TrimSuffix(pathTail, "/")
+}
+
+func (archive *zipFile) read(inner FS, zipPath string) {
+	// Go through the inner file system so that the archive is included in the
+	// watch data and is affected by the file system cache
+	contents, err, _ := inner.ReadFile(zipPath)
+	if err != nil {
+		archive.err = err
+		return
+	}
+	reader, err := zip.NewReader(strings.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		archive.err = err
+		return
+	}
+
+	dirs := make(map[string]*compressedDir)
+	files := make(map[string]*zip.File)
+
+	// Make sure the root directory exists even if the archive is empty
+	dirs[""] = &compressedDir{entries: make(map[string]EntryKind)}
+
+	for _, file := range reader.File {
+		path := strings.TrimPrefix(file.Name, "/")
+		kind := FileEntry
+		if strings.HasSuffix(path, "/") {
+			path = path[:len(path)-1]
+			kind = DirEntry
+		}
+		if path == "" {
+			continue
+		}
+		if kind == FileEntry {
+			files[path] = file
+		}
+
+		// Add an entry for this path and every implicit parent directory
+		for {
+			slash := strings.LastIndexByte(path, '/')
+			dirPath := ""
+			if slash != -1 {
+				dirPath = path[:slash]
+			}
+			dir := dirs[dirPath]
+			if dir == nil {
+				dir = &compressedDir{entries: make(map[string]EntryKind)}
+				dirs[dirPath] = dir
+			}
+			dir.entries[path[slash+1:]] = kind
+			if dirPath == "" {
+				break
+			}
+			path = dirPath
+			kind = DirEntry
+		}
+	}
+
+	archive.dirs = dirs
+	archive.files = files
+}
+
+func (fs *zipFS) ReadDirectory(path string) (entries DirEntries, canonicalError error, originalError error) {
+	path = mangleYarnPnPVirtualPath(fs.inner, path)
+
+	entries, canonicalError, originalError = fs.inner.ReadDirectory(path)
+
+	// Only continue if reading this path as a directory caused an error that's
+	// consistent with trying to read a zip file as a directory
+	if canonicalError != syscall.ENOENT && canonicalError != syscall.ENOTDIR {
+		return
+	}
+
+	// The "__virtual__" directory and the directories directly inside of it
+	// don't exist on the file system. Pretend they are empty directories so
+	// that the paths below them can be accessed.
+	if canonicalError == syscall.ENOENT {
+		if base := fs.inner.Base(path); base == "__virtual__" || fs.inner.Base(fs.inner.Dir(path)) == "__virtual__" {
+			return MakeEmptyDirEntries(path), nil, nil
+		}
+	}
+
+	// If the directory doesn't exist, try reading from an enclosing zip archive
+	archive, _, pathTail := fs.checkForZip(path)
+	if archive == nil {
+		return
+	}
+	dir := archive.dirs[pathTail]
+	if dir == nil {
+		return
+	}
+
+	// Lazily compute the directory entries since most aren't needed
+	dir.mutex.Lock()
+	defer dir.mutex.Unlock()
+	if dir.dirEntries.data == nil {
+		data := make(map[string]*Entry, len(dir.entries))
+		for name, kind := range dir.entries {
+			data[strings.ToLower(name)] = &Entry{
+				dir:  path,
+				base: name,
+				kind: kind,
+			}
+		}
+		dir.dirEntries = DirEntries{dir: path, data: data}
+	}
+	return dir.dirEntries, nil, nil
+}
+
+func (fs *zipFS) ReadFile(path string) (contents string, canonicalError error, originalError error) {
+	path = mangleYarnPnPVirtualPath(fs.inner, path)
+
+	contents, canonicalError, originalError = fs.inner.ReadFile(path)
+	if canonicalError != syscall.ENOENT && canonicalError != syscall.ENOTDIR {
+		return
+	}
+
+	// If the file doesn't exist, try reading from an enclosing zip archive
+	archive, _, pathTail := fs.checkForZip(path)
+	if archive == nil {
+		return
+	}
+	file := archive.files[pathTail]
+	if file == nil {
+		return
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return "", err, err
+	}
+	defer reader.Close()
+	bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err, err
+	}
+	return string(bytes), nil, nil
+}
+
+func (fs *zipFS) ModKey(path string) (ModKey, error) {
+	path = mangleYarnPnPVirtualPath(fs.inner, path)
+
+	// Files inside a zip archive are only modified when the archive is
+	// modified, so use the key for the archive itself
+	if archive, zipPath, pathTail := fs.checkForZip(path); archive != nil {
+		if _, ok := archive.files[pathTail]; ok {
+			return fs.inner.ModKey(zipPath)
+		}
+	}
+	return fs.inner.ModKey(path)
+}
+
+func (fs *zipFS) IsAbs(path string) bool {
+	return fs.inner.IsAbs(path)
+}
+
+func (fs *zipFS) Abs(path string) (string, bool) {
+	return fs.inner.Abs(path)
+}
+
+func (fs *zipFS) Dir(path string) string {
+	return fs.inner.Dir(path)
+}
+
+func (fs *zipFS) Base(path string) string {
+	return fs.inner.Base(path)
+}
+
+func (fs *zipFS) Ext(path string) string {
+	return fs.inner.Ext(path)
+}
+
+func (fs *zipFS) Join(parts ...string) string {
+	return fs.inner.Join(parts...)
+}
+
+func (fs *zipFS) Cwd() string {
+	return fs.inner.Cwd()
+}
+
+func (fs *zipFS) Rel(base string, target string) (string, bool) {
+	return fs.inner.Rel(base, target)
+}
+
+func (fs *zipFS) kind(dir string, base string) (symlink string, kind EntryKind) {
+	return fs.inner.kind(mangleYarnPnPVirtualPath(fs.inner, dir), base)
+}
+
+func (fs *zipFS) WatchData() WatchData {
+	return fs.inner.WatchData()
+}
+
+// Yarn uses paths of the form "/a/__virtual__/b/N/c" to give packages with
+// peer dependencies a unique identity for each set of peers. These paths
+// don't exist on the file system. They refer to the path "/a/c" after going
+// up "N" more directories, so "/a/__virtual__/b/1/c" is actually "/c". See
+// https://yarnpkg.com/advanced/pnp-spec/#virtual-folders for details.
+func mangleYarnPnPVirtualPath(fs FS, path string) string {
+	for _, sep := range []string{"/", "\\"} {
+		marker := sep + "__virtual__" + sep
+		i := strings.LastIndex(path, marker)
+		if i == -1 {
+			continue
+		}
+
+		// Skip over the hash segment and parse the depth segment
+		rest := path[i+len(marker):]
+		slash := strings.Index(rest, sep)
+		if slash == -1 {
+			continue
+		}
+		rest = rest[slash+1:]
+		depthText := rest
+		tail := ""
+		if slash := strings.Index(rest, sep); slash != -1 {
+			depthText = rest[:slash]
+			tail = rest[slash+1:]
+		}
+		depth, err := strconv.Atoi(depthText)
+		if err != nil || depth < 0 {
+			continue
+		}
+
+		// Go up "depth" directories from the parent of "__virtual__"
+		dir := path[:i]
+		for ; depth > 0; depth-- {
+			dir = fs.Dir(dir)
+		}
+		if tail == "" {
+			return dir
+		}
+		return fs.Join(dir, tail)
+	}
+	return path
+}
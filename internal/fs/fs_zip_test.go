@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestZipFS(t *testing.T) {
+	buffer := bytes.Buffer{}
+	writer := zip.NewWriter(&buffer)
+	for name, contents := range map[string]string{
+		"node_modules/pkg/package.json": `{"main": "lib/index.js"}`,
+		"node_modules/pkg/lib/index.js": "// index.js",
+	} {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte(contents))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := &zipFS{
+		inner: MockFS(map[string]string{
+			"/project/.yarn/cache/pkg.zip": buffer.String(),
+			"/project/src/entry.js":        "// entry.js",
+		}),
+		zipFiles: make(map[string]*zipFile),
+	}
+
+	// Test a file outside of a zip archive
+	entry, err, _ := fs.ReadFile("/project/src/entry.js")
+	if err != nil || entry != "// entry.js" {
+		t.Fatalf("Incorrect contents for /project/src/entry.js: %q", entry)
+	}
+
+	// Test a file inside a zip archive
+	index, err, _ := fs.ReadFile("/project/.yarn/cache/pkg.zip/node_modules/pkg/lib/index.js")
+	if err != nil || index != "// index.js" {
+		t.Fatalf("Incorrect contents for index.js: %q", index)
+	}
+
+	// Test a missing file inside a zip archive
+	if _, err, _ := fs.ReadFile("/project/.yarn/cache/pkg.zip/node_modules/pkg/missing.js"); err == nil {
+		t.Fatal("Unexpectedly found missing.js")
+	}
+
+	// Test a directory inside a zip archive, including an implicit directory
+	dir, err, _ := fs.ReadDirectory("/project/.yarn/cache/pkg.zip/node_modules/pkg")
+	if err != nil {
+		t.Fatal("Expected to find the package directory")
+	}
+	if entry, _ := dir.Get("lib"); entry == nil || entry.Kind(fs) != DirEntry {
+		t.Fatal("Expected \"lib\" to be a directory")
+	}
+	if entry, _ := dir.Get("package.json"); entry == nil || entry.Kind(fs) != FileEntry {
+		t.Fatal("Expected \"package.json\" to be a file")
+	}
+
+	// Test a Yarn PnP virtual path
+	virtual, err, _ := fs.ReadFile("/project/.yarn/__virtual__/pkg-virtual-123/0/cache/pkg.zip/node_modules/pkg/lib/index.js")
+	if err != nil || virtual != "// index.js" {
+		t.Fatalf("Incorrect contents for the virtual path: %q", virtual)
+	}
+	if _, err, _ := fs.ReadDirectory("/project/.yarn/__virtual__/pkg-virtual-123"); err != nil {
+		t.Fatal("Expected the virtual directory to exist")
+	}
+}
+
+func TestMangleYarnPnPVirtualPath(t *testing.T) {
+	fs := MockFS(map[string]string{})
+
+	expect := func(input string, expected string) {
+		t.Helper()
+		if output := mangleYarnPnPVirtualPath(fs, input); output != expected {
+			t.Fatalf("Expected %q to map to %q but got %q", input, expected, output)
+		}
+	}
+
+	expect("/a/b/c", "/a/b/c")
+	expect("/a/__virtual__/b/0/c", "/a/c")
+	expect("/a/__virtual__/b/1/c/d", "/c/d")
+	expect("/a/b/__virtual__/c/2/d", "/d")
+	expect("/a/__virtual__/b/0", "/a")
+	expect("/a/__virtual__/b/x/c", "/a/__virtual__/b/x/c")
+	expect("/a/__virtual__/b", "/a/__virtual__/b")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/evanw/esbuild/internal/ast"
 	"github.com/evanw/esbuild/internal/config"
 	"github.com/evanw/esbuild/internal/css_ast"
+	"github.com/evanw/esbuild/internal/html_ast"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/logger"
 	"github.com/evanw/esbuild/internal/resolver"
@@ -28,6 +29,10 @@ type InputFile struct {
 	AdditionalFiles        []OutputFile
 	UniqueKeyForFileLoader string
 
+	// This is true if this file would have used the "file" loader but was
+	// smaller than "AssetInlineLimit", so it was inlined as a data URL instead
+	IsInlinedAsset bool
+
 	SideEffects SideEffects
 	Loader      config.Loader
 }
@@ -41,6 +46,15 @@ type OutputFile struct {
 	// fully assembled later.
 	JSONMetadataChunk string
 
+	// If "NeedsManifest" is present, this will be filled out for the output
+	// files of entry points. It's the JSON key and value for this entry point
+	// in the manifest, which will be fully assembled later.
+	JSONManifestChunk string
+
+	// If "IntegrityMap" is present, these are the absolute paths of the chunks
+	// that this output file loads using "import()" expressions
+	DynamicImportAbsPaths []string
+
 	IsExecutable bool
 }
 
@@ -111,3 +125,11 @@ type CSSRepr struct {
 func (repr *CSSRepr) ImportRecords() *[]ast.ImportRecord {
 	return &repr.AST.ImportRecords
 }
+
+type HTMLRepr struct {
+	AST html_ast.AST
+}
+
+func (repr *HTMLRepr) ImportRecords() *[]ast.ImportRecord {
+	return &repr.AST.ImportRecords
+}
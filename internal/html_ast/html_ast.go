@@ -0,0 +1,43 @@
+package html_ast
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// HTML files are not transformed. The only thing the bundler needs to know
+// about an HTML file is where the URLs of the files it references are so
+// that they can be replaced with the paths of the generated output files.
+// Everything else is copied through to the output verbatim.
+
+type AST struct {
+	ImportRecords []ast.ImportRecord
+
+	// These are in the order they appear in the file
+	URLs []URL
+}
+
+type URLKind uint8
+
+const (
+	// A "<script type="module" src="...">" tag. The script becomes an entry point.
+	URLScript URLKind = iota
+
+	// A "<link rel="stylesheet" href="...">" tag. The stylesheet becomes an entry point.
+	URLStylesheet
+
+	// Any other reference to a file such as "<img src="...">". These files are
+	// loaded with the loader for their extension like CSS "url()" tokens are.
+	URLAsset
+)
+
+type URL struct {
+	// The range of the attribute value, not including any quotes
+	Range logger.Range
+
+	// The location of the "<" character that starts the tag containing this URL
+	TagLoc logger.Loc
+
+	ImportRecordIndex uint32
+	Kind              URLKind
+}
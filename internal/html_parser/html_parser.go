@@ -0,0 +1,327 @@
+package html_parser
+
+import (
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/html_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This is not a full HTML parser. It only scans the tags in the file for the
+// attributes that reference other files. The contents of "<script>", "<style>",
+// "<textarea>", and "<title>" elements (and a few others) are text, so they
+// are skipped over to avoid mistaking something like a "<" operator for the
+// start of a tag.
+
+type parser struct {
+	log           logger.Log
+	source        logger.Source
+	tracker       logger.LineColumnTracker
+	index         int
+	importRecords []ast.ImportRecord
+	urls          []html_ast.URL
+}
+
+type attribute struct {
+	name       string // This is always lowercase
+	value      string // This has had character references decoded
+	valueRange logger.Range
+}
+
+func Parse(log logger.Log, source logger.Source) html_ast.AST {
+	p := parser{
+		log:     log,
+		source:  source,
+		tracker: logger.MakeLineColumnTracker(&source),
+	}
+	p.parse()
+
+	// Attributes are handled in a fixed order instead of the order they appear
+	// in, so the URLs in a tag may be out of order
+	sort.SliceStable(p.urls, func(i int, j int) bool {
+		return p.urls[i].Range.Loc.Start < p.urls[j].Range.Loc.Start
+	})
+	return html_ast.AST{
+		ImportRecords: p.importRecords,
+		URLs:          p.urls,
+	}
+}
+
+func (p *parser) parse() {
+	contents := p.source.Contents
+
+	for {
+		lessThan := strings.IndexByte(contents[p.index:], '<')
+		if lessThan == -1 {
+			return
+		}
+		start := p.index + lessThan
+		rest := contents[start:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end == -1 {
+				p.log.AddID(logger.MsgID_HTML_SyntaxError, logger.Warning, &p.tracker, logger.Range{Loc: logger.Loc{Start: int32(start)}, Len: 4},
+					"Expected \"-->\" to terminate comment")
+				return
+			}
+			p.index = start + 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"), strings.HasPrefix(rest, "</"):
+			// Skip over doctypes, processing instructions, and end tags
+			end := strings.IndexByte(rest, '>')
+			if end == -1 {
+				return
+			}
+			p.index = start + end + 1
+
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			p.index = start + 1
+			p.parseTag(start)
+
+		default:
+			// A "<" that doesn't start a tag is just text
+			p.index = start + 1
+		}
+	}
+}
+
+func (p *parser) parseTag(tagStart int) {
+	contents := p.source.Contents
+
+	// Parse the tag name
+	nameStart := p.index
+	for p.index < len(contents) && !isSpace(contents[p.index]) && contents[p.index] != '/' && contents[p.index] != '>' {
+		p.index++
+	}
+	tagName := strings.ToLower(contents[nameStart:p.index])
+
+	// Parse the attributes
+	var attributes []attribute
+	for {
+		for p.index < len(contents) && (isSpace(contents[p.index]) || contents[p.index] == '/') {
+			p.index++
+		}
+		if p.index >= len(contents) {
+			// Ignore tags that aren't terminated
+			return
+		}
+		if contents[p.index] == '>' {
+			p.index++
+			break
+		}
+
+		// Parse the attribute name. Note that the first character is allowed to
+		// be "=" since that's how browsers handle it.
+		attrStart := p.index
+		p.index++
+		for p.index < len(contents) && !isSpace(contents[p.index]) && contents[p.index] != '/' &&
+			contents[p.index] != '>' && contents[p.index] != '=' {
+			p.index++
+		}
+		attr := attribute{name: strings.ToLower(contents[attrStart:p.index])}
+
+		// Parse the optional attribute value
+		afterName := p.index
+		for afterName < len(contents) && isSpace(contents[afterName]) {
+			afterName++
+		}
+		if afterName < len(contents) && contents[afterName] == '=' {
+			p.index = afterName + 1
+			for p.index < len(contents) && isSpace(contents[p.index]) {
+				p.index++
+			}
+			if p.index < len(contents) && (contents[p.index] == '"' || contents[p.index] == '\'') {
+				end := strings.IndexByte(contents[p.index+1:], contents[p.index])
+				if end == -1 {
+					return
+				}
+				attr.valueRange = logger.Range{Loc: logger.Loc{Start: int32(p.index + 1)}, Len: int32(end)}
+				p.index += end + 2
+			} else {
+				valueStart := p.index
+				for p.index < len(contents) && !isSpace(contents[p.index]) && contents[p.index] != '>' {
+					p.index++
+				}
+				attr.valueRange = logger.Range{Loc: logger.Loc{Start: int32(valueStart)}, Len: int32(p.index - valueStart)}
+			}
+			attr.value = html.UnescapeString(p.source.TextForRange(attr.valueRange))
+		}
+		attributes = append(attributes, attr)
+	}
+
+	p.addURLsForTag(tagName, attributes, logger.Loc{Start: int32(tagStart)})
+
+	// Skip over the contents of elements that can't contain tags. This includes
+	// raw text elements such as "<script>" as well as RCDATA elements such as
+	// "<textarea>", which can contain character references but not tags.
+	switch tagName {
+	case "script", "style", "textarea", "title", "xmp", "iframe", "noembed", "noframes":
+		for {
+			end := strings.Index(contents[p.index:], "</")
+			if end == -1 {
+				p.index = len(contents)
+				return
+			}
+			p.index += end + 2
+			if rest := contents[p.index:]; len(rest) >= len(tagName) && strings.EqualFold(rest[:len(tagName)], tagName) &&
+				(len(rest) == len(tagName) || isSpace(rest[len(tagName)]) || rest[len(tagName)] == '/' || rest[len(tagName)] == '>') {
+				p.index -= 2
+				return
+			}
+		}
+	}
+}
+
+func (p *parser) addURLsForTag(tagName string, attributes []attribute, tagLoc logger.Loc) {
+	switch tagName {
+	case "script":
+		// Only module scripts are bundled. Classic scripts can depend on being
+		// evaluated in the global scope, so they are left alone.
+		if typeAttr, ok := findAttribute(attributes, "type"); ok && strings.EqualFold(strings.TrimSpace(typeAttr.value), "module") {
+			if src, ok := findAttribute(attributes, "src"); ok {
+				p.addURL(src, html_ast.URLScript, tagLoc)
+			}
+		}
+
+	case "link":
+		if rel, ok := findAttribute(attributes, "rel"); ok {
+			if href, ok := findAttribute(attributes, "href"); ok {
+				for _, value := range strings.Fields(strings.ToLower(rel.value)) {
+					switch value {
+					case "stylesheet":
+						p.addURL(href, html_ast.URLStylesheet, tagLoc)
+						return
+
+					case "icon", "apple-touch-icon", "manifest":
+						p.addURL(href, html_ast.URLAsset, tagLoc)
+						return
+					}
+				}
+			}
+		}
+
+	case "img", "source", "audio", "video", "track":
+		if src, ok := findAttribute(attributes, "src"); ok {
+			p.addURL(src, html_ast.URLAsset, tagLoc)
+		}
+		if tagName == "video" {
+			if poster, ok := findAttribute(attributes, "poster"); ok {
+				p.addURL(poster, html_ast.URLAsset, tagLoc)
+			}
+		}
+		if tagName == "img" || tagName == "source" {
+			if srcset, ok := findAttribute(attributes, "srcset"); ok {
+				p.addSrcsetURLs(srcset, tagLoc)
+			}
+		}
+	}
+}
+
+// A "srcset" attribute is a comma-separated list of image candidates, each of
+// which is a URL followed by optional descriptors such as "2x" or "100w". Each
+// URL gets its own range so that it can be replaced separately.
+func (p *parser) addSrcsetURLs(attr attribute, tagLoc logger.Loc) {
+	text := p.source.TextForRange(attr.valueRange)
+	i := 0
+	for i < len(text) {
+		for i < len(text) && (isSpace(text[i]) || text[i] == ',') {
+			i++
+		}
+		urlStart := i
+		for i < len(text) && !isSpace(text[i]) {
+			i++
+		}
+
+		// Commas at the end of a URL end the candidate, so it has no descriptors.
+		// Commas inside the URL are part of it (e.g. in "data:" URLs).
+		urlEnd := i
+		for urlEnd > urlStart && text[urlEnd-1] == ',' {
+			urlEnd--
+		}
+		if urlEnd == i {
+			for i < len(text) && text[i] != ',' {
+				i++
+			}
+		}
+
+		if urlEnd > urlStart {
+			p.addURL(attribute{
+				name:       attr.name,
+				value:      html.UnescapeString(text[urlStart:urlEnd]),
+				valueRange: logger.Range{Loc: logger.Loc{Start: attr.valueRange.Loc.Start + int32(urlStart)}, Len: int32(urlEnd - urlStart)},
+			}, html_ast.URLAsset, tagLoc)
+		}
+	}
+}
+
+func (p *parser) addURL(attr attribute, kind html_ast.URLKind, tagLoc logger.Loc) {
+	path := strings.TrimSpace(attr.value)
+
+	// Leave URLs alone that don't refer to a file next to this one. Absolute
+	// paths are left alone too since what they refer to depends on where the
+	// HTML file is served from.
+	if path == "" || path[0] == '#' || path[0] == '/' || hasURLScheme(path) {
+		return
+	}
+
+	// URLs in HTML are always relative, but paths without a "./" prefix are
+	// package paths to the resolver
+	if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		path = "./" + path
+	}
+
+	importKind := ast.ImportEntryPoint
+	if kind == html_ast.URLAsset {
+		importKind = ast.ImportURL
+	}
+
+	p.urls = append(p.urls, html_ast.URL{
+		Range:             attr.valueRange,
+		TagLoc:            tagLoc,
+		ImportRecordIndex: uint32(len(p.importRecords)),
+		Kind:              kind,
+	})
+	p.importRecords = append(p.importRecords, ast.ImportRecord{
+		Range: attr.valueRange,
+		Path:  logger.Path{Text: path},
+		Kind:  importKind,
+	})
+}
+
+func findAttribute(attributes []attribute, name string) (attribute, bool) {
+	for _, attr := range attributes {
+		if attr.name == name {
+			return attr, true
+		}
+	}
+	return attribute{}, false
+}
+
+func hasURLScheme(path string) bool {
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case ':':
+			return i > 0
+		case '/', '?', '#':
+			return false
+		default:
+			if !isASCIILetter(c) && (i == 0 || (c < '0' || c > '9') && c != '+' && c != '-' && c != '.') {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
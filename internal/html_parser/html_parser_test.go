@@ -0,0 +1,131 @@
+package html_parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evanw/esbuild/internal/html_ast"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectURLs(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		source := test.SourceForTest(contents)
+		tree := Parse(log, source)
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqual(t, text, "")
+		for _, url := range tree.URLs {
+			var kind string
+			switch url.Kind {
+			case html_ast.URLScript:
+				kind = "script"
+			case html_ast.URLStylesheet:
+				kind = "stylesheet"
+			case html_ast.URLAsset:
+				kind = "asset"
+			}
+			record := tree.ImportRecords[url.ImportRecordIndex]
+			text += fmt.Sprintf("%s %s %q %d\n", kind, record.Path.Text, source.TextForRange(url.Range), url.TagLoc.Start)
+		}
+		test.AssertEqual(t, text, expected)
+	})
+}
+
+func expectParseError(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+		Parse(log, test.SourceForTest(contents))
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqual(t, text, expected)
+	})
+}
+
+func TestScripts(t *testing.T) {
+	expectURLs(t, `<script type="module" src="app.js"></script>`, "script ./app.js \"app.js\" 0\n")
+	expectURLs(t, `<script type=module src=./app.js></script>`, "script ./app.js \"./app.js\" 0\n")
+	expectURLs(t, `<SCRIPT TYPE='MODULE' SRC='../app.js'></SCRIPT>`, "script ../app.js \"../app.js\" 0\n")
+	expectURLs(t, `<p><script src="app.js" type = "module" async></script>`, "script ./app.js \"app.js\" 3\n")
+
+	// Classic scripts are left alone
+	expectURLs(t, `<script src="app.js"></script>`, "")
+	expectURLs(t, `<script type="text/javascript" src="app.js"></script>`, "")
+
+	// Inline scripts are left alone
+	expectURLs(t, `<script type="module">import "./app.js"</script>`, "")
+}
+
+func TestStylesheets(t *testing.T) {
+	expectURLs(t, `<link rel="stylesheet" href="app.css">`, "stylesheet ./app.css \"app.css\" 0\n")
+	expectURLs(t, `<link href="app.css" rel="preload stylesheet"/>`, "stylesheet ./app.css \"app.css\" 0\n")
+	expectURLs(t, `<link rel="alternate" href="app.css">`, "")
+	expectURLs(t, `<link rel="stylesheet">`, "")
+}
+
+func TestAssets(t *testing.T) {
+	expectURLs(t, `<img src="logo.png">`, "asset ./logo.png \"logo.png\" 0\n")
+	expectURLs(t, `<link rel="icon" href="favicon.ico">`, "asset ./favicon.ico \"favicon.ico\" 0\n")
+	expectURLs(t, `<link rel="apple-touch-icon" href="icon.png">`, "asset ./icon.png \"icon.png\" 0\n")
+	expectURLs(t, `<link rel="manifest" href="app.webmanifest">`, "asset ./app.webmanifest \"app.webmanifest\" 0\n")
+	expectURLs(t, `<video src="a.mp4" poster="a.jpg"></video>`,
+		"asset ./a.mp4 \"a.mp4\" 0\nasset ./a.jpg \"a.jpg\" 0\n")
+	expectURLs(t, `<audio><source src="a.ogg"></audio>`, "asset ./a.ogg \"a.ogg\" 7\n")
+	expectURLs(t, `<img src="a&amp;b.png">`, "asset ./a&b.png \"a&amp;b.png\" 0\n")
+
+	// URLs are in the order they appear in the file
+	expectURLs(t, `<video poster="a.jpg" src="a.mp4"></video>`,
+		"asset ./a.jpg \"a.jpg\" 0\nasset ./a.mp4 \"a.mp4\" 0\n")
+}
+
+func TestSrcset(t *testing.T) {
+	expectURLs(t, `<img srcset="a.png">`, "asset ./a.png \"a.png\" 0\n")
+	expectURLs(t, `<img srcset="a.png 1x, b.png 2x">`, "asset ./a.png \"a.png\" 0\nasset ./b.png \"b.png\" 0\n")
+	expectURLs(t, `<img srcset=" a.png  100w ,b.png 200w, ">`, "asset ./a.png \"a.png\" 0\nasset ./b.png \"b.png\" 0\n")
+	expectURLs(t, `<img srcset="a.png 1x,b.png 2x">`, "asset ./a.png \"a.png\" 0\nasset ./b.png \"b.png\" 0\n")
+	expectURLs(t, `<img srcset="a.png,, b.png">`, "asset ./a.png \"a.png\" 0\nasset ./b.png \"b.png\" 0\n")
+	expectURLs(t, `<img srcset="a&amp;b.png 2x">`, "asset ./a&b.png \"a&amp;b.png\" 0\n")
+	expectURLs(t, `<img srcset="c.png 2x" src="a.png">`, "asset ./c.png \"c.png\" 0\nasset ./a.png \"a.png\" 0\n")
+	expectURLs(t, `<picture><source srcset="a.webp" type="image/webp"></picture>`, "asset ./a.webp \"a.webp\" 9\n")
+	expectURLs(t, `<img srcset="data:image/png;base64,AAAA 1x, /b.png 2x, c.png 3x">`, "asset ./c.png \"c.png\" 0\n")
+
+	// Only images use "srcset"
+	expectURLs(t, `<video srcset="a.png"></video>`, "")
+}
+
+func TestNonLocalURLs(t *testing.T) {
+	expectURLs(t, `<img src="">`, "")
+	expectURLs(t, `<img src="#top">`, "")
+	expectURLs(t, `<img src="/logo.png">`, "")
+	expectURLs(t, `<img src="//example.com/logo.png">`, "")
+	expectURLs(t, `<img src="https://example.com/logo.png">`, "")
+	expectURLs(t, `<img src="data:image/png;base64,AAAA">`, "")
+	expectURLs(t, `<img src="a:b/logo.png">`, "")
+	expectURLs(t, `<img src="a/b:c.png">`, "asset ./a/b:c.png \"a/b:c.png\" 0\n")
+}
+
+func TestRawTextAndComments(t *testing.T) {
+	expectURLs(t, `<!-- <img src="a.png"> --><img src="b.png">`, "asset ./b.png \"b.png\" 26\n")
+	expectURLs(t, `<script>if (a <img) x = "<img src='a.png'>"</script><img src="b.png">`, "asset ./b.png \"b.png\" 52\n")
+	expectURLs(t, `<style>a::after { content: "<img src='a.png'>" }</STYLE><img src="b.png">`, "asset ./b.png \"b.png\" 56\n")
+	expectURLs(t, `<!DOCTYPE html><img src="a.png">`, "asset ./a.png \"a.png\" 15\n")
+	expectURLs(t, `a < b <img src="a.png">`, "asset ./a.png \"a.png\" 6\n")
+	expectURLs(t, `<textarea><link rel="stylesheet" href="a.css"></textarea><img src="b.png">`, "asset ./b.png \"b.png\" 57\n")
+	expectURLs(t, `<title>a <img src="a.png"></TITLE ><img src="b.png">`, "asset ./b.png \"b.png\" 35\n")
+	expectURLs(t, `<textarea></textareas><img src="a.png"></textarea><img src="b.png">`, "asset ./b.png \"b.png\" 50\n")
+	expectURLs(t, `<textarea><img src="a.png">`, "")
+
+	expectParseError(t, `<!-- <img src="a.png">`, "<stdin>: warning: Expected \"-->\" to terminate comment\n")
+}
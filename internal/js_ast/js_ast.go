@@ -265,6 +265,10 @@ const (
 	PropertyGet
 	PropertySet
 	PropertySpread
+
+	// This is a class field with the "accessor" keyword, which declares a
+	// getter/setter pair backed by hidden storage: "class { accessor x = 1 }"
+	PropertyAutoAccessor
 )
 
 type Property struct {
@@ -1471,6 +1475,11 @@ type Symbol struct {
 	// them as strings instead.
 	MustStartWithCapitalLetterForJSX bool
 
+	// This is true if this symbol is ever the target of an assignment. The
+	// linker won't replace references to a symbol with its value unless it's
+	// known to never change.
+	CouldPotentiallyBeMutated bool
+
 	// We automatically generate import items for property accesses off of
 	// namespace imports. This lets us remove the expensive namespace imports
 	// while bundling in many cases, replacing them with a cheap import item
@@ -1725,6 +1734,12 @@ type AST struct {
 	UsesModuleRef  bool
 	ExportsKind    ExportsKind
 
+	// This is true if this file was originally CommonJS but the parser was able
+	// to convert it to ESM because it only has simple top-level assignments to
+	// "exports". Imports of missing exports from these files are not an error
+	// because they would have just been undefined in CommonJS.
+	IsHoistedCommonJS bool
+
 	// This is a list of ES6 features. They are ranges instead of booleans so
 	// that they can be used in log messages. Check to see if "Len > 0".
 	ImportKeyword        logger.Range // Does not include TypeScript-specific syntax or "import()"
@@ -1907,6 +1922,11 @@ type Part struct {
 	// An estimate of the number of uses of all symbols used within this part.
 	SymbolUses map[Ref]SymbolUse
 
+	// An estimate of the number of uses of all symbols within this part that
+	// are calls with an argument list that can always be inlined. This is a
+	// subset of the uses in "SymbolUses" above.
+	SymbolCallUses map[Ref]SymbolCallUse
+
 	// The indices of the other parts in this file that are needed if this part
 	// is needed.
 	Dependencies []Dependency
@@ -1916,6 +1936,19 @@ type Part struct {
 	// don't have this flag enabled must be included.
 	CanBeRemovedIfUnused bool
 
+	// These are the results of purity analysis. Calls and "new" expressions that
+	// target the symbols in "PureCallees" were assumed to have no side effects
+	// when computing the flags below, so the linker must check that each one
+	// refers to a pure function or class before relying on either flag.
+	//
+	// If "CanBeRemovedIfCalleesArePure" is true, the only side effects in this
+	// part are the calls in "PureCallees". If "IsPureFunction" is true, this
+	// part declares a single function or class and calling it (or constructing
+	// it) has no side effects other than the calls in "PureCallees".
+	PureCallees                  []Ref
+	CanBeRemovedIfCalleesArePure bool
+	IsPureFunction               bool
+
 	// This is used for generated parts that we don't want to be present if they
 	// aren't needed. This enables tree shaking for these parts even if global
 	// tree shaking isn't enabled.
@@ -1940,6 +1973,25 @@ type SymbolUse struct {
 	CountEstimate uint32
 }
 
+type SymbolCallUse struct {
+	CallCountEstimate uint32
+}
+
+// Returns true if a call with these arguments can be replaced with the body of
+// the called function no matter how many times (including zero times) each
+// argument is referenced in the body. This means the arguments must not have
+// side effects and must always evaluate to the same value.
+func CallArgsCanBeInlined(args []Expr) bool {
+	for _, arg := range args {
+		switch arg.Data.(type) {
+		case *ENull, *EUndefined, *EBoolean, *ENumber, *EBigInt, *EString, *EIdentifier, *EImportIdentifier:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Returns the canonical ref that represents the ref for the provided symbol.
 // This may not be the provided ref if the symbol has been merged with another
 // symbol.
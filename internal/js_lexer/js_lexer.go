@@ -1349,7 +1349,7 @@ func (lexer *Lexer) Next() {
 				if lexer.codePoint == '>' && lexer.HasNewlineBefore {
 					lexer.step()
 					lexer.LegacyHTMLCommentRange = lexer.Range()
-					lexer.log.AddRangeWarning(&lexer.tracker, lexer.Range(),
+					lexer.log.AddID(logger.MsgID_JS_HTMLCommentInJS, logger.Warning, &lexer.tracker, lexer.Range(),
 						"Treating \"-->\" as the start of a legacy HTML single-line comment")
 				singleLineHTMLCloseComment:
 					for {
@@ -1504,7 +1504,7 @@ func (lexer *Lexer) Next() {
 					lexer.step()
 					lexer.step()
 					lexer.LegacyHTMLCommentRange = lexer.Range()
-					lexer.log.AddRangeWarning(&lexer.tracker, lexer.Range(),
+					lexer.log.AddID(logger.MsgID_JS_HTMLCommentInJS, logger.Warning, &lexer.tracker, lexer.Range(),
 						"Treating \"<!--\" as the start of a legacy HTML single-line comment")
 				singleLineHTMLOpenComment:
 					for {
@@ -2156,7 +2156,7 @@ func (lexer *Lexer) ScanRegExp() {
 						for r1.Loc.Start < r2.Loc.Start && lexer.source.Contents[r1.Loc.Start] != byte(lexer.codePoint) {
 							r1.Loc.Start++
 						}
-						lexer.log.AddRangeErrorWithNotes(&lexer.tracker, r2,
+						lexer.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &lexer.tracker, r2,
 							fmt.Sprintf("Duplicate flag \"%c\" in regular expression", lexer.codePoint),
 							[]logger.MsgData{logger.RangeData(&lexer.tracker, r1,
 								fmt.Sprintf("The first \"%c\" was here", lexer.codePoint))})
@@ -2567,7 +2567,7 @@ func (lexer *Lexer) addError(loc logger.Loc, text string) {
 	lexer.prevErrorLoc = loc
 
 	if !lexer.IsLogDisabled {
-		lexer.log.AddError(&lexer.tracker, loc, text)
+		lexer.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &lexer.tracker, logger.Range{Loc: loc}, text)
 	}
 }
 
@@ -2579,7 +2579,7 @@ func (lexer *Lexer) addErrorWithNotes(loc logger.Loc, text string, notes []logge
 	lexer.prevErrorLoc = loc
 
 	if !lexer.IsLogDisabled {
-		lexer.log.AddErrorWithNotes(&lexer.tracker, loc, text, notes)
+		lexer.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &lexer.tracker, logger.Range{Loc: loc}, text, notes)
 	}
 }
 
@@ -2591,7 +2591,7 @@ func (lexer *Lexer) addRangeError(r logger.Range, text string) {
 	lexer.prevErrorLoc = r.Loc
 
 	if !lexer.IsLogDisabled {
-		lexer.log.AddRangeError(&lexer.tracker, r, text)
+		lexer.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &lexer.tracker, r, text)
 	}
 }
 
@@ -2603,7 +2603,7 @@ func (lexer *Lexer) addRangeErrorWithNotes(r logger.Range, text string, notes []
 	lexer.prevErrorLoc = r.Loc
 
 	if !lexer.IsLogDisabled {
-		lexer.log.AddRangeErrorWithNotes(&lexer.tracker, r, text, notes)
+		lexer.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &lexer.tracker, r, text, notes)
 	}
 }
 
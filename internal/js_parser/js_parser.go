@@ -66,6 +66,7 @@ type parser struct {
 	injectedDefineSymbols      []js_ast.Ref
 	injectedSymbolSources      map[js_ast.Ref]injectedSymbolSource
 	symbolUses                 map[js_ast.Ref]js_ast.SymbolUse
+	symbolCallUses             map[js_ast.Ref]js_ast.SymbolCallUse
 	declaredSymbols            []js_ast.DeclaredSymbol
 	runtimeImports             map[string]js_ast.Ref
 	duplicateCaseChecker       duplicateCaseChecker
@@ -75,6 +76,16 @@ type parser struct {
 	// For strict mode handling
 	hoistedRefForSloppyModeBlockFn map[js_ast.Ref]js_ast.Ref
 
+	// For purity analysis. When this is non-nil, calls and "new" expressions
+	// that target an identifier are assumed to have no side effects and the
+	// target is appended here so that the linker can check this assumption.
+	calleesAssumedPure *[]js_ast.Ref
+
+	// For converting simple CommonJS modules into ESM-style parts. The count is
+	// the number of identifiers named "exports" or "module" seen while parsing.
+	exportsOrModuleIdentifierCount int
+	isHoistedCommonJS              bool
+
 	// For lowering private methods
 	weakMapRef     js_ast.Ref
 	weakSetRef     js_ast.Ref
@@ -336,8 +347,11 @@ type optionsThatSupportStructuralEquality struct {
 	minifyIdentifiers       bool
 	omitRuntimeForTests     bool
 	ignoreDCEAnnotations    bool
+	inferPureFunctions      bool
 	preserveUnusedImportsTS bool
 	useDefineForClassFields config.MaybeBool
+	experimentalDecorators  config.MaybeBool
+	hoistSimpleCommonJS     bool
 }
 
 func OptionsFromConfig(options *config.Options) Options {
@@ -361,8 +375,11 @@ func OptionsFromConfig(options *config.Options) Options {
 			minifyIdentifiers:       options.MinifyIdentifiers,
 			omitRuntimeForTests:     options.OmitRuntimeForTests,
 			ignoreDCEAnnotations:    options.IgnoreDCEAnnotations,
+			inferPureFunctions:      options.InferPureFunctions,
 			preserveUnusedImportsTS: options.PreserveUnusedImportsTS,
 			useDefineForClassFields: options.UseDefineForClassFields,
+			experimentalDecorators:  options.ExperimentalDecorators,
+			hoistSimpleCommonJS:     options.HoistSimpleCommonJS,
 		},
 	}
 }
@@ -400,11 +417,10 @@ func (a *Options) Equal(b *Options) bool {
 		return false
 	}
 
-	// Do a cheap assert that the defines object hasn't changed
-	if (a.defines != nil || b.defines != nil) && (a.defines == nil || b.defines == nil ||
-		len(a.defines.IdentifierDefines) != len(b.defines.IdentifierDefines) ||
-		len(a.defines.DotDefines) != len(b.defines.DotDefines)) {
-		panic("Internal error")
+	// Compare "Defines". Builds that share a cache may use different defines.
+	if a.defines != b.defines && (a.defines == nil || b.defines == nil ||
+		a.defines.InputKey == "" || a.defines.InputKey != b.defines.InputKey) {
+		return false
 	}
 
 	return true
@@ -593,9 +609,9 @@ func (dc *duplicateCaseChecker) check(p *parser, expr js_ast.Expr) {
 							text = "This case clause may never be evaluated because it likely duplicates an earlier case clause"
 						}
 						if !p.suppressWarningsAboutWeirdCode {
-							p.log.AddRangeWarning(&p.tracker, r, text)
+							p.log.AddID(logger.MsgID_JS_DuplicateCase, logger.Warning, &p.tracker, r, text)
 						} else {
-							p.log.AddRangeDebug(&p.tracker, r, text)
+							p.log.AddID(logger.MsgID_JS_DuplicateCase, logger.Debug, &p.tracker, r, text)
 						}
 					}
 					return
@@ -1393,7 +1409,7 @@ func (p *parser) declareSymbol(kind js_ast.SymbolKind, loc logger.Loc, name stri
 		switch p.canMergeSymbols(p.currentScope, symbol.Kind, kind) {
 		case mergeForbidden:
 			r := js_lexer.RangeOfIdentifier(p.source, loc)
-			p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf("%q has already been declared", name),
+			p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("%q has already been declared", name),
 				[]logger.MsgData{logger.RangeData(&p.tracker, js_lexer.RangeOfIdentifier(p.source, existing.Loc),
 					fmt.Sprintf("%q was originally declared here", name))})
 			return existing.Ref
@@ -1513,7 +1529,7 @@ func (p *parser) hoistSymbols(scope *js_ast.Scope) {
 						if symbol.Kind != js_ast.SymbolCatchIdentifier && symbol.Kind != js_ast.SymbolHoistedFunction {
 							if !isSloppyModeBlockLevelFnStmt {
 								r := js_lexer.RangeOfIdentifier(p.source, member.Loc)
-								p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf("%q has already been declared", symbol.OriginalName),
+								p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("%q has already been declared", symbol.OriginalName),
 									[]logger.MsgData{logger.RangeData(&p.tracker, js_lexer.RangeOfIdentifier(p.source, existingMember.Loc),
 										fmt.Sprintf("%q was originally declared here", symbol.OriginalName))})
 							} else if s == scope.Parent {
@@ -1651,6 +1667,12 @@ func (p *parser) makePromiseRef() js_ast.Ref {
 // rare case, the name is an externally-allocated string. In that case we store
 // an index to the string and use that index during the scope traversal pass.
 func (p *parser) storeNameInRef(name string) js_ast.Ref {
+	// This is an over-estimate since it also includes things like labels, but
+	// that's ok because it's only used to rule out hoisting CommonJS modules
+	if name == "exports" || name == "module" {
+		p.exportsOrModuleIdentifierCount++
+	}
+
 	c := (*reflect.StringHeader)(unsafe.Pointer(&p.source.Contents))
 	n := (*reflect.StringHeader)(unsafe.Pointer(&name))
 
@@ -1712,12 +1734,12 @@ func (from *deferredErrors) mergeInto(to *deferredErrors) {
 
 func (p *parser) logExprErrors(errors *deferredErrors) {
 	if errors.invalidExprDefaultValue.Len > 0 {
-		p.log.AddRangeError(&p.tracker, errors.invalidExprDefaultValue, "Unexpected \"=\"")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, errors.invalidExprDefaultValue, "Unexpected \"=\"")
 	}
 
 	if errors.invalidExprAfterQuestion.Len > 0 {
 		r := errors.invalidExprAfterQuestion
-		p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Unexpected %q", p.source.Contents[r.Loc.Start:r.Loc.Start+r.Len]))
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Unexpected %q", p.source.Contents[r.Loc.Start:r.Loc.Start+r.Len]))
 	}
 
 	if errors.arraySpreadFeature.Len > 0 {
@@ -1755,12 +1777,12 @@ type deferredArrowArgErrors struct {
 func (p *parser) logArrowArgErrors(errors *deferredArrowArgErrors) {
 	if errors.invalidExprAwait.Len > 0 {
 		r := errors.invalidExprAwait
-		p.log.AddRangeError(&p.tracker, r, "Cannot use an \"await\" expression here")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot use an \"await\" expression here")
 	}
 
 	if errors.invalidExprYield.Len > 0 {
 		r := errors.invalidExprYield
-		p.log.AddRangeError(&p.tracker, r, "Cannot use a \"yield\" expression here")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot use a \"yield\" expression here")
 	}
 }
 
@@ -1807,6 +1829,7 @@ type propertyOpts struct {
 
 	// Class-related options
 	isStatic          bool
+	isAccessor        bool
 	isTSDeclare       bool
 	isClass           bool
 	classHasExtends   bool
@@ -1836,7 +1859,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		p.lexer.Next()
 
 	case js_lexer.TPrivateIdentifier:
-		if !opts.isClass || len(opts.tsDecorators) > 0 {
+		if !opts.isClass || (len(opts.tsDecorators) > 0 && p.useLegacyDecorators()) {
 			p.lexer.Expected(js_lexer.TIdentifier)
 		}
 		key = js_ast.Expr{Loc: p.lexer.Loc(), Data: &js_ast.EPrivateIdentifier{Ref: p.storeNameInRef(p.lexer.Identifier)}}
@@ -1921,11 +1944,17 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 					}
 
 				case "static":
-					if !opts.isStatic && !opts.isAsync && opts.isClass && raw == name {
+					if !opts.isStatic && !opts.isAsync && !opts.isAccessor && opts.isClass && raw == name {
 						opts.isStatic = true
 						return p.parseProperty(kind, opts, nil)
 					}
 
+				case "accessor":
+					if !opts.isAccessor && !opts.isAsync && opts.isClass && !p.lexer.HasNewlineBefore && raw == name {
+						opts.isAccessor = true
+						return p.parseProperty(kind, opts, nil)
+					}
+
 				case "declare":
 					if opts.isClass && p.options.ts.Parse && !opts.isTSDeclare && raw == name {
 						opts.isTSDeclare = true
@@ -1935,7 +1964,18 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 						return js_ast.Property{}, false
 					}
 
-				case "private", "protected", "public", "readonly", "abstract", "override":
+				case "abstract":
+					// Abstract auto-accessors are type-only, just like "declare" fields
+					if opts.isClass && p.options.ts.Parse && raw == name {
+						scopeIndex := len(p.scopesInOrder)
+						if property, ok := p.parseProperty(kind, opts, nil); !ok || property.Kind != js_ast.PropertyAutoAccessor {
+							return property, ok
+						}
+						p.discardScopesUpTo(scopeIndex)
+						return js_ast.Property{}, false
+					}
+
+				case "private", "protected", "public", "readonly", "override":
 					// Skip over TypeScript keywords
 					if opts.isClass && p.options.ts.Parse && raw == name {
 						return p.parseProperty(kind, opts, nil)
@@ -1951,7 +1991,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 			p.lexer.Token != js_lexer.TOpenParen && p.lexer.Token != js_lexer.TLessThan &&
 			!opts.isGenerator && !opts.isAsync && js_lexer.Keywords[name] == js_lexer.T(0) {
 			if (p.fnOrArrowDataParse.await != allowIdent && name == "await") || (p.fnOrArrowDataParse.yield != allowIdent && name == "yield") {
-				p.log.AddRangeError(&p.tracker, nameRange, fmt.Sprintf("Cannot use %q as an identifier here", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, fmt.Sprintf("Cannot use %q as an identifier here", name))
 			}
 			ref := p.storeNameInRef(name)
 			value := js_ast.Expr{Loc: key.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
@@ -1995,7 +2035,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		if !isComputed {
 			if str, ok := key.Data.(*js_ast.EString); ok && (js_lexer.UTF16EqualsString(str.Value, "constructor") ||
 				(opts.isStatic && js_lexer.UTF16EqualsString(str.Value, "prototype"))) {
-				p.log.AddRangeError(&p.tracker, keyRange, fmt.Sprintf("Invalid field name %q", js_lexer.UTF16ToString(str.Value)))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, fmt.Sprintf("Invalid field name %q", js_lexer.UTF16ToString(str.Value)))
 			}
 		}
 
@@ -2020,15 +2060,32 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		if private, ok := key.Data.(*js_ast.EPrivateIdentifier); ok {
 			name := p.loadNameFromRef(private.Ref)
 			if name == "#constructor" {
-				p.log.AddRangeError(&p.tracker, keyRange, fmt.Sprintf("Invalid field name %q", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, fmt.Sprintf("Invalid field name %q", name))
 			}
 			var declare js_ast.SymbolKind
-			if opts.isStatic {
+			if opts.isAccessor {
+				// Private auto-accessors are a getter and setter pair that is always
+				// lowered, since the getter and setter may be replaced by decorators
+				if opts.isStatic {
+					declare = js_ast.SymbolPrivateStaticGetSetPair
+				} else {
+					declare = js_ast.SymbolPrivateGetSetPair
+				}
+			} else if opts.isStatic {
 				declare = js_ast.SymbolPrivateStaticField
 			} else {
 				declare = js_ast.SymbolPrivateField
 			}
 			private.Ref = p.declareSymbol(declare, key.Loc, name)
+			if opts.isAccessor {
+				p.symbols[private.Ref.InnerIndex].PrivateSymbolMustBeLowered = true
+				p.privateGetters[private.Ref] = p.newSymbol(js_ast.SymbolOther, name[1:]+"_get")
+				p.privateSetters[private.Ref] = p.newSymbol(js_ast.SymbolOther, name[1:]+"_set")
+			}
+		}
+
+		if opts.isAccessor {
+			kind = js_ast.PropertyAutoAccessor
 		}
 
 		p.lexer.ExpectOrInsertSemicolon()
@@ -2046,6 +2103,11 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 	// Parse a method expression
 	if p.lexer.Token == js_lexer.TOpenParen || kind != js_ast.PropertyNormal ||
 		opts.isClass || opts.isAsync || opts.isGenerator {
+		// "accessor" can only be used on fields
+		if opts.isAccessor {
+			p.lexer.Expected(js_lexer.TEquals)
+		}
+
 		if p.lexer.Token == js_lexer.TOpenParen && kind != js_ast.PropertyGet && kind != js_ast.PropertySet {
 			p.markSyntaxFeature(compat.ObjectExtensions, p.lexer.Range())
 		}
@@ -2059,18 +2121,18 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 				if !opts.isStatic && js_lexer.UTF16EqualsString(str.Value, "constructor") {
 					switch {
 					case kind == js_ast.PropertyGet:
-						p.log.AddRangeError(&p.tracker, keyRange, "Class constructor cannot be a getter")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, "Class constructor cannot be a getter")
 					case kind == js_ast.PropertySet:
-						p.log.AddRangeError(&p.tracker, keyRange, "Class constructor cannot be a setter")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, "Class constructor cannot be a setter")
 					case opts.isAsync:
-						p.log.AddRangeError(&p.tracker, keyRange, "Class constructor cannot be an async function")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, "Class constructor cannot be an async function")
 					case opts.isGenerator:
-						p.log.AddRangeError(&p.tracker, keyRange, "Class constructor cannot be a generator")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, "Class constructor cannot be a generator")
 					default:
 						isConstructor = true
 					}
 				} else if opts.isStatic && js_lexer.UTF16EqualsString(str.Value, "prototype") {
-					p.log.AddRangeError(&p.tracker, keyRange, "Invalid static method name \"prototype\"")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, "Invalid static method name \"prototype\"")
 				}
 			}
 		}
@@ -2114,7 +2176,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 		case js_ast.PropertyGet:
 			if len(fn.Args) > 0 {
 				r := js_lexer.RangeOfIdentifier(p.source, fn.Args[0].Binding.Loc)
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Getter %s must have zero arguments", p.keyNameForError(key)))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Getter %s must have zero arguments", p.keyNameForError(key)))
 			}
 
 		case js_ast.PropertySet:
@@ -2123,7 +2185,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 				if len(fn.Args) > 1 {
 					r = js_lexer.RangeOfIdentifier(p.source, fn.Args[1].Binding.Loc)
 				}
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Setter %s must have exactly one argument", p.keyNameForError(key)))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Setter %s must have exactly one argument", p.keyNameForError(key)))
 			}
 		}
 
@@ -2156,7 +2218,7 @@ func (p *parser) parseProperty(kind js_ast.PropertyKind, opts propertyOpts, erro
 			}
 			name := p.loadNameFromRef(private.Ref)
 			if name == "#constructor" {
-				p.log.AddRangeError(&p.tracker, keyRange, fmt.Sprintf("Invalid method name %q", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, keyRange, fmt.Sprintf("Invalid method name %q", name))
 			}
 			private.Ref = p.declareSymbol(declare, key.Loc, name)
 			methodRef := p.newSymbol(js_ast.SymbolOther, name[1:]+suffix)
@@ -2276,7 +2338,7 @@ func (p *parser) parseArrowBody(args []js_ast.Arg, data fnOrArrowDataParse) *js_
 
 	// Newlines are not allowed before "=>"
 	if p.lexer.HasNewlineBefore {
-		p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Unexpected newline before \"=>\"")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Unexpected newline before \"=>\"")
 		panic(js_lexer.LexerPanic{})
 	}
 
@@ -2369,7 +2431,7 @@ func (p *parser) parseAsyncPrefixExpr(asyncRange logger.Range, level js_ast.L, f
 					// Do not allow "for (async of []) ;" but do allow "for await (async of []) ;"
 					if !isArrowFn && (flags&exprFlagForAwaitLoopInit) == 0 && p.lexer.Raw() == "of" {
 						r := logger.Range{Loc: asyncRange.Loc, Len: p.lexer.Range().End() - asyncRange.Loc.Start}
-						p.log.AddRangeError(&p.tracker, r, "For loop initializers cannot start with \"async of\"")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "For loop initializers cannot start with \"async of\"")
 						panic(js_lexer.LexerPanic{})
 					}
 				}
@@ -2587,7 +2649,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 		if p.lexer.Token == js_lexer.TEqualsGreaterThan || (len(invalidLog.invalidTokens) == 0 &&
 			p.trySkipTypeScriptArrowReturnTypeWithBacktracking()) || opts.forceArrowFn {
 			if commaAfterSpread.Start != 0 {
-				p.log.AddRangeError(&p.tracker, logger.Range{Loc: commaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: commaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
 			p.logArrowArgErrors(&arrowArgErrors)
 
@@ -2595,7 +2657,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 			// conversion errors
 			if len(invalidLog.invalidTokens) > 0 {
 				for _, token := range invalidLog.invalidTokens {
-					p.log.AddRangeError(&p.tracker, token, "Invalid binding pattern")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, token, "Invalid binding pattern")
 				}
 				panic(js_lexer.LexerPanic{})
 			}
@@ -2628,7 +2690,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 
 	// If this isn't an arrow function, then types aren't allowed
 	if typeColonRange.Len > 0 {
-		p.log.AddRangeError(&p.tracker, typeColonRange, "Unexpected \":\"")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, typeColonRange, "Unexpected \":\"")
 		panic(js_lexer.LexerPanic{})
 	}
 
@@ -2646,7 +2708,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 	if len(items) > 0 {
 		p.logExprErrors(&errors)
 		if spreadRange.Len > 0 {
-			p.log.AddRangeError(&p.tracker, spreadRange, "Unexpected \"...\"")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, spreadRange, "Unexpected \"...\"")
 			panic(js_lexer.LexerPanic{})
 		}
 		value := js_ast.JoinAllWithComma(items)
@@ -2681,7 +2743,7 @@ func (p *parser) convertExprToBindingAndInitializer(
 	if initializerOrNil.Data != nil {
 		equalsRange := p.source.RangeOfOperatorBefore(initializerOrNil.Loc, "=")
 		if isSpread {
-			p.log.AddRangeError(&p.tracker, equalsRange, "A rest argument cannot have a default initializer")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, equalsRange, "A rest argument cannot have a default initializer")
 		} else {
 			invalidLog.syntaxFeatures = append(invalidLog.syntaxFeatures, syntaxFeature{
 				feature: compat.DefaultArgument,
@@ -2798,7 +2860,7 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 			}
 		}
 
-		p.log.AddRangeError(&p.tracker, superRange, "Unexpected \"super\"")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, superRange, "Unexpected \"super\"")
 		return js_ast.Expr{Loc: loc, Data: js_ast.ESuperShared}
 
 	case js_lexer.TOpenParen:
@@ -2876,11 +2938,11 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 		case "await":
 			switch p.fnOrArrowDataParse.await {
 			case forbidAll:
-				p.log.AddRangeError(&p.tracker, nameRange, "The keyword \"await\" cannot be used here")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, "The keyword \"await\" cannot be used here")
 
 			case allowExpr:
 				if raw != "await" {
-					p.log.AddRangeError(&p.tracker, nameRange, "The keyword \"await\" cannot be escaped")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, "The keyword \"await\" cannot be escaped")
 				} else {
 					if p.fnOrArrowDataParse.isTopLevel {
 						p.topLevelAwaitKeyword = nameRange
@@ -2905,14 +2967,14 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 		case "yield":
 			switch p.fnOrArrowDataParse.yield {
 			case forbidAll:
-				p.log.AddRangeError(&p.tracker, nameRange, "The keyword \"yield\" cannot be used here")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, "The keyword \"yield\" cannot be used here")
 
 			case allowExpr:
 				if raw != "yield" {
-					p.log.AddRangeError(&p.tracker, nameRange, "The keyword \"yield\" cannot be escaped")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, "The keyword \"yield\" cannot be escaped")
 				} else {
 					if level > js_ast.LAssign {
-						p.log.AddRangeError(&p.tracker, nameRange, "Cannot use a \"yield\" expression here without parentheses")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, "Cannot use a \"yield\" expression here without parentheses")
 					}
 					if p.fnOrArrowDataParse.arrowArgErrors != nil {
 						p.fnOrArrowDataParse.arrowArgErrors.invalidExprYield = nameRange
@@ -2926,7 +2988,7 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 					switch p.lexer.Token {
 					case js_lexer.TNull, js_lexer.TIdentifier, js_lexer.TFalse, js_lexer.TTrue,
 						js_lexer.TNumericLiteral, js_lexer.TBigIntegerLiteral, js_lexer.TStringLiteral:
-						p.log.AddRangeError(&p.tracker, nameRange, "Cannot use \"yield\" outside a generator function")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, nameRange, "Cannot use \"yield\" outside a generator function")
 						return p.parseYieldExpr(loc)
 					}
 				}
@@ -3014,7 +3076,7 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 			if private, ok := index.Index.Data.(*js_ast.EPrivateIdentifier); ok {
 				name := p.loadNameFromRef(private.Ref)
 				r := logger.Range{Loc: index.Index.Loc, Len: int32(len(name))}
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Deleting the private name %q is forbidden", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Deleting the private name %q is forbidden", name))
 			}
 		}
 		return js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{Op: js_ast.UnOpDelete, Value: value}}
@@ -3063,33 +3125,20 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 		return p.parseFnExpr(loc, false /* isAsync */, logger.Range{})
 
 	case js_lexer.TClass:
-		classKeyword := p.lexer.Range()
-		p.markSyntaxFeature(compat.Class, classKeyword)
-		p.lexer.Next()
-		var name *js_ast.LocRef
-
-		p.pushScopeForParsePass(js_ast.ScopeClassName, loc)
+		return p.parseClassExpr(loc, nil)
 
-		// Parse an optional class name
-		if p.lexer.Token == js_lexer.TIdentifier {
-			if nameText := p.lexer.Identifier; !p.options.ts.Parse || nameText != "implements" {
-				if p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
-					p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Cannot use \"await\" as an identifier here")
-				}
-				name = &js_ast.LocRef{Loc: p.lexer.Loc(), Ref: p.newSymbol(js_ast.SymbolOther, nameText)}
-				p.lexer.Next()
+	case js_lexer.TAt:
+		// JavaScript decorators can be used on class expressions
+		// "@decorator class {}"
+		if !p.useLegacyDecorators() {
+			tsDecorators := p.parseTypeScriptDecorators()
+			if p.lexer.Token != js_lexer.TClass {
+				p.lexer.Expected(js_lexer.TClass)
 			}
+			return p.parseClassExpr(loc, tsDecorators)
 		}
-
-		// Even anonymous classes can have TypeScript type parameters
-		if p.options.ts.Parse {
-			p.skipTypeScriptTypeParameters()
-		}
-
-		class := p.parseClass(classKeyword, name, parseClassOpts{})
-
-		p.popScope()
-		return js_ast.Expr{Loc: loc, Data: &js_ast.EClass{Class: class}}
+		p.lexer.Unexpected()
+		return js_ast.Expr{}
 
 	case js_lexer.TNew:
 		p.lexer.Next()
@@ -3290,6 +3339,7 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 		//   An arrow function with type parameters:
 		//     <A, B>(x) => {}
 		//     <A extends B>(x) => {}
+		//     <const A>(x) => {}
 		//
 		//   A syntax error:
 		//     <[]>(x)
@@ -3303,7 +3353,10 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 
 			// Look ahead to see if this should be an arrow function instead
 			isTSArrowFn := false
-			if p.lexer.Token == js_lexer.TIdentifier {
+			if p.lexer.Token == js_lexer.TConst {
+				// "<const T>() => {}" can't be a JSX element
+				isTSArrowFn = true
+			} else if p.lexer.Token == js_lexer.TIdentifier {
 				p.lexer.Next()
 				if p.lexer.Token == js_lexer.TComma {
 					isTSArrowFn = true
@@ -3434,7 +3487,7 @@ func (p *parser) parseImportExpr(loc logger.Loc, level js_ast.L) js_ast.Expr {
 
 	if level > js_ast.LCall {
 		r := js_lexer.RangeOfIdentifier(p.source, loc)
-		p.log.AddRangeError(&p.tracker, r, "Cannot use an \"import\" expression here without parentheses")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot use an \"import\" expression here without parentheses")
 	}
 
 	// Allow "in" inside call arguments
@@ -3671,7 +3724,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 
 		case js_lexer.TNoSubstitutionTemplateLiteral:
 			if oldOptionalChain != js_ast.OptionalChainNone {
-				p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Template literals cannot have an optional chain as a tag")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Template literals cannot have an optional chain as a tag")
 			}
 			headLoc := p.lexer.Loc()
 			headCooked, headRaw := p.lexer.CookedAndRawTemplateContents()
@@ -3685,7 +3738,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 
 		case js_lexer.TTemplateHead:
 			if oldOptionalChain != js_ast.OptionalChainNone {
-				p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Template literals cannot have an optional chain as a tag")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Template literals cannot have an optional chain as a tag")
 			}
 			headLoc := p.lexer.Loc()
 			headCooked, headRaw := p.lexer.CookedAndRawTemplateContents()
@@ -4124,7 +4177,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			// Warn about "!a in b" instead of "!(a in b)"
 			if !p.suppressWarningsAboutWeirdCode {
 				if e, ok := left.Data.(*js_ast.EUnary); ok && e.Op == js_ast.UnOpNot {
-					p.log.AddWarning(&p.tracker, left.Loc,
+					p.log.AddID(logger.MsgID_JS_SuspiciousBooleanNot, logger.Warning, &p.tracker, logger.Range{Loc: left.Loc},
 						"Suspicious use of the \"!\" operator inside the \"in\" operator")
 				}
 			}
@@ -4141,7 +4194,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			// example of code with this problem: https://github.com/mrdoob/three.js/pull/11182.
 			if !p.suppressWarningsAboutWeirdCode {
 				if e, ok := left.Data.(*js_ast.EUnary); ok && e.Op == js_ast.UnOpNot {
-					p.log.AddWarning(&p.tracker, left.Loc,
+					p.log.AddID(logger.MsgID_JS_SuspiciousBooleanNot, logger.Warning, &p.tracker, logger.Range{Loc: left.Loc},
 						"Suspicious use of the \"!\" operator inside the \"instanceof\" operator")
 				}
 			}
@@ -4150,8 +4203,9 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			left = js_ast.Expr{Loc: left.Loc, Data: &js_ast.EBinary{Op: js_ast.BinOpInstanceof, Left: left, Right: p.parseExpr(js_ast.LCompare)}}
 
 		default:
-			// Handle the TypeScript "as" operator
-			if p.options.ts.Parse && level < js_ast.LCompare && !p.lexer.HasNewlineBefore && p.lexer.IsContextualKeyword("as") {
+			// Handle the TypeScript "as" and "satisfies" operators
+			if p.options.ts.Parse && level < js_ast.LCompare && !p.lexer.HasNewlineBefore &&
+				(p.lexer.IsContextualKeyword("as") || p.lexer.IsContextualKeyword("satisfies")) {
 				p.lexer.Next()
 				p.skipTypeScriptType(js_ast.LLowest)
 
@@ -4280,7 +4334,7 @@ func (p *parser) parseJSXTag() (logger.Range, string, js_ast.Expr) {
 		// Dashes are not allowed in member expression chains
 		index := strings.IndexByte(member, '-')
 		if index >= 0 {
-			p.log.AddError(&p.tracker, logger.Loc{Start: memberRange.Loc.Start + int32(index)}, "Unexpected \"-\"")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: logger.Loc{Start: memberRange.Loc.Start + int32(index)}}, "Unexpected \"-\"")
 			panic(js_lexer.LexerPanic{})
 		}
 
@@ -4387,7 +4441,7 @@ func (p *parser) parseJSXElement(loc logger.Loc) js_ast.Expr {
 	//
 	// This code special-cases this error to provide a less obscure error message.
 	if p.lexer.Token == js_lexer.TSyntaxError && p.lexer.Raw() == "\\" && previousStringWithBackslashLoc.Start > 0 {
-		msg := logger.Msg{Kind: logger.Error, Data: logger.RangeData(&p.tracker, p.lexer.Range(),
+		msg := logger.Msg{ID: logger.MsgID_JS_SyntaxError, Kind: logger.Error, Data: logger.RangeData(&p.tracker, p.lexer.Range(),
 			"Unexpected backslash in JSX element")}
 
 		// Option 1: Suggest using an XML escape
@@ -4480,7 +4534,7 @@ func (p *parser) parseJSXElement(loc logger.Loc) js_ast.Expr {
 			p.lexer.NextInsideJSXElement()
 			endRange, endText, _ := p.parseJSXTag()
 			if startText != endText {
-				p.log.AddRangeErrorWithNotes(&p.tracker, endRange, fmt.Sprintf("Expected closing tag %q to match opening tag %q", endText, startText),
+				p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, endRange, fmt.Sprintf("Expected closing tag %q to match opening tag %q", endText, startText),
 					[]logger.MsgData{logger.RangeData(&p.tracker, startRange, fmt.Sprintf("The opening tag %q is here", startText))})
 			}
 			if p.lexer.Token != js_lexer.TGreaterThan {
@@ -4545,7 +4599,7 @@ func (p *parser) parseAndDeclareDecls(kind js_ast.SymbolKind, opts parseStmtOpts
 	for {
 		// Forbid "let let" and "const let" but not "var let"
 		if (kind == js_ast.SymbolOther || kind == js_ast.SymbolConst) && p.lexer.IsContextualKeyword("let") {
-			p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Cannot use \"let\" as an identifier here")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Cannot use \"let\" as an identifier here")
 		}
 
 		var valueOrNil js_ast.Expr
@@ -4588,10 +4642,10 @@ func (p *parser) requireInitializers(decls []js_ast.Decl) {
 		if d.ValueOrNil.Data == nil {
 			if id, ok := d.Binding.Data.(*js_ast.BIdentifier); ok {
 				r := js_lexer.RangeOfIdentifier(p.source, d.Binding.Loc)
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("The constant %q must be initialized",
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("The constant %q must be initialized",
 					p.symbols[id.Ref.InnerIndex].OriginalName))
 			} else {
-				p.log.AddError(&p.tracker, d.Binding.Loc, "This constant must be initialized")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: d.Binding.Loc}, "This constant must be initialized")
 			}
 		}
 	}
@@ -4599,7 +4653,7 @@ func (p *parser) requireInitializers(decls []js_ast.Decl) {
 
 func (p *parser) forbidInitializers(decls []js_ast.Decl, loopType string, isVar bool) {
 	if len(decls) > 1 {
-		p.log.AddError(&p.tracker, decls[0].Binding.Loc, fmt.Sprintf("for-%s loops must have a single declaration", loopType))
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: decls[0].Binding.Loc}, fmt.Sprintf("for-%s loops must have a single declaration", loopType))
 	} else if len(decls) == 1 && decls[0].ValueOrNil.Data != nil {
 		if isVar {
 			if _, ok := decls[0].Binding.Data.(*js_ast.BIdentifier); ok {
@@ -4608,7 +4662,7 @@ func (p *parser) forbidInitializers(decls []js_ast.Decl, loopType string, isVar
 				return
 			}
 		}
-		p.log.AddError(&p.tracker, decls[0].ValueOrNil.Loc, fmt.Sprintf("for-%s loop variables cannot have an initializer", loopType))
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: decls[0].ValueOrNil.Loc}, fmt.Sprintf("for-%s loop variables cannot have an initializer", loopType))
 	}
 }
 
@@ -4620,7 +4674,7 @@ func (p *parser) parseClauseAlias(kind string) string {
 		r := p.source.RangeOfString(loc)
 		alias, problem, ok := js_lexer.UTF16ToStringWithValidation(p.lexer.StringLiteral())
 		if !ok {
-			p.log.AddRangeError(&p.tracker, r,
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r,
 				fmt.Sprintf("This %s alias is invalid because it contains the unpaired Unicode surrogate U+%X", kind, problem))
 		} else {
 			p.markSyntaxFeature(compat.ArbitraryModuleNamespaceNames, r)
@@ -4664,7 +4718,7 @@ func (p *parser) parseImportClause() ([]js_ast.ClauseItem, bool) {
 		// Reject forbidden names
 		if isEvalOrArguments(originalName) {
 			r := js_lexer.RangeOfIdentifier(p.source, name.Loc)
-			p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Cannot use %q as an identifier here", originalName))
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot use %q as an identifier here", originalName))
 		}
 
 		items = append(items, js_ast.ClauseItem{
@@ -4755,7 +4809,7 @@ func (p *parser) parseExportClause() ([]js_ast.ClauseItem, bool) {
 	// "export from" statement after all
 	if firstNonIdentifierLoc.Start != 0 && !p.lexer.IsContextualKeyword("from") {
 		r := js_lexer.RangeOfIdentifier(p.source, firstNonIdentifierLoc)
-		p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Expected identifier but found %q", p.source.TextForRange(r)))
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Expected identifier but found %q", p.source.TextForRange(r)))
 		panic(js_lexer.LexerPanic{})
 	}
 
@@ -4770,7 +4824,7 @@ func (p *parser) parseBinding() js_ast.Binding {
 		name := p.lexer.Identifier
 		if (p.fnOrArrowDataParse.await != allowIdent && name == "await") ||
 			(p.fnOrArrowDataParse.yield != allowIdent && name == "yield") {
-			p.log.AddRangeError(&p.tracker, p.lexer.Range(), fmt.Sprintf("Cannot use %q as an identifier here", name))
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), fmt.Sprintf("Cannot use %q as an identifier here", name))
 		}
 		ref := p.storeNameInRef(name)
 		p.lexer.Next()
@@ -4814,7 +4868,7 @@ func (p *parser) parseBinding() js_ast.Binding {
 
 				// Commas after spread elements are not allowed
 				if hasSpread && p.lexer.Token == js_lexer.TComma {
-					p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Unexpected \",\" after rest pattern")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Unexpected \",\" after rest pattern")
 					panic(js_lexer.LexerPanic{})
 				}
 			}
@@ -4859,7 +4913,7 @@ func (p *parser) parseBinding() js_ast.Binding {
 
 			// Commas after spread elements are not allowed
 			if property.IsSpread && p.lexer.Token == js_lexer.TComma {
-				p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Unexpected \",\" after rest pattern")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Unexpected \",\" after rest pattern")
 				panic(js_lexer.LexerPanic{})
 			}
 
@@ -4936,8 +4990,9 @@ func (p *parser) parseFn(name *js_ast.LocRef, data fnOrArrowDataParse) (fn js_as
 			continue
 		}
 
+		// Parameter decorators only exist in TypeScript's legacy decorator mode
 		var tsDecorators []js_ast.Expr
-		if data.allowTSDecorators {
+		if data.allowTSDecorators && p.useLegacyDecorators() {
 			tsDecorators = p.parseTypeScriptDecorators()
 		}
 
@@ -5060,10 +5115,10 @@ func (p *parser) validateFunctionName(fn js_ast.Fn, kind fnKind) {
 	// Prevent the function name from being the same as a function-specific keyword
 	if fn.Name != nil {
 		if fn.IsAsync && p.symbols[fn.Name.Ref.InnerIndex].OriginalName == "await" {
-			p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, fn.Name.Loc),
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, fn.Name.Loc),
 				"An async function cannot be named \"await\"")
 		} else if fn.IsGenerator && p.symbols[fn.Name.Ref.InnerIndex].OriginalName == "yield" && kind == fnExpr {
-			p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, fn.Name.Loc),
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, fn.Name.Loc),
 				"A generator function expression cannot be named \"yield\"")
 		}
 	}
@@ -5092,7 +5147,7 @@ func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt
 		nameText := p.lexer.Identifier
 		p.lexer.Expect(js_lexer.TIdentifier)
 		if p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
-			p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, nameLoc), "Cannot use \"await\" as an identifier here")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, nameLoc), "Cannot use \"await\" as an identifier here")
 		}
 		name = &js_ast.LocRef{Loc: nameLoc, Ref: js_ast.InvalidRef}
 		if !opts.isTypeScriptDeclare {
@@ -5208,11 +5263,15 @@ func (p *parser) parseClass(classKeyword logger.Range, name *js_ast.LocRef, clas
 			// Forbid decorators on class constructors
 			if key, ok := property.Key.Data.(*js_ast.EString); ok && js_lexer.UTF16EqualsString(key.Value, "constructor") {
 				if len(opts.tsDecorators) > 0 {
-					p.log.AddError(&p.tracker, firstDecoratorLoc, "TypeScript does not allow decorators on class constructors")
+					if p.useLegacyDecorators() {
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: firstDecoratorLoc}, "TypeScript does not allow decorators on class constructors")
+					} else {
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: firstDecoratorLoc}, "Decorators are not allowed on class constructors")
+					}
 				}
 				if property.IsMethod && !property.IsStatic && !property.IsComputed {
 					if hasConstructor {
-						p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, property.Key.Loc),
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, property.Key.Loc),
 							"Classes cannot contain more than one constructor")
 					}
 					hasConstructor = true
@@ -5242,6 +5301,40 @@ func (p *parser) parseClass(classKeyword logger.Range, name *js_ast.LocRef, clas
 	}
 }
 
+func (p *parser) parseClassExpr(loc logger.Loc, tsDecorators []js_ast.Expr) js_ast.Expr {
+	classKeyword := p.lexer.Range()
+	p.markSyntaxFeature(compat.Class, classKeyword)
+	p.lexer.Next()
+	var name *js_ast.LocRef
+
+	p.pushScopeForParsePass(js_ast.ScopeClassName, loc)
+
+	// Parse an optional class name
+	if p.lexer.Token == js_lexer.TIdentifier {
+		if nameText := p.lexer.Identifier; !p.options.ts.Parse || nameText != "implements" {
+			if p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Cannot use \"await\" as an identifier here")
+			}
+			name = &js_ast.LocRef{Loc: p.lexer.Loc(), Ref: p.newSymbol(js_ast.SymbolOther, nameText)}
+			p.lexer.Next()
+		}
+	}
+
+	// Even anonymous classes can have TypeScript type parameters
+	if p.options.ts.Parse {
+		p.skipTypeScriptTypeParameters()
+	}
+
+	// TypeScript's legacy decorators don't work on class expressions
+	class := p.parseClass(classKeyword, name, parseClassOpts{
+		tsDecorators:      tsDecorators,
+		allowTSDecorators: !p.useLegacyDecorators(),
+	})
+
+	p.popScope()
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EClass{Class: class}}
+}
+
 func (p *parser) parseLabelName() *js_ast.LocRef {
 	if p.lexer.Token != js_lexer.TIdentifier || p.lexer.HasNewlineBefore {
 		return nil
@@ -5287,7 +5380,7 @@ func (p *parser) parsePath() (logger.Loc, string, *[]ast.AssertEntry) {
 				p.lexer.Expect(js_lexer.TIdentifier)
 			}
 			if prevRange, ok := duplicates[keyText]; ok {
-				p.log.AddRangeErrorWithNotes(&p.tracker, p.lexer.Range(), fmt.Sprintf("Duplicate import assertion %q", keyText),
+				p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), fmt.Sprintf("Duplicate import assertion %q", keyText),
 					[]logger.MsgData{logger.RangeData(&p.tracker, prevRange, fmt.Sprintf("The first %q was here", keyText))})
 			}
 			duplicates[keyText] = p.lexer.Range()
@@ -5349,7 +5442,7 @@ func (p *parser) parseFnStmt(loc logger.Loc, opts parseStmtOpts, isAsync bool, a
 		nameLoc := p.lexer.Loc()
 		nameText = p.lexer.Identifier
 		if !isAsync && p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
-			p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, nameLoc), "Cannot use \"await\" as an identifier here")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, nameLoc), "Cannot use \"await\" as an identifier here")
 		}
 		p.lexer.Expect(js_lexer.TIdentifier)
 		name = &js_ast.LocRef{Loc: nameLoc, Ref: js_ast.InvalidRef}
@@ -5494,6 +5587,16 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			opts.isExport = true
 			return p.parseStmt(opts)
 
+		case js_lexer.TAt:
+			// JavaScript decorators can also come after the "export" keyword
+			// "export @decorator class Foo {}"
+			if opts.tsDecorators == nil && !p.useLegacyDecorators() {
+				opts.isExport = true
+				return p.parseStmt(opts)
+			}
+			p.lexer.Unexpected()
+			return js_ast.Stmt{}
+
 		case js_lexer.TImport:
 			// "export import foo = bar"
 			if p.options.ts.Parse && (opts.isModuleScope || opts.isNamespaceScope) {
@@ -5531,7 +5634,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				asyncRange := p.lexer.Range()
 				p.lexer.Next()
 				if p.lexer.HasNewlineBefore {
-					p.log.AddError(&p.tracker, logger.Loc{Start: asyncRange.End()}, "Unexpected newline after \"async\"")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: logger.Loc{Start: asyncRange.End()}}, "Unexpected newline after \"async\"")
 					panic(js_lexer.LexerPanic{})
 				}
 				p.lexer.Expect(js_lexer.TFunction)
@@ -5546,7 +5649,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 					typeRange := p.lexer.Range()
 					p.lexer.Next()
 					if p.lexer.HasNewlineBefore {
-						p.log.AddError(&p.tracker, logger.Loc{Start: typeRange.End()}, "Unexpected newline after \"type\"")
+						p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: logger.Loc{Start: typeRange.End()}}, "Unexpected newline after \"type\"")
 						panic(js_lexer.LexerPanic{})
 					}
 					p.skipTypeScriptTypeStmt(parseStmtOpts{isModuleScope: opts.isModuleScope, isExport: true})
@@ -5777,44 +5880,39 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 	case js_lexer.TAt:
 		// Parse decorators before class statements, which are potentially exported
-		if p.options.ts.Parse {
-			scopeIndex := len(p.scopesInOrder)
-			tsDecorators := p.parseTypeScriptDecorators()
+		scopeIndex := len(p.scopesInOrder)
+		tsDecorators := p.parseTypeScriptDecorators()
 
-			// If this turns out to be a "declare class" statement, we need to undo the
-			// scopes that were potentially pushed while parsing the decorator arguments.
-			// That can look like any one of the following:
-			//
-			//   "@decorator declare class Foo {}"
-			//   "@decorator declare abstract class Foo {}"
-			//   "@decorator export declare class Foo {}"
-			//   "@decorator export declare abstract class Foo {}"
-			//
-			opts.tsDecorators = &deferredTSDecorators{
-				values:     tsDecorators,
-				scopeIndex: scopeIndex,
-			}
-
-			// "@decorator class Foo {}"
-			// "@decorator abstract class Foo {}"
-			// "@decorator declare class Foo {}"
-			// "@decorator declare abstract class Foo {}"
-			// "@decorator export class Foo {}"
-			// "@decorator export abstract class Foo {}"
-			// "@decorator export declare class Foo {}"
-			// "@decorator export declare abstract class Foo {}"
-			// "@decorator export default class Foo {}"
-			// "@decorator export default abstract class Foo {}"
-			if p.lexer.Token != js_lexer.TClass && p.lexer.Token != js_lexer.TExport &&
-				!p.lexer.IsContextualKeyword("abstract") && !p.lexer.IsContextualKeyword("declare") {
-				p.lexer.Expected(js_lexer.TClass)
-			}
+		// If this turns out to be a "declare class" statement, we need to undo the
+		// scopes that were potentially pushed while parsing the decorator arguments.
+		// That can look like any one of the following:
+		//
+		//   "@decorator declare class Foo {}"
+		//   "@decorator declare abstract class Foo {}"
+		//   "@decorator export declare class Foo {}"
+		//   "@decorator export declare abstract class Foo {}"
+		//
+		opts.tsDecorators = &deferredTSDecorators{
+			values:     tsDecorators,
+			scopeIndex: scopeIndex,
+		}
 
-			return p.parseStmt(opts)
+		// "@decorator class Foo {}"
+		// "@decorator abstract class Foo {}"
+		// "@decorator declare class Foo {}"
+		// "@decorator declare abstract class Foo {}"
+		// "@decorator export class Foo {}"
+		// "@decorator export abstract class Foo {}"
+		// "@decorator export declare class Foo {}"
+		// "@decorator export declare abstract class Foo {}"
+		// "@decorator export default class Foo {}"
+		// "@decorator export default abstract class Foo {}"
+		if p.lexer.Token != js_lexer.TClass && p.lexer.Token != js_lexer.TExport && (!p.options.ts.Parse ||
+			(!p.lexer.IsContextualKeyword("abstract") && !p.lexer.IsContextualKeyword("declare"))) {
+			p.lexer.Expected(js_lexer.TClass)
 		}
 
-		p.lexer.Unexpected()
-		return js_ast.Stmt{}
+		return p.parseStmt(opts)
 
 	case js_lexer.TClass:
 		if opts.lexicalDecl != lexicalDeclAllowAll {
@@ -5926,7 +6024,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 			if p.lexer.Token == js_lexer.TDefault {
 				if foundDefault {
-					p.log.AddRangeError(&p.tracker, p.lexer.Range(), "Multiple default clauses are not allowed")
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.Range(), "Multiple default clauses are not allowed")
 					panic(js_lexer.LexerPanic{})
 				}
 				foundDefault = true
@@ -6041,7 +6139,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		if isForAwait {
 			awaitRange := p.lexer.Range()
 			if p.fnOrArrowDataParse.await != allowExpr {
-				p.log.AddRangeError(&p.tracker, awaitRange, "Cannot use \"await\" outside an async function")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, awaitRange, "Cannot use \"await\" outside an async function")
 				isForAwait = false
 			} else {
 				didGenerateError := p.markSyntaxFeature(compat.ForAwait, awaitRange)
@@ -6106,7 +6204,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		// Detect for-of loops
 		if p.lexer.IsContextualKeyword("of") || isForAwait {
 			if badLetRange.Len > 0 {
-				p.log.AddRangeError(&p.tracker, badLetRange, "\"let\" must be wrapped in parentheses to be used as an expression here")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, badLetRange, "\"let\" must be wrapped in parentheses to be used as an expression here")
 			}
 			if isForAwait && !p.lexer.IsContextualKeyword("of") {
 				if initOrNil.Data != nil {
@@ -6379,7 +6477,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 	case js_lexer.TThrow:
 		p.lexer.Next()
 		if p.lexer.HasNewlineBefore {
-			p.log.AddError(&p.tracker, logger.Loc{Start: loc.Start + 5}, "Unexpected newline after \"throw\"")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: logger.Loc{Start: loc.Start + 5}}, "Unexpected newline after \"throw\"")
 			panic(js_lexer.LexerPanic{})
 		}
 		expr := p.parseExpr(js_ast.LLowest)
@@ -6606,7 +6704,7 @@ func (p *parser) parseFnBody(data fnOrArrowDataParse) js_ast.FnBody {
 
 func (p *parser) forbidLexicalDecl(loc logger.Loc) {
 	r := js_lexer.RangeOfIdentifier(p.source, loc)
-	p.log.AddRangeError(&p.tracker, r, "Cannot use a declaration in a single-statement context")
+	p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot use a declaration in a single-statement context")
 }
 
 func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stmt {
@@ -6687,7 +6785,7 @@ func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stm
 			} else {
 				if returnWithoutSemicolonStart != -1 {
 					if _, ok := stmt.Data.(*js_ast.SExpr); ok {
-						p.log.AddWarning(&p.tracker, logger.Loc{Start: returnWithoutSemicolonStart + 6},
+						p.log.AddID(logger.MsgID_JS_SemicolonAfterReturn, logger.Warning, &p.tracker, logger.Range{Loc: logger.Loc{Start: returnWithoutSemicolonStart + 6}},
 							"The following expression is not returned because of an automatically-inserted semicolon")
 					}
 				}
@@ -6769,7 +6867,7 @@ func (p *parser) findSymbol(loc logger.Loc, name string) findSymbolResult {
 		// Forbid referencing "arguments" inside class bodies
 		if s.ForbidArguments && name == "arguments" && !didForbidArguments {
 			r := js_lexer.RangeOfIdentifier(p.source, loc)
-			p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Cannot access %q here", name))
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot access %q here", name))
 			didForbidArguments = true
 		}
 
@@ -6817,7 +6915,7 @@ func (p *parser) findLabelSymbol(loc logger.Loc, name string) (ref js_ast.Ref, i
 	}
 
 	r := js_lexer.RangeOfIdentifier(p.source, loc)
-	p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("There is no containing label named %q", name))
+	p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("There is no containing label named %q", name))
 
 	// Allocate an "unbound" symbol
 	ref = p.newSymbol(js_ast.SymbolUnbound, name)
@@ -7939,7 +8037,7 @@ func (p *parser) visitBinding(binding js_ast.Binding, opts bindingOpts) {
 		p.validateDeclaredSymbolName(binding.Loc, name)
 		if opts.duplicateArgCheck != nil {
 			if opts.duplicateArgCheck[name] {
-				p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, binding.Loc),
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, binding.Loc),
 					fmt.Sprintf("%q cannot be bound multiple times in the same parameter list", name))
 			}
 			opts.duplicateArgCheck[name] = true
@@ -8459,7 +8557,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 				// non-local symbols as errors in JavaScript.
 				if !p.options.ts.Parse {
 					r := js_lexer.RangeOfIdentifier(p.source, item.Name.Loc)
-					p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("%q is not declared in this file", name))
+					p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("%q is not declared in this file", name))
 				}
 				continue
 			}
@@ -8596,7 +8694,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			s.Label.Ref, _, _ = p.findLabelSymbol(s.Label.Loc, name)
 		} else if !p.fnOrArrowDataVisit.isInsideLoop && !p.fnOrArrowDataVisit.isInsideSwitch {
 			r := js_lexer.RangeOfIdentifier(p.source, stmt.Loc)
-			p.log.AddRangeError(&p.tracker, r, "Cannot use \"break\" here")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot use \"break\" here")
 		}
 
 	case *js_ast.SContinue:
@@ -8606,11 +8704,11 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			s.Label.Ref, isLoop, ok = p.findLabelSymbol(s.Label.Loc, name)
 			if ok && !isLoop {
 				r := js_lexer.RangeOfIdentifier(p.source, s.Label.Loc)
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Cannot continue to label \"%s\"", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot continue to label \"%s\"", name))
 			}
 		} else if !p.fnOrArrowDataVisit.isInsideLoop {
 			r := js_lexer.RangeOfIdentifier(p.source, stmt.Loc)
-			p.log.AddRangeError(&p.tracker, r, "Cannot use \"continue\" here")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot use \"continue\" here")
 		}
 
 	case *js_ast.SLabel:
@@ -8625,7 +8723,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		// Duplicate labels are an error
 		for scope := p.currentScope.Parent; scope != nil; scope = scope.Parent {
 			if scope.Label.Ref != js_ast.InvalidRef && name == p.symbols[scope.Label.Ref.InnerIndex].OriginalName {
-				p.log.AddRangeErrorWithNotes(&p.tracker, js_lexer.RangeOfIdentifier(p.source, s.Name.Loc),
+				p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, s.Name.Loc),
 					fmt.Sprintf("Duplicate label %q", name),
 					[]logger.MsgData{logger.RangeData(&p.tracker, js_lexer.RangeOfIdentifier(p.source, scope.Label.Loc),
 						fmt.Sprintf("The original label %q is here", name))})
@@ -8734,7 +8832,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		// Forbid top-level return inside modules with ECMAScript syntax
 		if p.fnOrArrowDataVisit.isOutsideFnOrArrow {
 			if p.hasESModuleSyntax {
-				p.log.AddRangeErrorWithNotes(&p.tracker, js_lexer.RangeOfIdentifier(p.source, stmt.Loc),
+				p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, stmt.Loc),
 					"Top-level return cannot be used inside an ECMAScript module", p.whyESModule())
 			} else {
 				p.hasTopLevelReturn = true
@@ -9223,8 +9321,10 @@ const (
 // during bundling. Now it is sufficient to just scan the top-level statements
 // instead of having to traverse recursively into the statement tree.
 func (p *parser) maybeRelocateVarsToTopLevel(decls []js_ast.Decl, mode relocateVarsMode) (js_ast.Stmt, bool) {
-	// Only do this when bundling, and not when the scope is already top-level
-	if p.options.mode != config.ModeBundle || p.currentScope == p.moduleScope {
+	// Only do this when bundling or when generating SystemJS code (which hoists
+	// top-level variables out of the "execute" function), and not when the
+	// scope is already top-level
+	if (p.options.mode != config.ModeBundle && p.options.outputFormat != config.FormatSystem) || p.currentScope == p.moduleScope {
 		return js_ast.Stmt{}, false
 	}
 
@@ -9498,6 +9598,17 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class) js_ast
 		}
 	}
 
+	// Private members with JavaScript decorators are always lowered because the
+	// decorators may replace them, and because the decorator context needs to be
+	// able to access them from outside the class body.
+	if !p.useLegacyDecorators() {
+		for _, prop := range class.Properties {
+			if private, ok := prop.Key.Data.(*js_ast.EPrivateIdentifier); ok && len(prop.TSDecorators) > 0 {
+				p.symbols[private.Ref.InnerIndex].PrivateSymbolMustBeLowered = true
+			}
+		}
+	}
+
 	// Conservatively lower all private names that have been used in a private
 	// brand check anywhere in the file. See the comment on this map for details.
 	if p.classPrivateBrandChecksToLower != nil {
@@ -9706,7 +9817,7 @@ func (p *parser) visitArgs(args []js_ast.Arg, opts visitArgsOpts) {
 	// FunctionBodyContainsUseStrict of FunctionBody is true and
 	// IsSimpleParameterList of FormalParameters is false."
 	if hasUseStrict && !hasSimpleArgs {
-		p.log.AddRangeError(&p.tracker, p.source.RangeOfString(useStrictLoc),
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.source.RangeOfString(useStrictLoc),
 			"Cannot use a \"use strict\" directive in a function with a non-simple parameter list")
 	}
 
@@ -9842,7 +9953,7 @@ func (p *parser) checkForUnrepresentableIdentifier(loc logger.Loc, name string)
 			p.unrepresentableIdentifiers[name] = true
 			where, notes := p.prettyPrintTargetEnvironment(compat.UnicodeEscapes)
 			r := js_lexer.RangeOfIdentifier(p.source, loc)
-			p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf("%q cannot be escaped in %s but you "+
+			p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("%q cannot be escaped in %s but you "+
 				"can set the charset to \"utf8\" to allow unescaped Unicode characters", name, where), notes)
 		}
 	}
@@ -9861,9 +9972,9 @@ func (p *parser) warnAboutTypeofAndString(a js_ast.Expr, b js_ast.Expr) {
 				r := p.source.RangeOfString(b.Loc)
 				text := fmt.Sprintf("The \"typeof\" operator will never evaluate to %q", value)
 				if !p.suppressWarningsAboutWeirdCode {
-					p.log.AddRangeWarning(&p.tracker, r, text)
+					p.log.AddID(logger.MsgID_JS_ImpossibleTypeof, logger.Warning, &p.tracker, r, text)
 				} else {
-					p.log.AddRangeDebug(&p.tracker, r, text)
+					p.log.AddID(logger.MsgID_JS_ImpossibleTypeof, logger.Debug, &p.tracker, r, text)
 				}
 			}
 		}
@@ -9908,9 +10019,9 @@ func (p *parser) warnAboutEqualityCheck(op string, value js_ast.Expr, afterOpLoc
 				text = "Comparison with -0 using a case clause will also match 0"
 			}
 			if !p.suppressWarningsAboutWeirdCode {
-				p.log.AddRangeWarning(&p.tracker, r, text)
+				p.log.AddID(logger.MsgID_JS_EqualsNegativeZero, logger.Warning, &p.tracker, r, text)
 			} else {
-				p.log.AddRangeDebug(&p.tracker, r, text)
+				p.log.AddID(logger.MsgID_JS_EqualsNegativeZero, logger.Debug, &p.tracker, r, text)
 			}
 			return true
 		}
@@ -9923,9 +10034,9 @@ func (p *parser) warnAboutEqualityCheck(op string, value js_ast.Expr, afterOpLoc
 			}
 			r := p.source.RangeOfOperatorBefore(afterOpLoc, op)
 			if !p.suppressWarningsAboutWeirdCode {
-				p.log.AddRangeWarning(&p.tracker, r, text)
+				p.log.AddID(logger.MsgID_JS_EqualsNaN, logger.Warning, &p.tracker, r, text)
 			} else {
-				p.log.AddRangeDebug(&p.tracker, r, text)
+				p.log.AddID(logger.MsgID_JS_EqualsNaN, logger.Debug, &p.tracker, r, text)
 			}
 			return true
 		}
@@ -9943,9 +10054,9 @@ func (p *parser) warnAboutEqualityCheck(op string, value js_ast.Expr, afterOpLoc
 			}
 			r := p.source.RangeOfOperatorBefore(afterOpLoc, op)
 			if !p.suppressWarningsAboutWeirdCode {
-				p.log.AddRangeWarning(&p.tracker, r, text)
+				p.log.AddID(logger.MsgID_JS_EqualsNewObject, logger.Warning, &p.tracker, r, text)
 			} else {
-				p.log.AddRangeDebug(&p.tracker, r, text)
+				p.log.AddID(logger.MsgID_JS_EqualsNewObject, logger.Debug, &p.tracker, r, text)
 			}
 			return true
 		}
@@ -10365,9 +10476,9 @@ func (p *parser) valueForThis(
 
 					// Show the warning as a debug message if we're in "node_modules"
 					if !p.suppressWarningsAboutWeirdCode {
-						p.log.AddRangeWarningWithNotes(&p.tracker, r, text, notes)
+						p.log.AddIDWithNotes(logger.MsgID_JS_ThisIsUndefinedInESM, logger.Warning, &p.tracker, r, text, notes)
 					} else {
-						p.log.AddRangeDebugWithNotes(&p.tracker, r, text, notes)
+						p.log.AddIDWithNotes(logger.MsgID_JS_ThisIsUndefinedInESM, logger.Debug, &p.tracker, r, text, notes)
 					}
 				}
 
@@ -10517,7 +10628,7 @@ func (p *parser) mangleTemplate(loc logger.Loc, e *js_ast.ETemplate) js_ast.Expr
 // for the caller to pass along extra data. This is mostly for optional chaining.
 func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprOut) {
 	if in.assignTarget != js_ast.AssignTargetNone && !p.isValidAssignmentTarget(expr) {
-		p.log.AddError(&p.tracker, expr.Loc, "Invalid assignment target")
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: expr.Loc}, "Invalid assignment target")
 	}
 
 	switch e := expr.Data.(type) {
@@ -10527,13 +10638,13 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 	case *js_ast.ENewTarget:
 		if !p.fnOnlyDataVisit.isNewTargetAllowed {
-			p.log.AddRangeError(&p.tracker, e.Range, "Cannot use \"new.target\" here")
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, e.Range, "Cannot use \"new.target\" here")
 		}
 
 	case *js_ast.EString:
 		if e.LegacyOctalLoc.Start > 0 {
 			if e.PreferTemplate {
-				p.log.AddRangeError(&p.tracker, p.source.RangeOfLegacyOctalEscape(e.LegacyOctalLoc),
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.source.RangeOfLegacyOctalEscape(e.LegacyOctalLoc),
 					"Legacy octal escape sequences cannot be used in template literals")
 			} else if p.isStrictMode() {
 				p.markStrictModeFeature(legacyOctalEscape, p.source.RangeOfLegacyOctalEscape(e.LegacyOctalLoc), "")
@@ -10603,6 +10714,8 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 		// Handle assigning to a constant
 		if in.assignTarget != js_ast.AssignTargetNone {
+			p.symbols[result.ref.InnerIndex].CouldPotentiallyBeMutated = true
+
 			switch p.symbols[result.ref.InnerIndex].Kind {
 			case js_ast.SymbolConst:
 				r := js_lexer.RangeOfIdentifier(p.source, expr.Loc)
@@ -10612,9 +10725,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				// Make this an error when bundling because we may need to convert this
 				// "const" into a "var" during bundling.
 				if p.options.mode == config.ModeBundle {
-					p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf("Cannot assign to %q because it is a constant", name), notes)
+					p.log.AddIDWithNotes(logger.MsgID_JS_AssignToConstant, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot assign to %q because it is a constant", name), notes)
 				} else {
-					p.log.AddRangeWarningWithNotes(&p.tracker, r, fmt.Sprintf("This assignment will throw because %q is a constant", name), notes)
+					p.log.AddIDWithNotes(logger.MsgID_JS_AssignToConstant, logger.Warning, &p.tracker, r, fmt.Sprintf("This assignment will throw because %q is a constant", name), notes)
 				}
 
 			case js_ast.SymbolInjected:
@@ -10623,7 +10736,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					tracker := logger.MakeLineColumnTracker(&where.source)
 					notes := []logger.MsgData{logger.RangeData(&tracker, js_lexer.RangeOfIdentifier(where.source, where.loc),
 						fmt.Sprintf("%q was exported from %q here", name, where.source.PrettyPath))}
-					p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf("Cannot assign to %q because it's an import from an injected file", name), notes)
+					p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot assign to %q because it's an import from an injected file", name), notes)
 				}
 			}
 		}
@@ -10742,7 +10855,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 	case *js_ast.ETemplate:
 		if e.LegacyOctalLoc.Start > 0 {
-			p.log.AddRangeError(&p.tracker, p.source.RangeOfLegacyOctalEscape(e.LegacyOctalLoc),
+			p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.source.RangeOfLegacyOctalEscape(e.LegacyOctalLoc),
 				"Legacy octal escape sequences cannot be used in template literals")
 		}
 
@@ -10813,7 +10926,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			symbol := &p.symbols[result.ref.InnerIndex]
 			if !symbol.Kind.IsPrivate() {
 				r := logger.Range{Loc: e.Left.Loc, Len: int32(len(name))}
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Private name %q must be declared in an enclosing class", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Private name %q must be declared in an enclosing class", name))
 			}
 
 			e.Right = p.visitExpr(e.Right)
@@ -11331,7 +11444,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			kind := p.symbols[result.ref.InnerIndex].Kind
 			if !kind.IsPrivate() {
 				r := logger.Range{Loc: e.Index.Loc, Len: int32(len(name))}
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Private name %q must be declared in an enclosing class", name))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Private name %q must be declared in an enclosing class", name))
 			} else {
 				var r logger.Range
 				var text string
@@ -11347,9 +11460,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				}
 				if text != "" {
 					if !p.suppressWarningsAboutWeirdCode {
-						p.log.AddRangeWarning(&p.tracker, r, text)
+						p.log.AddID(logger.MsgID_JS_PrivateNameWillThrow, logger.Warning, &p.tracker, r, text)
 					} else {
-						p.log.AddRangeDebug(&p.tracker, r, text)
+						p.log.AddID(logger.MsgID_JS_PrivateNameWillThrow, logger.Debug, &p.tracker, r, text)
 					}
 				}
 			}
@@ -11402,7 +11515,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		if p.options.mode == config.ModeBundle && (in.assignTarget != js_ast.AssignTargetNone || isDeleteTarget) {
 			if id, ok := e.Target.Data.(*js_ast.EIdentifier); ok && p.symbols[id.Ref.InnerIndex].Kind == js_ast.SymbolImport {
 				r := js_lexer.RangeOfIdentifier(p.source, e.Target.Loc)
-				p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Cannot assign to property on import %q", p.symbols[id.Ref.InnerIndex].OriginalName))
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot assign to property on import %q", p.symbols[id.Ref.InnerIndex].OriginalName))
 			}
 		}
 
@@ -11444,9 +11557,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				r := js_lexer.RangeOfIdentifier(p.source, superPropLoc)
 				text := "Attempting to delete a property of \"super\" will throw a ReferenceError"
 				if !p.suppressWarningsAboutWeirdCode {
-					p.log.AddRangeWarning(&p.tracker, r, text)
+					p.log.AddID(logger.MsgID_JS_DeleteSuperProperty, logger.Warning, &p.tracker, r, text)
 				} else {
-					p.log.AddRangeDebug(&p.tracker, r, text)
+					p.log.AddID(logger.MsgID_JS_DeleteSuperProperty, logger.Debug, &p.tracker, r, text)
 				}
 			}
 
@@ -11718,7 +11831,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 	case *js_ast.EArray:
 		if in.assignTarget != js_ast.AssignTargetNone {
 			if e.CommaAfterSpread.Start != 0 {
-				p.log.AddRangeError(&p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
 			p.markSyntaxFeature(compat.Destructuring, logger.Range{Loc: expr.Loc, Len: 1})
 		}
@@ -11757,7 +11870,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 	case *js_ast.EObject:
 		if in.assignTarget != js_ast.AssignTargetNone {
 			if e.CommaAfterSpread.Start != 0 {
-				p.log.AddRangeError(&p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
+				p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
 			p.markSyntaxFeature(compat.Destructuring, logger.Range{Loc: expr.Loc, Len: 1})
 		}
@@ -11775,7 +11888,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					if str, ok := key.Data.(*js_ast.EString); ok && js_lexer.UTF16EqualsString(str.Value, "__proto__") {
 						if hasProto {
 							r := js_lexer.RangeOfIdentifier(p.source, key.Loc)
-							p.log.AddRangeError(&p.tracker, r, "Cannot specify the \"__proto__\" property more than once per object")
+							p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, "Cannot specify the \"__proto__\" property more than once per object")
 						}
 						hasProto = true
 					}
@@ -11847,7 +11960,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 								nextKey.kind = keyGetAndSet
 							} else {
 								r := js_lexer.RangeOfIdentifier(p.source, property.Key.Loc)
-								p.log.AddRangeWarningWithNotes(&p.tracker, r, fmt.Sprintf("Duplicate key %q in object literal", key),
+								p.log.AddIDWithNotes(logger.MsgID_JS_DuplicateObjectKey, logger.Warning, &p.tracker, r, fmt.Sprintf("Duplicate key %q in object literal", key),
 									[]logger.MsgData{logger.RangeData(&p.tracker, js_lexer.RangeOfIdentifier(p.source, prevKey.loc),
 										fmt.Sprintf("The original %q is here", key))})
 							}
@@ -11989,9 +12102,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				if p.options.mode == config.ModeBundle {
 					text := "This \"import()\" was not recognized because " + why
 					if !p.suppressWarningsAboutWeirdCode {
-						p.log.AddWarning(&p.tracker, whyLoc, text)
+						p.log.AddID(logger.MsgID_JS_UnsupportedDynamicImport, logger.Warning, &p.tracker, logger.Range{Loc: whyLoc}, text)
 					} else {
-						p.log.AddDebug(&p.tracker, whyLoc, text)
+						p.log.AddID(logger.MsgID_JS_UnsupportedDynamicImport, logger.Debug, &p.tracker, logger.Range{Loc: whyLoc}, text)
 					}
 				}
 
@@ -12035,7 +12148,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 			// Use a debug log so people can see this if they want to
 			r := js_lexer.RangeOfIdentifier(p.source, expr.Loc)
-			p.log.AddRangeDebug(&p.tracker, r,
+			p.log.AddID(logger.MsgID_JS_UnsupportedDynamicImport, logger.Debug, &p.tracker, r,
 				"This \"import\" expression will not be bundled because the argument is not a string literal")
 
 			// We need to convert this into a call to "require()" if ES6 syntax is
@@ -12211,9 +12324,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					if p.options.mode == config.ModeBundle {
 						text := "Using direct eval with a bundler is not recommended and may cause problems (more info: https://esbuild.github.io/link/direct-eval)"
 						if p.hasESModuleSyntax && !p.suppressWarningsAboutWeirdCode {
-							p.log.AddRangeWarning(&p.tracker, js_lexer.RangeOfIdentifier(p.source, e.Target.Loc), text)
+							p.log.AddID(logger.MsgID_JS_DirectEval, logger.Warning, &p.tracker, js_lexer.RangeOfIdentifier(p.source, e.Target.Loc), text)
 						} else {
-							p.log.AddRangeDebug(&p.tracker, js_lexer.RangeOfIdentifier(p.source, e.Target.Loc), text)
+							p.log.AddID(logger.MsgID_JS_DirectEval, logger.Debug, &p.tracker, js_lexer.RangeOfIdentifier(p.source, e.Target.Loc), text)
 						}
 					}
 				}
@@ -12296,7 +12409,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 							// Use a debug log so people can see this if they want to
 							r := js_lexer.RangeOfIdentifier(p.source, e.Target.Loc)
-							p.log.AddRangeDebug(&p.tracker, r,
+							p.log.AddID(logger.MsgID_JS_UnsupportedRequireCall, logger.Debug, &p.tracker, r,
 								"This call to \"require\" will not be bundled because the argument is not a string literal")
 
 							// Otherwise just return a clone of the "require()" call
@@ -12308,7 +12421,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					} else {
 						r := js_lexer.RangeOfIdentifier(p.source, e.Target.Loc)
 						text := fmt.Sprintf("This call to \"require\" will not be bundled because it has %d arguments", len(e.Args))
-						p.log.AddRangeDebug(&p.tracker, r, text)
+						p.log.AddID(logger.MsgID_JS_UnsupportedRequireCall, logger.Debug, &p.tracker, r, text)
 					}
 
 					return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
@@ -12317,11 +12430,28 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					}}, exprOut{}
 				} else if p.options.outputFormat == config.FormatESModule && !omitWarnings {
 					r := js_lexer.RangeOfIdentifier(p.source, e.Target.Loc)
-					p.log.AddRangeWarning(&p.tracker, r, "Converting \"require\" to \"esm\" is currently not supported")
+					p.log.AddID(logger.MsgID_JS_UnsupportedRequireCall, logger.Warning, &p.tracker, r, "Converting \"require\" to \"esm\" is currently not supported")
 				}
 			}
 		}
 
+		// Track simple calls to identifiers so the linker can potentially inline
+		// the called function if it turns out to be small enough
+		if e.OptionalChain == js_ast.OptionalChainNone && !p.isControlFlowDead && js_ast.CallArgsCanBeInlined(e.Args) {
+			ref := js_ast.InvalidRef
+			switch t := e.Target.Data.(type) {
+			case *js_ast.EIdentifier:
+				ref = t.Ref
+			case *js_ast.EImportIdentifier:
+				ref = t.Ref
+			}
+			if ref != js_ast.InvalidRef {
+				use := p.symbolCallUses[ref]
+				use.CallCountEstimate++
+				p.symbolCallUses[ref] = use
+			}
+		}
+
 		out = exprOut{
 			childContainsOptionalChain: containsOptionalChain,
 			thisArgFunc:                out.thisArgFunc,
@@ -12478,7 +12608,7 @@ func (p *parser) warnAboutImportNamespaceCall(target js_ast.Expr, kind importNam
 				noun = "component"
 			}
 
-			p.log.AddRangeWarningWithNotes(&p.tracker, r, fmt.Sprintf(
+			p.log.AddIDWithNotes(logger.MsgID_JS_CallImportNamespace, logger.Warning, &p.tracker, r, fmt.Sprintf(
 				"%s %q%s will crash at run-time because it's an import namespace object, not a %s%s",
 				verb,
 				p.symbols[id.Ref.InnerIndex].OriginalName,
@@ -12527,7 +12657,7 @@ func (p *parser) handleIdentifier(loc logger.Loc, e *js_ast.EIdentifier, opts id
 	if p.options.mode == config.ModeBundle && (opts.assignTarget != js_ast.AssignTargetNone || opts.isDeleteTarget) && p.symbols[ref.InnerIndex].Kind == js_ast.SymbolImport {
 		// Create an error for assigning to an import namespace
 		r := js_lexer.RangeOfIdentifier(p.source, loc)
-		p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("Cannot assign to import %q", p.symbols[ref.InnerIndex].OriginalName))
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, r, fmt.Sprintf("Cannot assign to import %q", p.symbols[ref.InnerIndex].OriginalName))
 	}
 
 	// Substitute an EImportIdentifier now if this is an import item
@@ -12617,12 +12747,12 @@ func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc) {
 func (p *parser) recordExport(loc logger.Loc, alias string, ref js_ast.Ref) {
 	if name, ok := p.namedExports[alias]; ok {
 		// Duplicate exports are an error
-		p.log.AddRangeErrorWithNotes(&p.tracker, js_lexer.RangeOfIdentifier(p.source, loc),
+		p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, loc),
 			fmt.Sprintf("Multiple exports with the same name %q", alias),
 			[]logger.MsgData{logger.RangeData(&p.tracker, js_lexer.RangeOfIdentifier(p.source, name.AliasLoc),
 				fmt.Sprintf("%q was originally exported here", alias))})
 	} else if alias == "__esModule" {
-		p.log.AddRangeError(&p.tracker, js_lexer.RangeOfIdentifier(p.source, loc),
+		p.log.AddID(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, loc),
 			"The export name \"__esModule\" is reserved and cannot be used (it's needed as an export marker when converting ES module syntax to CommonJS)")
 	} else {
 		p.namedExports[alias] = js_ast.NamedExport{AliasLoc: loc, Ref: ref}
@@ -13088,13 +13218,15 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 
 func (p *parser) appendPart(parts []js_ast.Part, stmts []js_ast.Stmt) []js_ast.Part {
 	p.symbolUses = make(map[js_ast.Ref]js_ast.SymbolUse)
+	p.symbolCallUses = make(map[js_ast.Ref]js_ast.SymbolCallUse)
 	p.declaredSymbols = nil
 	p.importRecordsForCurrentPart = nil
 	p.scopesForCurrentPart = nil
 	part := js_ast.Part{
 		Stmts: p.visitStmtsAndPrependTempRefs(stmts, prependTempRefsOpts{}),
 
-		SymbolUses: p.symbolUses,
+		SymbolUses:     p.symbolUses,
+		SymbolCallUses: p.symbolCallUses,
 	}
 
 	// Insert any relocated variable statements now
@@ -13125,6 +13257,9 @@ func (p *parser) appendPart(parts []js_ast.Part, stmts []js_ast.Stmt) []js_ast.P
 
 	if len(part.Stmts) > 0 {
 		part.CanBeRemovedIfUnused = p.stmtsCanBeRemovedIfUnused(part.Stmts)
+		if p.options.mode == config.ModeBundle && p.options.inferPureFunctions && !p.options.ignoreDCEAnnotations {
+			p.analyzePartPurity(&part)
+		}
 		part.DeclaredSymbols = p.declaredSymbols
 		part.ImportRecordIndices = p.importRecordsForCurrentPart
 		part.Scopes = p.scopesForCurrentPart
@@ -13337,7 +13472,7 @@ func (p *parser) exprCanBeRemovedIfUnused(expr js_ast.Expr) bool {
 	case *js_ast.ECall:
 		// A call that has been marked "__PURE__" can be removed if all arguments
 		// can be removed. The annotation causes us to ignore the target.
-		if e.CanBeUnwrappedIfUnused {
+		if e.CanBeUnwrappedIfUnused || (e.OptionalChain == js_ast.OptionalChainNone && p.assumeCalleeIsPure(e.Target)) {
 			for _, arg := range e.Args {
 				if !p.exprCanBeRemovedIfUnused(arg) {
 					return false
@@ -13349,7 +13484,7 @@ func (p *parser) exprCanBeRemovedIfUnused(expr js_ast.Expr) bool {
 	case *js_ast.ENew:
 		// A constructor call that has been marked "__PURE__" can be removed if all
 		// arguments can be removed. The annotation causes us to ignore the target.
-		if e.CanBeUnwrappedIfUnused {
+		if e.CanBeUnwrappedIfUnused || p.assumeCalleeIsPure(e.Target) {
 			for _, arg := range e.Args {
 				if !p.exprCanBeRemovedIfUnused(arg) {
 					return false
@@ -13380,6 +13515,241 @@ func (p *parser) exprCanBeRemovedIfUnused(expr js_ast.Expr) bool {
 	return false
 }
 
+// During purity analysis, calls to bound identifiers are assumed to be pure
+// and are recorded so that the linker can verify this later on
+func (p *parser) assumeCalleeIsPure(target js_ast.Expr) bool {
+	if p.calleesAssumedPure == nil {
+		return false
+	}
+
+	var ref js_ast.Ref
+	switch e := target.Data.(type) {
+	case *js_ast.EIdentifier:
+		if e.MustKeepDueToWithStmt || p.symbols[e.Ref.InnerIndex].Kind == js_ast.SymbolUnbound {
+			return false
+		}
+		ref = e.Ref
+
+	case *js_ast.EImportIdentifier:
+		ref = e.Ref
+
+	default:
+		return false
+	}
+
+	*p.calleesAssumedPure = append(*p.calleesAssumedPure, ref)
+	return true
+}
+
+// This records which other top-level functions and classes would have to be
+// pure for this part to be pure. The parser can only see a single file, so
+// the linker is responsible for resolving these calls across files.
+func (p *parser) analyzePartPurity(part *js_ast.Part) {
+	var callees []js_ast.Ref
+	p.calleesAssumedPure = &callees
+
+	if !part.CanBeRemovedIfUnused {
+		// Check whether the statements only have side effects due to calls
+		if p.stmtsCanBeRemovedIfUnused(part.Stmts) {
+			part.CanBeRemovedIfCalleesArePure = true
+			part.PureCallees = callees
+		}
+	} else if len(part.Stmts) == 1 {
+		// Check whether this part declares a function or class that is pure
+		isPure := false
+		switch s := part.Stmts[0].Data.(type) {
+		case *js_ast.SFunction:
+			isPure = p.fnIsPureToCall(s.Fn)
+
+		case *js_ast.SClass:
+			isPure = p.classIsPureToConstruct(s.Class)
+
+		case *js_ast.SLocal:
+			// The linker will check that the symbol is never reassigned
+			if len(s.Decls) == 1 && s.Decls[0].ValueOrNil.Data != nil {
+				if _, ok := s.Decls[0].Binding.Data.(*js_ast.BIdentifier); ok {
+					switch e := s.Decls[0].ValueOrNil.Data.(type) {
+					case *js_ast.EFunction:
+						isPure = p.fnIsPureToCall(e.Fn)
+
+					case *js_ast.EArrow:
+						isPure = !e.IsAsync && p.fnBodyIsPure(e.Args, e.Body.Stmts, nil)
+
+					case *js_ast.EClass:
+						isPure = p.classIsPureToConstruct(e.Class)
+					}
+				}
+			}
+		}
+		if isPure {
+			part.IsPureFunction = true
+			part.PureCallees = callees
+		}
+	}
+
+	p.calleesAssumedPure = nil
+}
+
+func (p *parser) fnIsPureToCall(fn js_ast.Fn) bool {
+	// Calling an async function or a generator function does not run the body
+	// synchronously, but it's not worth special-casing these
+	return !fn.IsAsync && !fn.IsGenerator && p.fnBodyIsPure(fn.Args, fn.Body.Stmts, nil)
+}
+
+func (p *parser) classIsPureToConstruct(class js_ast.Class) bool {
+	// Calling the base class constructor could have side effects. Classes that
+	// extend anything are rejected since assigning to a property on "this" could
+	// also call a setter that was inherited from the base class.
+	if class.ExtendsOrNil.Data != nil || len(class.TSDecorators) > 0 {
+		return false
+	}
+
+	// Assigning to a property on "this" calls the setter if there is an
+	// accessor with the same name, so remember all accessor names
+	accessors := make(map[string]bool)
+	for _, property := range class.Properties {
+		if property.IsStatic || (property.Kind != js_ast.PropertyGet && property.Kind != js_ast.PropertySet) {
+			continue
+		}
+		str, ok := property.Key.Data.(*js_ast.EString)
+		if !ok || property.IsComputed {
+			// We don't know the name of this accessor
+			return false
+		}
+		accessors[js_lexer.UTF16ToString(str.Value)] = true
+	}
+
+	var ctor *js_ast.EFunction
+	for _, property := range class.Properties {
+		if len(property.TSDecorators) > 0 {
+			return false
+		}
+		if property.IsStatic {
+			// Static properties are evaluated when the class is declared, which is
+			// already covered by "CanBeRemovedIfUnused" for the enclosing part
+			continue
+		}
+
+		if property.IsMethod {
+			if str, ok := property.Key.Data.(*js_ast.EString); ok && !property.IsComputed && js_lexer.UTF16EqualsString(str.Value, "constructor") {
+				ctor, _ = property.ValueOrNil.Data.(*js_ast.EFunction)
+			}
+			continue
+		}
+
+		// Instance fields may be initialized using assignment instead of being
+		// defined, which would call the setter of an accessor with that name
+		if str, ok := property.Key.Data.(*js_ast.EString); ok && accessors[js_lexer.UTF16ToString(str.Value)] {
+			return false
+		}
+
+		// Instance fields are initialized every time the class is constructed
+		if property.InitializerOrNil.Data != nil && !p.exprCanBeRemovedIfUnused(property.InitializerOrNil) {
+			return false
+		}
+	}
+
+	return ctor == nil || p.fnBodyIsPure(ctor.Fn.Args, ctor.Fn.Body.Stmts, accessors)
+}
+
+// A function body is pure if calling the function has no observable side
+// effects other than returning a value. Assigning to local variables is fine
+// but assigning to anything else is not. Constructors may also assign to
+// properties on "this" since that object is created by the call, except for
+// properties with an accessor. "classAccessors" is nil for non-constructors.
+func (p *parser) fnBodyIsPure(args []js_ast.Arg, stmts []js_ast.Stmt, classAccessors map[string]bool) bool {
+	locals := make(map[js_ast.Ref]bool)
+
+	for _, arg := range args {
+		id, ok := arg.Binding.Data.(*js_ast.BIdentifier)
+		if !ok || len(arg.TSDecorators) > 0 {
+			return false
+		}
+		if arg.DefaultOrNil.Data != nil && !p.exprCanBeRemovedIfUnused(arg.DefaultOrNil) {
+			return false
+		}
+		locals[id.Ref] = true
+	}
+
+	return p.fnStmtsArePure(stmts, locals, classAccessors)
+}
+
+func (p *parser) fnStmtsArePure(stmts []js_ast.Stmt, locals map[js_ast.Ref]bool, classAccessors map[string]bool) bool {
+	for _, stmt := range stmts {
+		switch s := stmt.Data.(type) {
+		case *js_ast.SEmpty, *js_ast.SDirective, *js_ast.SFunction:
+			// These never have side effects
+
+		case *js_ast.SLocal:
+			for _, decl := range s.Decls {
+				id, ok := decl.Binding.Data.(*js_ast.BIdentifier)
+				if !ok {
+					return false
+				}
+				if decl.ValueOrNil.Data != nil && !p.exprCanBeRemovedIfUnused(decl.ValueOrNil) {
+					return false
+				}
+				locals[id.Ref] = true
+			}
+
+		case *js_ast.SExpr:
+			if !p.fnExprIsPure(s.Value, locals, classAccessors) {
+				return false
+			}
+
+		case *js_ast.SReturn:
+			if s.ValueOrNil.Data != nil && !p.exprCanBeRemovedIfUnused(s.ValueOrNil) {
+				return false
+			}
+
+		case *js_ast.SIf:
+			if !p.exprCanBeRemovedIfUnused(s.Test) || !p.fnStmtsArePure([]js_ast.Stmt{s.Yes}, locals, classAccessors) {
+				return false
+			}
+			if s.NoOrNil.Data != nil && !p.fnStmtsArePure([]js_ast.Stmt{s.NoOrNil}, locals, classAccessors) {
+				return false
+			}
+
+		case *js_ast.SBlock:
+			if !p.fnStmtsArePure(s.Stmts, locals, classAccessors) {
+				return false
+			}
+
+		default:
+			// Assume that all statements not explicitly special-cased here have side
+			// effects (e.g. loops may never terminate and "throw" is observable)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *parser) fnExprIsPure(expr js_ast.Expr, locals map[js_ast.Ref]bool, classAccessors map[string]bool) bool {
+	if e, ok := expr.Data.(*js_ast.EBinary); ok {
+		switch e.Op {
+		case js_ast.BinOpComma:
+			return p.fnExprIsPure(e.Left, locals, classAccessors) && p.fnExprIsPure(e.Right, locals, classAccessors)
+
+		case js_ast.BinOpAssign:
+			switch target := e.Left.Data.(type) {
+			case *js_ast.EIdentifier:
+				if locals[target.Ref] {
+					return p.exprCanBeRemovedIfUnused(e.Right)
+				}
+
+			case *js_ast.EDot:
+				if _, ok := target.Target.Data.(*js_ast.EThis); ok && classAccessors != nil && !classAccessors[target.Name] && target.OptionalChain == js_ast.OptionalChainNone {
+					return p.exprCanBeRemovedIfUnused(e.Right)
+				}
+			}
+			return false
+		}
+	}
+
+	return p.exprCanBeRemovedIfUnused(expr)
+}
+
 // This will return a nil expression if the expression can be totally removed
 func (p *parser) simplifyUnusedExpr(expr js_ast.Expr) js_ast.Expr {
 	switch e := expr.Data.(type) {
@@ -13668,6 +14038,374 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 var defaultJSXFactory = []string{"React", "createElement"}
 var defaultJSXFragment = []string{"React", "Fragment"}
 
+type commonJSExportKind uint8
+
+const (
+	commonJSExportNone commonJSExportKind = iota
+
+	// "exports.foo = bar" or "module.exports.foo = bar"
+	commonJSExportAssign
+
+	// "module.exports = { foo: bar }" is split into one of these per property
+	commonJSExportProperty
+
+	// "exports.__esModule = true" or "Object.defineProperty(exports, '__esModule', { value: true })"
+	commonJSExportESModuleMarker
+)
+
+type commonJSExport struct {
+	alias      string
+	aliasLoc   logger.Loc
+	ref        js_ast.Ref
+	partIndex  int
+	kind       commonJSExportKind
+	isDecl     bool // This is the first assignment, which became "var foo = bar"
+	isLast     bool // This is the last property of "module.exports = {...}"
+	usesModule bool // This was "module.exports" instead of "exports"
+}
+
+// Returns true for either "exports" or "module.exports". This runs before the
+// visit pass, so identifiers are still unresolved and must be checked by name.
+func (p *parser) isCommonJSExportsObject(expr js_ast.Expr) (ok bool, usesModule bool) {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		return p.loadNameFromRef(e.Ref) == "exports", false
+
+	case *js_ast.EDot:
+		if id, ok := e.Target.Data.(*js_ast.EIdentifier); ok && e.Name == "exports" && e.OptionalChain == js_ast.OptionalChainNone {
+			return p.loadNameFromRef(id.Ref) == "module", true
+		}
+	}
+	return false, false
+}
+
+func isCommonJSTrue(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EBoolean:
+		return e.Value
+
+	case *js_ast.EUnary:
+		// Minifiers turn "true" into "!0"
+		if number, ok := e.Value.Data.(*js_ast.ENumber); ok && e.Op == js_ast.UnOpNot {
+			return number.Value == 0
+		}
+	}
+	return false
+}
+
+// "Object.defineProperty(exports, '__esModule', { value: true })"
+func (p *parser) isCommonJSESModuleMarkerCall(expr js_ast.Expr) bool {
+	call, ok := expr.Data.(*js_ast.ECall)
+	if !ok || len(call.Args) != 3 || call.OptionalChain != js_ast.OptionalChainNone {
+		return false
+	}
+	if dot, ok := call.Target.Data.(*js_ast.EDot); !ok || dot.Name != "defineProperty" || dot.OptionalChain != js_ast.OptionalChainNone {
+		return false
+	} else if id, ok := dot.Target.Data.(*js_ast.EIdentifier); !ok || p.loadNameFromRef(id.Ref) != "Object" {
+		return false
+	} else if _, ok := p.moduleScope.Members["Object"]; ok {
+		return false
+	}
+	if ok, _ := p.isCommonJSExportsObject(call.Args[0]); !ok {
+		return false
+	}
+	if str, ok := call.Args[1].Data.(*js_ast.EString); !ok || js_lexer.UTF16ToString(str.Value) != "__esModule" {
+		return false
+	}
+	object, ok := call.Args[2].Data.(*js_ast.EObject)
+	if !ok || len(object.Properties) != 1 {
+		return false
+	}
+	property := object.Properties[0]
+	key, ok := property.Key.Data.(*js_ast.EString)
+	return ok && property.Kind == js_ast.PropertyNormal && !property.IsComputed && !property.IsMethod &&
+		js_lexer.UTF16ToString(key.Value) == "value" && isCommonJSTrue(property.ValueOrNil)
+}
+
+func (p *parser) newCommonJSExportSymbol(alias string) js_ast.Ref {
+	name := alias
+	if !js_lexer.IsIdentifier(name) || js_lexer.Keywords[name] != 0 ||
+		js_lexer.StrictModeReservedWords[name] || isEvalOrArguments(name) {
+		name = js_lexer.ForceValidIdentifier(p.source.IdentifierName + "_" + name)
+	}
+	ref := p.newSymbol(js_ast.SymbolHoisted, name)
+	p.moduleScope.Generated = append(p.moduleScope.Generated, ref)
+	return ref
+}
+
+// Some CommonJS modules only ever assign to properties of "exports" at the
+// top level. These modules don't need to be wrapped in a closure because their
+// exports are statically known, so they can be treated like ESM instead:
+//
+//   exports.foo = 123;            =>  var foo = 123;
+//   module.exports = { bar: 456 } =>  var bar = 456;
+//
+// This runs before the visit pass. It returns the rewritten statements along
+// with a parallel array describing the exports, or nil if the module doesn't
+// qualify. The conversion is only committed after the visit pass confirms
+// that there are no other uses of "exports" or "module".
+func (p *parser) scanForSimpleCommonJS(stmts []js_ast.Stmt) ([]js_ast.Stmt, []commonJSExport) {
+	if p.hasESModuleSyntax || p.exportsOrModuleIdentifierCount == 0 {
+		return stmts, nil
+	}
+
+	// Bail if the code declares its own "exports" or "module" variables
+	if member := p.moduleScope.Members["exports"]; member.Ref != p.exportsRef || member.Loc.Start != -1 {
+		return stmts, nil
+	}
+	if member := p.moduleScope.Members["module"]; member.Ref != p.moduleRef || member.Loc.Start != -1 {
+		return stmts, nil
+	}
+
+	newStmts := make([]js_ast.Stmt, 0, len(stmts))
+	exports := make([]commonJSExport, 0, len(stmts))
+	refs := make(map[string]js_ast.Ref)
+	identifierCount := 0
+	hasAssign := false
+	hasObject := false
+	hasESModuleMarker := false
+
+	for _, stmt := range stmts {
+		if s, ok := stmt.Data.(*js_ast.SExpr); ok {
+			// "Object.defineProperty(exports, '__esModule', { value: true })"
+			if p.isCommonJSESModuleMarkerCall(s.Value) {
+				identifierCount++
+				hasESModuleMarker = true
+				newStmts = append(newStmts, stmt)
+				exports = append(exports, commonJSExport{kind: commonJSExportESModuleMarker})
+				continue
+			}
+
+			if binary, ok := s.Value.Data.(*js_ast.EBinary); ok && binary.Op == js_ast.BinOpAssign {
+				if dot, ok := binary.Left.Data.(*js_ast.EDot); ok && dot.OptionalChain == js_ast.OptionalChainNone {
+					// "exports.foo = bar" or "module.exports.foo = bar"
+					if ok, usesModule := p.isCommonJSExportsObject(dot.Target); ok {
+						identifierCount++
+						if dot.Name == "__esModule" {
+							if !isCommonJSTrue(binary.Right) {
+								return stmts, nil
+							}
+							hasESModuleMarker = true
+							newStmts = append(newStmts, stmt)
+							exports = append(exports, commonJSExport{kind: commonJSExportESModuleMarker, usesModule: usesModule})
+							continue
+						}
+						if dot.Name == "__proto__" {
+							return stmts, nil
+						}
+						hasAssign = true
+						export := commonJSExport{
+							kind:       commonJSExportAssign,
+							alias:      dot.Name,
+							aliasLoc:   dot.NameLoc,
+							usesModule: usesModule,
+						}
+
+						// Subsequent assignments are rewritten after the visit pass
+						if ref, ok := refs[dot.Name]; ok {
+							export.ref = ref
+							newStmts = append(newStmts, stmt)
+							exports = append(exports, export)
+							continue
+						}
+
+						export.ref = p.newCommonJSExportSymbol(dot.Name)
+						export.isDecl = true
+						refs[dot.Name] = export.ref
+						newStmts = append(newStmts, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SLocal{Decls: []js_ast.Decl{{
+							Binding:    js_ast.Binding{Loc: dot.NameLoc, Data: &js_ast.BIdentifier{Ref: export.ref}},
+							ValueOrNil: binary.Right,
+						}}}})
+						exports = append(exports, export)
+						continue
+					}
+
+					// "module.exports = { foo: bar }"
+					if id, ok := dot.Target.Data.(*js_ast.EIdentifier); ok && dot.Name == "exports" && p.loadNameFromRef(id.Ref) == "module" {
+						object, ok := binary.Right.Data.(*js_ast.EObject)
+						if !ok || hasObject || len(object.Properties) == 0 {
+							return stmts, nil
+						}
+						identifierCount++
+						hasObject = true
+						for i, property := range object.Properties {
+							key, ok := property.Key.Data.(*js_ast.EString)
+							if !ok || property.Kind != js_ast.PropertyNormal || property.IsComputed || property.IsMethod ||
+								property.ValueOrNil.Data == nil || property.InitializerOrNil.Data != nil {
+								return stmts, nil
+							}
+							alias := js_lexer.UTF16ToString(key.Value)
+							if _, ok := refs[alias]; ok || alias == "__proto__" || alias == "__esModule" {
+								return stmts, nil
+							}
+							ref := p.newCommonJSExportSymbol(alias)
+							refs[alias] = ref
+							newStmts = append(newStmts, js_ast.Stmt{Loc: property.Key.Loc, Data: &js_ast.SLocal{Decls: []js_ast.Decl{{
+								Binding:    js_ast.Binding{Loc: property.Key.Loc, Data: &js_ast.BIdentifier{Ref: ref}},
+								ValueOrNil: property.ValueOrNil,
+							}}}})
+							exports = append(exports, commonJSExport{
+								kind:       commonJSExportProperty,
+								alias:      alias,
+								aliasLoc:   property.Key.Loc,
+								ref:        ref,
+								isDecl:     true,
+								isLast:     i+1 == len(object.Properties),
+								usesModule: true,
+							})
+						}
+						continue
+					}
+				}
+			}
+		}
+
+		newStmts = append(newStmts, stmt)
+		exports = append(exports, commonJSExport{})
+	}
+
+	// Every single reference to "exports" and "module" must be accounted for.
+	// Replacing "module.exports" also replaces the object that any earlier
+	// assignments were made to, so the two styles can't be mixed. And without
+	// "__esModule", the default import is the exports object itself, which
+	// conflicts with an export named "default".
+	_, hasDefault := refs["default"]
+	if identifierCount != p.exportsOrModuleIdentifierCount || hasAssign == hasObject ||
+		(hasObject && hasESModuleMarker) || (hasDefault && !hasESModuleMarker) {
+		return stmts, nil
+	}
+	return newStmts, exports
+}
+
+// This is called after the visit pass to either commit to treating a simple
+// CommonJS module as ESM, or to restore the CommonJS assignments if the visit
+// pass found something that makes this impossible (e.g. a top-level "this").
+func (p *parser) hoistSimpleCommonJS(parts []js_ast.Part, exports []commonJSExport) {
+	ok := !p.hasTopLevelReturn && !p.moduleScope.ContainsDirectEval
+	expectedUses := uint32(0)
+	for _, export := range exports {
+		if export.kind == commonJSExportNone || export.isDecl {
+			continue
+		}
+		if export.partIndex == -1 {
+			ok = false
+			break
+		}
+		part := &parts[export.partIndex]
+		uses := part.SymbolUses[p.exportsRef].CountEstimate + part.SymbolUses[p.moduleRef].CountEstimate
+		if uses != 1 || len(part.Stmts) != 1 {
+			ok = false
+			break
+		}
+		if export.kind == commonJSExportAssign {
+			if expr, isExpr := part.Stmts[0].Data.(*js_ast.SExpr); !isExpr {
+				ok = false
+				break
+			} else if binary, isBinary := expr.Value.Data.(*js_ast.EBinary); !isBinary || binary.Op != js_ast.BinOpAssign {
+				ok = false
+				break
+			}
+		}
+		expectedUses++
+	}
+	if p.symbols[p.exportsRef.InnerIndex].UseCountEstimate+p.symbols[p.moduleRef.InnerIndex].UseCountEstimate != expectedUses {
+		ok = false
+	}
+
+	oldSymbolUses := p.symbolUses
+	defer func() { p.symbolUses = oldSymbolUses }()
+
+	if !ok {
+		// Turn "var foo = bar" back into "var foo = bar; exports.foo = foo"
+		var properties []js_ast.Property
+		for _, export := range exports {
+			if !export.isDecl || export.partIndex == -1 {
+				continue
+			}
+			part := &parts[export.partIndex]
+			p.symbolUses = part.SymbolUses
+			loc := export.aliasLoc
+			p.recordUsage(export.ref)
+			value := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: export.ref}}
+
+			if export.kind == commonJSExportProperty {
+				// Turn "var foo = bar" back into "module.exports = { foo }"
+				properties = append(properties, js_ast.Property{
+					Key:        js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(export.alias)}},
+					ValueOrNil: value,
+				})
+				if !export.isLast {
+					continue
+				}
+				p.recordUsage(p.moduleRef)
+				part.Stmts = append(part.Stmts, js_ast.AssignStmt(
+					js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+						Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.moduleRef}},
+						Name:    "exports",
+						NameLoc: loc,
+					}},
+					js_ast.Expr{Loc: loc, Data: &js_ast.EObject{Properties: properties}},
+				))
+			} else {
+				var target js_ast.Expr
+				if export.usesModule {
+					p.recordUsage(p.moduleRef)
+					target = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+						Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.moduleRef}},
+						Name:    "exports",
+						NameLoc: loc,
+					}}
+				} else {
+					p.recordUsage(p.exportsRef)
+					target = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.exportsRef}}
+				}
+				part.Stmts = append(part.Stmts, js_ast.AssignStmt(
+					js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: target, Name: export.alias, NameLoc: loc}},
+					value,
+				))
+			}
+			part.CanBeRemovedIfUnused = false
+		}
+		return
+	}
+
+	for _, export := range exports {
+		switch export.kind {
+		case commonJSExportAssign, commonJSExportProperty:
+			if export.isDecl {
+				p.namedExports[export.alias] = js_ast.NamedExport{Ref: export.ref, AliasLoc: export.aliasLoc}
+				continue
+			}
+
+			// Turn "exports.foo = bar" into "foo = bar"
+			part := &parts[export.partIndex]
+			p.symbolUses = part.SymbolUses
+			binary := part.Stmts[0].Data.(*js_ast.SExpr).Value.Data.(*js_ast.EBinary)
+			if export.usesModule {
+				p.ignoreUsage(p.moduleRef)
+			} else {
+				p.ignoreUsage(p.exportsRef)
+			}
+			p.recordUsage(export.ref)
+			p.symbols[export.ref.InnerIndex].CouldPotentiallyBeMutated = true
+			binary.Left = js_ast.Expr{Loc: binary.Left.Loc, Data: &js_ast.EIdentifier{Ref: export.ref}}
+
+		case commonJSExportESModuleMarker:
+			// Remove "exports.__esModule = true" since the bundler only keeps this
+			// file hoisted if nothing can observe the exports object
+			part := &parts[export.partIndex]
+			p.symbolUses = part.SymbolUses
+			if export.usesModule {
+				p.ignoreUsage(p.moduleRef)
+			} else {
+				p.ignoreUsage(p.exportsRef)
+			}
+			part.Stmts = nil
+		}
+	}
+	p.isHoistedCommonJS = true
+}
+
 func Parse(log logger.Log, source logger.Source, options Options) (result js_ast.AST, ok bool) {
 	ok = true
 	defer func() {
@@ -13708,6 +14446,16 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		}
 	}
 
+	if !options.ts.Parse {
+		// Non-TypeScript files always get the real JavaScript decorator behavior
+		options.experimentalDecorators = config.False
+	} else if options.experimentalDecorators == config.Unspecified {
+		// TypeScript files default to TypeScript's legacy decorator transform.
+		// Setting "experimentalDecorators": false in "tsconfig.json" opts into
+		// the JavaScript decorator specification instead.
+		options.experimentalDecorators = config.True
+	}
+
 	// If there is no top-level esbuild "target" setting, include unsupported
 	// JavaScript features from the TypeScript "target" setting. Otherwise the
 	// TypeScript "target" setting is ignored.
@@ -13816,8 +14564,25 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		// When not bundling, everything comes in a single part
 		parts = p.appendPart(parts, stmts)
 	} else {
+		// When bundling, simple CommonJS modules can be converted to ESM
+		var commonJSExports []commonJSExport
+		if p.options.mode == config.ModeBundle && p.options.hoistSimpleCommonJS {
+			stmts, commonJSExports = p.scanForSimpleCommonJS(stmts)
+		}
+
 		// When bundling, each top-level statement is potentially a separate part
-		for _, stmt := range stmts {
+		for i, stmt := range stmts {
+			if commonJSExports != nil && commonJSExports[i].kind != commonJSExportNone {
+				// Remember which part each CommonJS export ended up in
+				partCount := len(parts)
+				parts = p.appendPart(parts, []js_ast.Stmt{stmt})
+				commonJSExports[i].partIndex = -1
+				if len(parts) > partCount {
+					commonJSExports[i].partIndex = len(parts) - 1
+				}
+				continue
+			}
+
 			switch s := stmt.Data.(type) {
 			case *js_ast.SLocal:
 				// Split up top-level multi-declaration variable statements
@@ -13843,6 +14608,10 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 				parts = p.appendPart(parts, []js_ast.Stmt{stmt})
 			}
 		}
+
+		if commonJSExports != nil {
+			p.hoistSimpleCommonJS(parts, commonJSExports)
+		}
 	}
 
 	// Pop the module scope to apply the "ContainsDirectEval" rules
@@ -13953,7 +14722,7 @@ func (p *parser) prepareForVisitPass() {
 
 	// Legacy HTML comments are not allowed in ESM files
 	if p.hasESModuleSyntax && p.lexer.LegacyHTMLCommentRange.Len > 0 {
-		p.log.AddRangeErrorWithNotes(&p.tracker, p.lexer.LegacyHTMLCommentRange,
+		p.log.AddIDWithNotes(logger.MsgID_JS_SyntaxError, logger.Error, &p.tracker, p.lexer.LegacyHTMLCommentRange,
 			"Legacy HTML single-line comments are not allowed in ECMAScript modules", p.whyESModule())
 	}
 
@@ -13999,13 +14768,13 @@ func (p *parser) prepareForVisitPass() {
 	// Handle "@jsx" and "@jsxFrag" pragmas now that lexing is done
 	if p.options.jsx.Parse {
 		if expr, ok := ParseJSXExpr(p.lexer.JSXFactoryPragmaComment.Text, JSXFactory); !ok {
-			p.log.AddRangeWarning(&p.tracker, p.lexer.JSXFactoryPragmaComment.Range,
+			p.log.AddID(logger.MsgID_JS_UnsupportedJSXComment, logger.Warning, &p.tracker, p.lexer.JSXFactoryPragmaComment.Range,
 				fmt.Sprintf("Invalid JSX factory: %s", p.lexer.JSXFactoryPragmaComment.Text))
 		} else if len(expr.Parts) > 0 {
 			p.options.jsx.Factory = expr
 		}
 		if expr, ok := ParseJSXExpr(p.lexer.JSXFragmentPragmaComment.Text, JSXFragment); !ok {
-			p.log.AddRangeWarning(&p.tracker, p.lexer.JSXFragmentPragmaComment.Range,
+			p.log.AddID(logger.MsgID_JS_UnsupportedJSXComment, logger.Warning, &p.tracker, p.lexer.JSXFragmentPragmaComment.Range,
 				fmt.Sprintf("Invalid JSX fragment: %s", p.lexer.JSXFragmentPragmaComment.Text))
 		} else if len(expr.Parts) > 0 || expr.Constant != nil {
 			p.options.jsx.Fragment = expr
@@ -14289,7 +15058,7 @@ func (p *parser) toAST(parts []js_ast.Part, hashbang string, directive string) j
 	usesExportsRef := p.symbols[p.exportsRef.InnerIndex].UseCountEstimate > 0
 	usesModuleRef := p.symbols[p.moduleRef.InnerIndex].UseCountEstimate > 0
 
-	if p.es6ExportKeyword.Len > 0 || p.topLevelAwaitKeyword.Len > 0 {
+	if p.es6ExportKeyword.Len > 0 || p.topLevelAwaitKeyword.Len > 0 || p.isHoistedCommonJS {
 		exportsKind = js_ast.ExportsESM
 	} else if usesExportsRef || usesModuleRef || p.hasTopLevelReturn {
 		exportsKind = js_ast.ExportsCommonJS
@@ -14335,9 +15104,10 @@ func (p *parser) toAST(parts []js_ast.Part, hashbang string, directive string) j
 		ApproximateLineCount:            int32(p.lexer.ApproximateNewlineCount) + 1,
 
 		// CommonJS features
-		UsesExportsRef: usesExportsRef,
-		UsesModuleRef:  usesModuleRef,
-		ExportsKind:    exportsKind,
+		UsesExportsRef:    usesExportsRef,
+		UsesModuleRef:     usesModuleRef,
+		ExportsKind:       exportsKind,
+		IsHoistedCommonJS: p.isHoistedCommonJS,
 
 		// ES6 features
 		ImportKeyword:        p.es6ImportKeyword,
@@ -32,7 +32,7 @@ func (p *parser) markSyntaxFeature(feature compat.JSFeature, r logger.Range) (di
 
 	if !p.options.unsupportedJSFeatures.Has(feature) {
 		if feature == compat.TopLevelAwait && !p.options.outputFormat.KeepES6ImportExportSyntax() {
-			p.log.AddRangeError(&p.tracker, r, fmt.Sprintf(
+			p.log.AddID(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 				"Top-level await is currently not supported with the %q output format", p.options.outputFormat.String()))
 			return
 		}
@@ -94,39 +94,39 @@ func (p *parser) markSyntaxFeature(feature compat.JSFeature, r logger.Range) (di
 		name = "non-identifier array rest patterns"
 
 	case compat.ImportAssertions:
-		p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 			"Using an arbitrary value as the second argument to \"import()\" is not possible in %s", where), notes)
 		return
 
 	case compat.TopLevelAwait:
-		p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 			"Top-level await is not available in %s", where), notes)
 		return
 
 	case compat.ArbitraryModuleNamespaceNames:
-		p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 			"Using a string as a module namespace identifier name is not supported in %s", where), notes)
 		return
 
 	case compat.BigInt:
 		// Transforming these will never be supported
-		p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 			"Big integer literals are not available in %s", where), notes)
 		return
 
 	case compat.ImportMeta:
 		// This can't be polyfilled
-		p.log.AddRangeWarningWithNotes(&p.tracker, r, fmt.Sprintf(
+		p.log.AddIDWithNotes(logger.MsgID_JS_EmptyImportMeta, logger.Warning, &p.tracker, r, fmt.Sprintf(
 			"\"import.meta\" is not available in %s and will be empty", where), notes)
 		return
 
 	default:
-		p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 			"This feature is not available in %s", where), notes)
 		return
 	}
 
-	p.log.AddRangeErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+	p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r, fmt.Sprintf(
 		"Transforming %s to %s is not supported yet", name, where), notes)
 	return
 }
@@ -136,7 +136,7 @@ func (p *parser) isStrictMode() bool {
 }
 
 func (p *parser) isStrictModeOutputFormat() bool {
-	return p.options.outputFormat == config.FormatESModule
+	return p.options.outputFormat == config.FormatESModule || p.options.outputFormat == config.FormatSystem
 }
 
 type strictModeFeature uint8
@@ -200,10 +200,10 @@ func (p *parser) markStrictModeFeature(feature strictModeFeature, r logger.Range
 			}
 			notes = []logger.MsgData{logger.RangeData(&p.tracker, where, why)}
 		}
-		p.log.AddRangeErrorWithNotes(&p.tracker, r,
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r,
 			fmt.Sprintf("%s cannot be used in strict mode", text), notes)
 	} else if !canBeTransformed && p.isStrictModeOutputFormat() {
-		p.log.AddRangeError(&p.tracker, r,
+		p.log.AddID(logger.MsgID_JS_UnsupportedFeature, logger.Error, &p.tracker, r,
 			fmt.Sprintf("%s cannot be used with the \"esm\" output format due to strict mode", text))
 	}
 }
@@ -1692,6 +1692,13 @@ type classLoweringInfo struct {
 	lowerAllStaticFields    bool
 }
 
+// TypeScript files use TypeScript's legacy decorator transform unless
+// "experimentalDecorators" is set to false in "tsconfig.json". All other
+// files use the JavaScript decorator specification.
+func (p *parser) useLegacyDecorators() bool {
+	return p.options.ts.Parse && p.options.experimentalDecorators == config.True
+}
+
 func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLoweringInfo) {
 	// TypeScript has legacy behavior that uses assignment semantics instead of
 	// define semantics for class fields by default. This happened before class
@@ -1737,6 +1744,18 @@ func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLowe
 	//   _foo = new WeakMap();
 	//
 	for _, prop := range class.Properties {
+		// JavaScript decorators and auto-accessors are always lowered. Decorators
+		// are applied after the class body, but must be applied before any fields
+		// are initialized and before their initializers run. Auto-accessors are
+		// backed by storage that is lowered in the same way as a private field.
+		if prop.Kind == js_ast.PropertyAutoAccessor || (len(prop.TSDecorators) > 0 && !p.useLegacyDecorators()) {
+			if prop.IsStatic {
+				result.lowerAllStaticFields = true
+			} else {
+				result.lowerAllInstanceFields = true
+			}
+		}
+
 		if private, ok := prop.Key.Data.(*js_ast.EPrivateIdentifier); ok {
 			if prop.IsStatic {
 				if p.privateSymbolNeedsToBeLowered(private) {
@@ -1959,6 +1978,48 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	var instanceDecorators []js_ast.Expr
 	var staticDecorators []js_ast.Expr
 
+	// These are only for JavaScript decorators, which store the initializers
+	// returned by decorators in a single array that is shared by the class:
+	//
+	//   _init[0]: initializers added by class decorators
+	//   _init[1]: initializers added by static member decorators
+	//   _init[2]: initializers added by instance member decorators
+	//   _init[3...]: initializers for individual fields and auto-accessors
+	//
+	//
+	// Member decorators are applied in the order that the specification uses,
+	// which is static methods and accessors, then instance methods and
+	// accessors, then static fields, and then instance fields.
+	//
+	var decoratorInitializers []js_ast.Expr
+	var memberDecorators []js_ast.Expr
+	var staticMethodDecorators []js_ast.Expr
+	var instanceMethodDecorators []js_ast.Expr
+	var staticFieldDecorators []js_ast.Expr
+	var instanceFieldDecorators []js_ast.Expr
+	var classDecorators []js_ast.Expr
+	var instanceExtraInitializers []js_ast.Stmt
+	var autoAccessorMethods []js_ast.Property
+	hasStaticMemberDecorators := false
+	nextInitializerIndex := 3
+	initRef := js_ast.InvalidRef
+	useLegacyDecorators := p.useLegacyDecorators()
+	if !useLegacyDecorators && len(class.TSDecorators) > 0 {
+		classDecorators = class.TSDecorators
+		class.TSDecorators = nil
+	}
+	initRefFunc := func(loc logger.Loc) js_ast.Expr {
+		if initRef == js_ast.InvalidRef {
+			initRef = p.generateTempRef(tempRefNeedsDeclare, "_init")
+			decoratorInitializers = append(decoratorInitializers, js_ast.Assign(
+				js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: initRef}},
+				js_ast.Expr{Loc: loc, Data: &js_ast.EArray{}},
+			))
+		}
+		p.recordUsage(initRef)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: initRef}}
+	}
+
 	// These are only for class expressions that need to be captured
 	var nameFunc func() js_ast.Expr
 	var wrapFunc func(js_ast.Expr) js_ast.Expr
@@ -2055,7 +2116,8 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		private, _ := prop.Key.Data.(*js_ast.EPrivateIdentifier)
 		mustLowerPrivate := private != nil && p.privateSymbolNeedsToBeLowered(private)
 		shouldOmitFieldInitializer := p.options.ts.Parse && !prop.IsMethod && prop.InitializerOrNil.Data == nil &&
-			!classLoweringInfo.useDefineForClassFields && !mustLowerPrivate
+			!classLoweringInfo.useDefineForClassFields && !mustLowerPrivate && prop.Kind != js_ast.PropertyAutoAccessor &&
+			(useLegacyDecorators || len(prop.TSDecorators) == 0)
 
 		// Class fields must be lowered if the environment doesn't support them
 		mustLowerField := false
@@ -2099,7 +2161,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		}
 
 		// Handle decorators
-		if p.options.ts.Parse {
+		if useLegacyDecorators {
 			// Generate a single call to "__decorateClass()" for this property
 			if len(prop.TSDecorators) > 0 {
 				loc := prop.Key.Loc
@@ -2145,6 +2207,299 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 					instanceDecorators = append(instanceDecorators, decorator)
 				}
 			}
+		} else if len(prop.TSDecorators) > 0 {
+			// Generate a single call to "__decorateElement()" for this property
+			loc := prop.Key.Loc
+
+			// Clone the key for the decorator context
+			var nameExpr js_ast.Expr
+			switch k := keyExprNoSideEffects.Data.(type) {
+			case *js_ast.ENumber:
+				nameExpr = js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: k.Value}}
+			case *js_ast.EString:
+				nameExpr = js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: k.Value}}
+			case *js_ast.EIdentifier:
+				nameExpr = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: k.Ref}}
+			case *js_ast.EPrivateIdentifier:
+				nameExpr = js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(p.symbols[k.Ref.InnerIndex].OriginalName)}}
+			default:
+				panic("Internal error")
+			}
+
+			// This code tells "__decorateElement()" what kind of member this is
+			var flags float64
+			switch {
+			case prop.Kind == js_ast.PropertyAutoAccessor:
+				flags = 4
+			case !prop.IsMethod:
+				flags = 5
+			case prop.Kind == js_ast.PropertyGet:
+				flags = 2
+			case prop.Kind == js_ast.PropertySet:
+				flags = 3
+			default:
+				flags = 1
+			}
+			if prop.IsStatic {
+				flags += 8
+				hasStaticMemberDecorators = true
+			} else if instanceExtraInitializers == nil {
+				instanceExtraInitializers = append(instanceExtraInitializers, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SExpr{
+					Value: p.callRuntime(classLoc, "__runDecoratorInitializers", []js_ast.Expr{
+						initRefFunc(classLoc),
+						{Loc: classLoc, Data: &js_ast.ENumber{Value: 2}},
+						{Loc: classLoc, Data: js_ast.EThisShared},
+					}),
+				}})
+			}
+
+			// Private members pass the lowered private member instead of the class.
+			// Private methods, getters, and setters also pass the function, which
+			// is replaced by the return value.
+			target := nameFunc()
+			var privateFnRef js_ast.Ref
+			if private != nil {
+				flags += 16
+				target = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: private.Ref}}
+				if prop.IsMethod {
+					if prop.Kind == js_ast.PropertySet {
+						privateFnRef = p.privateSetters[private.Ref]
+					} else {
+						privateFnRef = p.privateGetters[private.Ref]
+					}
+				}
+			}
+
+			args := []js_ast.Expr{
+				initRefFunc(loc),
+				{Loc: loc, Data: &js_ast.ENumber{Value: flags}},
+				nameExpr,
+				{Loc: loc, Data: &js_ast.EArray{Items: prop.TSDecorators}},
+				target,
+			}
+			if private != nil && prop.IsMethod {
+				p.recordUsage(privateFnRef)
+				args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: privateFnRef}})
+			}
+
+			// Fields and auto-accessors pass their initial value through the
+			// initializers returned by their decorators
+			if !prop.IsMethod {
+				index := js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(nextInitializerIndex)}}
+				nextInitializerIndex++
+				args = append(args, index)
+
+				var target js_ast.Expr
+				if prop.IsStatic {
+					target = nameFunc()
+				} else {
+					target = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+				}
+				initArgs := []js_ast.Expr{initRefFunc(loc), index, target}
+				if prop.InitializerOrNil.Data != nil {
+					initArgs = append(initArgs, prop.InitializerOrNil)
+				}
+				prop.InitializerOrNil = p.callRuntime(loc, "__runDecoratorInitializers", initArgs)
+			}
+
+			decorator := p.callRuntime(loc, "__decorateElement", args)
+			if private != nil {
+				if prop.IsMethod {
+					// "_m_fn = __decorateElement(_init, 17, '#m', [dec], _m, _m_fn)"
+					p.recordUsage(privateFnRef)
+					decorator = js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: privateFnRef}}, decorator)
+				} else if prop.Kind == js_ast.PropertyAutoAccessor {
+					// "_a = __decorateElement(...), _x_get = _a.get, _x_set = _a.set"
+					ref := p.generateTempRef(tempRefNeedsDeclare, "")
+					decorator = js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}, decorator)
+					for _, fn := range []struct {
+						ref  js_ast.Ref
+						name string
+					}{{p.privateGetters[private.Ref], "get"}, {p.privateSetters[private.Ref], "set"}} {
+						p.recordUsage(fn.ref)
+						p.recordUsage(ref)
+						decorator = js_ast.JoinWithComma(decorator, js_ast.Assign(
+							js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: fn.ref}},
+							js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+								Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+								Name:    fn.name,
+								NameLoc: loc,
+							}},
+						))
+					}
+				}
+			}
+			switch {
+			case prop.IsStatic && (prop.IsMethod || prop.Kind == js_ast.PropertyAutoAccessor):
+				staticMethodDecorators = append(staticMethodDecorators, decorator)
+			case prop.IsMethod || prop.Kind == js_ast.PropertyAutoAccessor:
+				instanceMethodDecorators = append(instanceMethodDecorators, decorator)
+			case prop.IsStatic:
+				staticFieldDecorators = append(staticFieldDecorators, decorator)
+			default:
+				instanceFieldDecorators = append(instanceFieldDecorators, decorator)
+			}
+			prop.TSDecorators = nil
+		}
+
+		// Auto-accessors are lowered to a getter and setter pair. The storage for
+		// the value is a new private field that is always lowered:
+		//
+		//   class Foo {
+		//     accessor foo = 123
+		//   }
+		//
+		// This might be converted into the following:
+		//
+		//   var _foo;
+		//   class Foo {
+		//     constructor() {
+		//       __privateAdd(this, _foo, 123);
+		//     }
+		//     get foo() {
+		//       return __privateGet(this, _foo);
+		//     }
+		//     set foo(_) {
+		//       __privateSet(this, _foo, _);
+		//     }
+		//   }
+		//   _foo = new WeakMap();
+		//
+		// Private auto-accessors are lowered to a getter and setter pair that is
+		// stored in variables instead, and the private name shares the storage
+		// for brand checks:
+		//
+		//   class Foo {
+		//     accessor #foo = 123
+		//   }
+		//
+		// This might be converted into the following:
+		//
+		//   var _foo, foo_get, foo_set;
+		//   class Foo {
+		//     constructor() {
+		//       __privateAdd(this, _foo, 123);
+		//     }
+		//   }
+		//   _foo = new WeakMap();
+		//   foo_get = function() {
+		//     return __privateGet(this, _foo);
+		//   };
+		//   foo_set = function(_) {
+		//     __privateSet(this, _foo, _);
+		//   };
+		//
+		if prop.Kind == js_ast.PropertyAutoAccessor {
+			loc := prop.Key.Loc
+
+			// Generate a private symbol for the storage
+			storageName := "#accessor"
+			if key, ok := prop.Key.Data.(*js_ast.EString); ok && !prop.IsComputed && js_lexer.IsIdentifierUTF16(key.Value) {
+				storageName = "#" + js_lexer.UTF16ToString(key.Value)
+			} else if private != nil {
+				storageName = p.symbols[private.Ref.InnerIndex].OriginalName
+			}
+			storageKind := js_ast.SymbolPrivateField
+			if prop.IsStatic {
+				storageKind = js_ast.SymbolPrivateStaticField
+			}
+			storageRef := p.newSymbol(storageKind, storageName)
+			p.symbols[storageRef.InnerIndex].PrivateSymbolMustBeLowered = true
+			storage := &js_ast.EPrivateIdentifier{Ref: storageRef}
+
+			// "function() { return __privateGet(this, _foo); }"
+			getterFn := js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+				Body: js_ast.FnBody{Loc: loc, Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SReturn{
+					ValueOrNil: p.lowerPrivateGet(js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}, loc, storage),
+				}}}},
+			}}}
+
+			// "function(_) { __privateSet(this, _foo, _); }"
+			//
+			// The argument is declared in a scope of its own. Otherwise the renamer
+			// wouldn't know about it and it could end up with the same name as the
+			// storage variable, which is a top-level symbol.
+			argScope := &js_ast.Scope{
+				Kind:       js_ast.ScopeFunctionArgs,
+				Parent:     p.currentScope,
+				Members:    make(map[string]js_ast.ScopeMember),
+				Label:      js_ast.LocRef{Ref: js_ast.InvalidRef},
+				StrictMode: p.currentScope.StrictMode,
+			}
+			p.currentScope.Children = append(p.currentScope.Children, argScope)
+			argRef := p.newSymbol(js_ast.SymbolHoisted, "_")
+			argScope.Generated = append(argScope.Generated, argRef)
+			setterFn := js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+				Args: []js_ast.Arg{{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: argRef}}}},
+				Body: js_ast.FnBody{Loc: loc, Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SExpr{
+					Value: p.lowerPrivateSet(js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}, loc, storage,
+						js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: argRef}}),
+				}}}},
+			}}}
+
+			if private != nil {
+				// The private name shares the storage, so brand checks use the storage
+				p.symbols[private.Ref.InnerIndex].Link = storageRef
+
+				// Move the getter and setter definitions outside the class body
+				for _, fn := range []struct {
+					ref   js_ast.Ref
+					value js_ast.Expr
+				}{{p.privateGetters[private.Ref], getterFn}, {p.privateSetters[private.Ref], setterFn}} {
+					methodRef := p.generateTempRef(tempRefNeedsDeclare, "_")
+					p.symbols[methodRef.InnerIndex].Link = fn.ref
+					privateMembers = append(privateMembers, js_ast.Assign(
+						js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: methodRef}},
+						fn.value,
+					))
+				}
+
+			} else {
+				// The getter comes first, so it evaluates the computed key if necessary
+				getterKey := prop.Key
+				var setterKey js_ast.Expr
+				switch k := keyExprNoSideEffects.Data.(type) {
+				case *js_ast.ENumber:
+					setterKey = js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: k.Value}}
+				case *js_ast.EString:
+					setterKey = js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: k.Value}}
+				case *js_ast.EIdentifier:
+					setterKey = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: k.Ref}}
+				default:
+					panic("Internal error")
+				}
+				if prop.IsComputed && computedPropertyCache.Data != nil {
+					getterKey = computedPropertyCache
+					computedPropertyCache = js_ast.Expr{}
+				}
+
+				// "get foo() { return __privateGet(this, _foo); }"
+				autoAccessorMethods = append(autoAccessorMethods, js_ast.Property{
+					Kind:       js_ast.PropertyGet,
+					IsComputed: prop.IsComputed,
+					IsMethod:   true,
+					IsStatic:   prop.IsStatic,
+					Key:        getterKey,
+					ValueOrNil: getterFn,
+				})
+
+				// "set foo(_a) { __privateSet(this, _foo, _a); }"
+				autoAccessorMethods = append(autoAccessorMethods, js_ast.Property{
+					Kind:       js_ast.PropertySet,
+					IsComputed: prop.IsComputed,
+					IsMethod:   true,
+					IsStatic:   prop.IsStatic,
+					Key:        setterKey,
+					ValueOrNil: setterFn,
+				})
+			}
+
+			// Turn this property into the storage field
+			prop.Kind = js_ast.PropertyNormal
+			prop.Key = js_ast.Expr{Loc: loc, Data: storage}
+			prop.IsComputed = false
+			private = storage
+			mustLowerPrivate = true
 		}
 
 		// Handle lowering of instance and static fields. Move their initializers
@@ -2336,10 +2691,10 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	}
 
 	// Finish the filtering operation
-	class.Properties = class.Properties[:end]
+	class.Properties = append(class.Properties[:end], autoAccessorMethods...)
 
 	// Insert instance field initializers into the constructor
-	if len(parameterFields) > 0 || len(instancePrivateMethods) > 0 || len(instanceMembers) > 0 {
+	if len(parameterFields) > 0 || len(instancePrivateMethods) > 0 || len(instanceExtraInitializers) > 0 || len(instanceMembers) > 0 {
 		// Create a constructor if one doesn't already exist
 		if ctor == nil {
 			ctor = &js_ast.EFunction{}
@@ -2374,6 +2729,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		}
 		stmtsTo = append(stmtsTo, parameterFields...)
 		stmtsTo = append(stmtsTo, instancePrivateMethods...)
+		stmtsTo = append(stmtsTo, instanceExtraInitializers...)
 		stmtsTo = append(stmtsTo, instanceMembers...)
 		ctor.Fn.Body.Stmts = append(stmtsTo, stmtsFrom...)
 
@@ -2390,14 +2746,56 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		}
 	}
 
+	// JavaScript decorators on members are applied after the class body but
+	// before static fields are initialized. Class decorators are applied last.
+	memberDecorators = append(memberDecorators, staticMethodDecorators...)
+	memberDecorators = append(memberDecorators, instanceMethodDecorators...)
+	memberDecorators = append(memberDecorators, staticFieldDecorators...)
+	memberDecorators = append(memberDecorators, instanceFieldDecorators...)
+	if len(memberDecorators) > 0 {
+		memberDecorators = append(decoratorInitializers, memberDecorators...)
+		decoratorInitializers = nil
+		if hasStaticMemberDecorators {
+			memberDecorators = append(memberDecorators, p.callRuntime(classLoc, "__runDecoratorInitializers", []js_ast.Expr{
+				initRefFunc(classLoc),
+				{Loc: classLoc, Data: &js_ast.ENumber{Value: 1}},
+				nameFunc(),
+			}))
+		}
+	}
+	if len(classDecorators) > 0 {
+		var className js_ast.Expr
+		if nameToKeep != "" {
+			className = js_ast.Expr{Loc: classLoc, Data: &js_ast.EString{Value: js_lexer.StringToUTF16(nameToKeep)}}
+		} else {
+			className = js_ast.Expr{Loc: classLoc, Data: js_ast.EUndefinedShared}
+		}
+		init := initRefFunc(classLoc)
+		classDecorators = []js_ast.Expr{
+			js_ast.Assign(nameFunc(), p.callRuntime(classLoc, "__decorateElement", []js_ast.Expr{
+				init,
+				{Loc: classLoc, Data: &js_ast.ENumber{Value: 0}},
+				className,
+				{Loc: classLoc, Data: &js_ast.EArray{Items: classDecorators}},
+				nameFunc(),
+			})),
+			p.callRuntime(classLoc, "__runDecoratorInitializers", []js_ast.Expr{
+				initRefFunc(classLoc),
+				{Loc: classLoc, Data: &js_ast.ENumber{Value: 0}},
+				nameFunc(),
+			}),
+		}
+		classDecorators = append(decoratorInitializers, classDecorators...)
+	}
+
 	// Pack the class back into an expression. We don't need to handle TypeScript
 	// decorators for class expressions because TypeScript doesn't support them.
 	if kind == classKindExpr {
 		// Calling "nameFunc" will replace "expr", so make sure to do that first
 		// before joining "expr" with any other expressions
 		var nameToJoin js_ast.Expr
-		if didCaptureClassExpr || computedPropertyCache.Data != nil ||
-			len(privateMembers) > 0 || len(staticPrivateMethods) > 0 || len(staticMembers) > 0 {
+		if didCaptureClassExpr || computedPropertyCache.Data != nil || len(privateMembers) > 0 || len(staticPrivateMethods) > 0 ||
+			len(memberDecorators) > 0 || len(staticMembers) > 0 || len(classDecorators) > 0 {
 			nameToJoin = nameFunc()
 		}
 
@@ -2416,9 +2814,15 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		for _, value := range staticPrivateMethods {
 			expr = js_ast.JoinWithComma(expr, value)
 		}
+		for _, value := range memberDecorators {
+			expr = js_ast.JoinWithComma(expr, value)
+		}
 		for _, value := range staticMembers {
 			expr = js_ast.JoinWithComma(expr, value)
 		}
+		for _, value := range classDecorators {
+			expr = js_ast.JoinWithComma(expr, value)
+		}
 
 		// Finally join "expr" with the variable that holds the class object
 		if nameToJoin.Data != nil {
@@ -2441,7 +2845,9 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 			len(staticMembers) > 0 ||
 			len(instanceDecorators) > 0 ||
 			len(staticDecorators) > 0 ||
-			len(class.TSDecorators) > 0)
+			len(memberDecorators) > 0 ||
+			len(class.TSDecorators) > 0 ||
+			len(classDecorators) > 0)
 
 	// Optionally preserve the name
 	var keepNameStmt js_ast.Stmt
@@ -2455,7 +2861,8 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	var stmts []js_ast.Stmt
 	var nameForClassDecorators js_ast.LocRef
 	generatedLocalStmt := false
-	if len(class.TSDecorators) > 0 || hasPotentialShadowCaptureEscape || classLoweringInfo.avoidTDZ {
+	hasClassDecorators := len(class.TSDecorators) > 0 || len(classDecorators) > 0
+	if hasClassDecorators || hasPotentialShadowCaptureEscape || classLoweringInfo.avoidTDZ {
 		generatedLocalStmt = true
 		name := nameFunc()
 		nameRef := name.Data.(*js_ast.EIdentifier).Ref
@@ -2464,7 +2871,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		class = &classExpr.Class
 		init := js_ast.Expr{Loc: classLoc, Data: &classExpr}
 
-		if hasPotentialShadowCaptureEscape && len(class.TSDecorators) == 0 {
+		if hasPotentialShadowCaptureEscape && !hasClassDecorators {
 			// If something captures the shadowing name and escapes the class body,
 			// make a new constant to store the class and forward that value to a
 			// mutable alias. That way if the alias is mutated, everything bound to
@@ -2564,6 +2971,9 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 	for _, expr := range staticPrivateMethods {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
+	for _, expr := range memberDecorators {
+		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+	}
 	for _, expr := range staticMembers {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
@@ -2584,6 +2994,9 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, shadowRef js_ast
 		p.recordUsage(nameForClassDecorators.Ref)
 		p.recordUsage(nameForClassDecorators.Ref)
 	}
+	for _, expr := range classDecorators {
+		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+	}
 	if generatedLocalStmt {
 		// "export default class x {}" => "class x {} export {x as default}"
 		if kind == classKindExportDefaultStmt {
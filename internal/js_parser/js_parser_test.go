@@ -4786,3 +4786,246 @@ func TestASCIIOnly(t *testing.T) {
 	expectPrintedTargetASCII(t, 5, "export var π", "export var \\u03C0;\n")
 	expectParseErrorTargetASCII(t, 5, "export var 𐀀", es5)
 }
+
+func TestDecorators(t *testing.T) {
+	expectPrinted(t, "@dec class Foo {}",
+		`var _init;
+let Foo = class {
+};
+_init = [];
+Foo = __decorateElement(_init, 0, "Foo", [
+  dec
+], Foo);
+__runDecoratorInitializers(_init, 0, Foo);
+`)
+	expectPrinted(t, "class Foo { @dec foo() {} }",
+		`var _init;
+class Foo {
+  constructor() {
+    __runDecoratorInitializers(_init, 2, this);
+  }
+  foo() {
+  }
+}
+_init = [];
+__decorateElement(_init, 1, "foo", [
+  dec
+], Foo);
+`)
+	expectPrinted(t, "class Foo { @dec foo = 1; @dec static bar = 2 }",
+		`var _init;
+class Foo {
+  constructor() {
+    __runDecoratorInitializers(_init, 2, this);
+    __publicField(this, "foo", __runDecoratorInitializers(_init, 3, this, 1));
+  }
+}
+_init = [];
+__decorateElement(_init, 13, "bar", [
+  dec
+], Foo, 4);
+__decorateElement(_init, 5, "foo", [
+  dec
+], Foo, 3);
+__runDecoratorInitializers(_init, 1, Foo);
+__publicField(Foo, "bar", __runDecoratorInitializers(_init, 4, Foo, 2));
+`)
+	expectPrinted(t, "export @dec class Foo {}",
+		`var _init;
+export let Foo = class {
+};
+_init = [];
+Foo = __decorateElement(_init, 0, "Foo", [
+  dec
+], Foo);
+__runDecoratorInitializers(_init, 0, Foo);
+`)
+	expectPrinted(t, "x = @dec class {}",
+		`var _init, _a;
+x = (_a = class {
+}, _init = [], _a = __decorateElement(_init, 0, void 0, [
+  dec
+], _a), __runDecoratorInitializers(_init, 0, _a), _a);
+`)
+
+	expectPrinted(t, "class Foo { @dec #foo = 1; @dec #bar() {} }",
+		`var _init, _foo, _bar, bar_fn;
+class Foo {
+  constructor() {
+    __privateAdd(this, _bar);
+    __runDecoratorInitializers(_init, 2, this);
+    __privateAdd(this, _foo, __runDecoratorInitializers(_init, 3, this, 1));
+  }
+}
+_foo = new WeakMap();
+_bar = new WeakSet();
+bar_fn = function() {
+};
+_init = [];
+bar_fn = __decorateElement(_init, 17, "#bar", [
+  dec
+], _bar, bar_fn);
+__decorateElement(_init, 21, "#foo", [
+  dec
+], _foo, 3);
+`)
+	expectPrinted(t, "class Foo { @dec get #foo() { return 1 } @dec set #foo(x) {} }",
+		`var _init, _foo, foo_get, foo_set;
+class Foo {
+  constructor() {
+    __privateAdd(this, _foo);
+    __runDecoratorInitializers(_init, 2, this);
+  }
+}
+_foo = new WeakSet();
+foo_get = function() {
+  return 1;
+};
+foo_set = function(x) {
+};
+_init = [];
+foo_get = __decorateElement(_init, 18, "#foo", [
+  dec
+], _foo, foo_get);
+foo_set = __decorateElement(_init, 19, "#foo", [
+  dec
+], _foo, foo_set);
+`)
+	expectPrinted(t, "class Foo { @dec accessor #foo = 1; bar() { return this.#foo } }",
+		`var _init, _a, foo_get, foo_set, _foo;
+class Foo {
+  constructor() {
+    __runDecoratorInitializers(_init, 2, this);
+    __privateAdd(this, _foo, __runDecoratorInitializers(_init, 3, this, 1));
+  }
+  bar() {
+    return __privateGet(this, _foo, foo_get);
+  }
+}
+foo_get = function() {
+  return __privateGet(this, _foo);
+};
+foo_set = function(_) {
+  __privateSet(this, _foo, _);
+};
+_foo = new WeakMap();
+_init = [];
+_a = __decorateElement(_init, 20, "#foo", [
+  dec
+], _foo, 3), foo_get = _a.get, foo_set = _a.set;
+`)
+
+	// Member decorators are applied to static methods and accessors first, then
+	// instance methods and accessors, then static fields, then instance fields
+	expectPrinted(t, "class Foo { @a x = 1; @b static y = 2; @c z() {} @d static w() {} @e accessor v; @f static accessor u }",
+		`var _init, _v, _u;
+class Foo {
+  constructor() {
+    __runDecoratorInitializers(_init, 2, this);
+    __publicField(this, "x", __runDecoratorInitializers(_init, 3, this, 1));
+    __privateAdd(this, _v, __runDecoratorInitializers(_init, 5, this));
+  }
+  z() {
+  }
+  static w() {
+  }
+  get v() {
+    return __privateGet(this, _v);
+  }
+  set v(_) {
+    __privateSet(this, _v, _);
+  }
+  static get u() {
+    return __privateGet(this, _u);
+  }
+  static set u(_) {
+    __privateSet(this, _u, _);
+  }
+}
+_v = new WeakMap();
+_u = new WeakMap();
+_init = [];
+__decorateElement(_init, 9, "w", [
+  d
+], Foo);
+__decorateElement(_init, 12, "u", [
+  f
+], Foo, 6);
+__decorateElement(_init, 1, "z", [
+  c
+], Foo);
+__decorateElement(_init, 4, "v", [
+  e
+], Foo, 5);
+__decorateElement(_init, 13, "y", [
+  b
+], Foo, 4);
+__decorateElement(_init, 5, "x", [
+  a
+], Foo, 3);
+__runDecoratorInitializers(_init, 1, Foo);
+__publicField(Foo, "y", __runDecoratorInitializers(_init, 4, Foo, 2));
+__privateAdd(Foo, _u, __runDecoratorInitializers(_init, 6, Foo));
+`)
+
+	expectParseError(t, "@dec function foo() {}", "<stdin>: error: Expected \"class\" but found \"function\"\n")
+	expectParseError(t, "@dec abstract class Foo {}", "<stdin>: error: Expected \"class\" but found \"abstract\"\n")
+	expectParseError(t, "class Foo { foo(@dec x) {} }", "<stdin>: error: Expected identifier but found \"@\"\n")
+	expectParseError(t, "class Foo { @dec constructor() {} }", "<stdin>: error: Decorators are not allowed on class constructors\n")
+	expectParseError(t, "@dec export @dec class Foo {}", "<stdin>: error: Expected \"class\" but found \"@\"\n")
+}
+
+func TestAutoAccessors(t *testing.T) {
+	expectPrinted(t, "class Foo { accessor foo = 1 }",
+		`var _foo;
+class Foo {
+  constructor() {
+    __privateAdd(this, _foo, 1);
+  }
+  get foo() {
+    return __privateGet(this, _foo);
+  }
+  set foo(_) {
+    __privateSet(this, _foo, _);
+  }
+}
+_foo = new WeakMap();
+`)
+	expectPrinted(t, "class Foo { static accessor [foo] }",
+		`var _a, _accessor;
+class Foo {
+  static get [_a = foo]() {
+    return __privateGet(this, _accessor);
+  }
+  static set [_a](_) {
+    __privateSet(this, _accessor, _);
+  }
+}
+_accessor = new WeakMap();
+__privateAdd(Foo, _accessor, void 0);
+`)
+	expectPrinted(t, "class Foo { accessor #foo = 1; static check(x) { return #foo in x } }",
+		`var foo_get, foo_set, _foo;
+class Foo {
+  constructor() {
+    __privateAdd(this, _foo, 1);
+  }
+  static check(x) {
+    return __privateIn(_foo, x);
+  }
+}
+foo_get = function() {
+  return __privateGet(this, _foo);
+};
+foo_set = function(_) {
+  __privateSet(this, _foo, _);
+};
+_foo = new WeakMap();
+`)
+	expectPrinted(t, "class Foo { accessor\nfoo }", "class Foo {\n  accessor;\n  foo;\n}\n")
+	expectPrinted(t, "class Foo { accessor = 1 }", "class Foo {\n  accessor = 1;\n}\n")
+
+	expectParseError(t, "class Foo { accessor foo() {} }", "<stdin>: error: Expected \"=\" but found \"(\"\n")
+	expectParseError(t, "class Foo { accessor get foo() {} }", "<stdin>: error: Expected \"=\" but found \"(\"\n")
+	expectParseError(t, "({ accessor foo: 1 })", "<stdin>: error: Expected \"}\" but found \"foo\"\n")
+}
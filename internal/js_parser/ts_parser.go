@@ -526,7 +526,30 @@ func (p *parser) skipTypeScriptTypeParameters() {
 		p.lexer.Next()
 
 		for {
-			p.lexer.Expect(js_lexer.TIdentifier)
+			hasName := false
+
+			// "class Foo<in T> {}"
+			// "class Foo<out T> {}"
+			// "class Foo<in out T> {}"
+			// "class Foo<const T> {}"
+			for !hasName {
+				switch {
+				case p.lexer.Token == js_lexer.TIn || p.lexer.Token == js_lexer.TConst:
+					p.lexer.Next()
+
+				case p.lexer.IsContextualKeyword("out"):
+					// "out" is only a modifier if it's followed by the name. Otherwise
+					// it's the name itself, such as in "class Foo<out> {}".
+					p.lexer.Next()
+					if p.lexer.Token != js_lexer.TIdentifier {
+						hasName = true
+					}
+
+				default:
+					p.lexer.Expect(js_lexer.TIdentifier)
+					hasName = true
+				}
+			}
 
 			// "class Foo<T extends number> {}"
 			if p.lexer.Token == js_lexer.TExtends {
@@ -777,6 +800,21 @@ func (p *parser) skipTypeScriptTypeStmt(opts parseStmtOpts) {
 		return
 	}
 
+	if opts.isExport && p.lexer.Token == js_lexer.TAsterisk {
+		// "export type * from 'bar'"
+		// "export type * as foo from 'bar'"
+		p.lexer.Next()
+		if p.lexer.IsContextualKeyword("as") {
+			p.lexer.Next()
+			p.parseClauseAlias("export")
+			p.lexer.Next()
+		}
+		p.lexer.ExpectContextualKeyword("from")
+		p.parsePath()
+		p.lexer.ExpectOrInsertSemicolon()
+		return
+	}
+
 	name := p.lexer.Identifier
 	p.lexer.Expect(js_lexer.TIdentifier)
 
@@ -792,20 +830,18 @@ func (p *parser) skipTypeScriptTypeStmt(opts parseStmtOpts) {
 
 func (p *parser) parseTypeScriptDecorators() []js_ast.Expr {
 	var tsDecorators []js_ast.Expr
-	if p.options.ts.Parse {
-		for p.lexer.Token == js_lexer.TAt {
-			p.lexer.Next()
+	for p.lexer.Token == js_lexer.TAt {
+		p.lexer.Next()
 
-			// Parse a new/call expression with "exprFlagTSDecorator" so we ignore
-			// EIndex expressions, since they may be part of a computed property:
-			//
-			//   class Foo {
-			//     @foo ['computed']() {}
-			//   }
-			//
-			// This matches the behavior of the TypeScript compiler.
-			tsDecorators = append(tsDecorators, p.parseExprWithFlags(js_ast.LNew, exprFlagTSDecorator))
-		}
+		// Parse a new/call expression with "exprFlagTSDecorator" so we ignore
+		// EIndex expressions, since they may be part of a computed property:
+		//
+		//   class Foo {
+		//     @foo ['computed']() {}
+		//   }
+		//
+		// This matches the behavior of the TypeScript compiler.
+		tsDecorators = append(tsDecorators, p.parseExprWithFlags(js_ast.LNew, exprFlagTSDecorator))
 	}
 	return tsDecorators
 }
@@ -217,6 +217,93 @@ func TestTSAsCast(t *testing.T) {
 	expectParseErrorTS(t, "(x = y as any(z));", "<stdin>: error: Expected \")\" but found \"(\"\n")
 }
 
+func TestTSSatisfies(t *testing.T) {
+	expectPrintedTS(t, "x satisfies any", "x;\n")
+	expectPrintedTS(t, "x = y satisfies Z", "x = y;\n")
+	expectPrintedTS(t, "(x satisfies Y).z", "x.z;\n")
+	expectPrintedTS(t, "x satisfies Y as Z", "x;\n")
+	expectPrintedTS(t, "x as Y satisfies Z", "x;\n")
+	expectPrintedTS(t, "x satisfies any\n(y);", "x;\ny;\n")
+	expectPrintedTS(t, "const x = { y: 1 } satisfies Record<string, number>", "const x = { y: 1 };\n")
+	expectPrintedTS(t, "let satisfies = 1; satisfies satisfies any", "let satisfies = 1;\nsatisfies;\n")
+	expectParseErrorTS(t, "x = y satisfies any(z);", "<stdin>: error: Expected \";\" but found \"(\"\n")
+	expectParseErrorTS(t, "x satisfies any = y;", "<stdin>: error: Expected \";\" but found \"=\"\n")
+	expectParseError(t, "x satisfies y", "<stdin>: error: Expected \";\" but found \"satisfies\"\n")
+}
+
+func TestTSTypeParameterModifiers(t *testing.T) {
+	expectPrintedTS(t, "interface Foo<in T> {}", "")
+	expectPrintedTS(t, "interface Foo<out T> {}", "")
+	expectPrintedTS(t, "interface Foo<in out T> {}", "")
+	expectPrintedTS(t, "interface Foo<in T, out U> {}", "")
+	expectPrintedTS(t, "interface Foo<out> {}", "")
+	expectPrintedTS(t, "interface Foo<out extends T> {}", "")
+	expectPrintedTS(t, "interface Foo<in out> {}", "")
+	expectPrintedTS(t, "type Foo<in out T = any> = T", "")
+	expectPrintedTS(t, "class Foo<in T, out U> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo<const T> {}", "class Foo {\n}\n")
+	expectPrintedTS(t, "(class <const T extends unknown[]> {})", "(class {\n});\n")
+	expectPrintedTS(t, "function foo<const T>(x: T) {}", "function foo(x) {\n}\n")
+	expectPrintedTS(t, "function foo<const T extends readonly unknown[], U>(x: T) {}", "function foo(x) {\n}\n")
+	expectPrintedTS(t, "x = <const T>(y: T) => y", "x = (y) => y;\n")
+	expectPrintedTS(t, "x = { foo<const T>(y: T) {} }", "x = { foo(y) {\n} };\n")
+	expectPrintedTSX(t, "x = <const T,>(y: T) => y", "x = (y) => y;\n")
+	expectPrintedTSX(t, "x = <const T>(y: T) => y", "x = (y) => y;\n")
+
+	expectParseErrorTS(t, "interface Foo<in> {}", "<stdin>: error: Expected identifier but found \">\"\n")
+	expectParseErrorTS(t, "interface Foo<const> {}", "<stdin>: error: Expected identifier but found \">\"\n")
+}
+
+func TestTSAutoAccessors(t *testing.T) {
+	expectPrintedTS(t, "class Foo { accessor foo: number = 1 }",
+		`var _foo;
+class Foo {
+  constructor() {
+    __privateAdd(this, _foo, 1);
+  }
+  get foo() {
+    return __privateGet(this, _foo);
+  }
+  set foo(_) {
+    __privateSet(this, _foo, _);
+  }
+}
+_foo = new WeakMap();
+`)
+	expectPrintedTS(t, "class Foo { public static accessor foo?: string }",
+		`var _foo;
+class Foo {
+  static get foo() {
+    return __privateGet(this, _foo);
+  }
+  static set foo(_) {
+    __privateSet(this, _foo, _);
+  }
+}
+_foo = new WeakMap();
+__privateAdd(Foo, _foo, void 0);
+`)
+	expectPrintedTS(t, "class Foo extends Bar { override accessor foo!: number }",
+		`var _foo;
+class Foo extends Bar {
+  constructor() {
+    super(...arguments);
+    __privateAdd(this, _foo, void 0);
+  }
+  get foo() {
+    return __privateGet(this, _foo);
+  }
+  set foo(_) {
+    __privateSet(this, _foo, _);
+  }
+}
+_foo = new WeakMap();
+`)
+	expectPrintedTS(t, "abstract class Foo { abstract accessor foo: number }", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo { declare accessor foo: number }", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo { accessor: number }", "class Foo {\n}\n")
+}
+
 func TestTSClass(t *testing.T) {
 	expectPrintedTS(t, "export default class Foo {}", "export default class Foo {\n}\n")
 	expectPrintedTS(t, "export default class Foo extends Bar<T> {}", "export default class Foo extends Bar {\n}\n")
@@ -328,6 +415,9 @@ func TestTSClass(t *testing.T) {
 	expectPrintedTS(t, "class Foo { public override static foo: number }", "class Foo {\n}\n")
 	expectPrintedTS(t, "class Foo { public static override foo: number }", "class Foo {\n}\n")
 	expectPrintedTS(t, "class Foo { declare override public static foo: number }", "class Foo {\n}\n")
+	expectPrintedTS(t, "class Foo extends Bar { override foo() {} }", "class Foo extends Bar {\n  foo() {\n  }\n}\n")
+	expectPrintedTS(t, "class Foo extends Bar { public override async *foo() {} }", "class Foo extends Bar {\n  async *foo() {\n  }\n}\n")
+	expectPrintedTS(t, "class Foo extends Bar { override get foo() { return 1 } }", "class Foo extends Bar {\n  get foo() {\n    return 1;\n  }\n}\n")
 
 	expectPrintedTS(t, "class Foo { [key: string]: any\nfoo = 0 }", "class Foo {\n  constructor() {\n    this.foo = 0;\n  }\n}\n")
 	expectPrintedTS(t, "class Foo { [key: string]: any; foo = 0 }", "class Foo {\n  constructor() {\n    this.foo = 0;\n  }\n}\n")
@@ -1484,6 +1574,12 @@ func TestTSTypeOnlyExport(t *testing.T) {
 	expectPrintedTS(t, "export type {foo} from 'bar'\nx", "x;\n")
 	expectPrintedTS(t, "export type {default} from 'bar'", "")
 	expectParseErrorTS(t, "export type {default}", "<stdin>: error: Expected identifier but found \"default\"\n")
+	expectPrintedTS(t, "export type * from 'bar'", "")
+	expectPrintedTS(t, "export type * as foo from 'bar'", "")
+	expectPrintedTS(t, "export type * as 'foo' from 'bar'", "")
+	expectPrintedTS(t, "export type * from 'bar'; x", "x;\n")
+	expectParseErrorTS(t, "export type * as foo", "<stdin>: error: Expected \"from\" but found end of file\n")
+	expectParseErrorTS(t, "export type *", "<stdin>: error: Expected \"from\" but found end of file\n")
 
 	// Named exports should be removed if they don't refer to a local symbol
 	expectPrintedTS(t, "const Foo = {}; export {Foo}", "const Foo = {};\nexport { Foo };\n")
@@ -523,9 +523,20 @@ type printer struct {
 	callTarget             js_ast.E
 	intToBytesBuffer       [64]byte
 
+	// For inlining function calls. This maps each parameter of the function
+	// currently being inlined to the corresponding argument from the call site.
+	inlinedArgs map[js_ast.Ref]js_ast.Expr
+
+	// For the SystemJS format. Import statements are removed and collected here
+	// so the linker can turn them into dependencies of the registration.
+	systemImports          []SystemImport
+	systemExportValue      js_ast.E
+	systemUpdateTempIsUsed bool
+
 	// For source maps
 	sourceMap           []byte
 	prevLoc             logger.Loc
+	isInsideInlinedExpr bool
 	prevState           SourceMapState
 	lastGeneratedUpdate int
 	generatedColumn     int
@@ -576,7 +587,9 @@ func (p *printer) printQuotedUTF8(text string, allowBacktick bool) {
 }
 
 func (p *printer) addSourceMapping(loc logger.Loc) {
-	if !p.options.AddSourceMappings || loc == p.prevLoc {
+	// Inlined expressions may come from another file, so their locations don't
+	// make sense in this file
+	if !p.options.AddSourceMappings || loc == p.prevLoc || p.isInsideInlinedExpr {
 		return
 	}
 	p.prevLoc = loc
@@ -1171,6 +1184,11 @@ func (p *printer) printProperty(item js_ast.Property) {
 		p.printSpaceBeforeIdentifier()
 		p.print("set")
 		p.printSpace()
+
+	case js_ast.PropertyAutoAccessor:
+		p.printSpaceBeforeIdentifier()
+		p.print("accessor")
+		p.printSpace()
 	}
 
 	if fn, ok := item.ValueOrNil.Data.(*js_ast.EFunction); item.IsMethod && ok {
@@ -1223,7 +1241,7 @@ func (p *printer) printProperty(item js_ast.Property) {
 			if !p.options.UnsupportedFeatures.Has(compat.ObjectExtensions) && item.ValueOrNil.Data != nil {
 				switch e := item.ValueOrNil.Data.(type) {
 				case *js_ast.EIdentifier:
-					if js_lexer.UTF16EqualsString(key.Value, p.renamer.NameForSymbol(e.Ref)) {
+					if !p.isSubstitutedIdentifier(e.Ref) && js_lexer.UTF16EqualsString(key.Value, p.renamer.NameForSymbol(e.Ref)) {
 						if item.InitializerOrNil.Data != nil {
 							p.printSpace()
 							p.print("=")
@@ -1237,7 +1255,7 @@ func (p *printer) printProperty(item js_ast.Property) {
 					// Make sure we're not using a property access instead of an identifier
 					ref := js_ast.FollowSymbols(p.symbols, e.Ref)
 					symbol := p.symbols.Get(ref)
-					if symbol.NamespaceAlias == nil && js_lexer.UTF16EqualsString(key.Value, p.renamer.NameForSymbol(e.Ref)) {
+					if !p.isSubstitutedIdentifier(e.Ref) && symbol.NamespaceAlias == nil && js_lexer.UTF16EqualsString(key.Value, p.renamer.NameForSymbol(e.Ref)) {
 						if item.InitializerOrNil.Data != nil {
 							p.printSpace()
 							p.print("=")
@@ -1329,6 +1347,22 @@ func (p *printer) printQuotedUTF16(data []uint16, allowBacktick bool) {
 	p.print(c)
 }
 
+// Prints "a.b" or "a['b-c']" for the global name "a.b" or "a.b-c"
+func (p *printer) printGlobalName(name []string) {
+	p.printSpaceBeforeIdentifier()
+	p.printIdentifier(name[0])
+	for _, part := range name[1:] {
+		if js_lexer.IsIdentifier(part) {
+			p.print(".")
+			p.printIdentifier(part)
+		} else {
+			p.print("[")
+			p.printQuotedUTF8(part, false /* allowBacktick */)
+			p.print("]")
+		}
+	}
+}
+
 func (p *printer) printRequireOrImportExpr(
 	importRecordIndex uint32,
 	leadingInteriorComments []js_ast.Comment,
@@ -1352,6 +1386,13 @@ func (p *printer) printRequireOrImportExpr(
 				defer p.print(")")
 			}
 
+			// Read the external from a global variable if there is one
+			if name, ok := p.options.ExternalGlobals[record.Path.Text]; ok {
+				p.addSourceMapping(record.Range.Loc)
+				p.printGlobalName(name)
+				return
+			}
+
 			// Potentially substitute our own "__require" stub for "require"
 			if record.CallRuntimeRequire {
 				p.printSymbol(p.options.RuntimeRequireRef)
@@ -1368,7 +1409,11 @@ func (p *printer) printRequireOrImportExpr(
 		}
 
 		// External "import()"
-		if !p.options.UnsupportedFeatures.Has(compat.DynamicImport) {
+		if p.options.OutputFormat == config.FormatSystem {
+			p.printSpaceBeforeIdentifier()
+			p.print("_context.import(")
+			defer p.print(")")
+		} else if !p.options.UnsupportedFeatures.Has(compat.DynamicImport) {
 			p.printSpaceBeforeIdentifier()
 			p.print("import(")
 			defer p.print(")")
@@ -1530,6 +1575,14 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 
 	p.addSourceMapping(expr.Loc)
 
+	// Assignments to exported symbols must notify importers in the SystemJS format
+	if p.options.SystemExportAliases != nil && expr.Data != p.systemExportValue {
+		if aliases := p.systemExportAliasesForAssign(expr); aliases != nil {
+			p.printSystemExportUpdate(expr, aliases, level, flags)
+			return
+		}
+	}
+
 	switch e := expr.Data.(type) {
 	case *js_ast.EMissing:
 
@@ -1558,7 +1611,11 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 
 	case *js_ast.EImportMeta:
 		p.printSpaceBeforeIdentifier()
-		p.print("import.meta")
+		if p.options.OutputFormat == config.FormatSystem {
+			p.print("_context.meta")
+		} else {
+			p.print("import.meta")
+		}
 
 	case *js_ast.EJSXElement:
 		// Start the opening tag
@@ -1693,6 +1750,11 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		}
 
 	case *js_ast.ECall:
+		if fn := p.inlinedFunctionForCall(e); fn != nil {
+			p.printInlinedCall(expr.Loc, fn, e.Args, level, flags)
+			break
+		}
+
 		wrap := level >= js_ast.LNew || (flags&forbidCall) != 0
 		var targetFlags printExprFlags
 		if e.OptionalChain == js_ast.OptionalChainNone {
@@ -1781,7 +1843,11 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			p.print("(")
 		}
 		p.printSpaceBeforeIdentifier()
-		p.print("import(")
+		if p.options.OutputFormat == config.FormatSystem {
+			p.print("_context.import(")
+		} else {
+			p.print("import(")
+		}
 		if len(leadingInteriorComments) > 0 {
 			p.printNewline()
 			p.options.Indent++
@@ -2180,6 +2246,10 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		}
 
 	case *js_ast.EIdentifier:
+		if p.printSubstitutedIdentifier(e.Ref, level, flags) {
+			break
+		}
+
 		name := p.renamer.NameForSymbol(e.Ref)
 		wrap := len(p.js) == p.forOfInitStart && (name == "let" ||
 			(wasFollowedByOf && (flags&isInsideForAwait) == 0 && name == "async"))
@@ -2200,7 +2270,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		ref := js_ast.FollowSymbols(p.symbols, e.Ref)
 		symbol := p.symbols.Get(ref)
 
-		if symbol.ImportItemStatus == js_ast.ImportItemMissing {
+		if p.printSubstitutedIdentifier(e.Ref, level, flags) {
+			// Nothing more to do
+		} else if symbol.ImportItemStatus == js_ast.ImportItemMissing {
 			p.printUndefined(level)
 		} else if symbol.NamespaceAlias != nil {
 			wrap := p.callTarget == e && e.WasOriginallyIdentifier
@@ -2387,6 +2459,91 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 	}
 }
 
+// Returns true if references to this symbol should be replaced with another
+// expression. This is either an argument for a function call that's being
+// inlined or a constant that the linker decided to inline.
+func (p *printer) isSubstitutedIdentifier(ref js_ast.Ref) bool {
+	if p.inlinedArgs != nil {
+		if _, ok := p.inlinedArgs[ref]; ok {
+			return true
+		}
+	}
+	if p.options.ConstValues != nil {
+		if _, ok := p.options.ConstValues[js_ast.FollowSymbols(p.symbols, ref)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *printer) printSubstitutedIdentifier(ref js_ast.Ref, level js_ast.L, flags printExprFlags) bool {
+	oldInlinedArgs := p.inlinedArgs
+	wasInsideInlinedExpr := p.isInsideInlinedExpr
+
+	if p.inlinedArgs != nil {
+		if arg, ok := p.inlinedArgs[ref]; ok {
+			// Arguments come from the call site, so they have valid source locations
+			p.inlinedArgs = nil
+			p.isInsideInlinedExpr = false
+			p.printExpr(arg, level, flags)
+			p.inlinedArgs = oldInlinedArgs
+			p.isInsideInlinedExpr = wasInsideInlinedExpr
+			return true
+		}
+	}
+
+	if p.options.ConstValues != nil {
+		if value, ok := p.options.ConstValues[js_ast.FollowSymbols(p.symbols, ref)]; ok {
+			p.isInsideInlinedExpr = true
+			p.printExpr(value, level, flags)
+			p.isInsideInlinedExpr = wasInsideInlinedExpr
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *printer) inlinedFunctionForCall(call *js_ast.ECall) *InlinedFunction {
+	if p.options.InlinedFunctions == nil || call.OptionalChain != js_ast.OptionalChainNone {
+		return nil
+	}
+
+	var ref js_ast.Ref
+	switch target := call.Target.Data.(type) {
+	case *js_ast.EIdentifier:
+		ref = target.Ref
+	case *js_ast.EImportIdentifier:
+		ref = target.Ref
+	default:
+		return nil
+	}
+
+	if fn, ok := p.options.InlinedFunctions[js_ast.FollowSymbols(p.symbols, ref)]; ok && js_ast.CallArgsCanBeInlined(call.Args) {
+		return fn
+	}
+	return nil
+}
+
+func (p *printer) printInlinedCall(loc logger.Loc, fn *InlinedFunction, args []js_ast.Expr, level js_ast.L, flags printExprFlags) {
+	inlinedArgs := make(map[js_ast.Ref]js_ast.Expr, len(fn.Params))
+	for i, param := range fn.Params {
+		if i < len(args) {
+			inlinedArgs[param] = args[i]
+		} else {
+			inlinedArgs[param] = js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared}
+		}
+	}
+
+	oldInlinedArgs := p.inlinedArgs
+	wasInsideInlinedExpr := p.isInsideInlinedExpr
+	p.inlinedArgs = inlinedArgs
+	p.isInsideInlinedExpr = true
+	p.printExpr(fn.Body, level, flags)
+	p.inlinedArgs = oldInlinedArgs
+	p.isInsideInlinedExpr = wasInsideInlinedExpr
+}
+
 func (p *printer) isUnboundEvalIdentifier(value js_ast.Expr) bool {
 	if id, ok := value.Data.(*js_ast.EIdentifier); ok {
 		// Using the original name here is ok since unbound symbols are not renamed
@@ -2827,6 +2984,410 @@ func (p *printer) printImportAssertionsClause(assertions []ast.AssertEntry) {
 	p.print("}")
 }
 
+func appendBindingRefs(refs []js_ast.Ref, binding js_ast.Binding) []js_ast.Ref {
+	switch b := binding.Data.(type) {
+	case *js_ast.BIdentifier:
+		refs = append(refs, b.Ref)
+	case *js_ast.BArray:
+		for _, item := range b.Items {
+			refs = appendBindingRefs(refs, item.Binding)
+		}
+	case *js_ast.BObject:
+		for _, property := range b.Properties {
+			refs = appendBindingRefs(refs, property.Value)
+		}
+	}
+	return refs
+}
+
+// Appends an export clause item for each export name of this symbol
+func (p *printer) appendSystemExportItems(items []js_ast.ClauseItem, ref js_ast.Ref) []js_ast.ClauseItem {
+	for _, alias := range p.options.SystemExportAliases[js_ast.FollowSymbols(p.symbols, ref)] {
+		items = append(items, js_ast.ClauseItem{Alias: alias, Name: js_ast.LocRef{Ref: ref}})
+	}
+	return items
+}
+
+// Appends the exports of all symbols that are assigned to by this assignment
+// target, which is either an identifier or a destructuring pattern
+func (p *printer) appendSystemExportItemsForTarget(items []js_ast.ClauseItem, target js_ast.Expr) []js_ast.ClauseItem {
+	switch t := target.Data.(type) {
+	case *js_ast.EIdentifier:
+		items = p.appendSystemExportItems(items, t.Ref)
+
+	case *js_ast.EImportIdentifier:
+		items = p.appendSystemExportItems(items, t.Ref)
+
+	case *js_ast.EBinary:
+		if t.Op == js_ast.BinOpAssign {
+			items = p.appendSystemExportItemsForTarget(items, t.Left)
+		}
+
+	case *js_ast.ESpread:
+		items = p.appendSystemExportItemsForTarget(items, t.Value)
+
+	case *js_ast.EArray:
+		for _, item := range t.Items {
+			items = p.appendSystemExportItemsForTarget(items, item)
+		}
+
+	case *js_ast.EObject:
+		for _, property := range t.Properties {
+			items = p.appendSystemExportItemsForTarget(items, property.ValueOrNil)
+		}
+	}
+	return items
+}
+
+// Destructuring assignments can assign to many exported symbols at once and
+// can't be wrapped in a single "_export()" call, so the exports for all of
+// them are updated after the statement instead. This returns the exports for
+// all destructuring assignments in this expression. Nested functions and
+// classes aren't traversed since their statements are handled separately.
+func (p *printer) appendSystemExportItemsForPatterns(items []js_ast.ClauseItem, expr js_ast.Expr) []js_ast.ClauseItem {
+	switch e := expr.Data.(type) {
+	case *js_ast.EBinary:
+		if e.Op == js_ast.BinOpAssign {
+			switch e.Left.Data.(type) {
+			case *js_ast.EArray, *js_ast.EObject:
+				items = p.appendSystemExportItemsForTarget(items, e.Left)
+			}
+		}
+		items = p.appendSystemExportItemsForPatterns(items, e.Left)
+		items = p.appendSystemExportItemsForPatterns(items, e.Right)
+
+	case *js_ast.EUnary:
+		items = p.appendSystemExportItemsForPatterns(items, e.Value)
+
+	case *js_ast.EIf:
+		items = p.appendSystemExportItemsForPatterns(items, e.Test)
+		items = p.appendSystemExportItemsForPatterns(items, e.Yes)
+		items = p.appendSystemExportItemsForPatterns(items, e.No)
+
+	case *js_ast.ECall:
+		items = p.appendSystemExportItemsForPatterns(items, e.Target)
+		for _, arg := range e.Args {
+			items = p.appendSystemExportItemsForPatterns(items, arg)
+		}
+
+	case *js_ast.ENew:
+		items = p.appendSystemExportItemsForPatterns(items, e.Target)
+		for _, arg := range e.Args {
+			items = p.appendSystemExportItemsForPatterns(items, arg)
+		}
+
+	case *js_ast.EDot:
+		items = p.appendSystemExportItemsForPatterns(items, e.Target)
+
+	case *js_ast.EIndex:
+		items = p.appendSystemExportItemsForPatterns(items, e.Target)
+		items = p.appendSystemExportItemsForPatterns(items, e.Index)
+
+	case *js_ast.ESpread:
+		items = p.appendSystemExportItemsForPatterns(items, e.Value)
+
+	case *js_ast.EAwait:
+		items = p.appendSystemExportItemsForPatterns(items, e.Value)
+
+	case *js_ast.EYield:
+		if e.ValueOrNil.Data != nil {
+			items = p.appendSystemExportItemsForPatterns(items, e.ValueOrNil)
+		}
+
+	case *js_ast.EArray:
+		for _, item := range e.Items {
+			items = p.appendSystemExportItemsForPatterns(items, item)
+		}
+
+	case *js_ast.EObject:
+		for _, property := range e.Properties {
+			if property.ValueOrNil.Data != nil {
+				items = p.appendSystemExportItemsForPatterns(items, property.ValueOrNil)
+			}
+		}
+
+	case *js_ast.ETemplate:
+		if e.TagOrNil.Data != nil {
+			items = p.appendSystemExportItemsForPatterns(items, e.TagOrNil)
+		}
+		for _, part := range e.Parts {
+			items = p.appendSystemExportItemsForPatterns(items, part.Value)
+		}
+	}
+	return items
+}
+
+// Returns the exports that must be updated at the start of every iteration
+// of a loop. These are the symbols that the loop header assigns to, either as
+// the loop variable of a "for-in" or "for-of" loop or using a destructuring
+// assignment.
+func (p *printer) systemExportItemsForLoop(stmt js_ast.Stmt) (items []js_ast.ClauseItem) {
+	if p.options.SystemExportAliases == nil {
+		return
+	}
+	switch s := stmt.Data.(type) {
+	case *js_ast.SFor:
+		if init, ok := s.InitOrNil.Data.(*js_ast.SExpr); ok {
+			items = p.appendSystemExportItemsForPatterns(items, init.Value)
+		}
+		if s.TestOrNil.Data != nil {
+			items = p.appendSystemExportItemsForPatterns(items, s.TestOrNil)
+		}
+		if s.UpdateOrNil.Data != nil {
+			items = p.appendSystemExportItemsForPatterns(items, s.UpdateOrNil)
+		}
+
+	case *js_ast.SForIn:
+		items = p.appendSystemExportItemsForLoopInit(items, s.Init)
+		items = p.appendSystemExportItemsForPatterns(items, s.Value)
+
+	case *js_ast.SForOf:
+		items = p.appendSystemExportItemsForLoopInit(items, s.Init)
+		items = p.appendSystemExportItemsForPatterns(items, s.Value)
+	}
+	return
+}
+
+func (p *printer) appendSystemExportItemsForLoopInit(items []js_ast.ClauseItem, init js_ast.Stmt) []js_ast.ClauseItem {
+	switch s := init.Data.(type) {
+	case *js_ast.SExpr:
+		items = p.appendSystemExportItemsForTarget(items, s.Value)
+
+	case *js_ast.SLocal:
+		if s.Kind == js_ast.LocalVar {
+			for _, decl := range s.Decls {
+				for _, ref := range appendBindingRefs(nil, decl.Binding) {
+					items = p.appendSystemExportItems(items, ref)
+				}
+			}
+		}
+	}
+	return items
+}
+
+// Prints the body of a loop with the given export updates at the start
+func (p *printer) printLoopBodyWithSystemExports(body js_ast.Stmt, items []js_ast.ClauseItem) {
+	if len(items) == 0 {
+		p.printBody(body)
+		return
+	}
+	stmts := []js_ast.Stmt{{Loc: body.Loc, Data: &js_ast.SExportClause{Items: items}}}
+	switch b := body.Data.(type) {
+	case *js_ast.SBlock:
+		stmts = append(stmts, b.Stmts...)
+	case *js_ast.SEmpty:
+	default:
+		stmts = append(stmts, body)
+	}
+	p.printBody(js_ast.Stmt{Loc: body.Loc, Data: &js_ast.SBlock{Stmts: stmts}})
+}
+
+// Prints "_export({ a: x, b: y });" for the SystemJS format. The export names
+// default to the symbol names if "aliases" is nil.
+func (p *printer) printSystemExportRefs(refs []js_ast.Ref, aliases []string) {
+	// Exports of hoisted functions are done in the declaration prologue
+	if p.options.SystemHoistedExports != nil {
+		end := 0
+		for i, ref := range refs {
+			if !p.options.SystemHoistedExports[js_ast.FollowSymbols(p.symbols, ref)] {
+				refs[end] = ref
+				if aliases != nil {
+					aliases[end] = aliases[i]
+				}
+				end++
+			}
+		}
+		refs = refs[:end]
+		if aliases != nil {
+			aliases = aliases[:end]
+		}
+	}
+
+	if len(refs) == 0 {
+		return
+	}
+	p.printIndent()
+	p.printSpaceBeforeIdentifier()
+	p.printSystemExportObject(refs, aliases)
+	p.printSemicolonAfterStatement()
+}
+
+// Prints "_export({ a: x, b: y })" without a trailing semicolon
+func (p *printer) printSystemExportCall(items []js_ast.ClauseItem) {
+	refs := make([]js_ast.Ref, len(items))
+	aliases := make([]string, len(items))
+	for i, item := range items {
+		refs[i] = item.Name.Ref
+		aliases[i] = item.Alias
+	}
+	p.printSpaceBeforeIdentifier()
+	p.printSystemExportObject(refs, aliases)
+}
+
+func (p *printer) printSystemExportObject(refs []js_ast.Ref, aliases []string) {
+	p.print("_export({")
+	for i, ref := range refs {
+		if i != 0 {
+			p.print(",")
+		}
+		p.printSpace()
+		name := p.renamer.NameForSymbol(ref)
+		if aliases != nil {
+			p.printClauseAlias(aliases[i])
+		} else {
+			p.printIdentifier(name)
+		}
+		p.print(":")
+		p.printSpace()
+		p.printIdentifier(name)
+	}
+	p.printSpace()
+	p.print("})")
+}
+
+func (p *printer) printSystemExportDefault(s *js_ast.SExportDefault) {
+	switch s2 := s.Value.Data.(type) {
+	case *js_ast.SExpr:
+		p.printIndent()
+		p.printSpaceBeforeIdentifier()
+		p.print("_export(\"default\",")
+		p.printSpace()
+		p.printExpr(s2.Value, js_ast.LComma, 0)
+		p.print(")")
+		p.printSemicolonAfterStatement()
+
+	case *js_ast.SFunction:
+		if s2.Fn.Name == nil {
+			s2 = &js_ast.SFunction{Fn: s2.Fn}
+			s2.Fn.Name = &js_ast.LocRef{Loc: s.DefaultName.Loc, Ref: s.DefaultName.Ref}
+		}
+		p.printStmt(js_ast.Stmt{Loc: s.Value.Loc, Data: s2})
+		p.printSystemExportRefs([]js_ast.Ref{s2.Fn.Name.Ref}, []string{"default"})
+
+	case *js_ast.SClass:
+		if s2.Class.Name == nil {
+			s2 = &js_ast.SClass{Class: s2.Class}
+			s2.Class.Name = &js_ast.LocRef{Loc: s.DefaultName.Loc, Ref: s.DefaultName.Ref}
+		}
+		p.printStmt(js_ast.Stmt{Loc: s.Value.Loc, Data: s2})
+		p.printSystemExportRefs([]js_ast.Ref{s2.Class.Name.Ref}, []string{"default"})
+
+	default:
+		panic("Internal error")
+	}
+}
+
+// Returns the export names of the symbol that this expression assigns to, if
+// any. Only assignments to identifiers need to be handled since exported
+// symbols can't be the target of a property assignment.
+func (p *printer) systemExportAliasesForAssign(expr js_ast.Expr) []string {
+	var target js_ast.Expr
+	switch e := expr.Data.(type) {
+	case *js_ast.EBinary:
+		if e.Op.BinaryAssignTarget() == js_ast.AssignTargetNone {
+			return nil
+		}
+		target = e.Left
+	case *js_ast.EUnary:
+		if e.Op.UnaryAssignTarget() == js_ast.AssignTargetNone {
+			return nil
+		}
+		target = e.Value
+	default:
+		return nil
+	}
+
+	var ref js_ast.Ref
+	switch t := target.Data.(type) {
+	case *js_ast.EIdentifier:
+		ref = t.Ref
+	case *js_ast.EImportIdentifier:
+		ref = t.Ref
+	default:
+		return nil
+	}
+	return p.options.SystemExportAliases[js_ast.FollowSymbols(p.symbols, ref)]
+}
+
+// Prints "_export("a", x = y)" for assignments and prefix updates, and
+// "(_export("a", x + 1), x++)" for postfix updates so that the value of the
+// expression is unchanged.
+// The new value is passed to "_export()" after the assignment has happened.
+// A postfix update is turned into a prefix update if its value is unused.
+// Otherwise the value from before the update is kept in a temporary:
+//
+//   _export("n", ++n);
+//   (_t = n++, _export("n", n), _t)
+//
+// This does the real update instead of printing "n + 1" so that coercion
+// behaves the same (e.g. for strings and BigInts).
+func (p *printer) printSystemExportUpdate(expr js_ast.Expr, aliases []string, level js_ast.L, flags printExprFlags) {
+	if e, ok := expr.Data.(*js_ast.EUnary); ok && !e.Op.IsPrefix() {
+		if (flags & exprResultIsUnused) != 0 {
+			op := js_ast.UnOpPreInc
+			if e.Op == js_ast.UnOpPostDec {
+				op = js_ast.UnOpPreDec
+			}
+			expr = js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EUnary{Op: op, Value: e.Value}}
+		} else {
+			wrap := level >= js_ast.LComma
+			if wrap {
+				p.print("(")
+			}
+			p.systemUpdateTempIsUsed = true
+			p.printSpaceBeforeIdentifier()
+			p.print(SystemUpdateTempName)
+			p.printSpace()
+			p.print("=")
+			p.printSpace()
+			old := p.systemExportValue
+			p.systemExportValue = expr.Data
+			p.printExpr(expr, js_ast.LAssign, 0)
+			p.systemExportValue = old
+			p.print(",")
+			p.printSpace()
+			p.printSystemExportCalls(e.Value, aliases)
+			p.print(",")
+			p.printSpace()
+			p.printSpaceBeforeIdentifier()
+			p.print(SystemUpdateTempName)
+			if wrap {
+				p.print(")")
+			}
+			return
+		}
+	}
+
+	wrap := level >= js_ast.LNew || (flags&forbidCall) != 0
+	if wrap {
+		p.print("(")
+	}
+	p.printSystemExportCalls(expr, aliases)
+	if wrap {
+		p.print(")")
+	}
+}
+
+// This prints "_export("a", _export("b", value))" for each alias
+func (p *printer) printSystemExportCalls(value js_ast.Expr, aliases []string) {
+	for _, alias := range aliases {
+		p.printSpaceBeforeIdentifier()
+		p.print("_export(")
+		p.printQuotedUTF8(alias, false /* allowBacktick */)
+		p.print(",")
+		p.printSpace()
+	}
+
+	old := p.systemExportValue
+	p.systemExportValue = value.Data
+	p.printExpr(value, js_ast.LComma, 0)
+	p.systemExportValue = old
+
+	for range aliases {
+		p.print(")")
+	}
+}
+
 func (p *printer) printStmt(stmt js_ast.Stmt) {
 	p.addSourceMapping(stmt.Loc)
 
@@ -2855,7 +3416,8 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 	case *js_ast.SFunction:
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
-		if s.IsExport {
+		isSystemExport := s.IsExport && p.options.OutputFormat == config.FormatSystem
+		if s.IsExport && !isSystemExport {
 			p.print("export ")
 		}
 		if s.Fn.IsAsync {
@@ -2869,17 +3431,24 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSymbol(s.Fn.Name.Ref)
 		p.printFn(s.Fn)
 		p.printNewline()
+		if isSystemExport {
+			p.printSystemExportRefs([]js_ast.Ref{s.Fn.Name.Ref}, nil)
+		}
 
 	case *js_ast.SClass:
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
-		if s.IsExport {
+		isSystemExport := s.IsExport && p.options.OutputFormat == config.FormatSystem
+		if s.IsExport && !isSystemExport {
 			p.print("export ")
 		}
 		p.print("class")
 		p.printSymbol(s.Class.Name.Ref)
 		p.printClass(s.Class)
 		p.printNewline()
+		if isSystemExport {
+			p.printSystemExportRefs([]js_ast.Ref{s.Class.Name.Ref}, nil)
+		}
 
 	case *js_ast.SEmpty:
 		p.printIndent()
@@ -2887,6 +3456,11 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printNewline()
 
 	case *js_ast.SExportDefault:
+		if p.options.OutputFormat == config.FormatSystem {
+			p.printSystemExportDefault(s)
+			return
+		}
+
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("export default")
@@ -2931,6 +3505,17 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		}
 
 	case *js_ast.SExportStar:
+		if p.options.OutputFormat == config.FormatSystem {
+			item := SystemImport{Path: p.importRecords[s.ImportRecordIndex].Path.Text}
+			if s.Alias != nil {
+				item.ReExports = []SystemBinding{{Name: s.Alias.OriginalName, Imported: "*"}}
+			} else {
+				item.ExportStar = true
+			}
+			p.systemImports = append(p.systemImports, item)
+			return
+		}
+
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("export")
@@ -2950,6 +3535,17 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSemicolonAfterStatement()
 
 	case *js_ast.SExportClause:
+		if p.options.OutputFormat == config.FormatSystem {
+			refs := make([]js_ast.Ref, len(s.Items))
+			aliases := make([]string, len(s.Items))
+			for i, item := range s.Items {
+				refs[i] = item.Name.Ref
+				aliases[i] = item.Alias
+			}
+			p.printSystemExportRefs(refs, aliases)
+			return
+		}
+
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("export")
@@ -2993,6 +3589,15 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSemicolonAfterStatement()
 
 	case *js_ast.SExportFrom:
+		if p.options.OutputFormat == config.FormatSystem {
+			item := SystemImport{Path: p.importRecords[s.ImportRecordIndex].Path.Text}
+			for _, clause := range s.Items {
+				item.ReExports = append(item.ReExports, SystemBinding{Name: clause.Alias, Imported: clause.OriginalName})
+			}
+			p.systemImports = append(p.systemImports, item)
+			return
+		}
+
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("export")
@@ -3041,13 +3646,21 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSemicolonAfterStatement()
 
 	case *js_ast.SLocal:
+		isSystemExport := s.IsExport && p.options.OutputFormat == config.FormatSystem
 		switch s.Kind {
 		case js_ast.LocalConst:
-			p.printDeclStmt(s.IsExport, "const", s.Decls)
+			p.printDeclStmt(s.IsExport && !isSystemExport, "const", s.Decls)
 		case js_ast.LocalLet:
-			p.printDeclStmt(s.IsExport, "let", s.Decls)
+			p.printDeclStmt(s.IsExport && !isSystemExport, "let", s.Decls)
 		case js_ast.LocalVar:
-			p.printDeclStmt(s.IsExport, "var", s.Decls)
+			p.printDeclStmt(s.IsExport && !isSystemExport, "var", s.Decls)
+		}
+		if isSystemExport {
+			var refs []js_ast.Ref
+			for _, decl := range s.Decls {
+				refs = appendBindingRefs(refs, decl.Binding)
+			}
+			p.printSystemExportRefs(refs, nil)
 		}
 
 	case *js_ast.SIf:
@@ -3078,6 +3691,7 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSemicolonAfterStatement()
 
 	case *js_ast.SForIn:
+		exports := p.systemExportItemsForLoop(stmt)
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("for")
@@ -3090,9 +3704,10 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSpace()
 		p.printExpr(s.Value, js_ast.LLowest, 0)
 		p.print(")")
-		p.printBody(s.Body)
+		p.printLoopBodyWithSystemExports(s.Body, exports)
 
 	case *js_ast.SForOf:
+		exports := p.systemExportItemsForLoop(stmt)
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("for")
@@ -3113,7 +3728,7 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printSpace()
 		p.printExpr(s.Value, js_ast.LComma, 0)
 		p.print(")")
-		p.printBody(s.Body)
+		p.printLoopBodyWithSystemExports(s.Body, exports)
 
 	case *js_ast.SWhile:
 		p.printIndent()
@@ -3171,6 +3786,7 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printNewline()
 
 	case *js_ast.SFor:
+		exports := p.systemExportItemsForLoop(stmt)
 		p.printIndent()
 		p.printSpaceBeforeIdentifier()
 		p.print("for")
@@ -3190,7 +3806,7 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 			p.printExpr(s.UpdateOrNil, js_ast.LLowest, 0)
 		}
 		p.print(")")
-		p.printBody(s.Body)
+		p.printLoopBodyWithSystemExports(s.Body, exports)
 
 	case *js_ast.SSwitch:
 		p.printIndent()
@@ -3243,6 +3859,31 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.needsSemicolon = false
 
 	case *js_ast.SImport:
+		if p.options.OutputFormat == config.FormatSystem {
+			item := SystemImport{Path: p.importRecords[s.ImportRecordIndex].Path.Text}
+			addBinding := func(ref js_ast.Ref, imported string) {
+				item.Bindings = append(item.Bindings, SystemBinding{Name: p.renamer.NameForSymbol(ref), Imported: imported})
+
+				// Imported symbols that are also exported must be forwarded on update
+				for _, alias := range p.options.SystemExportAliases[js_ast.FollowSymbols(p.symbols, ref)] {
+					item.ReExports = append(item.ReExports, SystemBinding{Name: alias, Imported: imported})
+				}
+			}
+			if s.DefaultName != nil {
+				addBinding(s.DefaultName.Ref, "default")
+			}
+			if s.Items != nil {
+				for _, clause := range *s.Items {
+					addBinding(clause.Name.Ref, clause.Alias)
+				}
+			}
+			if s.StarNameLoc != nil {
+				addBinding(s.NamespaceRef, "*")
+			}
+			p.systemImports = append(p.systemImports, item)
+			return
+		}
+
 		itemCount := 0
 
 		p.printIndent()
@@ -3382,6 +4023,15 @@ func (p *printer) printStmt(stmt js_ast.Stmt) {
 		p.printIndent()
 		p.stmtStart = len(p.js)
 		p.printExpr(s.Value, js_ast.LLowest, exprResultIsUnused)
+		if p.options.SystemExportAliases != nil {
+			// Update the exports in the same statement so that this still works
+			// when the statement is the body of an "if" statement or a loop
+			if items := p.appendSystemExportItemsForPatterns(nil, s.Value); len(items) > 0 {
+				p.print(",")
+				p.printSpace()
+				p.printSystemExportCall(items)
+			}
+		}
 		p.printSemicolonAfterStatement()
 
 	default:
@@ -3411,6 +4061,27 @@ type Options struct {
 	UnsupportedFeatures          compat.JSFeature
 	RequireOrImportMetaForSource func(uint32) RequireOrImportMeta
 
+	// These are filled in by the linker when inlining is enabled. References to
+	// symbols in "ConstValues" are replaced by the value, and calls to functions
+	// in "InlinedFunctions" are replaced by the function body.
+	ConstValues      map[js_ast.Ref]js_ast.Expr
+	InlinedFunctions map[js_ast.Ref]*InlinedFunction
+
+	// This maps the paths of external modules to the global variables that they
+	// are read from instead of calling "require()". For example, the path
+	// "react" may map to "window.React".
+	ExternalGlobals map[string][]string
+
+	// This is filled in by the linker for the SystemJS format. It maps each
+	// exported symbol to its export names. Assignments to these symbols are
+	// wrapped in calls to "_export()" since that format has no live bindings.
+	SystemExportAliases map[js_ast.Ref][]string
+
+	// These are exported functions that the linker moved into the declaration
+	// prologue of the SystemJS wrapper, which also exports them. Other exports
+	// of these symbols are omitted.
+	SystemHoistedExports map[js_ast.Ref]bool
+
 	// If we're writing out a source map, this table of line start indices lets
 	// us do binary search on to figure out what line a given AST node came from
 	LineOffsetTables []LineOffsetTable
@@ -3429,6 +4100,14 @@ type RequireOrImportMeta struct {
 	IsWrapperAsync bool
 }
 
+// The body of a function that can be inlined at its call sites. The body must
+// be an expression that doesn't have side effects and that only references
+// the parameters and top-level symbols.
+type InlinedFunction struct {
+	Params []js_ast.Ref
+	Body   js_ast.Expr
+}
+
 type SourceMapChunk struct {
 	Buffer []byte
 
@@ -3453,6 +4132,32 @@ type PrintResult struct {
 	SourceMapChunk SourceMapChunk
 
 	ExtractedLegalComments map[string]bool
+
+	// Import and re-export statements that were removed from the output because
+	// the output format is SystemJS. The linker turns these into dependencies.
+	SystemImports []SystemImport
+
+	// This is true if the code uses "SystemUpdateTempName". The linker must
+	// declare it in the SystemJS wrapper.
+	SystemUpdateTempIsUsed bool
+}
+
+// Postfix updates of exported variables in the SystemJS format store the old
+// value in this variable. The linker reserves this name.
+const SystemUpdateTempName = "_t"
+
+type SystemImport struct {
+	Path       string
+	Bindings   []SystemBinding
+	ReExports  []SystemBinding
+	ExportStar bool
+}
+
+// For bindings, "Name" is the local name. For re-exports, "Name" is the
+// export name. "Imported" is "*" for the module namespace object.
+type SystemBinding struct {
+	Name     string
+	Imported string
 }
 
 func Print(tree js_ast.AST, symbols js_ast.SymbolMap, r renamer.Renamer, options Options) PrintResult {
@@ -3504,6 +4209,8 @@ func Print(tree js_ast.AST, symbols js_ast.SymbolMap, r renamer.Renamer, options
 	return PrintResult{
 		JS:                     p.js,
 		ExtractedLegalComments: p.extractedLegalComments,
+		SystemImports:          p.systemImports,
+		SystemUpdateTempIsUsed: p.systemUpdateTempIsUsed,
 		SourceMapChunk: SourceMapChunk{
 			Buffer:               p.sourceMap,
 			EndState:             p.prevState,
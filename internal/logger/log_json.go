@@ -0,0 +1,119 @@
+package logger
+
+// This implements "--log-format=json", which writes each log message as a
+// single line of JSON instead of the human-readable format. It's intended for
+// tools that consume esbuild's output (e.g. CI annotators) so that they don't
+// have to parse terminal output.
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type LogFormat uint8
+
+const (
+	LogFormatText LogFormat = iota
+	LogFormatJSON
+)
+
+type locationJSON struct {
+	File       string `json:"file"`
+	Namespace  string `json:"namespace"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Length     int    `json:"length"`
+	LineText   string `json:"lineText"`
+	Suggestion string `json:"suggestion"`
+}
+
+type noteJSON struct {
+	Text     string        `json:"text"`
+	Location *locationJSON `json:"location"`
+}
+
+type msgJSON struct {
+	Kind       string        `json:"kind"`
+	ID         string        `json:"id"`
+	PluginName string        `json:"pluginName"`
+	Text       string        `json:"text"`
+	Location   *locationJSON `json:"location"`
+	Notes      []noteJSON    `json:"notes"`
+}
+
+type summaryJSON struct {
+	Kind        string              `json:"kind"`
+	OutputFiles []summaryOutputJSON `json:"outputFiles"`
+	Time        *int64              `json:"time,omitempty"`
+}
+
+type summaryOutputJSON struct {
+	Path  string `json:"path"`
+	Bytes int    `json:"bytes"`
+}
+
+// Each record must fit on a single line, which "encoding/json" guarantees
+// since it escapes all control characters in strings
+func encodeJSON(value interface{}) string {
+	sb := strings.Builder{}
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		panic("Internal error: " + err.Error())
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func toLocationJSON(loc *MsgLocation) *locationJSON {
+	if loc == nil {
+		return nil
+	}
+	return &locationJSON{
+		File:       loc.File,
+		Namespace:  loc.Namespace,
+		Line:       loc.Line,
+		Column:     loc.Column,
+		Length:     loc.Length,
+		LineText:   loc.LineText,
+		Suggestion: loc.Suggestion,
+	}
+}
+
+// This returns a single line of JSON without a trailing newline
+func (msg Msg) JSON() string {
+	notes := make([]noteJSON, 0, len(msg.Notes))
+	for _, note := range msg.Notes {
+		notes = append(notes, noteJSON{Text: note.Text, Location: toLocationJSON(note.Location)})
+	}
+	return encodeJSON(msgJSON{
+		Kind:       msg.Kind.String(),
+		ID:         MsgIDToString(msg.ID),
+		PluginName: msg.PluginName,
+		Text:       msg.Data.Text,
+		Location:   toLocationJSON(msg.Data.Location),
+		Notes:      notes,
+	})
+}
+
+// This is the JSON equivalent of "PrintSummary". Unlike the human-readable
+// table, every output file is included and the paths are not truncated.
+func PrintSummaryJSON(table SummaryTable, start *time.Time) {
+	var elapsed *int64
+	if start != nil {
+		ms := time.Since(*start).Milliseconds()
+		elapsed = &ms
+	}
+	os.Stderr.WriteString(summaryJSONLine(table, elapsed) + "\n")
+}
+
+func summaryJSONLine(table SummaryTable, elapsed *int64) string {
+	sort.Sort(table)
+	outputFiles := make([]summaryOutputJSON, 0, len(table))
+	for _, entry := range table {
+		outputFiles = append(outputFiles, summaryOutputJSON{Path: entry.Dir + entry.Base, Bytes: entry.Bytes})
+	}
+	return encodeJSON(summaryJSON{Kind: "summary", OutputFiles: outputFiles, Time: elapsed})
+}
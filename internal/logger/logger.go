@@ -79,6 +79,7 @@ type Msg struct {
 	Kind       MsgKind
 	Data       MsgData
 	Notes      []MsgData
+	ID         MsgID
 }
 
 type MsgData struct {
@@ -384,6 +385,20 @@ func NewStderrLog(options OutputOptions) Log {
 	var deferredWarnings []Msg
 	didFinalizeLog := false
 
+	// Tools consuming JSON output want every message, so there is no limit
+	if options.Format == LogFormatJSON {
+		options.MessageLimit = 0
+		remainingMessagesBeforeLimit = 0x7FFFFFFF
+	}
+
+	writeMsg := func(msg Msg) {
+		if options.Format == LogFormatJSON {
+			os.Stderr.WriteString(msg.JSON() + "\n")
+		} else {
+			writeStringWithColor(os.Stderr, msg.String(options, terminalInfo))
+		}
+	}
+
 	finalizeLog := func() {
 		if didFinalizeLog {
 			return
@@ -393,7 +408,7 @@ func NewStderrLog(options OutputOptions) Log {
 		// Print the deferred warning now if there was no error after all
 		for remainingMessagesBeforeLimit > 0 && len(deferredWarnings) > 0 {
 			shownWarnings++
-			writeStringWithColor(os.Stderr, deferredWarnings[0].String(options, terminalInfo))
+			writeMsg(deferredWarnings[0])
 			deferredWarnings = deferredWarnings[1:]
 			remainingMessagesBeforeLimit--
 		}
@@ -402,7 +417,7 @@ func NewStderrLog(options OutputOptions) Log {
 		if options.MessageLimit > 0 && errors+warnings > options.MessageLimit {
 			writeStringWithColor(os.Stderr, fmt.Sprintf("%s shown (disable the message limit with --log-limit=0)\n",
 				errorAndWarningSummary(errors, warnings, shownErrors, shownWarnings)))
-		} else if options.LogLevel <= LevelInfo && (warnings != 0 || errors != 0) {
+		} else if options.LogLevel <= LevelInfo && (warnings != 0 || errors != 0) && options.Format != LogFormatJSON {
 			writeStringWithColor(os.Stderr, fmt.Sprintf("%s\n",
 				errorAndWarningSummary(errors, warnings, shownErrors, shownWarnings)))
 		}
@@ -419,6 +434,9 @@ func NewStderrLog(options OutputOptions) Log {
 		Level: options.LogLevel,
 
 		AddMsg: func(msg Msg) {
+			if !applyLogOverride(options.Overrides, &msg) {
+				return
+			}
 			mutex.Lock()
 			defer mutex.Unlock()
 			msgs = append(msgs, msg)
@@ -426,17 +444,17 @@ func NewStderrLog(options OutputOptions) Log {
 			switch msg.Kind {
 			case Verbose:
 				if options.LogLevel <= LevelVerbose {
-					writeStringWithColor(os.Stderr, msg.String(options, terminalInfo))
+					writeMsg(msg)
 				}
 
 			case Debug:
 				if options.LogLevel <= LevelDebug {
-					writeStringWithColor(os.Stderr, msg.String(options, terminalInfo))
+					writeMsg(msg)
 				}
 
 			case Info:
 				if options.LogLevel <= LevelInfo {
-					writeStringWithColor(os.Stderr, msg.String(options, terminalInfo))
+					writeMsg(msg)
 				}
 
 			case Error:
@@ -460,7 +478,7 @@ func NewStderrLog(options OutputOptions) Log {
 			case Error:
 				if options.LogLevel <= LevelError {
 					shownErrors++
-					writeStringWithColor(os.Stderr, msg.String(options, terminalInfo))
+					writeMsg(msg)
 					remainingMessagesBeforeLimit--
 				}
 
@@ -468,7 +486,7 @@ func NewStderrLog(options OutputOptions) Log {
 				if options.LogLevel <= LevelWarning {
 					if remainingMessagesBeforeLimit > (options.MessageLimit+1)/2 {
 						shownWarnings++
-						writeStringWithColor(os.Stderr, msg.String(options, terminalInfo))
+						writeMsg(msg)
 						remainingMessagesBeforeLimit--
 					} else {
 						// If we have less than half of the slots left, wait for potential
@@ -528,6 +546,8 @@ func OutputOptionsForArgs(osArgs []string) OutputOptions {
 			options.LogLevel = LevelError
 		case "--log-level=silent":
 			options.LogLevel = LevelSilent
+		case "--log-format=json":
+			options.Format = LogFormatJSON
 		}
 	}
 
@@ -865,6 +885,34 @@ type OutputOptions struct {
 	MessageLimit  int
 	Color         UseColor
 	LogLevel      LogLevel
+	Format        LogFormat
+
+	// This changes the log level of individual kinds of non-error messages
+	Overrides map[MsgID]LogLevel
+}
+
+// Returns false if the override says that the message should be dropped
+func applyLogOverride(overrides map[MsgID]LogLevel, msg *Msg) bool {
+	if msg.ID == MsgID_None || msg.Kind == Error {
+		return true
+	}
+	if level, ok := overrides[msg.ID]; ok {
+		switch level {
+		case LevelVerbose:
+			msg.Kind = Verbose
+		case LevelDebug:
+			msg.Kind = Debug
+		case LevelInfo:
+			msg.Kind = Info
+		case LevelWarning:
+			msg.Kind = Warning
+		case LevelError:
+			msg.Kind = Error
+		case LevelSilent:
+			return false
+		}
+	}
+	return true
 }
 
 func (msg Msg) String(options OutputOptions, terminalInfo TerminalInfo) string {
@@ -1366,6 +1414,23 @@ func renderTabStops(withTabs string, spacesPerTab int) string {
 	return withoutTabs.String()
 }
 
+func (log Log) AddID(id MsgID, kind MsgKind, tracker *LineColumnTracker, r Range, text string) {
+	log.AddMsg(Msg{
+		ID:   id,
+		Kind: kind,
+		Data: RangeData(tracker, r, text),
+	})
+}
+
+func (log Log) AddIDWithNotes(id MsgID, kind MsgKind, tracker *LineColumnTracker, r Range, text string, notes []MsgData) {
+	log.AddMsg(Msg{
+		ID:    id,
+		Kind:  kind,
+		Data:  RangeData(tracker, r, text),
+		Notes: notes,
+	})
+}
+
 func (log Log) AddError(tracker *LineColumnTracker, loc Loc, text string) {
 	log.AddMsg(Msg{
 		Kind: Error,
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"testing"
+)
+
+func TestLogOverride(t *testing.T) {
+	overrides := map[MsgID]LogLevel{
+		MsgID_JS_EqualsNaN:          LevelError,
+		MsgID_JS_DuplicateCase:      LevelVerbose,
+		MsgID_JS_DuplicateObjectKey: LevelSilent,
+		MsgID_JS_SyntaxError:        LevelWarning,
+	}
+	check := func(name string, msg Msg, expectedKeep bool, expectedKind MsgKind) {
+		t.Helper()
+		keep := applyLogOverride(overrides, &msg)
+		if keep != expectedKeep {
+			t.Fatalf("%s: expected keep to be %v but got %v", name, expectedKeep, keep)
+		}
+		if keep && msg.Kind != expectedKind {
+			t.Fatalf("%s: expected %s but got %s", name, expectedKind.String(), msg.Kind.String())
+		}
+	}
+
+	check("promote", Msg{ID: MsgID_JS_EqualsNaN, Kind: Warning}, true, Error)
+	check("demote", Msg{ID: MsgID_JS_DuplicateCase, Kind: Warning}, true, Verbose)
+	check("silence", Msg{ID: MsgID_JS_DuplicateObjectKey, Kind: Warning}, false, Warning)
+	check("error", Msg{ID: MsgID_JS_SyntaxError, Kind: Error}, true, Error)
+	check("no override", Msg{ID: MsgID_JS_DirectEval, Kind: Warning}, true, Warning)
+	check("no ID", Msg{ID: MsgID_None, Kind: Warning}, true, Warning)
+}
+
+func TestMsgIDStrings(t *testing.T) {
+	for id := MsgID_None + 1; id < MsgID_END; id++ {
+		text := MsgIDToString(id)
+		if text == "" {
+			t.Fatalf("Message ID %d has no name", id)
+		}
+		if back, ok := StringToMsgID(text); !ok || back != id {
+			t.Fatalf("Message ID %q did not round-trip", text)
+		}
+	}
+	if _, ok := StringToMsgID("not-a-real-id"); ok {
+		t.Fatal("Expected an unknown message ID to be rejected")
+	}
+}
+
+func TestMsgJSON(t *testing.T) {
+	msg := Msg{
+		ID:         MsgID_JS_EqualsNaN,
+		Kind:       Warning,
+		PluginName: "plugin",
+		Data: MsgData{
+			Text: "Line\nbreak \"quoted\" \u2028 <tag>",
+			Location: &MsgLocation{
+				File:      "src/file.js",
+				Namespace: "file",
+				Line:      2,
+				Column:    4,
+				Length:    3,
+				LineText:  "a\tb",
+			},
+		},
+		Notes: []MsgData{{Text: "note"}},
+	}
+	expected := `{"kind":"warning","id":"equals-nan","pluginName":"plugin","text":"Line\nbreak \"quoted\" \u2028 <tag>",` +
+		`"location":{"file":"src/file.js","namespace":"file","line":2,"column":4,"length":3,"lineText":"a\tb","suggestion":""},` +
+		`"notes":[{"text":"note","location":null}]}`
+	if text := msg.JSON(); text != expected {
+		t.Fatalf("Expected:\n%s\nActual:\n%s", expected, text)
+	}
+
+	// Messages without a location or notes still have every field
+	msg = Msg{Kind: Error, Data: MsgData{Text: "error"}}
+	expected = `{"kind":"error","id":"","pluginName":"","text":"error","location":null,"notes":[]}`
+	if text := msg.JSON(); text != expected {
+		t.Fatalf("Expected:\n%s\nActual:\n%s", expected, text)
+	}
+}
+
+func TestSummaryJSON(t *testing.T) {
+	table := SummaryTable{
+		{Dir: "out/", Base: "b.js", Bytes: 20},
+		{Dir: "out/", Base: "a.js", Bytes: 1000},
+		{Dir: "out/", Base: "a.js.map", Bytes: 2000, IsSourceMap: true},
+	}
+	elapsed := int64(12)
+	expected := `{"kind":"summary","outputFiles":[{"path":"out/a.js","bytes":1000},{"path":"out/b.js","bytes":20},` +
+		`{"path":"out/a.js.map","bytes":2000}],"time":12}`
+	if text := summaryJSONLine(table, &elapsed); text != expected {
+		t.Fatalf("Expected:\n%s\nActual:\n%s", expected, text)
+	}
+
+	// The time is omitted if it's unknown
+	expected = `{"kind":"summary","outputFiles":[]}`
+	if text := summaryJSONLine(nil, nil); text != expected {
+		t.Fatalf("Expected:\n%s\nActual:\n%s", expected, text)
+	}
+}
@@ -0,0 +1,161 @@
+package logger
+
+// Each kind of log message has a stable ID that is exposed in the API. These
+// can be used to change the log level for a specific kind of message using
+// "LogOverride". Errors also have IDs so that tools can tell them apart, but
+// they can't be overridden because the build would then incorrectly succeed.
+// Messages without an ID (such as verbose resolver output and messages from
+// plugins) use "MsgID_None".
+type MsgID = uint8
+
+const (
+	MsgID_None MsgID = iota
+
+	// JavaScript
+	MsgID_JS_AssignToConstant
+	MsgID_JS_CallImportNamespace
+	MsgID_JS_DeleteSuperProperty
+	MsgID_JS_DirectEval
+	MsgID_JS_DuplicateCase
+	MsgID_JS_DuplicateObjectKey
+	MsgID_JS_EmptyImportMeta
+	MsgID_JS_EqualsNaN
+	MsgID_JS_EqualsNegativeZero
+	MsgID_JS_EqualsNewObject
+	MsgID_JS_HTMLCommentInJS
+	MsgID_JS_ImpossibleTypeof
+	MsgID_JS_PrivateNameWillThrow
+	MsgID_JS_SemicolonAfterReturn
+	MsgID_JS_SuspiciousBooleanNot
+	MsgID_JS_SyntaxError
+	MsgID_JS_ThisIsUndefinedInESM
+	MsgID_JS_UnsupportedDynamicImport
+	MsgID_JS_UnsupportedFeature
+	MsgID_JS_UnsupportedJSXComment
+	MsgID_JS_UnsupportedRequireCall
+
+	// CSS
+	MsgID_CSS_InvalidAtCharset
+	MsgID_CSS_InvalidAtImport
+	MsgID_CSS_SyntaxError
+	MsgID_CSS_UnsupportedAtCharset
+	MsgID_CSS_UnsupportedAtNamespace
+
+	// HTML
+	MsgID_HTML_SyntaxError
+
+	// Source maps
+	MsgID_SourceMap_InvalidSourceMappings
+	MsgID_SourceMap_MissingSourceMap
+	MsgID_SourceMap_SectionsInSourceMap
+	MsgID_SourceMap_UnsupportedSourceMapComment
+
+	// Resolver
+	MsgID_Resolver_PackageJSON
+	MsgID_Resolver_PnPManifest
+	MsgID_Resolver_TSConfigJSON
+
+	// Bundler
+	MsgID_Bundler_AmbiguousReexport
+	MsgID_Bundler_DifferentPathCase
+	MsgID_Bundler_IgnoredBareImport
+	MsgID_Bundler_ImportIsUndefined
+	MsgID_Bundler_InternalError
+	MsgID_Bundler_InvalidImport
+	MsgID_Bundler_InvalidManualChunkName
+	MsgID_Bundler_ManualChunkCycle
+	MsgID_Bundler_MissingExport
+	MsgID_Bundler_MissingExternalGlobal
+	MsgID_Bundler_NoLoader
+	MsgID_Bundler_OutputCollision
+	MsgID_Bundler_ReadError
+	MsgID_Bundler_RequireResolveNotExternal
+	MsgID_Bundler_UnresolvedImport
+
+	// This must be last
+	MsgID_END
+)
+
+var msgIDStrings = [MsgID_END]string{
+	MsgID_None: "",
+
+	// JavaScript
+	MsgID_JS_AssignToConstant:         "assign-to-constant",
+	MsgID_JS_CallImportNamespace:      "call-import-namespace",
+	MsgID_JS_DeleteSuperProperty:      "delete-super-property",
+	MsgID_JS_DirectEval:               "direct-eval",
+	MsgID_JS_DuplicateCase:            "duplicate-case",
+	MsgID_JS_DuplicateObjectKey:       "duplicate-object-key",
+	MsgID_JS_EmptyImportMeta:          "empty-import-meta",
+	MsgID_JS_EqualsNaN:                "equals-nan",
+	MsgID_JS_EqualsNegativeZero:       "equals-negative-zero",
+	MsgID_JS_EqualsNewObject:          "equals-new-object",
+	MsgID_JS_HTMLCommentInJS:          "html-comment-in-js",
+	MsgID_JS_ImpossibleTypeof:         "impossible-typeof",
+	MsgID_JS_PrivateNameWillThrow:     "private-name-will-throw",
+	MsgID_JS_SemicolonAfterReturn:     "semicolon-after-return",
+	MsgID_JS_SuspiciousBooleanNot:     "suspicious-boolean-not",
+	MsgID_JS_SyntaxError:              "js-syntax-error",
+	MsgID_JS_ThisIsUndefinedInESM:     "this-is-undefined-in-esm",
+	MsgID_JS_UnsupportedDynamicImport: "unsupported-dynamic-import",
+	MsgID_JS_UnsupportedFeature:       "unsupported-js-feature",
+	MsgID_JS_UnsupportedJSXComment:    "unsupported-jsx-comment",
+	MsgID_JS_UnsupportedRequireCall:   "unsupported-require-call",
+
+	// CSS
+	MsgID_CSS_InvalidAtCharset:       "invalid-@charset",
+	MsgID_CSS_InvalidAtImport:        "invalid-@import",
+	MsgID_CSS_SyntaxError:            "css-syntax-error",
+	MsgID_CSS_UnsupportedAtCharset:   "unsupported-@charset",
+	MsgID_CSS_UnsupportedAtNamespace: "unsupported-@namespace",
+
+	// HTML
+	MsgID_HTML_SyntaxError: "html-syntax-error",
+
+	// Source maps
+	MsgID_SourceMap_InvalidSourceMappings:       "invalid-source-mappings",
+	MsgID_SourceMap_MissingSourceMap:            "missing-source-map",
+	MsgID_SourceMap_SectionsInSourceMap:         "sections-in-source-map",
+	MsgID_SourceMap_UnsupportedSourceMapComment: "unsupported-source-map-comment",
+
+	// Resolver
+	MsgID_Resolver_PackageJSON:  "package.json",
+	MsgID_Resolver_PnPManifest:  "pnp-manifest",
+	MsgID_Resolver_TSConfigJSON: "tsconfig.json",
+
+	// Bundler
+	MsgID_Bundler_AmbiguousReexport:         "ambiguous-reexport",
+	MsgID_Bundler_DifferentPathCase:         "different-path-case",
+	MsgID_Bundler_IgnoredBareImport:         "ignored-bare-import",
+	MsgID_Bundler_ImportIsUndefined:         "import-is-undefined",
+	MsgID_Bundler_InternalError:             "internal-error",
+	MsgID_Bundler_InvalidImport:             "invalid-import",
+	MsgID_Bundler_InvalidManualChunkName:    "invalid-manual-chunk-name",
+	MsgID_Bundler_ManualChunkCycle:          "manual-chunk-cycle",
+	MsgID_Bundler_MissingExport:             "missing-export",
+	MsgID_Bundler_MissingExternalGlobal:     "missing-external-global",
+	MsgID_Bundler_NoLoader:                  "no-loader",
+	MsgID_Bundler_OutputCollision:           "output-collision",
+	MsgID_Bundler_ReadError:                 "read-error",
+	MsgID_Bundler_RequireResolveNotExternal: "require-resolve-not-external",
+	MsgID_Bundler_UnresolvedImport:          "unresolved-import",
+}
+
+func MsgIDToString(id MsgID) string {
+	if id < MsgID_END {
+		return msgIDStrings[id]
+	}
+	return ""
+}
+
+// Returns false if there is no message with this ID
+func StringToMsgID(str string) (MsgID, bool) {
+	if str != "" {
+		for id, text := range msgIDStrings {
+			if text == str {
+				return MsgID(id), true
+			}
+		}
+	}
+	return MsgID_None, false
+}
@@ -63,6 +63,10 @@ type packageJSON struct {
 
 	// This represents the "exports" field in this package.json file.
 	exportsMap *peMap
+
+	// This is the "tsconfig" field, which TypeScript uses to find the base
+	// config file when a "tsconfig.json" file extends this package by name.
+	tsconfig string
 }
 
 type browserPathKind uint8
@@ -213,7 +217,7 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 		r.debugLogs.addNote(fmt.Sprintf("Failed to read file %q: %s", packageJSONPath, originalError.Error()))
 	}
 	if err != nil {
-		r.log.AddError(nil, logger.Loc{},
+		r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, nil, logger.Range{},
 			fmt.Sprintf("Cannot read file %q: %s",
 				r.PrettyPath(logger.Path{Text: packageJSONPath, Namespace: "file"}), err.Error()))
 		return nil
@@ -246,11 +250,11 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 			case "module":
 				packageJSON.moduleType = config.ModuleESM
 			default:
-				r.log.AddRangeWarning(&tracker, jsonSource.RangeOfString(typeJSON.Loc),
+				r.log.AddID(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, jsonSource.RangeOfString(typeJSON.Loc),
 					fmt.Sprintf("%q is not a valid value for the \"type\" field (must be either \"commonjs\" or \"module\")", typeValue))
 			}
 		} else {
-			r.log.AddWarning(&tracker, typeJSON.Loc,
+			r.log.AddID(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, logger.Range{Loc: typeJSON.Loc},
 				"The value for \"type\" must be a string")
 		}
 	}
@@ -300,7 +304,7 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 							browserMap[key] = nil
 						}
 					} else {
-						r.log.AddWarning(&tracker, prop.ValueOrNil.Loc,
+						r.log.AddID(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, logger.Range{Loc: prop.ValueOrNil.Loc},
 							"Each \"browser\" mapping must be a string or a boolean")
 					}
 				}
@@ -337,7 +341,7 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 			for _, itemJSON := range data.Items {
 				item, ok := itemJSON.Data.(*js_ast.EString)
 				if !ok || item.Value == nil {
-					r.log.AddWarning(&tracker, itemJSON.Loc,
+					r.log.AddID(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, logger.Range{Loc: itemJSON.Loc},
 						"Expected string in array for \"sideEffects\"")
 					continue
 				}
@@ -361,7 +365,7 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 			}
 
 		default:
-			r.log.AddWarning(&tracker, sideEffectsJSON.Loc,
+			r.log.AddID(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, logger.Range{Loc: sideEffectsJSON.Loc},
 				"The value for \"sideEffects\" must be a boolean or an array")
 		}
 	}
@@ -374,6 +378,13 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 		}
 	}
 
+	// Read the "tsconfig" field
+	if tsconfigJSON, _, ok := getProperty(json, "tsconfig"); ok {
+		if tsconfig, ok := getString(tsconfigJSON); ok {
+			packageJSON.tsconfig = tsconfig
+		}
+	}
+
 	return packageJSON
 }
 
@@ -537,7 +548,7 @@ func parseExportsMap(source logger.Source, log logger.Log, json js_ast.Expr) *pe
 					isConditionalSugar = curIsConditionalSugar
 				} else if isConditionalSugar != curIsConditionalSugar {
 					prevEntry := mapData[i-1]
-					log.AddRangeWarningWithNotes(&tracker, keyRange,
+					log.AddIDWithNotes(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, keyRange,
 						"This object cannot contain keys that both start with \".\" and don't start with \".\"",
 						[]logger.MsgData{logger.RangeData(&tracker, prevEntry.keyRange,
 							fmt.Sprintf("The previous key %q is incompatible with the current key %q", prevEntry.key, key))})
@@ -581,7 +592,7 @@ func parseExportsMap(source logger.Source, log logger.Log, json js_ast.Expr) *pe
 			firstToken.Loc = expr.Loc
 		}
 
-		log.AddRangeWarning(&tracker, firstToken, "This value must be a string, an object, an array, or null")
+		log.AddID(logger.MsgID_Resolver_PackageJSON, logger.Warning, &tracker, firstToken, "This value must be a string, an object, an array, or null")
 		return peEntry{
 			kind:       peInvalid,
 			firstToken: firstToken,
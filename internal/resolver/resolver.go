@@ -113,6 +113,10 @@ type ResolveResult struct {
 	// If true, the class field transform should use Object.defineProperty().
 	UseDefineForClassFieldsTS config.MaybeBool
 
+	// If false, decorators in TypeScript files use the JavaScript decorator
+	// specification instead of TypeScript's legacy decorator transform.
+	ExperimentalDecoratorsTS config.MaybeBool
+
 	// If true, unused imports are retained in TypeScript code. This matches the
 	// behavior of the "importsNotUsedAsValues" field in "tsconfig.json" when the
 	// value is not "remove".
@@ -120,6 +124,10 @@ type ResolveResult struct {
 
 	// This is the "type" field from "package.json"
 	ModuleType config.ModuleType
+
+	// If the import path was rewritten by a package alias, this is the import
+	// path before it was rewritten
+	OriginalImportPath string
 }
 
 type DebugMeta struct {
@@ -132,6 +140,7 @@ func (dm DebugMeta) LogErrorMsg(log logger.Log, source *logger.Source, r logger.
 	tracker := logger.MakeLineColumnTracker(source)
 
 	msg := logger.Msg{
+		ID:    logger.MsgID_Bundler_UnresolvedImport,
 		Kind:  logger.Error,
 		Data:  logger.RangeData(&tracker, r, text),
 		Notes: dm.notes,
@@ -279,11 +288,33 @@ func (rr *resolver) Resolve(sourceDir string, importPath string, kind ast.Import
 			importPath, sourceDir, kind.StringForMetafile())}
 	}
 
+	// Package aliases are applied before anything else, so the substituted path
+	// may itself be external, a data URL, or a relative path. It's resolved
+	// relative to the working directory because it came from the build options,
+	// not from the importing file.
+	if substitution, ok := r.checkPackageAliases(importPath); ok {
+		if r.debugLogs != nil {
+			r.debugLogs.addNote(fmt.Sprintf("Rewrote the import path %q to %q using an alias", importPath, substitution))
+		}
+		result, debug := r.resolve(r.fs.Cwd(), substitution)
+		if result != nil {
+			result.OriginalImportPath = importPath
+		} else {
+			debug.notes = append([]logger.MsgData{{Text: fmt.Sprintf(
+				"The import path %q was rewritten to %q by the \"alias\" setting", importPath, substitution)}}, debug.notes...)
+		}
+		return result, debug
+	}
+
+	return r.resolve(sourceDir, importPath)
+}
+
+func (r resolverQuery) resolve(sourceDir string, importPath string) (*ResolveResult, DebugMeta) {
 	// Certain types of URLs default to being external for convenience
 	if r.isExternalPattern(importPath) ||
 
 		// "fill: url(#filter);"
-		(kind.IsFromCSS() && strings.HasPrefix(importPath, "#")) ||
+		(r.kind.IsFromCSS() && strings.HasPrefix(importPath, "#")) ||
 
 		// "background: url(http://example.com/images/image.png);"
 		strings.HasPrefix(importPath, "http://") ||
@@ -375,6 +406,26 @@ func (rr *resolver) Resolve(sourceDir string, importPath string, kind ast.Import
 	return result, debug
 }
 
+// Aliases match a whole package path or a prefix of it that ends at a slash.
+// The longest match wins, so "@scope/pkg/sub" takes precedence over
+// "@scope/pkg" for the import path "@scope/pkg/sub/file".
+func (r resolverQuery) checkPackageAliases(importPath string) (string, bool) {
+	if r.options.PackageAliases == nil || !IsPackagePath(importPath) {
+		return "", false
+	}
+	if substitution, ok := r.options.PackageAliases[importPath]; ok {
+		return substitution, true
+	}
+	for slash := len(importPath) - 1; slash > 0; slash-- {
+		if importPath[slash] == '/' {
+			if substitution, ok := r.options.PackageAliases[importPath[:slash]]; ok {
+				return substitution + importPath[slash:], true
+			}
+		}
+	}
+	return "", false
+}
+
 func (r resolverQuery) isExternalPattern(path string) bool {
 	for _, pattern := range r.options.ExternalModules.Patterns {
 		if len(path) >= len(pattern.Prefix)+len(pattern.Suffix) &&
@@ -505,6 +556,8 @@ func (r resolverQuery) finalizeResolve(result *ResolveResult) {
 
 				// Copy various fields from the nearest enclosing "tsconfig.json" file if present
 				if path == &result.PathPair.Primary && dirInfo.enclosingTSConfigJSON != nil {
+					tsConfigJSON := r.tsConfigForPath(dirInfo.enclosingTSConfigJSON, path.Text, false)
+
 					// Except don't do this if we're inside a "node_modules" directory. Package
 					// authors often publish their "tsconfig.json" files to npm because of
 					// npm's default-include publishing model and because these authors
@@ -522,28 +575,29 @@ func (r resolverQuery) finalizeResolve(result *ResolveResult) {
 					if helpers.IsInsideNodeModules(result.PathPair.Primary.Text) {
 						if r.debugLogs != nil {
 							r.debugLogs.addNote(fmt.Sprintf("Ignoring %q because %q is inside \"node_modules\"",
-								dirInfo.enclosingTSConfigJSON.AbsPath,
+								tsConfigJSON.AbsPath,
 								result.PathPair.Primary.Text))
 						}
 					} else {
-						result.JSXFactory = dirInfo.enclosingTSConfigJSON.JSXFactory
-						result.JSXFragment = dirInfo.enclosingTSConfigJSON.JSXFragmentFactory
-						result.UseDefineForClassFieldsTS = dirInfo.enclosingTSConfigJSON.UseDefineForClassFields
-						result.PreserveUnusedImportsTS = dirInfo.enclosingTSConfigJSON.PreserveImportsNotUsedAsValues
-						result.TSTarget = dirInfo.enclosingTSConfigJSON.TSTarget
+						result.JSXFactory = tsConfigJSON.JSXFactory
+						result.JSXFragment = tsConfigJSON.JSXFragmentFactory
+						result.UseDefineForClassFieldsTS = tsConfigJSON.UseDefineForClassFields
+						result.ExperimentalDecoratorsTS = tsConfigJSON.ExperimentalDecorators
+						result.PreserveUnusedImportsTS = tsConfigJSON.PreserveImportsNotUsedAsValues
+						result.TSTarget = tsConfigJSON.TSTarget
 
 						if r.debugLogs != nil {
 							r.debugLogs.addNote(fmt.Sprintf("This import is under the effect of %q",
-								dirInfo.enclosingTSConfigJSON.AbsPath))
+								tsConfigJSON.AbsPath))
 							if result.JSXFactory != nil {
 								r.debugLogs.addNote(fmt.Sprintf("\"jsxFactory\" is %q due to %q",
 									strings.Join(result.JSXFactory, "."),
-									dirInfo.enclosingTSConfigJSON.AbsPath))
+									tsConfigJSON.AbsPath))
 							}
 							if result.JSXFragment != nil {
 								r.debugLogs.addNote(fmt.Sprintf("\"jsxFragment\" is %q due to %q",
 									strings.Join(result.JSXFragment, "."),
-									dirInfo.enclosingTSConfigJSON.AbsPath))
+									tsConfigJSON.AbsPath))
 							}
 						}
 					}
@@ -599,9 +653,11 @@ func (r resolverQuery) resolveWithoutSymlinks(sourceDir string, importPath strin
 		}
 
 		// First, check path overrides from the nearest enclosing TypeScript "tsconfig.json" file
-		if dirInfo := r.dirInfoCached(sourceDir); dirInfo != nil && dirInfo.enclosingTSConfigJSON != nil && dirInfo.enclosingTSConfigJSON.Paths != nil {
-			if absolute, ok, diffCase := r.matchTSConfigPaths(dirInfo.enclosingTSConfigJSON, importPath); ok {
-				return &ResolveResult{PathPair: absolute, DifferentCase: diffCase}, DebugMeta{}
+		if dirInfo := r.dirInfoCached(sourceDir); dirInfo != nil && dirInfo.enclosingTSConfigJSON != nil {
+			if tsConfigJSON := r.tsConfigForPath(dirInfo.enclosingTSConfigJSON, dirInfo.absPath, true); tsConfigJSON.Paths != nil {
+				if absolute, ok, diffCase := r.matchTSConfigPaths(tsConfigJSON, importPath); ok {
+					return &ResolveResult{PathPair: absolute, DifferentCase: diffCase}, DebugMeta{}
+				}
 			}
 		}
 
@@ -692,6 +748,21 @@ func (r resolverQuery) resolveWithoutSymlinks(sourceDir string, importPath strin
 			return nil, DebugMeta{}
 		}
 
+		// Mark all package paths as external if requested. Package paths that
+		// "tsconfig.json" maps to local files are still bundled because they
+		// are part of the project. The original import path is preserved so
+		// that it can be resolved again at run-time. Entry points are exempt
+		// since they can't be external, so "esbuild pkg" still bundles "pkg".
+		if r.options.ExternalPackages && r.kind != ast.ImportEntryPoint {
+			if absolute, ok, diffCase := r.loadTSConfigPathsOrBaseURL(importPath, sourceDirInfo); ok {
+				return &ResolveResult{PathPair: absolute, DifferentCase: diffCase}, DebugMeta{}
+			}
+			if r.debugLogs != nil {
+				r.debugLogs.addNote(fmt.Sprintf("The path %q was marked as external because all packages are external", importPath))
+			}
+			return &ResolveResult{PathPair: PathPair{Primary: logger.Path{Text: importPath}}, IsExternal: true}, DebugMeta{}
+		}
+
 		// Support remapping one package path to another via the "browser" field
 		if remapped, ok := r.checkBrowserMap(sourceDirInfo, importPath, packagePathKind); ok {
 			if remapped == nil {
@@ -775,6 +846,7 @@ type dirInfo struct {
 	packageJSON           *packageJSON  // Is there a "package.json" file in this directory?
 	enclosingPackageJSON  *packageJSON  // Is there a "package.json" file in this directory or a parent directory?
 	enclosingTSConfigJSON *TSConfigJSON // Is there a "tsconfig.json" file in this directory or a parent directory?
+	enclosingPnPManifest  *pnpData      // Is there a Yarn PnP manifest in this directory or a parent directory?
 	absRealPath           string        // If non-empty, this is the real absolute path resolving any symlinks
 }
 
@@ -844,70 +916,46 @@ func (r resolverQuery) parseTSConfig(file string, visited map[string]bool) (*TSC
 	fileDir := r.fs.Dir(file)
 
 	result := ParseTSConfigJSON(r.log, source, &r.caches.JSONCache, func(extends string, extendsRange logger.Range) *TSConfigJSON {
-		if IsPackagePath(extends) {
+		var fileToCheck string
+		if IsPackagePath(extends) && !r.fs.IsAbs(extends) {
 			// If this is a package path, try to resolve it to a "node_modules"
 			// folder. This doesn't use the normal node module resolution algorithm
 			// both because it's different (e.g. we don't want to match a directory)
 			// and because it would deadlock since we're currently in the middle of
 			// populating the directory info cache.
-			current := fileDir
-			for {
-				// Skip "node_modules" folders
-				if r.fs.Base(current) != "node_modules" {
-					join := r.fs.Join(current, "node_modules", extends)
-					filesToCheck := []string{r.fs.Join(join, "tsconfig.json"), join, join + ".json"}
-					for _, fileToCheck := range filesToCheck {
-						base, err := r.parseTSConfig(fileToCheck, visited)
-						if err == nil {
-							return base
-						} else if err == syscall.ENOENT {
-							continue
-						} else if err == errParseErrorImportCycle {
-							r.log.AddRangeWarning(&tracker, extendsRange,
-								fmt.Sprintf("Base config file %q forms cycle", extends))
-						} else if err != errParseErrorAlreadyLogged {
-							r.log.AddRangeError(&tracker, extendsRange,
-								fmt.Sprintf("Cannot read file %q: %s",
-									r.PrettyPath(logger.Path{Text: fileToCheck, Namespace: "file"}), err.Error()))
-						}
-						return nil
-					}
-				}
-
-				// Go to the parent directory, stopping at the file system root
-				next := r.fs.Dir(current)
-				if current == next {
-					break
-				}
-				current = next
-			}
+			fileToCheck = r.findTSConfigExtendsPackage(fileDir, extends)
 		} else {
 			// If this is a regular path, search relative to the enclosing directory
 			extendsFile := extends
 			if !r.fs.IsAbs(extends) {
 				extendsFile = r.fs.Join(fileDir, extends)
 			}
-			for _, fileToCheck := range []string{extendsFile, extendsFile + ".json"} {
-				base, err := r.parseTSConfig(fileToCheck, visited)
-				if err == nil {
-					return base
-				} else if err == syscall.ENOENT {
-					continue
-				} else if err == errParseErrorImportCycle {
-					r.log.AddRangeWarning(&tracker, extendsRange,
-						fmt.Sprintf("Base config file %q forms cycle", extends))
-				} else if err != errParseErrorAlreadyLogged {
-					r.log.AddRangeError(&tracker, extendsRange,
-						fmt.Sprintf("Cannot read file %q: %s",
-							r.PrettyPath(logger.Path{Text: fileToCheck, Namespace: "file"}), err.Error()))
+			for _, path := range []string{extendsFile, extendsFile + ".json"} {
+				if r.tsConfigEntryExists(path) {
+					fileToCheck = path
+					break
 				}
-				return nil
 			}
 		}
 
+		if fileToCheck != "" {
+			base, err := r.parseTSConfig(fileToCheck, visited)
+			if err == nil {
+				return base
+			} else if err == errParseErrorImportCycle {
+				r.log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, &tracker, extendsRange,
+					fmt.Sprintf("Base config file %q forms cycle", extends))
+			} else if err != errParseErrorAlreadyLogged {
+				r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, &tracker, extendsRange,
+					fmt.Sprintf("Cannot read file %q: %s",
+						r.PrettyPath(logger.Path{Text: fileToCheck, Namespace: "file"}), err.Error()))
+			}
+			return nil
+		}
+
 		// Suppress warnings about missing base config files inside "node_modules"
 		if !helpers.IsInsideNodeModules(file) {
-			r.log.AddRangeWarning(&tracker, extendsRange,
+			r.log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, &tracker, extendsRange,
 				fmt.Sprintf("Cannot find base config file %q", extends))
 		}
 
@@ -926,9 +974,196 @@ func (r resolverQuery) parseTSConfig(file string, visited map[string]bool) (*TSC
 		result.BaseURLForPaths = r.fs.Join(fileDir, result.BaseURLForPaths)
 	}
 
+	// Inherited patterns are already relative to the base config file
+	for _, patterns := range [][]string{result.Files, result.Include, result.Exclude} {
+		for i, pattern := range patterns {
+			if !r.fs.IsAbs(pattern) {
+				patterns[i] = r.fs.Join(fileDir, pattern)
+			}
+		}
+	}
+	result.scope = compileTSConfigScope(fileDir, result.Files, result.Include, result.Exclude)
+
+	// A reference can either be the path of a config file or the path of a
+	// directory containing a "tsconfig.json" file
+	for i, reference := range result.References {
+		if !r.fs.IsAbs(reference) {
+			reference = r.fs.Join(fileDir, reference)
+		}
+		if !strings.HasSuffix(reference, ".json") {
+			reference = r.fs.Join(reference, "tsconfig.json")
+		}
+		result.References[i] = reference
+	}
+
 	return result, nil
 }
 
+// This finds the base config file for a package path in "extends" the way the
+// TypeScript compiler does. It checks "node_modules" folders for the package's
+// "exports" map, then for a file, and then for a directory containing either
+// the file in the "tsconfig" field of "package.json" or a "tsconfig.json" file.
+func (r resolverQuery) findTSConfigExtendsPackage(fileDir string, extends string) string {
+	esmPackageName, esmPackageSubpath, esmOK := esmParsePackageName(extends)
+
+	for current := fileDir; ; {
+		// Skip "node_modules" folders
+		if r.fs.Base(current) != "node_modules" {
+			join := r.fs.Join(current, "node_modules", extends)
+			var packageJSON *packageJSON
+
+			// Check for an "exports" map in the package's "package.json" file
+			if esmOK {
+				absPkgPath := r.fs.Join(current, "node_modules", esmPackageName)
+				if r.isTSConfigFile(r.fs.Join(absPkgPath, "package.json")) {
+					packageJSON = r.parsePackageJSON(absPkgPath)
+					if packageJSON != nil && packageJSON.exportsMap != nil {
+						if r.debugLogs != nil {
+							r.debugLogs.addNote(fmt.Sprintf("Looking for %q in \"exports\" map in %q", esmPackageSubpath, packageJSON.source.KeyPath.Text))
+						}
+						resolvedPath, status, _ := r.esmPackageExportsResolveWithPostConditions(
+							"/", esmPackageSubpath, packageJSON.exportsMap.root, tsConfigExtendsConditions)
+						if (status == peStatusExact || status == peStatusInexact) && strings.HasPrefix(resolvedPath, "/") {
+							absResolvedPath := r.fs.Join(absPkgPath, resolvedPath[1:])
+							if r.isTSConfigFile(absResolvedPath) {
+								return absResolvedPath
+							}
+							if status == peStatusInexact && r.isTSConfigFile(absResolvedPath+".json") {
+								return absResolvedPath + ".json"
+							}
+						}
+
+						// The "exports" map is authoritative when it's present
+						return ""
+					}
+				}
+				if esmPackageSubpath != "." {
+					packageJSON = nil
+				}
+			}
+
+			// Check for a file
+			for _, path := range []string{join, join + ".json"} {
+				if r.isTSConfigFile(path) {
+					return path
+				}
+			}
+
+			// Check for a directory
+			if packageJSON == nil && r.isTSConfigFile(r.fs.Join(join, "package.json")) {
+				packageJSON = r.parsePackageJSON(join)
+			}
+			if packageJSON != nil && packageJSON.tsconfig != "" {
+				if path := r.fs.Join(join, packageJSON.tsconfig); r.isTSConfigFile(path) {
+					return path
+				}
+			}
+			if path := r.fs.Join(join, "tsconfig.json"); r.tsConfigEntryExists(path) {
+				return path
+			}
+		}
+
+		// Go to the parent directory, stopping at the file system root
+		next := r.fs.Dir(current)
+		if current == next {
+			break
+		}
+		current = next
+	}
+
+	return ""
+}
+
+// These are the conditions that the TypeScript compiler uses when resolving
+// package paths in "extends"
+var tsConfigExtendsConditions = map[string]bool{
+	"default": true,
+	"node":    true,
+	"require": true,
+	"types":   true,
+}
+
+// This can't use "dirInfoCached" because it's called while the directory info
+// cache is being populated, so it reads the directory directly instead
+func (r resolverQuery) isTSConfigFile(path string) bool {
+	entries, err, _ := r.fs.ReadDirectory(r.fs.Dir(path))
+	if err != nil {
+		return false
+	}
+	entry, _ := entries.Get(r.fs.Base(path))
+	return entry != nil && entry.Kind(r.fs) == fs.FileEntry
+}
+
+// Unlike "isTSConfigFile", this also matches directories. It's used for paths
+// that "extends" names directly so that a directory there is reported as a
+// read error instead of silently turning into a "cannot find" warning.
+func (r resolverQuery) tsConfigEntryExists(path string) bool {
+	entries, err, _ := r.fs.ReadDirectory(r.fs.Dir(path))
+	if err != nil {
+		return false
+	}
+	entry, _ := entries.Get(r.fs.Base(path))
+	return entry != nil
+}
+
+// Referenced projects may also have references of their own. Each project is
+// only loaded once per directory to avoid infinite loops due to cycles.
+func (r resolverQuery) loadTSConfigReferences(tsConfigJSON *TSConfigJSON, visited map[string]bool) {
+	for _, reference := range tsConfigJSON.References {
+		if visited[reference] {
+			continue
+		}
+		visited[reference] = true
+
+		project, err := r.parseTSConfig(reference, make(map[string]bool))
+		if err != nil {
+			if err == syscall.ENOENT {
+				r.log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, nil, logger.Range{},
+					fmt.Sprintf("Cannot find the project %q referenced by %q",
+						r.PrettyPath(logger.Path{Text: reference, Namespace: "file"}),
+						r.PrettyPath(logger.Path{Text: tsConfigJSON.AbsPath, Namespace: "file"})))
+			} else if err != errParseErrorAlreadyLogged {
+				r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, nil, logger.Range{},
+					fmt.Sprintf("Cannot read file %q: %s",
+						r.PrettyPath(logger.Path{Text: reference, Namespace: "file"}), err.Error()))
+			}
+			continue
+		}
+
+		r.loadTSConfigReferences(project, visited)
+		tsConfigJSON.referencedProjects = append(tsConfigJSON.referencedProjects, project)
+	}
+}
+
+// This returns a project that has already been loaded through "references"
+// so that it isn't loaded again when its directory is visited
+func findReferencedProject(tsConfigJSON *TSConfigJSON, absPath string) *TSConfigJSON {
+	for _, project := range tsConfigJSON.referencedProjects {
+		if project.AbsPath == absPath {
+			return project
+		}
+		if found := findReferencedProject(project, absPath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// A file that belongs to a project listed in "references" is compiled using
+// that project's settings instead of the settings of the referencing project
+func (r resolverQuery) tsConfigForPath(tsConfigJSON *TSConfigJSON, path string, isDir bool) *TSConfigJSON {
+	for _, project := range tsConfigJSON.referencedProjects {
+		if project.scope.includesPath(path, isDir) {
+			if r.debugLogs != nil {
+				r.debugLogs.addNote(fmt.Sprintf("The path %q belongs to the project %q referenced by %q",
+					path, project.AbsPath, tsConfigJSON.AbsPath))
+			}
+			return r.tsConfigForPath(project, path, isDir)
+		}
+	}
+	return tsConfigJSON
+}
+
 func (r resolverQuery) dirInfoUncached(path string) *dirInfo {
 	// Get the info for the parent directory
 	var parentInfo *dirInfo
@@ -964,7 +1199,7 @@ func (r resolverQuery) dirInfoUncached(path string) *dirInfo {
 		// list which contains such paths and treating them as missing means we just
 		// ignore them during path resolution.
 		if err != syscall.ENOENT && err != syscall.ENOTDIR {
-			r.log.AddError(nil, logger.Loc{},
+			r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, nil, logger.Range{},
 				fmt.Sprintf("Cannot read directory %q: %s",
 					r.PrettyPath(logger.Path{Text: path, Namespace: "file"}), err.Error()))
 		}
@@ -989,6 +1224,7 @@ func (r resolverQuery) dirInfoUncached(path string) *dirInfo {
 		info.enclosingPackageJSON = parentInfo.enclosingPackageJSON
 		info.enclosingBrowserScope = parentInfo.enclosingBrowserScope
 		info.enclosingTSConfigJSON = parentInfo.enclosingTSConfigJSON
+		info.enclosingPnPManifest = parentInfo.enclosingPnPManifest
 
 		// Make sure "absRealPath" is the real path of the directory (resolving any symlinks)
 		if !r.options.PreserveSymlinks {
@@ -1036,18 +1272,39 @@ func (r resolverQuery) dirInfoUncached(path string) *dirInfo {
 			tsConfigPath = forceTsConfig
 		}
 		if tsConfigPath != "" {
-			var err error
-			info.enclosingTSConfigJSON, err = r.parseTSConfig(tsConfigPath, make(map[string]bool))
-			if err != nil {
-				if err == syscall.ENOENT {
-					r.log.AddError(nil, logger.Loc{}, fmt.Sprintf("Cannot find tsconfig file %q",
-						r.PrettyPath(logger.Path{Text: tsConfigPath, Namespace: "file"})))
-				} else if err != errParseErrorAlreadyLogged {
-					r.log.AddError(nil, logger.Loc{},
-						fmt.Sprintf("Cannot read file %q: %s",
-							r.PrettyPath(logger.Path{Text: tsConfigPath, Namespace: "file"}), err.Error()))
+			// Reuse this project if it was already loaded through "references"
+			var tsConfigJSON *TSConfigJSON
+			if parentInfo != nil && parentInfo.enclosingTSConfigJSON != nil {
+				tsConfigJSON = findReferencedProject(parentInfo.enclosingTSConfigJSON, tsConfigPath)
+			}
+			if tsConfigJSON == nil {
+				var err error
+				tsConfigJSON, err = r.parseTSConfig(tsConfigPath, make(map[string]bool))
+				if err != nil {
+					if err == syscall.ENOENT {
+						r.log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Error, nil, logger.Range{}, fmt.Sprintf("Cannot find tsconfig file %q",
+							r.PrettyPath(logger.Path{Text: tsConfigPath, Namespace: "file"})))
+					} else if err != errParseErrorAlreadyLogged {
+						r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, nil, logger.Range{},
+							fmt.Sprintf("Cannot read file %q: %s",
+								r.PrettyPath(logger.Path{Text: tsConfigPath, Namespace: "file"}), err.Error()))
+					}
+				} else {
+					r.loadTSConfigReferences(tsConfigJSON, map[string]bool{tsConfigPath: true})
 				}
 			}
+			info.enclosingTSConfigJSON = tsConfigJSON
+		}
+	}
+
+	// Record if this directory has a Yarn PnP manifest. The JSON form is only
+	// present if Yarn was configured to not inline the data into the script.
+	for _, name := range []string{".pnp.data.json", ".pnp.cjs", ".pnp.js"} {
+		if entry, _ := entries.Get(name); entry != nil && entry.Kind(r.fs) == fs.FileEntry {
+			if manifest := r.parsePnPManifest(r.fs.Join(path, name)); manifest != nil {
+				info.enclosingPnPManifest = manifest
+			}
+			break
 		}
 	}
 
@@ -1069,7 +1326,7 @@ func (r resolverQuery) loadAsFile(path string, extensionOrder []string) (string,
 	}
 	if err != nil {
 		if err != syscall.ENOENT {
-			r.log.AddError(nil, logger.Loc{},
+			r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, nil, logger.Range{},
 				fmt.Sprintf("  Cannot read directory %q: %s",
 					r.PrettyPath(logger.Path{Text: dirPath, Namespace: "file"}), err.Error()))
 		}
@@ -1468,36 +1725,65 @@ func (r resolverQuery) matchTSConfigPaths(tsConfigJSON *TSConfigJSON, path strin
 	return PathPair{}, false, nil
 }
 
-func (r resolverQuery) loadNodeModules(importPath string, dirInfo *dirInfo) (PathPair, bool, *fs.DifferentCase, DebugMeta) {
-	if r.debugLogs != nil {
-		r.debugLogs.addNote(fmt.Sprintf("Searching for %q in \"node_modules\" directories starting from %q", importPath, dirInfo.absPath))
-		r.debugLogs.increaseIndent()
-		defer r.debugLogs.decreaseIndent()
-	}
-
-	// First, check path overrides from the nearest enclosing TypeScript "tsconfig.json" file
+func (r resolverQuery) loadTSConfigPathsOrBaseURL(importPath string, dirInfo *dirInfo) (PathPair, bool, *fs.DifferentCase) {
 	if dirInfo.enclosingTSConfigJSON != nil {
+		tsConfigJSON := r.tsConfigForPath(dirInfo.enclosingTSConfigJSON, dirInfo.absPath, true)
+
 		// Try path substitutions first
-		if dirInfo.enclosingTSConfigJSON.Paths != nil {
-			if absolute, ok, diffCase := r.matchTSConfigPaths(dirInfo.enclosingTSConfigJSON, importPath); ok {
-				return absolute, true, diffCase, DebugMeta{}
+		if tsConfigJSON.Paths != nil {
+			if absolute, ok, diffCase := r.matchTSConfigPaths(tsConfigJSON, importPath); ok {
+				return absolute, true, diffCase
 			}
 		}
 
 		// Try looking up the path relative to the base URL
-		if dirInfo.enclosingTSConfigJSON.BaseURL != nil {
-			basePath := r.fs.Join(*dirInfo.enclosingTSConfigJSON.BaseURL, importPath)
+		if tsConfigJSON.BaseURL != nil {
+			basePath := r.fs.Join(*tsConfigJSON.BaseURL, importPath)
 			if absolute, ok, diffCase := r.loadAsFileOrDirectory(basePath); ok {
-				return absolute, true, diffCase, DebugMeta{}
+				return absolute, true, diffCase
 			}
 		}
 	}
+	return PathPair{}, false, nil
+}
+
+func (r resolverQuery) loadNodeModules(importPath string, dirInfo *dirInfo) (PathPair, bool, *fs.DifferentCase, DebugMeta) {
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Searching for %q in \"node_modules\" directories starting from %q", importPath, dirInfo.absPath))
+		r.debugLogs.increaseIndent()
+		defer r.debugLogs.decreaseIndent()
+	}
+
+	// First, check path overrides from the nearest enclosing TypeScript "tsconfig.json" file
+	if absolute, ok, diffCase := r.loadTSConfigPathsOrBaseURL(importPath, dirInfo); ok {
+		return absolute, true, diffCase, DebugMeta{}
+	}
 
 	esmPackageName, esmPackageSubpath, esmOK := esmParsePackageName(importPath)
 	if r.debugLogs != nil && esmOK {
 		r.debugLogs.addNote(fmt.Sprintf("Parsed package name %q and package subpath %q", esmPackageName, esmPackageSubpath))
 	}
 
+	// If Yarn PnP is active, use its manifest to find the package instead of
+	// searching "node_modules" directories
+	if manifest := dirInfo.enclosingPnPManifest; manifest != nil && esmOK {
+		if r.debugLogs != nil {
+			r.debugLogs.addNote(fmt.Sprintf("Checking for %q in the Yarn PnP manifest %q", esmPackageName, manifest.absPath))
+		}
+		pkgSubpath := strings.TrimPrefix(importPath[len(esmPackageName):], "/")
+		switch result := r.pnpResolveToUnqualified(manifest, esmPackageName, pkgSubpath, dirInfo.absPath); result.status {
+		case pnpSuccess:
+			absPath := r.fs.Join(result.absPkgPath, result.pkgSubpath)
+			if absolute, ok, diffCase, debugMeta, done := r.loadPackage(result.absPkgPath, absPath, esmPackageName, esmPackageSubpath, esmOK); done {
+				return absolute, ok, diffCase, debugMeta
+			}
+			return PathPair{}, false, nil, DebugMeta{}
+
+		case pnpErrorDependencyNotFound, pnpErrorUnfulfilledPeerDependency:
+			return PathPair{}, false, nil, result.debugMeta()
+		}
+	}
+
 	// Then check for the package in any enclosing "node_modules" directories
 	for {
 		// Skip directories that are themselves called "node_modules", since we
@@ -1508,207 +1794,220 @@ func (r resolverQuery) loadNodeModules(importPath string, dirInfo *dirInfo) (Pat
 				r.debugLogs.addNote(fmt.Sprintf("Checking for a package in the directory %q", absPath))
 			}
 
-			// Check for an "exports" map in the package's package.json folder
-			if esmOK {
-				absPkgPath := r.fs.Join(dirInfo.absPath, "node_modules", esmPackageName)
-				if pkgDirInfo := r.dirInfoCached(absPkgPath); pkgDirInfo != nil {
-					// Check for an "exports" map in the package's package.json folder
-					if packageJSON := pkgDirInfo.packageJSON; packageJSON != nil && packageJSON.exportsMap != nil {
-						if r.debugLogs != nil {
-							r.debugLogs.addNote(fmt.Sprintf("Looking for %q in \"exports\" map in %q", esmPackageSubpath, packageJSON.source.KeyPath.Text))
-							r.debugLogs.increaseIndent()
-							defer r.debugLogs.decreaseIndent()
-						}
+			absPkgPath := r.fs.Join(dirInfo.absPath, "node_modules", esmPackageName)
+			if absolute, ok, diffCase, debugMeta, done := r.loadPackage(absPkgPath, absPath, esmPackageName, esmPackageSubpath, esmOK); done {
+				return absolute, ok, diffCase, debugMeta
+			}
+		}
 
-						// The condition set is determined by the kind of import
-						conditions := r.esmConditionsDefault
-						switch r.kind {
-						case ast.ImportStmt, ast.ImportDynamic:
-							conditions = r.esmConditionsImport
-						case ast.ImportRequire, ast.ImportRequireResolve:
-							conditions = r.esmConditionsRequire
-						}
+		// Go to the parent directory, stopping at the file system root
+		dirInfo = dirInfo.parent
+		if dirInfo == nil {
+			break
+		}
+	}
 
-						// Resolve against the path "/", then join it with the absolute
-						// directory path. This is done because ESM package resolution uses
-						// URLs while our path resolution uses file system paths. We don't
-						// want problems due to Windows paths, which are very unlike URL
-						// paths. We also want to avoid any "%" characters in the absolute
-						// directory path accidentally being interpreted as URL escapes.
-						resolvedPath, status, debug := r.esmPackageExportsResolveWithPostConditions("/", esmPackageSubpath, packageJSON.exportsMap.root, conditions)
-						if (status == peStatusExact || status == peStatusInexact) && strings.HasPrefix(resolvedPath, "/") {
-							absResolvedPath := r.fs.Join(absPkgPath, resolvedPath[1:])
+	// Then check the global "NODE_PATH" environment variable.
+	//
+	// Note: This is a deviation from node's published module resolution
+	// algorithm. The published algorithm says "NODE_PATH" must take precedence
+	// over "node_modules" paths, but it appears that the published algorithm is
+	// incorrect. We follow node's actual behavior instead of following the
+	// published algorithm. See also: https://github.com/nodejs/node/issues/38128.
+	for _, absDir := range r.options.AbsNodePaths {
+		absPath := r.fs.Join(absDir, importPath)
+		if absolute, ok, diffCase := r.loadAsFileOrDirectory(absPath); ok {
+			return absolute, true, diffCase, DebugMeta{}
+		}
+	}
 
-							switch status {
-							case peStatusExact:
-								if r.debugLogs != nil {
-									r.debugLogs.addNote(fmt.Sprintf("The resolved path %q is exact", absResolvedPath))
-								}
-								resolvedDirInfo := r.dirInfoCached(r.fs.Dir(absResolvedPath))
-								if resolvedDirInfo == nil {
-									status = peStatusModuleNotFound
-								} else if entry, diffCase := resolvedDirInfo.entries.Get(r.fs.Base(absResolvedPath)); entry == nil {
-									status = peStatusModuleNotFound
-								} else if kind := entry.Kind(r.fs); kind == fs.DirEntry {
-									if r.debugLogs != nil {
-										r.debugLogs.addNote(fmt.Sprintf("The path %q is a directory, which is not allowed", absResolvedPath))
-									}
-									status = peStatusUnsupportedDirectoryImport
-								} else if kind != fs.FileEntry {
-									status = peStatusModuleNotFound
-								} else {
-									if r.debugLogs != nil {
-										r.debugLogs.addNote(fmt.Sprintf("Resolved to %q", absResolvedPath))
-									}
-									return PathPair{Primary: logger.Path{Text: absResolvedPath, Namespace: "file"}}, true, diffCase, DebugMeta{}
-								}
+	return PathPair{}, false, nil, DebugMeta{}
+}
 
-							case peStatusInexact:
-								// If this was resolved against an expansion key ending in a "/"
-								// instead of a "*", we need to try CommonJS-style implicit
-								// extension and/or directory detection.
-								if r.debugLogs != nil {
-									r.debugLogs.addNote(fmt.Sprintf("The resolved path %q is inexact", absResolvedPath))
-								}
-								if absolute, ok, diffCase := r.loadAsFileOrDirectory(absResolvedPath); ok {
-									return absolute, true, diffCase, DebugMeta{}
-								}
-								status = peStatusModuleNotFound
-							}
-						}
+// This loads a package from the directory "absPkgPath" using its "exports"
+// map, its "browser" map, or the path "absPath" within it. If the last return
+// value is false, the package wasn't found and the caller should keep looking
+// elsewhere.
+func (r resolverQuery) loadPackage(
+	absPkgPath string, absPath string, esmPackageName string, esmPackageSubpath string, esmOK bool,
+) (PathPair, bool, *fs.DifferentCase, DebugMeta, bool) {
+	// Check for an "exports" map in the package's package.json folder
+	if esmOK {
+		if pkgDirInfo := r.dirInfoCached(absPkgPath); pkgDirInfo != nil {
+			// Check for an "exports" map in the package's package.json folder
+			if packageJSON := pkgDirInfo.packageJSON; packageJSON != nil && packageJSON.exportsMap != nil {
+				if r.debugLogs != nil {
+					r.debugLogs.addNote(fmt.Sprintf("Looking for %q in \"exports\" map in %q", esmPackageSubpath, packageJSON.source.KeyPath.Text))
+					r.debugLogs.increaseIndent()
+					defer r.debugLogs.decreaseIndent()
+				}
 
-						var debugMeta DebugMeta
-						if strings.HasPrefix(resolvedPath, "/") {
-							resolvedPath = "." + resolvedPath
-						}
+				// The condition set is determined by the kind of import
+				conditions := r.esmConditionsDefault
+				switch r.kind {
+				case ast.ImportStmt, ast.ImportDynamic:
+					conditions = r.esmConditionsImport
+				case ast.ImportRequire, ast.ImportRequireResolve:
+					conditions = r.esmConditionsRequire
+				}
 
-						// Provide additional details about the failure to help with debugging
-						tracker := logger.MakeLineColumnTracker(&packageJSON.source)
-						switch status {
-						case peStatusInvalidModuleSpecifier:
-							debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
-								fmt.Sprintf("The module specifier %q is invalid", resolvedPath))}
-
-						case peStatusInvalidPackageConfiguration:
-							debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
-								"The package configuration has an invalid value here")}
-
-						case peStatusInvalidPackageTarget:
-							why := fmt.Sprintf("The package target %q is invalid", resolvedPath)
-							if resolvedPath == "" {
-								// "PACKAGE_TARGET_RESOLVE" is specified to throw an "Invalid
-								// Package Target" error for what is actually an invalid package
-								// configuration error
-								why = "The package configuration has an invalid value here"
+				// Resolve against the path "/", then join it with the absolute
+				// directory path. This is done because ESM package resolution uses
+				// URLs while our path resolution uses file system paths. We don't
+				// want problems due to Windows paths, which are very unlike URL
+				// paths. We also want to avoid any "%" characters in the absolute
+				// directory path accidentally being interpreted as URL escapes.
+				resolvedPath, status, debug := r.esmPackageExportsResolveWithPostConditions("/", esmPackageSubpath, packageJSON.exportsMap.root, conditions)
+				if (status == peStatusExact || status == peStatusInexact) && strings.HasPrefix(resolvedPath, "/") {
+					absResolvedPath := r.fs.Join(absPkgPath, resolvedPath[1:])
+
+					switch status {
+					case peStatusExact:
+						if r.debugLogs != nil {
+							r.debugLogs.addNote(fmt.Sprintf("The resolved path %q is exact", absResolvedPath))
+						}
+						resolvedDirInfo := r.dirInfoCached(r.fs.Dir(absResolvedPath))
+						if resolvedDirInfo == nil {
+							status = peStatusModuleNotFound
+						} else if entry, diffCase := resolvedDirInfo.entries.Get(r.fs.Base(absResolvedPath)); entry == nil {
+							status = peStatusModuleNotFound
+						} else if kind := entry.Kind(r.fs); kind == fs.DirEntry {
+							if r.debugLogs != nil {
+								r.debugLogs.addNote(fmt.Sprintf("The path %q is a directory, which is not allowed", absResolvedPath))
 							}
-							debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token, why)}
-
-						case peStatusPackagePathNotExported:
-							debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
-								fmt.Sprintf("The path %q is not exported by package %q", esmPackageSubpath, esmPackageName))}
-
-							// If this fails, try to resolve it using the old algorithm
-							if absolute, ok, _ := r.loadAsFileOrDirectory(absPath); ok && absolute.Primary.Namespace == "file" {
-								if relPath, ok := r.fs.Rel(absPkgPath, absolute.Primary.Text); ok {
-									query := "." + path.Join("/", strings.ReplaceAll(relPath, "\\", "/"))
-
-									// If that succeeds, try to do a reverse lookup using the
-									// "exports" map for the currently-active set of conditions
-									if ok, subpath, token := r.esmPackageExportsReverseResolve(
-										query, pkgDirInfo.packageJSON.exportsMap.root, conditions); ok {
-										debugMeta.notes = append(debugMeta.notes, logger.RangeData(&tracker, token,
-											fmt.Sprintf("The file %q is exported at path %q", query, subpath)))
-
-										// Provide an inline suggestion message with the correct import path
-										actualImportPath := path.Join(esmPackageName, subpath)
-										debugMeta.suggestionText = string(js_printer.QuoteForJSON(actualImportPath, false))
-										debugMeta.suggestionMessage = fmt.Sprintf("Import from %q to get the file %q",
-											actualImportPath, r.PrettyPath(absolute.Primary))
-									}
-								}
+							status = peStatusUnsupportedDirectoryImport
+						} else if kind != fs.FileEntry {
+							status = peStatusModuleNotFound
+						} else {
+							if r.debugLogs != nil {
+								r.debugLogs.addNote(fmt.Sprintf("Resolved to %q", absResolvedPath))
 							}
+							return PathPair{Primary: logger.Path{Text: absResolvedPath, Namespace: "file"}}, true, diffCase, DebugMeta{}, true
+						}
 
-						case peStatusModuleNotFound:
-							debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
-								fmt.Sprintf("The module %q was not found on the file system", resolvedPath))}
+					case peStatusInexact:
+						// If this was resolved against an expansion key ending in a "/"
+						// instead of a "*", we need to try CommonJS-style implicit
+						// extension and/or directory detection.
+						if r.debugLogs != nil {
+							r.debugLogs.addNote(fmt.Sprintf("The resolved path %q is inexact", absResolvedPath))
+						}
+						if absolute, ok, diffCase := r.loadAsFileOrDirectory(absResolvedPath); ok {
+							return absolute, true, diffCase, DebugMeta{}, true
+						}
+						status = peStatusModuleNotFound
+					}
+				}
 
-						case peStatusUnsupportedDirectoryImport:
-							debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
-								fmt.Sprintf("Importing the directory %q is not supported", resolvedPath))}
+				var debugMeta DebugMeta
+				if strings.HasPrefix(resolvedPath, "/") {
+					resolvedPath = "." + resolvedPath
+				}
 
-						case peStatusUndefinedNoConditionsMatch:
-							prettyPrintConditions := func(conditions []string) string {
-								quoted := make([]string, len(conditions))
-								for i, condition := range conditions {
-									quoted[i] = fmt.Sprintf("%q", condition)
-								}
-								return strings.Join(quoted, ", ")
-							}
-							keys := make([]string, 0, len(conditions))
-							for key := range conditions {
-								keys = append(keys, key)
-							}
-							sort.Strings(keys)
-							debugMeta.notes = []logger.MsgData{
-								logger.RangeData(&tracker, packageJSON.exportsMap.root.firstToken,
-									fmt.Sprintf("The path %q is not currently exported by package %q",
-										esmPackageSubpath, esmPackageName)),
-								logger.RangeData(&tracker, debug.token,
-									fmt.Sprintf("None of the conditions provided (%s) match any of the currently active conditions (%s)",
-										prettyPrintConditions(debug.unmatchedConditions),
-										prettyPrintConditions(keys),
-									))}
-							for _, key := range debug.unmatchedConditions {
-								if key == "import" && (r.kind == ast.ImportRequire || r.kind == ast.ImportRequireResolve) {
-									debugMeta.suggestionMessage = "Consider using an \"import\" statement to import this file"
-								} else if key == "require" && (r.kind == ast.ImportStmt || r.kind == ast.ImportDynamic) {
-									debugMeta.suggestionMessage = "Consider using a \"require()\" call to import this file"
-								}
+				// Provide additional details about the failure to help with debugging
+				tracker := logger.MakeLineColumnTracker(&packageJSON.source)
+				switch status {
+				case peStatusInvalidModuleSpecifier:
+					debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
+						fmt.Sprintf("The module specifier %q is invalid", resolvedPath))}
+
+				case peStatusInvalidPackageConfiguration:
+					debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
+						"The package configuration has an invalid value here")}
+
+				case peStatusInvalidPackageTarget:
+					why := fmt.Sprintf("The package target %q is invalid", resolvedPath)
+					if resolvedPath == "" {
+						// "PACKAGE_TARGET_RESOLVE" is specified to throw an "Invalid
+						// Package Target" error for what is actually an invalid package
+						// configuration error
+						why = "The package configuration has an invalid value here"
+					}
+					debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token, why)}
+
+				case peStatusPackagePathNotExported:
+					debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
+						fmt.Sprintf("The path %q is not exported by package %q", esmPackageSubpath, esmPackageName))}
+
+					// If this fails, try to resolve it using the old algorithm
+					if absolute, ok, _ := r.loadAsFileOrDirectory(absPath); ok && absolute.Primary.Namespace == "file" {
+						if relPath, ok := r.fs.Rel(absPkgPath, absolute.Primary.Text); ok {
+							query := "." + path.Join("/", strings.ReplaceAll(relPath, "\\", "/"))
+
+							// If that succeeds, try to do a reverse lookup using the
+							// "exports" map for the currently-active set of conditions
+							if ok, subpath, token := r.esmPackageExportsReverseResolve(
+								query, pkgDirInfo.packageJSON.exportsMap.root, conditions); ok {
+								debugMeta.notes = append(debugMeta.notes, logger.RangeData(&tracker, token,
+									fmt.Sprintf("The file %q is exported at path %q", query, subpath)))
+
+								// Provide an inline suggestion message with the correct import path
+								actualImportPath := path.Join(esmPackageName, subpath)
+								debugMeta.suggestionText = string(js_printer.QuoteForJSON(actualImportPath, false))
+								debugMeta.suggestionMessage = fmt.Sprintf("Import from %q to get the file %q",
+									actualImportPath, r.PrettyPath(absolute.Primary))
 							}
 						}
-
-						return PathPair{}, false, nil, debugMeta
 					}
 
-					// Check the "browser" map
-					if remapped, ok := r.checkBrowserMap(pkgDirInfo, absPath, absolutePathKind); ok {
-						if remapped == nil {
-							return PathPair{Primary: logger.Path{Text: absPath, Namespace: "file", Flags: logger.PathDisabled}}, true, nil, DebugMeta{}
+				case peStatusModuleNotFound:
+					debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
+						fmt.Sprintf("The module %q was not found on the file system", resolvedPath))}
+
+				case peStatusUnsupportedDirectoryImport:
+					debugMeta.notes = []logger.MsgData{logger.RangeData(&tracker, debug.token,
+						fmt.Sprintf("Importing the directory %q is not supported", resolvedPath))}
+
+				case peStatusUndefinedNoConditionsMatch:
+					prettyPrintConditions := func(conditions []string) string {
+						quoted := make([]string, len(conditions))
+						for i, condition := range conditions {
+							quoted[i] = fmt.Sprintf("%q", condition)
 						}
-						if remappedResult, ok, diffCase, notes := r.resolveWithoutRemapping(pkgDirInfo.enclosingBrowserScope, *remapped); ok {
-							return remappedResult, true, diffCase, notes
+						return strings.Join(quoted, ", ")
+					}
+					keys := make([]string, 0, len(conditions))
+					for key := range conditions {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					debugMeta.notes = []logger.MsgData{
+						logger.RangeData(&tracker, packageJSON.exportsMap.root.firstToken,
+							fmt.Sprintf("The path %q is not currently exported by package %q",
+								esmPackageSubpath, esmPackageName)),
+						logger.RangeData(&tracker, debug.token,
+							fmt.Sprintf("None of the conditions provided (%s) match any of the currently active conditions (%s)",
+								prettyPrintConditions(debug.unmatchedConditions),
+								prettyPrintConditions(keys),
+							))}
+					for _, key := range debug.unmatchedConditions {
+						if key == "import" && (r.kind == ast.ImportRequire || r.kind == ast.ImportRequireResolve) {
+							debugMeta.suggestionMessage = "Consider using an \"import\" statement to import this file"
+						} else if key == "require" && (r.kind == ast.ImportStmt || r.kind == ast.ImportDynamic) {
+							debugMeta.suggestionMessage = "Consider using a \"require()\" call to import this file"
 						}
 					}
 				}
-			}
 
-			if absolute, ok, diffCase := r.loadAsFileOrDirectory(absPath); ok {
-				return absolute, true, diffCase, DebugMeta{}
+				return PathPair{}, false, nil, debugMeta, true
 			}
-		}
 
-		// Go to the parent directory, stopping at the file system root
-		dirInfo = dirInfo.parent
-		if dirInfo == nil {
-			break
+			// Check the "browser" map
+			if remapped, ok := r.checkBrowserMap(pkgDirInfo, absPath, absolutePathKind); ok {
+				if remapped == nil {
+					return PathPair{Primary: logger.Path{Text: absPath, Namespace: "file", Flags: logger.PathDisabled}}, true, nil, DebugMeta{}, true
+				}
+				if remappedResult, ok, diffCase, notes := r.resolveWithoutRemapping(pkgDirInfo.enclosingBrowserScope, *remapped); ok {
+					return remappedResult, true, diffCase, notes, true
+				}
+			}
 		}
 	}
 
-	// Then check the global "NODE_PATH" environment variable.
-	//
-	// Note: This is a deviation from node's published module resolution
-	// algorithm. The published algorithm says "NODE_PATH" must take precedence
-	// over "node_modules" paths, but it appears that the published algorithm is
-	// incorrect. We follow node's actual behavior instead of following the
-	// published algorithm. See also: https://github.com/nodejs/node/issues/38128.
-	for _, absDir := range r.options.AbsNodePaths {
-		absPath := r.fs.Join(absDir, importPath)
-		if absolute, ok, diffCase := r.loadAsFileOrDirectory(absPath); ok {
-			return absolute, true, diffCase, DebugMeta{}
-		}
+	if absolute, ok, diffCase := r.loadAsFileOrDirectory(absPath); ok {
+		return absolute, true, diffCase, DebugMeta{}, true
 	}
-
-	return PathPair{}, false, nil, DebugMeta{}
+	return PathPair{}, false, nil, DebugMeta{}, false
 }
 
 // Package paths are loaded from a "node_modules" directory. Non-package paths
@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/evanw/esbuild/internal/cache"
@@ -38,7 +39,66 @@ type TSConfigJSON struct {
 	JSXFragmentFactory             []string
 	TSTarget                       *config.TSTarget
 	UseDefineForClassFields        config.MaybeBool
+	ExperimentalDecorators         config.MaybeBool
 	PreserveImportsNotUsedAsValues bool
+
+	// The values of the top-level "files", "include", and "exclude" settings.
+	// These determine which files belong to this project, which matters when
+	// this project is referenced by another project. They are nil if they are
+	// missing, and are absolute paths after the file has been fully parsed.
+	Files   []string
+	Include []string
+	Exclude []string
+
+	// The absolute paths of the config files for the projects listed in
+	// "references". Unlike the other settings, these are not inherited by
+	// config files that extend this one.
+	References []string
+
+	// These are filled in by the resolver after parsing
+	scope              tsConfigScope
+	referencedProjects []*TSConfigJSON
+}
+
+// This copies over the settings that were specified in a base config file.
+// The settings in each base config file override the settings in earlier base
+// config files, and are in turn overridden by the config file extending them.
+func (result *TSConfigJSON) applyExtendedConfig(base *TSConfigJSON) {
+	if base.BaseURL != nil {
+		baseURL := *base.BaseURL
+		result.BaseURL = &baseURL
+	}
+	if base.Paths != nil {
+		result.Paths = base.Paths
+		result.BaseURLForPaths = base.BaseURLForPaths
+	}
+	if base.JSXFactory != nil {
+		result.JSXFactory = base.JSXFactory
+	}
+	if base.JSXFragmentFactory != nil {
+		result.JSXFragmentFactory = base.JSXFragmentFactory
+	}
+	if base.TSTarget != nil {
+		result.TSTarget = base.TSTarget
+	}
+	if base.UseDefineForClassFields != config.Unspecified {
+		result.UseDefineForClassFields = base.UseDefineForClassFields
+	}
+	if base.ExperimentalDecorators != config.Unspecified {
+		result.ExperimentalDecorators = base.ExperimentalDecorators
+	}
+	if base.PreserveImportsNotUsedAsValues {
+		result.PreserveImportsNotUsedAsValues = true
+	}
+	if base.Files != nil {
+		result.Files = base.Files
+	}
+	if base.Include != nil {
+		result.Include = base.Include
+	}
+	if base.Exclude != nil {
+		result.Exclude = base.Exclude
+	}
 }
 
 func ParseTSConfigJSON(
@@ -67,12 +127,51 @@ func ParseTSConfigJSON(
 	result.AbsPath = source.KeyPath.Text
 	tracker := logger.MakeLineColumnTracker(&source)
 
-	// Parse "extends"
+	// Parse "extends". This is either a single base config file or (as of
+	// TypeScript 5.0) an array of base config files that are applied in order.
 	if extends != nil {
 		if valueJSON, _, ok := getProperty(json, "extends"); ok {
 			if value, ok := getString(valueJSON); ok {
 				if base := extends(value, source.RangeOfString(valueJSON.Loc)); base != nil {
-					result = *base
+					result.applyExtendedConfig(base)
+				}
+			} else if array, ok := valueJSON.Data.(*js_ast.EArray); ok {
+				for _, item := range array.Items {
+					if value, ok := getString(item); ok {
+						if base := extends(value, source.RangeOfString(item.Loc)); base != nil {
+							result.applyExtendedConfig(base)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Parse "files", "include", and "exclude"
+	if valueJSON, _, ok := getProperty(json, "files"); ok {
+		if value, ok := getStringArray(valueJSON); ok {
+			result.Files = value
+		}
+	}
+	if valueJSON, _, ok := getProperty(json, "include"); ok {
+		if value, ok := getStringArray(valueJSON); ok {
+			result.Include = value
+		}
+	}
+	if valueJSON, _, ok := getProperty(json, "exclude"); ok {
+		if value, ok := getStringArray(valueJSON); ok {
+			result.Exclude = value
+		}
+	}
+
+	// Parse "references"
+	if valueJSON, _, ok := getProperty(json, "references"); ok {
+		if array, ok := valueJSON.Data.(*js_ast.EArray); ok {
+			for _, item := range array.Items {
+				if pathJSON, _, ok := getProperty(item, "path"); ok {
+					if value, ok := getString(pathJSON); ok {
+						result.References = append(result.References, value)
+					}
 				}
 			}
 		}
@@ -112,6 +211,17 @@ func ParseTSConfigJSON(
 			}
 		}
 
+		// Parse "experimentalDecorators"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "experimentalDecorators"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				if value {
+					result.ExperimentalDecorators = config.True
+				} else {
+					result.ExperimentalDecorators = config.False
+				}
+			}
+		}
+
 		// Parse "target"
 		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "target"); ok {
 			if value, ok := getString(valueJSON); ok {
@@ -141,7 +251,7 @@ func ParseTSConfigJSON(
 					// Nothing to do in this case
 				default:
 					ok = false
-					log.AddRangeWarning(&tracker, r,
+					log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, &tracker, r,
 						fmt.Sprintf("Unrecognized target environment %q", value))
 				}
 
@@ -165,7 +275,7 @@ func ParseTSConfigJSON(
 					result.PreserveImportsNotUsedAsValues = true
 				case "remove":
 				default:
-					log.AddRangeWarning(&tracker, source.RangeOfString(valueJSON.Loc),
+					log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, &tracker, source.RangeOfString(valueJSON.Loc),
 						fmt.Sprintf("Invalid value %q for \"importsNotUsedAsValues\"", value))
 				}
 			}
@@ -218,7 +328,7 @@ func ParseTSConfigJSON(
 								}
 							}
 						} else {
-							log.AddRangeWarning(&tracker, source.RangeOfString(prop.ValueOrNil.Loc), fmt.Sprintf(
+							log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, &tracker, source.RangeOfString(prop.ValueOrNil.Loc), fmt.Sprintf(
 								"Substitutions for pattern %q should be an array", key))
 						}
 					}
@@ -230,6 +340,22 @@ func ParseTSConfigJSON(
 	return &result
 }
 
+// Note that this returns a non-nil slice for an empty array because an empty
+// array is meaningful (e.g. "files": [] means the project has no files)
+func getStringArray(json js_ast.Expr) ([]string, bool) {
+	array, ok := json.Data.(*js_ast.EArray)
+	if !ok {
+		return nil, false
+	}
+	result := make([]string, 0, len(array.Items))
+	for _, item := range array.Items {
+		if value, ok := getString(item); ok {
+			result = append(result, value)
+		}
+	}
+	return result, true
+}
+
 func parseMemberExpressionForJSX(log logger.Log, source *logger.Source, tracker *logger.LineColumnTracker, loc logger.Loc, text string) []string {
 	if text == "" {
 		return nil
@@ -238,7 +364,7 @@ func parseMemberExpressionForJSX(log logger.Log, source *logger.Source, tracker
 	for _, part := range parts {
 		if !js_lexer.IsIdentifier(part) {
 			warnRange := source.RangeOfString(loc)
-			log.AddRangeWarning(tracker, warnRange, fmt.Sprintf("Invalid JSX member expression: %q", text))
+			log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, tracker, warnRange, fmt.Sprintf("Invalid JSX member expression: %q", text))
 			return nil
 		}
 	}
@@ -251,7 +377,7 @@ func isValidTSConfigPathPattern(text string, log logger.Log, source *logger.Sour
 		if text[i] == '*' {
 			if foundAsterisk {
 				r := source.RangeOfString(loc)
-				log.AddRangeWarning(tracker, r, fmt.Sprintf(
+				log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, tracker, r, fmt.Sprintf(
 					"Invalid pattern %q, must have at most one \"*\" character", text))
 				return false
 			}
@@ -302,7 +428,108 @@ func isValidTSConfigPathNoBaseURLPattern(text string, log logger.Log, source *lo
 	}
 
 	r := source.RangeOfString(loc)
-	log.AddRangeWarning(tracker, r, fmt.Sprintf(
+	log.AddID(logger.MsgID_Resolver_TSConfigJSON, logger.Warning, tracker, r, fmt.Sprintf(
 		"Non-relative path %q is not allowed when \"baseUrl\" is not set (did you forget a leading \"./\"?)", text))
 	return false
 }
+
+// This determines which files belong to a project using its "files",
+// "include", and "exclude" settings. Paths are compared with forward slashes.
+type tsConfigScope struct {
+	files   map[string]bool
+	include []tsConfigGlob
+	exclude []tsConfigGlob
+}
+
+type tsConfigGlob struct {
+	// This matches paths that the pattern matches, as well as everything inside
+	// of a matched directory (e.g. "src" includes everything in "src")
+	file *regexp.Regexp
+
+	// This matches directories that may contain files that the pattern matches
+	dir *regexp.Regexp
+}
+
+// The patterns must be absolute paths. If both "files" and "include" are
+// missing, TypeScript includes everything in the directory of the config file.
+func compileTSConfigScope(fileDir string, files []string, include []string, exclude []string) tsConfigScope {
+	if files == nil && include == nil {
+		include = []string{strings.TrimSuffix(strings.ReplaceAll(fileDir, "\\", "/"), "/") + "/**/*"}
+	}
+	scope := tsConfigScope{files: make(map[string]bool)}
+	for _, file := range files {
+		scope.files[strings.ReplaceAll(file, "\\", "/")] = true
+	}
+	for _, pattern := range include {
+		scope.include = append(scope.include, compileTSConfigGlob(pattern))
+	}
+	for _, pattern := range exclude {
+		scope.exclude = append(scope.exclude, compileTSConfigGlob(pattern))
+	}
+	return scope
+}
+
+func compileTSConfigGlob(pattern string) tsConfigGlob {
+	parts := strings.Split(strings.ReplaceAll(pattern, "\\", "/"), "/")
+	dirParts := parts
+	if strings.ContainsAny(parts[len(parts)-1], "*?") {
+		dirParts = parts[:len(parts)-1]
+	}
+	return tsConfigGlob{
+		file: regexp.MustCompile("^" + tsConfigGlobToRegexp(parts) + "(?:/.*)?$"),
+		dir:  regexp.MustCompile("^" + tsConfigGlobToRegexp(dirParts) + "(?:/.*)?$"),
+	}
+}
+
+// TypeScript globs support "*" (zero or more characters except a slash), "?"
+// (exactly one character except a slash), and "**/" (zero or more directories)
+func tsConfigGlobToRegexp(parts []string) string {
+	sb := strings.Builder{}
+	for i, part := range parts {
+		if part == "**" {
+			sb.WriteString("(?:/[^/]*)*")
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('/')
+		}
+		for _, c := range part {
+			switch c {
+			case '*':
+				sb.WriteString("[^/]*")
+			case '?':
+				sb.WriteString("[^/]")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Directories are considered to be included if they may contain included files
+func (scope *tsConfigScope) includesPath(path string, isDir bool) bool {
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	// Files listed in "files" are always included, even if they are excluded
+	if !isDir && scope.files[path] {
+		return true
+	}
+
+	for _, glob := range scope.exclude {
+		if glob.file.MatchString(path) {
+			return false
+		}
+	}
+
+	for _, glob := range scope.include {
+		re := glob.file
+		if isDir {
+			re = glob.dir
+		}
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,469 @@
+package resolver
+
+// This file implements the Yarn Plug'n'Play resolution algorithm. Yarn PnP
+// projects don't have a "node_modules" directory. Instead, Yarn generates a
+// manifest (either ".pnp.data.json" or the "RAW_RUNTIME_STATE" string inside
+// ".pnp.cjs") containing the dependency graph of every package, and package
+// files are stored in zip archives in ".yarn/cache". The specification for
+// this algorithm is here: https://yarnpkg.com/advanced/pnp-spec/.
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/js_parser"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type pnpData struct {
+	// The absolute path of the manifest file and the directory containing it.
+	// Package locations in the manifest are relative to that directory.
+	absPath    string
+	absDirPath string
+
+	// A regular expression for paths that should not be considered part of the
+	// dependency tree. This is ignored if it's not valid Go regular expression
+	// syntax (Yarn's default pattern uses lookahead, for example).
+	ignorePattern *regexp.Regexp
+
+	// If true, packages that aren't in the exclusion list are allowed to import
+	// any dependency of the top-level package or of the fallback pool, even if
+	// they don't explicitly list it as a dependency. This is a compatibility
+	// mode for packages that rely on hoisting.
+	enableTopLevelFallback bool
+	fallbackExclusionList  map[string]map[string]bool
+	fallbackPool           map[string]*pnpIdentAndReference
+
+	// The dependency graph, indexed by package name and then by reference. The
+	// top-level package has an empty name and reference.
+	packageRegistryData map[string]map[string]pnpPackage
+
+	// This is used to find which package a given path belongs to. The keys are
+	// "packageLocation" values (relative paths starting with "./" or "../" and
+	// ending with "/").
+	packageLocatorsByLocation map[string]pnpIdentAndReference
+}
+
+type pnpIdentAndReference struct {
+	ident     string
+	reference string
+}
+
+type pnpPackage struct {
+	packageLocation string
+
+	// A nil value means this is a peer dependency that wasn't provided
+	packageDependencies map[string]*pnpIdentAndReference
+}
+
+type pnpStatus uint8
+
+const (
+	// The importer isn't part of the dependency tree, so regular "node_modules"
+	// resolution should be used instead
+	pnpSkipped pnpStatus = iota
+
+	pnpSuccess
+	pnpErrorDependencyNotFound
+	pnpErrorUnfulfilledPeerDependency
+)
+
+type pnpResult struct {
+	status pnpStatus
+
+	// These are valid if "status" is "pnpSuccess"
+	absPkgPath string
+	pkgSubpath string
+
+	// These are used for error messages
+	ident   string
+	locator pnpIdentAndReference
+}
+
+// This is the "RESOLVE_TO_UNQUALIFIED" function from the specification
+func (r resolverQuery) pnpResolveToUnqualified(manifest *pnpData, pkgName string, pkgSubpath string, importDir string) pnpResult {
+	// Find the package that contains the importing directory
+	locator, ok := r.pnpFindLocator(manifest, importDir)
+	if !ok {
+		if r.debugLogs != nil {
+			r.debugLogs.addNote(fmt.Sprintf("The directory %q is not part of the Yarn PnP dependency tree", importDir))
+		}
+		return pnpResult{status: pnpSkipped}
+	}
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Found Yarn PnP locator [%q, %q] for directory %q", locator.ident, locator.reference, importDir))
+	}
+
+	// Look for the dependency in the package's own dependency list
+	parentPkg := manifest.packageRegistryData[locator.ident][locator.reference]
+	dependency, ok := parentPkg.packageDependencies[pkgName]
+
+	// Otherwise use the top-level fallback, if enabled
+	if !ok && manifest.enableTopLevelFallback && !manifest.fallbackExclusionList[locator.ident][locator.reference] {
+		if r.debugLogs != nil {
+			r.debugLogs.addNote(fmt.Sprintf("Checking the Yarn PnP fallback for %q", pkgName))
+		}
+		topLevelPkg := manifest.packageRegistryData[""][""]
+		if dependency, ok = topLevelPkg.packageDependencies[pkgName]; !ok {
+			dependency, ok = manifest.fallbackPool[pkgName]
+		}
+	}
+
+	result := pnpResult{
+		ident:   pkgName,
+		locator: locator,
+	}
+	if !ok {
+		result.status = pnpErrorDependencyNotFound
+		return result
+	}
+	if dependency == nil {
+		result.status = pnpErrorUnfulfilledPeerDependency
+		return result
+	}
+
+	// Map the dependency to its location on the file system
+	dependencyPkg, ok := manifest.packageRegistryData[dependency.ident][dependency.reference]
+	if !ok {
+		result.status = pnpErrorDependencyNotFound
+		return result
+	}
+	result.status = pnpSuccess
+	result.absPkgPath = r.fs.Join(manifest.absDirPath, dependencyPkg.packageLocation)
+	result.pkgSubpath = pkgSubpath
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Resolved %q via Yarn PnP to %q with subpath %q", pkgName, result.absPkgPath, pkgSubpath))
+	}
+	return result
+}
+
+// This is the "FIND_LOCATOR" function from the specification
+func (r resolverQuery) pnpFindLocator(manifest *pnpData, absDirPath string) (pnpIdentAndReference, bool) {
+	relPath, ok := r.fs.Rel(manifest.absDirPath, absDirPath)
+	if !ok {
+		return pnpIdentAndReference{}, false
+	}
+	relPath = strings.ReplaceAll(relPath, "\\", "/")
+	if relPath == "." {
+		relPath = ""
+	}
+
+	if manifest.ignorePattern != nil && manifest.ignorePattern.MatchString(relPath) {
+		return pnpIdentAndReference{}, false
+	}
+
+	// Package locations always end with a slash
+	if relPath == "" {
+		relPath = "./"
+	} else {
+		if !strings.HasPrefix(relPath, "../") {
+			relPath = "./" + relPath
+		}
+		relPath += "/"
+	}
+
+	// Find the longest package location that's a prefix of this path
+	for {
+		if locator, ok := manifest.packageLocatorsByLocation[relPath]; ok {
+			return locator, true
+		}
+		slash := strings.LastIndexByte(relPath[:len(relPath)-1], '/')
+		if slash == -1 {
+			return pnpIdentAndReference{}, false
+		}
+		relPath = relPath[:slash+1]
+	}
+}
+
+func (result pnpResult) debugMeta() DebugMeta {
+	importer := fmt.Sprintf("package %q", result.locator.ident)
+	if result.locator.ident == "" {
+		importer = "the top-level package"
+	}
+	var text string
+	switch result.status {
+	case pnpErrorDependencyNotFound:
+		text = fmt.Sprintf("The Yarn Plug'n'Play manifest forbids importing %q here because it's not listed as a dependency of %s",
+			result.ident, importer)
+
+	case pnpErrorUnfulfilledPeerDependency:
+		text = fmt.Sprintf("The package %q is a peer dependency of %s, but it was not provided by the package that depends on it",
+			result.ident, importer)
+	}
+	return DebugMeta{notes: []logger.MsgData{{Text: text}}}
+}
+
+// The manifest is either a JSON file or a JavaScript file with the JSON data
+// embedded in a string. This returns nil if the manifest couldn't be loaded.
+func (r resolverQuery) parsePnPManifest(absPath string) *pnpData {
+	contents, err, originalError := r.caches.FSCache.ReadFile(r.fs, absPath)
+	if r.debugLogs != nil && originalError != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Failed to read file %q: %s", absPath, originalError.Error()))
+	}
+	if err != nil {
+		r.log.AddID(logger.MsgID_Bundler_ReadError, logger.Error, nil, logger.Range{},
+			fmt.Sprintf("Cannot read file %q: %s",
+				r.PrettyPath(logger.Path{Text: absPath, Namespace: "file"}), err.Error()))
+		return nil
+	}
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("The file %q exists", absPath))
+	}
+
+	keyPath := logger.Path{Text: absPath, Namespace: "file"}
+	source := logger.Source{
+		KeyPath:    keyPath,
+		PrettyPath: r.PrettyPath(keyPath),
+		Contents:   contents,
+	}
+
+	var json js_ast.Expr
+	if strings.HasSuffix(absPath, ".json") {
+		var ok bool
+		if json, ok = r.caches.JSONCache.Parse(r.log, source, js_parser.JSONOptions{}); !ok {
+			return nil
+		}
+	} else {
+		var ok bool
+		if json, ok = r.extractPnPDataFromJS(source); !ok {
+			return nil
+		}
+	}
+
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Using the Yarn PnP manifest in %q", absPath))
+	}
+	return r.compilePnPData(absPath, json)
+}
+
+// Recent versions of Yarn store the manifest as a JSON string in a variable
+// called "RAW_RUNTIME_STATE". Older versions store it as an object literal
+// passed to "hydrateRuntimeState" inside a function called "$$SETUP_STATE".
+func (r resolverQuery) extractPnPDataFromJS(source logger.Source) (js_ast.Expr, bool) {
+	// Don't show warnings about code in the manifest since the user didn't
+	// write it and it's never going to be part of the bundle
+	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+	tree, ok := js_parser.Parse(log, source, js_parser.OptionsFromConfig(&config.Options{}))
+	if !ok || log.HasErrors() {
+		r.log.AddID(logger.MsgID_Resolver_PnPManifest, logger.Error, nil, logger.Range{},
+			fmt.Sprintf("Failed to parse the Yarn PnP manifest %q", source.PrettyPath))
+		return js_ast.Expr{}, false
+	}
+
+	for _, part := range tree.Parts {
+		for _, stmt := range part.Stmts {
+			switch s := stmt.Data.(type) {
+			case *js_ast.SLocal:
+				for _, decl := range s.Decls {
+					if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok && tree.Symbols[id.Ref.InnerIndex].OriginalName == "RAW_RUNTIME_STATE" {
+						if str, ok := decl.ValueOrNil.Data.(*js_ast.EString); ok {
+							jsonSource := logger.Source{
+								KeyPath:    source.KeyPath,
+								PrettyPath: source.PrettyPath,
+								Contents:   js_lexer.UTF16ToString(str.Value),
+							}
+							return js_parser.ParseJSON(r.log, jsonSource, js_parser.JSONOptions{})
+						}
+					}
+				}
+
+			case *js_ast.SFunction:
+				if s.Fn.Name != nil && tree.Symbols[s.Fn.Name.Ref.InnerIndex].OriginalName == "$$SETUP_STATE" {
+					for _, bodyStmt := range s.Fn.Body.Stmts {
+						if ret, ok := bodyStmt.Data.(*js_ast.SReturn); ok {
+							if call, ok := ret.ValueOrNil.Data.(*js_ast.ECall); ok && len(call.Args) > 0 {
+								if _, ok := call.Args[0].Data.(*js_ast.EObject); ok {
+									return call.Args[0], true
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	r.log.AddID(logger.MsgID_Resolver_PnPManifest, logger.Error, nil, logger.Range{},
+		fmt.Sprintf("Failed to find the dependency data in the Yarn PnP manifest %q", source.PrettyPath))
+	return js_ast.Expr{}, false
+}
+
+// Yarn generates "ignorePatternData" using micromatch, which uses negative
+// lookahead to avoid matching "." and ".." path segments. Go's regular
+// expressions don't support lookahead, so those groups are removed here. This
+// doesn't change what the pattern matches because the paths that it's tested
+// against have already been cleaned and never contain these segments. Any
+// other lookahead will still fail to compile.
+func translatePnPIgnorePattern(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, `(?!(?:^|\/)\.{1,2}(?:\/|$))`, "")
+	pattern = strings.ReplaceAll(pattern, `(?!\.{1,2}(?:\/|$))`, "")
+	return pattern
+}
+
+func (r resolverQuery) compilePnPData(absPath string, json js_ast.Expr) *pnpData {
+	data := &pnpData{
+		absPath:                   absPath,
+		absDirPath:                r.fs.Dir(absPath),
+		fallbackExclusionList:     make(map[string]map[string]bool),
+		fallbackPool:              make(map[string]*pnpIdentAndReference),
+		packageRegistryData:       make(map[string]map[string]pnpPackage),
+		packageLocatorsByLocation: make(map[string]pnpIdentAndReference),
+	}
+
+	if value, _, ok := getProperty(json, "enableTopLevelFallback"); ok {
+		data.enableTopLevelFallback, _ = getBool(value)
+	}
+
+	if value, _, ok := getProperty(json, "ignorePatternData"); ok {
+		if pattern, ok := getString(value); ok {
+			if compiled, err := regexp.Compile(translatePnPIgnorePattern(pattern)); err == nil {
+				data.ignorePattern = compiled
+			} else {
+				r.log.AddID(logger.MsgID_Resolver_PnPManifest, logger.Warning, nil, logger.Range{},
+					fmt.Sprintf("Ignoring the unsupported \"ignorePatternData\" regular expression in the Yarn PnP manifest %q: %s",
+						r.PrettyPath(logger.Path{Text: absPath, Namespace: "file"}), err.Error()))
+			}
+		}
+	}
+
+	// Each item is "[name, [reference, ...]]"
+	if value, _, ok := getProperty(json, "fallbackExclusionList"); ok {
+		for _, item := range getArray(value) {
+			if tuple := getArray(item); len(tuple) == 2 {
+				if ident, ok := getStringOrNull(tuple[0]); ok {
+					references := data.fallbackExclusionList[ident]
+					if references == nil {
+						references = make(map[string]bool)
+						data.fallbackExclusionList[ident] = references
+					}
+					for _, reference := range getArray(tuple[1]) {
+						if reference, ok := getStringOrNull(reference); ok {
+							references[reference] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Each item is "[name, dependency]"
+	if value, _, ok := getProperty(json, "fallbackPool"); ok {
+		for _, item := range getArray(value) {
+			if tuple := getArray(item); len(tuple) == 2 {
+				if ident, ok := getString(tuple[0]); ok {
+					if dependency, ok := getPnPDependency(ident, tuple[1]); ok {
+						data.fallbackPool[ident] = dependency
+					}
+				}
+			}
+		}
+	}
+
+	// Each item is "[name, [[reference, info], ...]]"
+	if value, _, ok := getProperty(json, "packageRegistryData"); ok {
+		for _, item := range getArray(value) {
+			tuple := getArray(item)
+			if len(tuple) != 2 {
+				continue
+			}
+			ident, ok := getStringOrNull(tuple[0])
+			if !ok {
+				continue
+			}
+			references := data.packageRegistryData[ident]
+			if references == nil {
+				references = make(map[string]pnpPackage)
+				data.packageRegistryData[ident] = references
+			}
+
+			for _, item := range getArray(tuple[1]) {
+				tuple := getArray(item)
+				if len(tuple) != 2 {
+					continue
+				}
+				reference, ok := getStringOrNull(tuple[0])
+				if !ok {
+					continue
+				}
+				pkg := pnpPackage{packageDependencies: make(map[string]*pnpIdentAndReference)}
+
+				if value, _, ok := getProperty(tuple[1], "packageLocation"); ok {
+					pkg.packageLocation, _ = getString(value)
+				}
+
+				// Each item is "[name, dependency]"
+				if value, _, ok := getProperty(tuple[1], "packageDependencies"); ok {
+					for _, item := range getArray(value) {
+						if tuple := getArray(item); len(tuple) == 2 {
+							if depIdent, ok := getString(tuple[0]); ok {
+								if dependency, ok := getPnPDependency(depIdent, tuple[1]); ok {
+									pkg.packageDependencies[depIdent] = dependency
+								}
+							}
+						}
+					}
+				}
+
+				references[reference] = pkg
+
+				// Packages marked with "discardFromLookup" are never used to find
+				// which package a path belongs to
+				discardFromLookup := false
+				if value, _, ok := getProperty(tuple[1], "discardFromLookup"); ok {
+					discardFromLookup, _ = getBool(value)
+				}
+				if !discardFromLookup && pkg.packageLocation != "" {
+					data.packageLocatorsByLocation[pkg.packageLocation] = pnpIdentAndReference{
+						ident:     ident,
+						reference: reference,
+					}
+				}
+			}
+		}
+	}
+
+	return data
+}
+
+// A dependency is either a reference string (for a package with the same
+// name), an array of "[name, reference]" (for an aliased package), or null
+// (for a peer dependency that was not provided).
+func getPnPDependency(ident string, json js_ast.Expr) (*pnpIdentAndReference, bool) {
+	switch value := json.Data.(type) {
+	case *js_ast.ENull:
+		return nil, true
+
+	case *js_ast.EString:
+		return &pnpIdentAndReference{ident: ident, reference: js_lexer.UTF16ToString(value.Value)}, true
+
+	case *js_ast.EArray:
+		if len(value.Items) == 2 {
+			if aliasIdent, ok := getString(value.Items[0]); ok {
+				if reference, ok := getString(value.Items[1]); ok {
+					return &pnpIdentAndReference{ident: aliasIdent, reference: reference}, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func getArray(json js_ast.Expr) []js_ast.Expr {
+	if value, ok := json.Data.(*js_ast.EArray); ok {
+		return value.Items
+	}
+	return nil
+}
+
+// The top-level package is identified using null for both its name and its
+// reference, which is represented using an empty string here
+func getStringOrNull(json js_ast.Expr) (string, bool) {
+	if _, ok := json.Data.(*js_ast.ENull); ok {
+		return "", true
+	}
+	return getString(json)
+}
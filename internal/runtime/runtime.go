@@ -231,6 +231,80 @@ func code(isES6 bool) string {
 		}
 		export var __decorateParam = (index, decorator) => (target, key) => decorator(target, key, index)
 
+		// For JavaScript decorators
+		// - kind === 0: class
+		// - kind === 1: method
+		// - kind === 2: getter
+		// - kind === 3: setter
+		// - kind === 4: accessor
+		// - kind === 5: field
+		// - flags & 8: static
+		// - flags & 16: private
+		var __decoratorKinds = ['class', 'method', 'getter', 'setter', 'accessor', 'field']
+		var __decoratorContext = (kind, name, done, initializers) => ({
+			kind: __decoratorKinds[kind],
+			name,
+			addInitializer: fn => {
+				if (done._) throw TypeError('Cannot add initializers after decoration has completed')
+				if (typeof fn !== 'function') throw TypeError('Function expected')
+				initializers.push(fn)
+			},
+		})
+		export var __decorateElement = (array, flags, name, decorators, target, extra) => {
+			var kind = flags & 7, isStatic = !!(flags & 8), isPrivate = !!(flags & 16)
+			var initializers = array[kind ? isStatic ? 1 : 2 : 0] ||= []
+			var fieldInitializers = kind > 3 && (array[extra] = [])
+			var object = kind && !isStatic && !isPrivate ? target.prototype : target
+			var key = kind > 2 ? 'set' : kind > 1 ? 'get' : 'value'
+			var desc = kind && kind < 5 && (isPrivate ? {} : __getOwnPropDesc(object, name))
+			if (isPrivate && kind < 4) desc[key] = extra
+			if (isPrivate && kind === 4) {
+				desc.get = function () { return __privateGet(this, target) }
+				desc.set = function (value) { __privateSet(this, target, value) }
+			}
+			for (var i = decorators.length - 1; i >= 0; i--) {
+				var done = {}
+				var context = __decoratorContext(kind, name, done, initializers)
+				if (kind) {
+					var access = { has: isPrivate ? obj => __privateIn(target, obj) : obj => name in obj }
+					if (kind !== 3) access.get = isPrivate
+						? obj => kind === 1 ? __privateMethod(obj, target, desc.value) : __privateGet(obj, target, desc && desc.get)
+						: obj => obj[name]
+					if (kind > 2) access.set = isPrivate
+						? (obj, value) => { __privateSet(obj, target, value, desc && desc.set) }
+						: (obj, value) => { obj[name] = value }
+					context.static = isStatic
+					context.private = isPrivate
+					context.access = access
+				}
+				var value = kind ? kind < 4 ? desc[key] : kind < 5 ? { get: desc.get, set: desc.set } : void 0 : target
+				var result = (0, decorators[i])(value, context)
+				done._ = 1
+				if (result === void 0) continue
+				if (kind === 4) {
+					if (typeof result !== 'object' || result === null) throw TypeError('Object expected')
+					if (result.get) desc.get = result.get
+					if (result.set) desc.set = result.set
+					if (result.init) fieldInitializers.unshift(result.init)
+				}
+				else if (typeof result !== 'function') throw TypeError('Function expected')
+				else if (kind > 4) fieldInitializers.unshift(result)
+				else if (kind) desc[key] = result
+				else target = result
+			}
+
+			// Private methods, getters, and setters return the replacement function
+			// and private auto-accessors return the replacement getter and setter
+			if (isPrivate) return kind < 4 ? desc[key] : desc
+			if (desc) __defProp(object, name, desc)
+			return target
+		}
+		export var __runDecoratorInitializers = (array, index, self, value) => {
+			for (var i = 0, fns = array[index] || []; i < fns.length; i++)
+				index > 2 ? value = fns[i].call(self, value) : fns[i].call(self)
+			return value
+		}
+
 		// For class members
 		export var __publicField = (obj, key, value) => {
 			__defNormalProp(obj, typeof key !== 'symbol' ? key + '' : key, value)
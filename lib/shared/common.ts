@@ -89,11 +89,20 @@ function pushLogFlags(flags: string[], options: CommonOptions, keys: OptionKeys,
   let color = getFlag(options, keys, 'color', mustBeBoolean);
   let logLevel = getFlag(options, keys, 'logLevel', mustBeString);
   let logLimit = getFlag(options, keys, 'logLimit', mustBeInteger);
+  let logOverride = getFlag(options, keys, 'logOverride', mustBeObject);
+  let logFormat = getFlag(options, keys, 'logFormat', mustBeString);
 
   if (color !== void 0) flags.push(`--color=${color}`);
   else if (isTTY) flags.push(`--color=true`); // This is needed to fix "execFileSync" which buffers stderr
   flags.push(`--log-level=${logLevel || logLevelDefault}`);
   flags.push(`--log-limit=${logLimit || 0}`);
+  if (logFormat) flags.push(`--log-format=${logFormat}`);
+  if (logOverride) {
+    for (let id in logOverride) {
+      if (id.indexOf('=') >= 0) throw new Error(`Invalid log override: ${id}`);
+      flags.push(`--log-override:${id}=${logOverride[id]}`);
+    }
+  }
 }
 
 function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKeys): void {
@@ -178,7 +187,12 @@ function flagsForBuildOptions(
   let watch = getFlag(options, keys, 'watch', mustBeBooleanOrObject);
   let splitting = getFlag(options, keys, 'splitting', mustBeBoolean);
   let preserveSymlinks = getFlag(options, keys, 'preserveSymlinks', mustBeBoolean);
+  let minifyInline = getFlag(options, keys, 'minifyInline', mustBeBoolean);
+  let inferPureFunctions = getFlag(options, keys, 'inferPureFunctions', mustBeBoolean);
   let metafile = getFlag(options, keys, 'metafile', mustBeBoolean);
+  let manifest = getFlag(options, keys, 'manifest', mustBeBoolean);
+  let integrity = getFlag(options, keys, 'integrity', mustBeString);
+  let integrityMap = getFlag(options, keys, 'integrityMap', mustBeBoolean);
   let outfile = getFlag(options, keys, 'outfile', mustBeString);
   let outdir = getFlag(options, keys, 'outdir', mustBeString);
   let outbase = getFlag(options, keys, 'outbase', mustBeString);
@@ -189,12 +203,22 @@ function flagsForBuildOptions(
   let mainFields = getFlag(options, keys, 'mainFields', mustBeArray);
   let conditions = getFlag(options, keys, 'conditions', mustBeArray);
   let external = getFlag(options, keys, 'external', mustBeArray);
+  let packages = getFlag(options, keys, 'packages', mustBeString);
+  let externalGlobals = getFlag(options, keys, 'externalGlobals', mustBeObject);
+  let alias = getFlag(options, keys, 'alias', mustBeObject);
   let loader = getFlag(options, keys, 'loader', mustBeObject);
   let outExtension = getFlag(options, keys, 'outExtension', mustBeObject);
   let publicPath = getFlag(options, keys, 'publicPath', mustBeString);
   let entryNames = getFlag(options, keys, 'entryNames', mustBeString);
   let chunkNames = getFlag(options, keys, 'chunkNames', mustBeString);
+  let manualChunks = getFlag(options, keys, 'manualChunks', mustBeObject);
+  let minChunkSize = getFlag(options, keys, 'minChunkSize', mustBeInteger);
+  let maxChunkSize = getFlag(options, keys, 'maxChunkSize', mustBeInteger);
+  let chunkMergeBudget = getFlag(options, keys, 'chunkMergeBudget', mustBeInteger);
+  let sizeBudgets = getFlag(options, keys, 'sizeBudgets', mustBeObject);
+  let sizeBudgetWarnings = getFlag(options, keys, 'sizeBudgetWarnings', mustBeBoolean);
   let assetNames = getFlag(options, keys, 'assetNames', mustBeString);
+  let assetInlineLimit = getFlag(options, keys, 'assetInlineLimit', mustBeInteger);
   let inject = getFlag(options, keys, 'inject', mustBeArray);
   let banner = getFlag(options, keys, 'banner', mustBeObject);
   let footer = getFlag(options, keys, 'footer', mustBeObject);
@@ -223,7 +247,12 @@ function flagsForBuildOptions(
   }
   if (splitting) flags.push('--splitting');
   if (preserveSymlinks) flags.push('--preserve-symlinks');
+  if (minifyInline) flags.push('--minify-inline');
+  if (inferPureFunctions) flags.push('--infer-pure-functions');
   if (metafile) flags.push(`--metafile`);
+  if (manifest) flags.push(`--manifest`);
+  if (integrity) flags.push(`--integrity=${integrity}`);
+  if (integrityMap) flags.push(`--integrity-map`);
   if (outfile) flags.push(`--outfile=${outfile}`);
   if (outdir) flags.push(`--outdir=${outdir}`);
   if (outbase) flags.push(`--outbase=${outbase}`);
@@ -241,7 +270,30 @@ function flagsForBuildOptions(
   if (publicPath) flags.push(`--public-path=${publicPath}`);
   if (entryNames) flags.push(`--entry-names=${entryNames}`);
   if (chunkNames) flags.push(`--chunk-names=${chunkNames}`);
+  if (minChunkSize) flags.push(`--min-chunk-size=${minChunkSize}`);
+  if (maxChunkSize) flags.push(`--max-chunk-size=${maxChunkSize}`);
+  if (chunkMergeBudget) flags.push(`--chunk-merge-budget=${chunkMergeBudget}`);
+  if (manualChunks) {
+    for (let name in manualChunks) {
+      if (name.indexOf('=') >= 0) throw new Error(`Invalid manual chunk name: ${name}`);
+      let patterns = manualChunks[name];
+      if (!Array.isArray(patterns)) throw new Error(`Expected an array of patterns for manual chunk: ${name}`);
+      flags.push(`--manual-chunk:${name}=${patterns.join(',')}`);
+    }
+  }
+  if (sizeBudgets) {
+    for (let glob in sizeBudgets) {
+      let budget = sizeBudgets[glob];
+      let limits: string[] = [];
+      if (budget.raw) limits.push(`raw:${budget.raw}`);
+      if (budget.gzip) limits.push(`gzip:${budget.gzip}`);
+      if (budget.brotli) limits.push(`brotli:${budget.brotli}`);
+      if (limits.length > 0) flags.push(`--size-budget:${glob}=${limits.join(',')}`);
+    }
+  }
+  if (sizeBudgetWarnings) flags.push('--size-budget-warnings');
   if (assetNames) flags.push(`--asset-names=${assetNames}`);
+  if (assetInlineLimit) flags.push(`--asset-inline-limit=${assetInlineLimit}`);
   if (mainFields) {
     let values: string[] = [];
     for (let value of mainFields) {
@@ -261,6 +313,19 @@ function flagsForBuildOptions(
     flags.push(`--conditions=${values.join(',')}`);
   }
   if (external) for (let name of external) flags.push(`--external:${name}`);
+  if (packages) flags.push(`--packages=${packages}`);
+  if (externalGlobals) {
+    for (let path in externalGlobals) {
+      if (path.indexOf('=') >= 0) throw new Error(`Invalid external global path: ${path}`);
+      flags.push(`--external-global:${path}=${externalGlobals[path]}`);
+    }
+  }
+  if (alias) {
+    for (let old in alias) {
+      if (old.indexOf('=') >= 0) throw new Error(`Invalid package name in alias: ${old}`);
+      flags.push(`--alias:${old}=${alias[old]}`);
+    }
+  }
   if (banner) {
     for (let type in banner) {
       if (type.indexOf('=') >= 0) throw new Error(`Invalid banner file type: ${type}`);
@@ -1099,7 +1164,7 @@ export function createChannel(streamIn: StreamIn): StreamOut {
               sendRequest<protocol.RebuildRequest, protocol.BuildResponse>(refs, { command: 'rebuild', rebuildID: response!.rebuildID! },
                 (error2, response2) => {
                   if (error2) {
-                    const message: types.Message = { pluginName: '', text: error2, location: null, notes: [], detail: void 0 };
+                    const message: types.Message = { id: '', pluginName: '', text: error2, location: null, notes: [], detail: void 0 };
                     return callback(failureErrorWithLog('Build failed', [message], []), null);
                   }
                   buildResponseToResult(response2, (error3, result3) => {
@@ -1378,7 +1443,7 @@ function extractErrorMessageV8(e: any, streamIn: StreamIn, stash: ObjectStash |
   } catch {
   }
 
-  return { pluginName, text, location, notes: note ? [note] : [], detail: stash ? stash.store(e) : -1 }
+  return { id: '', pluginName, text, location, notes: note ? [note] : [], detail: stash ? stash.store(e) : -1 }
 }
 
 function parseStackLinesV8(streamIn: StreamIn, lines: string[], ident: string): types.Location | null {
@@ -1488,6 +1553,7 @@ function sanitizeMessages(messages: types.PartialMessage[], property: string, st
 
   for (const message of messages) {
     let keys: OptionKeys = {};
+    let id = getFlag(message, keys, 'id', mustBeString);
     let pluginName = getFlag(message, keys, 'pluginName', mustBeString);
     let text = getFlag(message, keys, 'text', mustBeString);
     let location = getFlag(message, keys, 'location', mustBeObjectOrNull);
@@ -1511,6 +1577,7 @@ function sanitizeMessages(messages: types.PartialMessage[], property: string, st
     }
 
     messagesClone.push({
+      id: id || '',
       pluginName: pluginName || fallbackPluginName,
       text: text || '',
       location: sanitizeLocation(location, where),
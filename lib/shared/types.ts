@@ -1,6 +1,6 @@
 export type Platform = 'browser' | 'node' | 'neutral';
-export type Format = 'iife' | 'cjs' | 'esm';
-export type Loader = 'js' | 'jsx' | 'ts' | 'tsx' | 'css' | 'json' | 'text' | 'base64' | 'file' | 'dataurl' | 'binary' | 'default';
+export type Format = 'iife' | 'cjs' | 'esm' | 'umd' | 'system';
+export type Loader = 'js' | 'jsx' | 'ts' | 'tsx' | 'css' | 'html' | 'json' | 'text' | 'base64' | 'file' | 'dataurl' | 'binary' | 'default';
 export type LogLevel = 'verbose' | 'debug' | 'info' | 'warning' | 'error' | 'silent';
 export type Charset = 'ascii' | 'utf8';
 export type TreeShaking = true | 'ignore-annotations';
@@ -33,18 +33,28 @@ interface CommonOptions {
   color?: boolean;
   logLevel?: LogLevel;
   logLimit?: number;
+  logOverride?: Record<string, LogLevel>;
+  logFormat?: 'text' | 'json';
 }
 
 export interface BuildOptions extends CommonOptions {
   bundle?: boolean;
   splitting?: boolean;
   preserveSymlinks?: boolean;
+  minifyInline?: boolean;
+  inferPureFunctions?: boolean;
   outfile?: string;
   metafile?: boolean;
+  manifest?: boolean;
+  integrity?: 'sha256' | 'sha384' | 'sha512';
+  integrityMap?: boolean;
   outdir?: string;
   outbase?: string;
   platform?: Platform;
   external?: string[];
+  packages?: 'external';
+  externalGlobals?: { [path: string]: string };
+  alias?: Record<string, string>;
   loader?: { [ext: string]: Loader };
   resolveExtensions?: string[];
   mainFields?: string[];
@@ -56,7 +66,14 @@ export interface BuildOptions extends CommonOptions {
   publicPath?: string;
   entryNames?: string;
   chunkNames?: string;
+  manualChunks?: { [name: string]: string[] }; // A callback to assign chunks ("ManualChunksFunc") is only in the Go API
+  minChunkSize?: number;
+  maxChunkSize?: number;
+  chunkMergeBudget?: number;
+  sizeBudgets?: { [glob: string]: { raw?: number, gzip?: number, brotli?: number } }; // In bytes
+  sizeBudgetWarnings?: boolean;
   assetNames?: string;
+  assetInlineLimit?: number;
   inject?: string[];
   banner?: { [type: string]: string };
   footer?: { [type: string]: string };
@@ -81,6 +98,7 @@ export interface StdinOptions {
 }
 
 export interface Message {
+  id: string;
   pluginName: string;
   text: string;
   location: Location | null;
@@ -276,6 +294,7 @@ export interface OnLoadResult {
 }
 
 export interface PartialMessage {
+  id?: string;
   pluginName?: string;
   text?: string;
   location?: Partial<Location> | null;
@@ -288,13 +307,26 @@ export interface PartialNote {
   location?: Partial<Location> | null;
 }
 
+// Why a code splitting chunk has the size it has when "minChunkSize" or
+// "maxChunkSize" is used. Sizes are estimated from the input files since
+// chunks are computed before any code is generated.
+export type ChunkSizeDecision =
+  | { kind: 'merged', inputs: string[], estimatedBytes: number, addedBytes: number }
+  | { kind: 'notMerged', estimatedBytes: number }
+  | { kind: 'split', piece: number, pieceCount: number, estimatedBytes: number }
+  | { kind: 'notSplit', estimatedBytes: number }
+
 export interface Metafile {
   inputs: {
     [path: string]: {
       bytes: number
+      inlined?: boolean
       imports: {
         path: string
         kind: ImportKind
+        external?: boolean
+        uses?: string[]
+        original?: string
       }[]
     }
   }
@@ -304,6 +336,7 @@ export interface Metafile {
       inputs: {
         [path: string]: {
           bytesInOutput: number
+          includedBy?: string[]
         }
       }
       imports: {
@@ -312,6 +345,9 @@ export interface Metafile {
       }[]
       exports: string[]
       entryPoint?: string
+      integrity?: string
+      integrityMap?: { [path: string]: string }
+      chunkSizeDecisions?: ChunkSizeDecision[]
     }
   }
 }
@@ -142,6 +142,7 @@ const (
 	LoaderFile
 	LoaderBinary
 	LoaderCSS
+	LoaderHTML
 	LoaderDefault
 )
 
@@ -153,6 +154,13 @@ const (
 	PlatformNeutral
 )
 
+type Packages uint8
+
+const (
+	PackagesDefault Packages = iota
+	PackagesExternal
+)
+
 type Format uint8
 
 const (
@@ -160,6 +168,8 @@ const (
 	FormatIIFE
 	FormatCommonJS
 	FormatESModule
+	FormatUMD
+	FormatSystem
 )
 
 type EngineName uint8
@@ -189,6 +199,7 @@ type Location struct {
 }
 
 type Message struct {
+	ID         string
 	PluginName string
 	Text       string
 	Location   *Location
@@ -223,6 +234,22 @@ const (
 	LogLevelError
 )
 
+type LogFormat uint8
+
+const (
+	LogFormatText LogFormat = iota
+	LogFormatJSON
+)
+
+// Sizes are in bytes. Zero means there is no limit. Brotli sizes are measured
+// with esbuild's own brotli compressor, which doesn't compress as well as the
+// reference one at its highest quality level.
+type SizeBudget struct {
+	Raw    int
+	Gzip   int
+	Brotli int
+}
+
 type Charset uint8
 
 const (
@@ -231,6 +258,15 @@ const (
 	CharsetUTF8
 )
 
+type Integrity uint8
+
+const (
+	IntegrityNone Integrity = iota
+	IntegritySHA256
+	IntegritySHA384
+	IntegritySHA512
+)
+
 type TreeShaking uint8
 
 const (
@@ -242,9 +278,11 @@ const (
 // Build API
 
 type BuildOptions struct {
-	Color    StderrColor
-	LogLimit int
-	LogLevel LogLevel
+	Color       StderrColor
+	LogLimit    int
+	LogLevel    LogLevel
+	LogOverride map[string]LogLevel
+	LogFormat   LogFormat
 
 	Sourcemap      SourceMap
 	SourceRoot     string
@@ -256,10 +294,15 @@ type BuildOptions struct {
 	MinifyWhitespace  bool
 	MinifyIdentifiers bool
 	MinifySyntax      bool
+	MinifyInline      bool // Inline single-use constants and small functions across modules
 	Charset           Charset
 	TreeShaking       TreeShaking
 	LegalComments     LegalComments
 
+	// Infer which functions and classes have no side effects so that unused
+	// calls to them can be removed without "/* @__PURE__ */" annotations
+	InferPureFunctions bool
+
 	JSXMode     JSXMode
 	JSXFactory  string
 	JSXFragment string
@@ -269,17 +312,23 @@ type BuildOptions struct {
 	KeepNames bool
 
 	GlobalName        string
+	ExternalGlobals   map[string]string // Maps external import paths to globals for the IIFE and UMD formats
+	Alias             map[string]string // Maps package names to substitute import paths
 	Bundle            bool
 	PreserveSymlinks  bool
 	Splitting         bool
 	Outfile           string
 	Metafile          bool
+	Integrity         Integrity // Add Subresource Integrity digests to the metafile
+	IntegrityMap      bool      // Also add the digests of chunks loaded with "import()"
+	Manifest          bool      // Write "manifest.json" to the output directory
 	Outdir            string
 	Outbase           string
 	AbsWorkingDir     string
 	Platform          Platform
 	Format            Format
 	External          []string
+	Packages          Packages // Use "PackagesExternal" to make all package imports external
 	MainFields        []string
 	Conditions        []string // For the "exports" field in "package.json"
 	Loader            map[string]Loader
@@ -292,9 +341,25 @@ type BuildOptions struct {
 	Footer            map[string]string
 	NodePaths         []string // The "NODE_PATH" variable from Node.js
 
-	EntryNames string
-	ChunkNames string
-	AssetNames string
+	EntryNames   string
+	ChunkNames   string
+	AssetNames   string
+	ManualChunks map[string][]string // Maps chunk names to path globs or package names
+
+	// This is called with the path of each module that isn't matched by
+	// "ManualChunks" and returns the name of the chunk to put it in, or an
+	// empty string to let esbuild decide. It's only available from Go because
+	// the JavaScript API can't call back into JavaScript for every module.
+	ManualChunksFunc func(path string) string
+
+	AssetInlineLimit int // Inline "file" loader files smaller than this as data URLs
+
+	MinChunkSize     int // Merge smaller code splitting chunks into other chunks
+	MaxChunkSize     int // Split larger code splitting chunks along module boundaries
+	ChunkMergeBudget int // The most unused code that merging may add to an entry point
+
+	SizeBudgets        map[string]SizeBudget // Maps output path globs to size limits
+	SizeBudgetWarnings bool                  // Exceeding a size budget is a warning instead of an error
 
 	EntryPoints         []string
 	EntryPointsAdvanced []EntryPoint
@@ -344,13 +409,23 @@ func Build(options BuildOptions) BuildResult {
 	return buildImpl(options).result
 }
 
+// This runs several builds at the same time. The builds share a cache, so a
+// file that is used by more than one build only needs to be read once, and is
+// only parsed once if the builds use compatible options. The results are
+// returned in the same order as the options.
+func BuildParallel(options []BuildOptions) []BuildResult {
+	return buildParallelImpl(options)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Transform API
 
 type TransformOptions struct {
-	Color    StderrColor
-	LogLimit int
-	LogLevel LogLevel
+	Color       StderrColor
+	LogLimit    int
+	LogLevel    LogLevel
+	LogOverride map[string]LogLevel
+	LogFormat   LogFormat
 
 	Sourcemap      SourceMap
 	SourceRoot     string
@@ -442,12 +517,36 @@ type Plugin struct {
 
 type PluginBuild struct {
 	InitialOptions *BuildOptions
+	Resolve        func(path string, options ResolveOptions) ResolveResult
 	OnStart        func(callback func() (OnStartResult, error))
 	OnEnd          func(callback func(result *BuildResult))
 	OnResolve      func(options OnResolveOptions, callback func(OnResolveArgs) (OnResolveResult, error))
 	OnLoad         func(options OnLoadOptions, callback func(OnLoadArgs) (OnLoadResult, error))
 }
 
+// "Resolve" runs the "OnResolve" callbacks of the other plugins followed by
+// esbuild's own path resolution. It can only be called once the build has
+// started (e.g. from inside an "OnResolve" or "OnLoad" callback). The
+// namespace of the importer defaults to "file".
+type ResolveOptions struct {
+	Importer   string
+	ResolveDir string
+	Kind       ResolveKind
+	Namespace  string
+	PluginData interface{}
+}
+
+type ResolveResult struct {
+	Errors   []Message
+	Warnings []Message
+
+	Path        string
+	External    bool
+	SideEffects SideEffects
+	Namespace   string
+	PluginData  interface{}
+}
+
 type OnStartResult struct {
 	Errors   []Message
 	Warnings []Message
@@ -540,3 +639,18 @@ type FormatMessagesOptions struct {
 func FormatMessages(msgs []Message, opts FormatMessagesOptions) []string {
 	return formatMsgsImpl(msgs, opts)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// AnalyzeMetafile API
+
+type AnalyzeMetafileOptions struct {
+	Color   bool
+	Verbose bool // Also show the chain of imports that included each file
+}
+
+// This returns a human-readable report of the input files and packages that
+// contribute the most bytes to each output file in the metafile. The result
+// is empty if the metafile could not be parsed.
+func AnalyzeMetafile(metafile string, opts AnalyzeMetafileOptions) string {
+	return analyzeMetafileImpl(metafile, opts)
+}
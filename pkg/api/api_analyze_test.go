@@ -0,0 +1,120 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/test"
+)
+
+const analyzeTestMetafile = `{
+	"inputs": {
+		"src/entry.js": { "bytes": 100, "imports": [{ "path": "src/b.js" }, { "path": "src/a.js" }, { "path": "node_modules/pkg/index.js" }] },
+		"src/a.js": { "bytes": 50, "imports": [] },
+		"src/b.js": { "bytes": 50, "imports": [{ "path": "node_modules/@scope/lib/main.js" }] },
+		"node_modules/pkg/index.js": { "bytes": 10, "imports": [{ "path": "node_modules/pkg/util.js" }] },
+		"node_modules/pkg/util.js": { "bytes": 10, "imports": [] },
+		"node_modules/@scope/lib/main.js": { "bytes": 10, "imports": [] }
+	},
+	"outputs": {
+		"out/entry.js.map": { "bytes": 900, "inputs": {}, "imports": [], "exports": [] },
+		"out/other.js": {
+			"bytes": 1000,
+			"entryPoint": "src/a.js",
+			"inputs": { "src/a.js": { "bytesInOutput": 1000 } }
+		},
+		"out/entry.js": {
+			"bytes": 1000,
+			"entryPoint": "src/entry.js",
+			"inputs": {
+				"src/b.js": { "bytesInOutput": 200 },
+				"src/a.js": { "bytesInOutput": 200 },
+				"src/entry.js": { "bytesInOutput": 50 },
+				"node_modules/pkg/util.js": { "bytesInOutput": 300 },
+				"node_modules/pkg/index.js": { "bytesInOutput": 100 },
+				"node_modules/@scope/lib/main.js": { "bytesInOutput": 150, "includedBy": ["src/a.js"] }
+			}
+		}
+	}
+}`
+
+func expectAnalyze(t *testing.T, metafile string, opts AnalyzeMetafileOptions, expected string) {
+	t.Helper()
+	test.AssertEqualWithDiff(t, AnalyzeMetafile(metafile, opts), expected)
+}
+
+func TestAnalyzeMetafile(t *testing.T) {
+	// Outputs and inputs are sorted largest-first with ties broken by path,
+	// files in the same package are grouped, and source maps are skipped
+	expectAnalyze(t, analyzeTestMetafile, AnalyzeMetafileOptions{}, `
+  out/entry.js                           1000b  100.0%
+   ├ node_modules/pkg                     400b   40.0%
+   │  ├ node_modules/pkg/util.js          300b   30.0%
+   │  └ node_modules/pkg/index.js         100b   10.0%
+   ├ src/a.js                             200b   20.0%
+   ├ src/b.js                             200b   20.0%
+   ├ node_modules/@scope/lib              150b   15.0%
+   │  └ node_modules/@scope/lib/main.js   150b   15.0%
+   └ src/entry.js                          50b    5.0%
+
+  out/other.js  1000b  100.0%
+   └ src/a.js   1000b  100.0%
+`)
+}
+
+func TestAnalyzeMetafileVerbose(t *testing.T) {
+	// Import chains use "includedBy" when present and the importers otherwise
+	expectAnalyze(t, analyzeTestMetafile, AnalyzeMetafileOptions{Verbose: true}, `
+  out/entry.js                           1000b  100.0%
+   ├ node_modules/pkg                     400b   40.0%
+   │  ├ node_modules/pkg/util.js          300b   30.0%
+   │  │  └ node_modules/pkg/index.js
+   │  │     └ src/entry.js
+   │  └ node_modules/pkg/index.js         100b   10.0%
+   │     └ src/entry.js
+   ├ src/a.js                             200b   20.0%
+   │  └ src/entry.js
+   ├ src/b.js                             200b   20.0%
+   │  └ src/entry.js
+   ├ node_modules/@scope/lib              150b   15.0%
+   │  └ node_modules/@scope/lib/main.js   150b   15.0%
+   │     └ src/a.js
+   │        └ src/entry.js
+   └ src/entry.js                          50b    5.0%
+
+  out/other.js  1000b  100.0%
+   └ src/a.js   1000b  100.0%
+`)
+}
+
+func TestAnalyzeMetafileSizes(t *testing.T) {
+	expectAnalyze(t, `{
+		"outputs": {
+			"out.js": {
+				"bytes": 3000000,
+				"inputs": {
+					"big.js": { "bytesInOutput": 2999000 },
+					"node_modules/a/node_modules/b/index.js": { "bytesInOutput": 999 },
+					"tiny.js": { "bytesInOutput": 1 }
+				}
+			}
+		}
+	}`, AnalyzeMetafileOptions{}, `
+  out.js                                        2.9mb  100.0%
+   ├ big.js                                     2.9mb  100.0%
+   ├ node_modules/a/node_modules/b               999b    0.0%
+   │  └ node_modules/a/node_modules/b/index.js   999b    0.0%
+   └ tiny.js                                       1b    0.0%
+`)
+}
+
+func TestAnalyzeMetafileInvalid(t *testing.T) {
+	for _, metafile := range []string{
+		``,
+		`{`,
+		`[]`,
+		`{ "inputs": {} }`,
+		`{ "outputs": { "out.js.map": { "bytes": 100, "inputs": {} } } }`,
+	} {
+		expectAnalyze(t, metafile, AnalyzeMetafileOptions{}, "")
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -12,9 +14,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/evanw/esbuild/internal/api_helpers"
 	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/brotli"
 	"github.com/evanw/esbuild/internal/bundler"
 	"github.com/evanw/esbuild/internal/cache"
 	"github.com/evanw/esbuild/internal/compat"
@@ -104,6 +108,17 @@ func validatePlatform(value Platform) config.Platform {
 	}
 }
 
+func validatePackages(value Packages) bool {
+	switch value {
+	case PackagesDefault:
+		return false
+	case PackagesExternal:
+		return true
+	default:
+		panic("Invalid packages")
+	}
+}
+
 func validateFormat(value Format) config.Format {
 	switch value {
 	case FormatDefault:
@@ -114,6 +129,10 @@ func validateFormat(value Format) config.Format {
 		return config.FormatCommonJS
 	case FormatESModule:
 		return config.FormatESModule
+	case FormatUMD:
+		return config.FormatUMD
+	case FormatSystem:
+		return config.FormatSystem
 	default:
 		panic("Invalid format")
 	}
@@ -159,6 +178,21 @@ func validateLegalComments(value LegalComments, bundle bool) config.LegalComment
 	}
 }
 
+func validateIntegrity(value Integrity) config.Integrity {
+	switch value {
+	case IntegrityNone:
+		return config.IntegrityNone
+	case IntegritySHA256:
+		return config.IntegritySHA256
+	case IntegritySHA384:
+		return config.IntegritySHA384
+	case IntegritySHA512:
+		return config.IntegritySHA512
+	default:
+		panic("Invalid integrity")
+	}
+}
+
 func validateColor(value StderrColor) logger.UseColor {
 	switch value {
 	case ColorIfTerminal:
@@ -191,6 +225,41 @@ func validateLogLevel(value LogLevel) logger.LogLevel {
 	}
 }
 
+func validateLogFormat(value LogFormat) logger.LogFormat {
+	switch value {
+	case LogFormatText:
+		return logger.LogFormatText
+	case LogFormatJSON:
+		return logger.LogFormatJSON
+	default:
+		panic("Invalid log format")
+	}
+}
+
+func validateLogOverrides(value map[string]LogLevel) (map[logger.MsgID]logger.LogLevel, []string) {
+	var overrides map[logger.MsgID]logger.LogLevel
+	var unknown []string
+	for key, level := range value {
+		id, ok := logger.StringToMsgID(key)
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if overrides == nil {
+			overrides = make(map[logger.MsgID]logger.LogLevel)
+		}
+		overrides[id] = validateLogLevel(level)
+	}
+	sort.Strings(unknown)
+	return overrides, unknown
+}
+
+func reportUnknownLogOverrides(log logger.Log, unknown []string) {
+	for _, key := range unknown {
+		log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid log override: %q is not a known message ID", key))
+	}
+}
+
 func validateASCIIOnly(value Charset) bool {
 	switch value {
 	case CharsetDefault, CharsetASCII:
@@ -239,6 +308,8 @@ func validateLoader(value Loader) config.Loader {
 		return config.LoaderBinary
 	case LoaderCSS:
 		return config.LoaderCSS
+	case LoaderHTML:
+		return config.LoaderHTML
 	case LoaderDefault:
 		return config.LoaderDefault
 	default:
@@ -365,6 +436,287 @@ func validateGlobalName(log logger.Log, text string) []string {
 	return nil
 }
 
+func validateExternalGlobals(log logger.Log, globals map[string]string) map[string][]string {
+	if len(globals) == 0 {
+		return nil
+	}
+
+	result := make(map[string][]string)
+	for path, text := range globals {
+		if text == "" {
+			log.AddError(nil, logger.Loc{}, fmt.Sprintf("Missing global name for external %q", path))
+			continue
+		}
+		if name := validateGlobalName(log, text); name != nil {
+			result[path] = name
+		}
+	}
+	return result
+}
+
+func validateAlias(log logger.Log, alias map[string]string) map[string]string {
+	if len(alias) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for old, new := range alias {
+		// Only package paths can be aliased, since relative and absolute paths
+		// already refer to a specific file
+		if old == "" || strings.HasSuffix(old, "/") || !resolver.IsPackagePath(old) {
+			log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid alias name: %q", old))
+			continue
+		}
+		if new == "" {
+			log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid alias substitution: %q", new))
+			continue
+		}
+		result[old] = new
+	}
+	return result
+}
+
+func validateManualChunks(log logger.Log, manualChunks map[string][]string) []config.ManualChunk {
+	if len(manualChunks) == 0 {
+		return nil
+	}
+
+	// Sort the chunks for determinism since the first matching chunk wins
+	names := make([]string, 0, len(manualChunks))
+	for name := range manualChunks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]config.ManualChunk, 0, len(names))
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "/\\") {
+			log.AddID(logger.MsgID_Bundler_InvalidManualChunkName, logger.Error, nil, logger.Range{}, fmt.Sprintf("Invalid manual chunk name: %q", name))
+			continue
+		}
+		chunk := config.ManualChunk{Name: name}
+		for _, pattern := range manualChunks[name] {
+			if pattern == "" {
+				log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid empty pattern for manual chunk %q", name))
+				continue
+			}
+			chunk.Filters = append(chunk.Filters, regexp.MustCompile(manualChunkPatternToRegExp(pattern)))
+		}
+		result = append(result, chunk)
+	}
+	return result
+}
+
+// Package names such as "react" or "@scope/pkg" match all files inside that
+// package. Anything else is a glob that matches a file or directory, where
+// "*" doesn't match "/" but "**" does. Globs are matched at any directory
+// boundary unless they start with "./".
+func manualChunkPatternToRegExp(pattern string) string {
+	isGlob := strings.ContainsAny(pattern, "*?")
+	slashes := strings.Count(pattern, "/")
+	if !isGlob && !strings.HasPrefix(pattern, ".") && !strings.HasPrefix(pattern, "/") &&
+		(slashes == 0 || (slashes == 1 && strings.HasPrefix(pattern, "@"))) {
+		return "(^|/)node_modules/" + regexp.QuoteMeta(pattern) + "(/|$)"
+	}
+	return globPatternToRegExp(pattern)
+}
+
+// In globs, "*" doesn't match "/" but "**" does. Globs are matched at any
+// directory boundary unless they start with "./".
+func globPatternToRegExp(pattern string) string {
+	sb := strings.Builder{}
+	if strings.HasPrefix(pattern, "./") {
+		pattern = pattern[2:]
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("(^|/)")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("(/|$)")
+	return sb.String()
+}
+
+type sizeBudget struct {
+	pattern string
+	filter  *regexp.Regexp
+	raw     int
+	gzip    int
+	brotli  int
+}
+
+func validateSizeBudgets(log logger.Log, budgets map[string]SizeBudget) []sizeBudget {
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	// Sort the budgets for determinism since they are checked in order
+	patterns := make([]string, 0, len(budgets))
+	for pattern := range budgets {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	result := make([]sizeBudget, 0, len(patterns))
+	for _, pattern := range patterns {
+		budget := budgets[pattern]
+		if pattern == "" {
+			log.AddError(nil, logger.Loc{}, "Invalid empty pattern for size budget")
+			continue
+		}
+		if budget.Raw < 0 || budget.Gzip < 0 || budget.Brotli < 0 {
+			log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid negative size budget for %q", pattern))
+			continue
+		}
+		result = append(result, sizeBudget{
+			pattern: pattern,
+			filter:  regexp.MustCompile(globPatternToRegExp(pattern)),
+			raw:     budget.Raw,
+			gzip:    budget.Gzip,
+			brotli:  budget.Brotli,
+		})
+	}
+	return result
+}
+
+// The number of inputs to mention when an output file is over budget
+const sizeBudgetInputCount = 5
+
+func checkSizeBudgets(
+	log logger.Log,
+	realFS fs.FS,
+	absOutputDir string,
+	budgets []sizeBudget,
+	warnOnly bool,
+	results []graph.OutputFile,
+	metafile string,
+) {
+	// Find the budgets for each output file. Budget patterns are matched
+	// against paths relative to the output directory.
+	matches := make([][]sizeBudget, len(results))
+	for i, result := range results {
+		relPath, ok := realFS.Rel(absOutputDir, result.AbsPath)
+		if !ok {
+			continue
+		}
+		relPath = strings.ReplaceAll(relPath, "\\", "/")
+		for _, budget := range budgets {
+			if budget.filter.MatchString(relPath) {
+				matches[i] = append(matches[i], budget)
+			}
+		}
+	}
+
+	// Compressing is slow, so only compress the files that need it
+	gzipSizes := make([]int, len(results))
+	brotliSizes := make([]int, len(results))
+	waitGroup := sync.WaitGroup{}
+	for i, result := range results {
+		needsGzip := false
+		needsBrotli := false
+		for _, budget := range matches[i] {
+			needsGzip = needsGzip || budget.gzip > 0
+			needsBrotli = needsBrotli || budget.brotli > 0
+		}
+		if needsGzip {
+			waitGroup.Add(1)
+			go func(i int, contents []byte) {
+				buffer := bytes.Buffer{}
+				writer, _ := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+				writer.Write(contents)
+				writer.Close()
+				gzipSizes[i] = buffer.Len()
+				waitGroup.Done()
+			}(i, result.Contents)
+		}
+		if needsBrotli {
+			waitGroup.Add(1)
+			go func(i int, contents []byte) {
+				brotliSizes[i] = len(brotli.Compress(contents))
+				waitGroup.Done()
+			}(i, result.Contents)
+		}
+	}
+	waitGroup.Wait()
+
+	outputs, _, _ := parseMetafile(metafile)
+	for i, result := range results {
+		if len(matches[i]) == 0 {
+			continue
+		}
+
+		// Output paths in the metafile are relative to the working directory
+		prettyPath := result.AbsPath
+		if relPath, ok := realFS.Rel(realFS.Cwd(), result.AbsPath); ok {
+			prettyPath = strings.ReplaceAll(relPath, "\\", "/")
+		}
+
+		for _, budget := range matches[i] {
+			var texts []string
+			if rawSize := len(result.Contents); budget.raw > 0 && rawSize > budget.raw {
+				texts = append(texts, fmt.Sprintf("Output file %q is %s, which is over the size budget of %s for %q",
+					prettyPath, bytesToText(rawSize), bytesToText(budget.raw), budget.pattern))
+			}
+			if budget.gzip > 0 && gzipSizes[i] > budget.gzip {
+				texts = append(texts, fmt.Sprintf("Output file %q is %s after gzip compression, which is over the gzip size budget of %s for %q",
+					prettyPath, bytesToText(gzipSizes[i]), bytesToText(budget.gzip), budget.pattern))
+			}
+			if budget.brotli > 0 && brotliSizes[i] > budget.brotli {
+				texts = append(texts, fmt.Sprintf("Output file %q is %s after brotli compression, which is over the brotli size budget of %s for %q",
+					prettyPath, bytesToText(brotliSizes[i]), bytesToText(budget.brotli), budget.pattern))
+			}
+			for _, text := range texts {
+				notes := sizeBudgetNotes(outputs, prettyPath)
+				if warnOnly {
+					log.AddRangeWarningWithNotes(nil, logger.Range{}, text, notes)
+				} else {
+					log.AddErrorWithNotes(nil, logger.Loc{}, text, notes)
+				}
+			}
+		}
+	}
+}
+
+// Returns notes about the inputs that contribute the most bytes to an output
+func sizeBudgetNotes(outputs []metafileOutput, prettyPath string) []logger.MsgData {
+	for _, output := range outputs {
+		if output.path != prettyPath {
+			continue
+		}
+		inputs := append([]metafileInput{}, output.inputs...)
+		sort.SliceStable(inputs, func(i int, j int) bool {
+			return inputs[i].bytes > inputs[j].bytes
+		})
+		var notes []logger.MsgData
+		for _, input := range inputs {
+			if len(notes) == sizeBudgetInputCount || input.bytes == 0 {
+				break
+			}
+			percent := 0.0
+			if output.bytes > 0 {
+				percent = float64(input.bytes) * 100 / float64(output.bytes)
+			}
+			notes = append(notes, logger.MsgData{Text: fmt.Sprintf(
+				"The input file %q contributes %s (%.1f%%)", input.path, bytesToText(input.bytes), percent)})
+		}
+		return notes
+	}
+	return nil
+}
+
 func validateExternals(log logger.Log, fs fs.FS, paths []string) config.ExternalModules {
 	result := config.ExternalModules{
 		NodeModules: make(map[string]bool),
@@ -555,9 +907,29 @@ func validateDefines(
 	// Processing defines is expensive. Process them once here so the same object
 	// can be shared between all parsers we create using these arguments.
 	processed := config.ProcessDefines(rawDefines)
+	processed.InputKey = definesInputKey(defines, pureFns, platform, minify)
 	return &processed, injectedDefines
 }
 
+// Parallel builds share a cache, and parsed files can only be reused by
+// builds whose defines were created from the same options
+func definesInputKey(defines map[string]string, pureFns []string, platform Platform, minify bool) string {
+	keys := make([]string, 0, len(defines))
+	for key := range defines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d %v %d\n", platform, minify, len(keys)))
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("%q=%q\n", key, defines[key]))
+	}
+	for _, pure := range pureFns {
+		sb.WriteString(fmt.Sprintf("%q\n", pure))
+	}
+	return sb.String()
+}
+
 func validatePath(log logger.Log, fs fs.FS, relPath string, pathKind string) string {
 	if relPath == "" {
 		return ""
@@ -627,6 +999,7 @@ func convertMessagesToPublic(kind logger.MsgKind, msgs []logger.Msg) []Message {
 				})
 			}
 			filtered = append(filtered, Message{
+				ID:         logger.MsgIDToString(msg.ID),
 				PluginName: msg.PluginName,
 				Text:       msg.Data.Text,
 				Location:   convertLocationToPublic(msg.Data.Location),
@@ -666,7 +1039,9 @@ func convertMessagesToInternal(msgs []logger.Msg, kind logger.MsgKind, messages
 				Location: convertLocationToInternal(note.Location),
 			})
 		}
+		id, _ := logger.StringToMsgID(message.ID)
 		msgs = append(msgs, logger.Msg{
+			ID:         id,
 			PluginName: message.PluginName,
 			Kind:       kind,
 			Data: logger.MsgData{
@@ -690,14 +1065,37 @@ type internalBuildResult struct {
 }
 
 func buildImpl(buildOpts BuildOptions) internalBuildResult {
+	return buildWithCachesImpl(buildOpts, cache.MakeCacheSet())
+}
+
+func buildParallelImpl(options []BuildOptions) []BuildResult {
+	caches := cache.MakeCacheSet()
+	results := make([]BuildResult, len(options))
+	waitGroup := sync.WaitGroup{}
+	waitGroup.Add(len(options))
+	for i, buildOpts := range options {
+		go func(i int, buildOpts BuildOptions) {
+			results[i] = buildWithCachesImpl(buildOpts, caches).result
+			waitGroup.Done()
+		}(i, buildOpts)
+	}
+	waitGroup.Wait()
+	return results
+}
+
+func buildWithCachesImpl(buildOpts BuildOptions, caches *cache.CacheSet) internalBuildResult {
 	start := time.Now()
+	logOverrides, unknownLogOverrides := validateLogOverrides(buildOpts.LogOverride)
 	logOptions := logger.OutputOptions{
 		IncludeSource: true,
 		MessageLimit:  buildOpts.LogLimit,
 		Color:         validateColor(buildOpts.Color),
 		LogLevel:      validateLogLevel(buildOpts.LogLevel),
+		Format:        validateLogFormat(buildOpts.LogFormat),
+		Overrides:     logOverrides,
 	}
 	log := logger.NewStderrLog(logOptions)
+	reportUnknownLogOverrides(log, unknownLogOverrides)
 
 	// Validate that the current working directory is an absolute path
 	realFS, err := fs.RealFS(fs.RealFSOptions{
@@ -712,12 +1110,12 @@ func buildImpl(buildOpts BuildOptions) internalBuildResult {
 	// directory doesn't change, since breaking that invariant would break the
 	// validation that we just did above.
 	oldAbsWorkingDir := buildOpts.AbsWorkingDir
-	plugins, onEndCallbacks := loadPlugins(&buildOpts, realFS, log)
+	plugins, onEndCallbacks, resolveState := loadPlugins(&buildOpts, realFS, log)
 	if buildOpts.AbsWorkingDir != oldAbsWorkingDir {
 		panic("Mutating \"AbsWorkingDir\" is not allowed")
 	}
 
-	internalResult := rebuildImpl(buildOpts, cache.MakeCacheSet(), plugins, onEndCallbacks, logOptions, log, false /* isRebuild */)
+	internalResult := rebuildImpl(buildOpts, caches, plugins, onEndCallbacks, resolveState, logOptions, log, false /* isRebuild */)
 
 	// Print a summary of the generated files to stderr. Except don't do
 	// this if the terminal is already being used for something else.
@@ -729,6 +1127,19 @@ func buildImpl(buildOpts BuildOptions) internalBuildResult {
 	return internalResult
 }
 
+func bytesToText(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%db", n)
+	}
+	if n < 1024*1024 {
+		return fmt.Sprintf("%.1fkb", float64(n)/(1024))
+	}
+	if n < 1024*1024*1024 {
+		return fmt.Sprintf("%.1fmb", float64(n)/(1024*1024))
+	}
+	return fmt.Sprintf("%.1fgb", float64(n)/(1024*1024*1024))
+}
+
 func printSummary(logOptions logger.OutputOptions, outputFiles []OutputFile, start time.Time) {
 	var table logger.SummaryTable = make([]logger.SummaryTableEntry, len(outputFiles))
 
@@ -742,15 +1153,9 @@ func printSummary(logOptions logger.OutputOptions, outputFiles []OutputFile, sta
 					}
 					base := realFS.Base(path)
 					n := len(file.Contents)
-					var size string
+					size := bytesToText(n)
 					if n < 1024 {
-						size = fmt.Sprintf("%db ", n)
-					} else if n < 1024*1024 {
-						size = fmt.Sprintf("%.1fkb", float64(n)/(1024))
-					} else if n < 1024*1024*1024 {
-						size = fmt.Sprintf("%.1fmb", float64(n)/(1024*1024))
-					} else {
-						size = fmt.Sprintf("%.1fgb", float64(n)/(1024*1024*1024))
+						size += " "
 					}
 					table[i] = logger.SummaryTableEntry{
 						Dir:         path[:len(path)-len(base)],
@@ -764,6 +1169,11 @@ func printSummary(logOptions logger.OutputOptions, outputFiles []OutputFile, sta
 		}
 	}
 
+	if logOptions.Format == logger.LogFormatJSON {
+		logger.PrintSummaryJSON(table, &start)
+		return
+	}
+
 	// Don't print the time taken by the build if we're running under Yarn 1
 	// since Yarn 1 always prints its own copy of the time taken by each command
 	for _, env := range os.Environ() {
@@ -781,6 +1191,7 @@ func rebuildImpl(
 	caches *cache.CacheSet,
 	plugins []config.Plugin,
 	onEndCallbacks []func(*BuildResult),
+	resolveState *pluginResolveState,
 	logOptions logger.OutputOptions,
 	log logger.Log,
 	isRebuild bool,
@@ -818,26 +1229,40 @@ func rebuildImpl(
 		SourceRoot:            buildOpts.SourceRoot,
 		ExcludeSourcesContent: buildOpts.SourcesContent == SourcesContentExclude,
 		MangleSyntax:          buildOpts.MinifySyntax,
+		MangleInline:          buildOpts.MinifyInline,
 		RemoveWhitespace:      buildOpts.MinifyWhitespace,
 		MinifyIdentifiers:     buildOpts.MinifyIdentifiers,
 		AllowOverwrite:        buildOpts.AllowOverwrite,
 		ASCIIOnly:             validateASCIIOnly(buildOpts.Charset),
 		IgnoreDCEAnnotations:  validateIgnoreDCEAnnotations(buildOpts.TreeShaking),
+		InferPureFunctions:    buildOpts.InferPureFunctions,
 		GlobalName:            validateGlobalName(log, buildOpts.GlobalName),
+		ExternalGlobals:       validateExternalGlobals(log, buildOpts.ExternalGlobals),
 		CodeSplitting:         buildOpts.Splitting,
 		OutputFormat:          validateFormat(buildOpts.Format),
 		AbsOutputFile:         validatePath(log, realFS, buildOpts.Outfile, "outfile path"),
 		AbsOutputDir:          validatePath(log, realFS, buildOpts.Outdir, "outdir path"),
 		AbsOutputBase:         validatePath(log, realFS, buildOpts.Outbase, "outbase path"),
 		NeedsMetafile:         buildOpts.Metafile,
+		NeedsManifest:         buildOpts.Manifest,
+		Integrity:             validateIntegrity(buildOpts.Integrity),
+		IntegrityMap:          buildOpts.IntegrityMap,
 		EntryPathTemplate:     validatePathTemplate(buildOpts.EntryNames),
 		ChunkPathTemplate:     validatePathTemplate(buildOpts.ChunkNames),
+		ManualChunks:          validateManualChunks(log, buildOpts.ManualChunks),
+		ManualChunksFunc:      buildOpts.ManualChunksFunc,
+		MinChunkSize:          buildOpts.MinChunkSize,
+		MaxChunkSize:          buildOpts.MaxChunkSize,
+		ChunkMergeBudget:      buildOpts.ChunkMergeBudget,
 		AssetPathTemplate:     validatePathTemplate(buildOpts.AssetNames),
+		AssetInlineLimit:      buildOpts.AssetInlineLimit,
 		OutputExtensionJS:     outJS,
 		OutputExtensionCSS:    outCSS,
 		ExtensionToLoader:     validateLoaders(log, buildOpts.Loader),
 		ExtensionOrder:        validateResolveExtensions(log, buildOpts.ResolveExtensions),
 		ExternalModules:       validateExternals(log, realFS, buildOpts.External),
+		PackageAliases:        validateAlias(log, buildOpts.Alias),
+		ExternalPackages:      validatePackages(buildOpts.Packages),
 		TsConfigOverride:      validatePath(log, realFS, buildOpts.Tsconfig, "tsconfig path"),
 		MainFields:            buildOpts.MainFields,
 		Conditions:            append([]string{}, buildOpts.Conditions...),
@@ -901,6 +1326,9 @@ func rebuildImpl(
 		if options.LegalComments.HasExternalFile() {
 			log.AddError(nil, logger.Loc{}, "Cannot use linked or external legal comments without an output path")
 		}
+		if options.NeedsManifest {
+			log.AddError(nil, logger.Loc{}, "Cannot generate a manifest without an output path")
+		}
 		for _, loader := range options.ExtensionToLoader {
 			if loader == config.LoaderFile {
 				log.AddError(nil, logger.Loc{}, "Cannot use the \"file\" loader without an output path")
@@ -938,16 +1366,53 @@ func rebuildImpl(
 	}
 
 	// Code splitting is experimental and currently only enabled for ES6 modules
-	if options.CodeSplitting && options.OutputFormat != config.FormatESModule {
-		log.AddError(nil, logger.Loc{}, "Splitting currently only works with the \"esm\" format")
+	if options.CodeSplitting && options.OutputFormat != config.FormatESModule && options.OutputFormat != config.FormatSystem {
+		log.AddError(nil, logger.Loc{}, "Splitting currently only works with the \"esm\" and \"system\" formats")
+	}
+
+	// Chunk sizes are byte counts, and chunks can't be both merged and split
+	if options.MinChunkSize < 0 {
+		log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid min chunk size: %d", options.MinChunkSize))
+	}
+	if options.MaxChunkSize < 0 {
+		log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid max chunk size: %d", options.MaxChunkSize))
+	}
+	if options.ChunkMergeBudget < 0 {
+		log.AddError(nil, logger.Loc{}, fmt.Sprintf("Invalid chunk merge budget: %d", options.ChunkMergeBudget))
+	}
+	if options.MaxChunkSize > 0 && options.MinChunkSize > options.MaxChunkSize {
+		log.AddError(nil, logger.Loc{}, fmt.Sprintf("The min chunk size (%d) must not be larger than the max chunk size (%d)",
+			options.MinChunkSize, options.MaxChunkSize))
+	}
+
+	// Integrity digests are only ever written to the metafile
+	if options.Integrity != config.IntegrityNone && !options.NeedsMetafile {
+		log.AddError(nil, logger.Loc{}, "Cannot use \"integrity\" without \"metafile\"")
+	} else if options.IntegrityMap && options.Integrity == config.IntegrityNone {
+		log.AddError(nil, logger.Loc{}, "Cannot use \"integrityMap\" without \"integrity\"")
+	}
+
+	// Size budget messages name the largest inputs in each output file, which
+	// comes from the metafile, so it's generated even if it wasn't requested
+	sizeBudgets := validateSizeBudgets(log, buildOpts.SizeBudgets)
+	if len(sizeBudgets) > 0 {
+		options.NeedsMetafile = true
 	}
 
 	var outputFiles []OutputFile
 	var metafileJSON string
 	var watchData fs.WatchData
 
-	// Stop now if there were errors
+	// Plugins can call "Resolve" once the build has started
 	resolver := resolver.NewResolver(realFS, log, caches, options)
+	resolveState.mutex.Lock()
+	resolveState.fs = realFS
+	resolveState.res = resolver
+	resolveState.caches = caches
+	resolveState.options = &options
+	resolveState.mutex.Unlock()
+
+	// Stop now if there were errors
 	if !log.HasErrors() {
 		var timer *helpers.Timer
 		if api_helpers.UseTimer {
@@ -963,9 +1428,16 @@ func rebuildImpl(
 			// Compile the bundle
 			results, metafile := bundle.Compile(log, options, timer)
 
+			// Check the final output files against the size budgets
+			if len(sizeBudgets) > 0 && !log.HasErrors() {
+				checkSizeBudgets(log, realFS, options.AbsOutputDir, sizeBudgets, buildOpts.SizeBudgetWarnings, results, metafile)
+			}
+
 			// Stop now if there were errors
 			if !log.HasErrors() {
-				metafileJSON = metafile
+				if buildOpts.Metafile {
+					metafileJSON = metafile
+				}
 
 				// Flush any deferred warnings now
 				log.AlmostDone()
@@ -1039,7 +1511,7 @@ func rebuildImpl(
 			data:     watchData,
 			resolver: resolver,
 			rebuild: func() fs.WatchData {
-				value := rebuildImpl(buildOpts, caches, plugins, onEndCallbacks, logOptions, logger.NewStderrLog(logOptions), true /* isRebuild */)
+				value := rebuildImpl(buildOpts, caches, plugins, onEndCallbacks, resolveState, logOptions, logger.NewStderrLog(logOptions), true /* isRebuild */)
 				if onRebuild != nil {
 					go onRebuild(value.result)
 				}
@@ -1056,7 +1528,7 @@ func rebuildImpl(
 	var rebuild func() BuildResult
 	if buildOpts.Incremental {
 		rebuild = func() BuildResult {
-			value := rebuildImpl(buildOpts, caches, plugins, onEndCallbacks, logOptions, logger.NewStderrLog(logOptions), true /* isRebuild */)
+			value := rebuildImpl(buildOpts, caches, plugins, onEndCallbacks, resolveState, logOptions, logger.NewStderrLog(logOptions), true /* isRebuild */)
 			if watch != nil {
 				watch.setWatchData(value.watchData)
 			}
@@ -1233,16 +1705,21 @@ func (w *watcher) tryToFindDirtyPath() string {
 // Transform API
 
 func transformImpl(input string, transformOpts TransformOptions) TransformResult {
+	logOverrides, unknownLogOverrides := validateLogOverrides(transformOpts.LogOverride)
 	log := logger.NewStderrLog(logger.OutputOptions{
 		IncludeSource: true,
 		MessageLimit:  transformOpts.LogLimit,
 		Color:         validateColor(transformOpts.Color),
 		LogLevel:      validateLogLevel(transformOpts.LogLevel),
+		Format:        validateLogFormat(transformOpts.LogFormat),
+		Overrides:     logOverrides,
 	})
+	reportUnknownLogOverrides(log, unknownLogOverrides)
 
 	// Settings from the user come first
 	preserveUnusedImportsTS := false
 	useDefineForClassFieldsTS := config.Unspecified
+	experimentalDecoratorsTS := config.Unspecified
 	jsx := config.JSXOptions{
 		Preserve: transformOpts.JSXMode == JSXModePreserve,
 		Factory:  validateJSXExpr(log, transformOpts.JSXFactory, "factory", js_parser.JSXFactory),
@@ -1268,6 +1745,9 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 			if result.UseDefineForClassFields != config.Unspecified {
 				useDefineForClassFieldsTS = result.UseDefineForClassFields
 			}
+			if result.ExperimentalDecorators != config.Unspecified {
+				experimentalDecoratorsTS = result.ExperimentalDecorators
+			}
 			if result.PreserveImportsNotUsedAsValues {
 				preserveUnusedImportsTS = true
 			}
@@ -1309,6 +1789,7 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		AbsOutputFile:           transformOpts.Sourcefile + "-out",
 		KeepNames:               transformOpts.KeepNames,
 		UseDefineForClassFields: useDefineForClassFieldsTS,
+		ExperimentalDecorators:  experimentalDecoratorsTS,
 		PreserveUnusedImportsTS: preserveUnusedImportsTS,
 		Stdin: &config.StdinInfo{
 			Loader:     validateLoader(transformOpts.Loader),
@@ -1546,10 +2027,13 @@ func (impl *pluginImpl) validatePathsArray(pathsIn []string, name string) (paths
 	return
 }
 
-func loadPlugins(initialOptions *BuildOptions, fs fs.FS, log logger.Log) (plugins []config.Plugin, onEndCallbacks []func(*BuildResult)) {
+func loadPlugins(initialOptions *BuildOptions, fs fs.FS, log logger.Log) (
+	plugins []config.Plugin, onEndCallbacks []func(*BuildResult), resolveState *pluginResolveState,
+) {
 	onEnd := func(callback func(*BuildResult)) {
 		onEndCallbacks = append(onEndCallbacks, callback)
 	}
+	resolveState = &pluginResolveState{}
 
 	// Clone the plugin array to guard against mutation during iteration
 	clone := append(make([]Plugin, 0, len(initialOptions.Plugins)), initialOptions.Plugins...)
@@ -1566,8 +2050,14 @@ func loadPlugins(initialOptions *BuildOptions, fs fs.FS, log logger.Log) (plugin
 			plugin: config.Plugin{Name: item.Name},
 		}
 
+		pluginIndex := len(plugins)
+		resolve := func(path string, options ResolveOptions) ResolveResult {
+			return resolveState.resolve(pluginIndex, path, options)
+		}
+
 		item.Setup(PluginBuild{
 			InitialOptions: initialOptions,
+			Resolve:        resolve,
 			OnStart:        impl.OnStart,
 			OnEnd:          onEnd,
 			OnResolve:      impl.OnResolve,
@@ -1579,6 +2069,95 @@ func loadPlugins(initialOptions *BuildOptions, fs fs.FS, log logger.Log) (plugin
 	return
 }
 
+// This holds the state of the current build that plugins need in order to
+// call "Resolve". It's filled in at the start of each build (and rebuild).
+// The build's resolver is reused so that its directory cache is shared.
+type pluginResolveState struct {
+	mutex   sync.Mutex
+	fs      fs.FS
+	res     resolver.Resolver
+	caches  *cache.CacheSet
+	options *config.Options
+}
+
+func (state *pluginResolveState) resolve(callerIndex int, path string, options ResolveOptions) ResolveResult {
+	state.mutex.Lock()
+	realFS := state.fs
+	res := state.res
+	caches := state.caches
+	buildOptions := state.options
+	state.mutex.Unlock()
+
+	if buildOptions == nil {
+		return ResolveResult{Errors: []Message{{Text: "Cannot call \"Resolve\" before the build has started"}}}
+	}
+	if path == "" {
+		return ResolveResult{Errors: []Message{{Text: "A path is required"}}}
+	}
+
+	// Leave out the plugin that called "Resolve" to avoid infinite recursion
+	plugins := make([]config.Plugin, 0, len(buildOptions.Plugins))
+	for i, plugin := range buildOptions.Plugins {
+		if i != callerIndex {
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	// Use a separate log so that messages can be returned to the caller
+	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+	absResolveDir := validatePath(log, realFS, options.ResolveDir, "resolve directory")
+	if log.HasErrors() {
+		return ResolveResult{Errors: convertMessagesToPublic(logger.Error, log.Done())}
+	}
+
+	kind := importKindFromResolveKind(options.Kind)
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = "file"
+	}
+	importer := logger.Path{Text: options.Importer, Namespace: namespace}
+	result, didLogError, debug := bundler.RunOnResolvePlugins(plugins, res, log, realFS, &caches.FSCache,
+		nil, logger.Range{}, importer, path, kind, absResolveDir, options.PluginData)
+	if result == nil && !didLogError {
+		debug.LogErrorMsg(log, nil, logger.Range{}, fmt.Sprintf("Could not resolve %q", path))
+	}
+
+	msgs := log.Done()
+	resolveResult := ResolveResult{
+		Errors:   convertMessagesToPublic(logger.Error, msgs),
+		Warnings: convertMessagesToPublic(logger.Warning, msgs),
+	}
+	if result != nil {
+		resolveResult.Path = result.PathPair.Primary.Text
+		resolveResult.External = result.IsExternal
+		resolveResult.Namespace = result.PathPair.Primary.Namespace
+		resolveResult.PluginData = result.PluginData
+		if result.PrimarySideEffectsData != nil {
+			resolveResult.SideEffects = SideEffectsFalse
+		}
+	}
+	return resolveResult
+}
+
+func importKindFromResolveKind(kind ResolveKind) ast.ImportKind {
+	switch kind {
+	case ResolveJSImportStatement:
+		return ast.ImportStmt
+	case ResolveJSRequireCall:
+		return ast.ImportRequire
+	case ResolveJSDynamicImport:
+		return ast.ImportDynamic
+	case ResolveJSRequireResolve:
+		return ast.ImportRequireResolve
+	case ResolveCSSImportRule:
+		return ast.ImportAt
+	case ResolveCSSURLToken:
+		return ast.ImportURL
+	default:
+		return ast.ImportEntryPoint
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // FormatMessages API
 
@@ -1602,3 +2181,326 @@ func formatMsgsImpl(msgs []Message, opts FormatMessagesOptions) []string {
 	}
 	return strings
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// AnalyzeMetafile API
+
+type metafileOutput struct {
+	path       string
+	entryPoint string
+	inputs     []metafileInput
+	bytes      int
+}
+
+type metafileInput struct {
+	path       string
+	includedBy []string
+	bytes      int
+}
+
+// A row in the report is either an input file or a package. Packages group
+// together all input files inside the same "node_modules" package.
+type analyzeEntry struct {
+	path    string
+	files   []metafileInput
+	bytes   int
+	isGroup bool
+}
+
+type analyzeRow struct {
+	prefix string
+	path   string
+	size   string
+	pct    string
+	isBold bool
+}
+
+func analyzeMetafileImpl(metafile string, opts AnalyzeMetafileOptions) string {
+	outputs, importers, ok := parseMetafile(metafile)
+	if !ok {
+		return ""
+	}
+
+	// Show the largest output files first
+	sort.SliceStable(outputs, func(i int, j int) bool {
+		a, b := outputs[i], outputs[j]
+		return a.bytes > b.bytes || (a.bytes == b.bytes && a.path < b.path)
+	})
+
+	var colors logger.Colors
+	if opts.Color {
+		colors = logger.TerminalColors
+	}
+
+	sb := strings.Builder{}
+	for _, output := range outputs {
+		rows := analyzeRowsForOutput(output, importers, opts.Verbose)
+
+		// Align the columns
+		maxPath := 0
+		maxSize := 0
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(row.prefix + row.path); n > maxPath {
+				maxPath = n
+			}
+			if n := len(row.size); n > maxSize {
+				maxSize = n
+			}
+		}
+
+		sb.WriteString("\n")
+		for _, row := range rows {
+			padding := strings.Repeat(" ", maxPath-utf8.RuneCountInString(row.prefix+row.path))
+			sb.WriteString(colors.Dim + row.prefix + colors.Reset)
+			if row.size == "" {
+				// Import chains are only shown in verbose mode and don't have sizes
+				sb.WriteString(colors.Dim + row.path + colors.Reset + "\n")
+				continue
+			}
+			if row.isBold {
+				sb.WriteString(colors.Bold + row.path + colors.Reset)
+			} else {
+				sb.WriteString(row.path)
+			}
+			sb.WriteString(fmt.Sprintf("%s  %s%*s%s  %s%6s%s\n", padding,
+				colors.Cyan, maxSize, row.size, colors.Reset,
+				colors.Dim, row.pct, colors.Reset))
+		}
+	}
+	return sb.String()
+}
+
+// This parses the parts of the metafile that are needed to explain the size
+// of each output file. It also returns the importers of each input file.
+func parseMetafile(metafile string) (outputs []metafileOutput, importers map[string][]string, ok bool) {
+	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug)
+	source := logger.Source{Contents: metafile}
+	root, ok := js_parser.ParseJSON(log, source, js_parser.JSONOptions{})
+	if !ok {
+		return
+	}
+	rootObject, ok := root.Data.(*js_ast.EObject)
+	if !ok {
+		return
+	}
+
+	importers = make(map[string][]string)
+	for _, prop := range rootObject.Properties {
+		switch metafileString(prop.Key) {
+		case "inputs":
+			forEachMetafileProperty(prop.ValueOrNil, func(path string, value js_ast.Expr) {
+				forEachMetafileProperty(value, func(key string, value js_ast.Expr) {
+					if key == "imports" {
+						if array, ok := value.Data.(*js_ast.EArray); ok {
+							for _, item := range array.Items {
+								forEachMetafileProperty(item, func(key string, value js_ast.Expr) {
+									if key == "path" {
+										imported := metafileString(value)
+										importers[imported] = append(importers[imported], path)
+									}
+								})
+							}
+						}
+					}
+				})
+			})
+
+		case "outputs":
+			forEachMetafileProperty(prop.ValueOrNil, func(path string, value js_ast.Expr) {
+				output := metafileOutput{path: path}
+				forEachMetafileProperty(value, func(key string, value js_ast.Expr) {
+					switch key {
+					case "bytes":
+						output.bytes = metafileInt(value)
+					case "entryPoint":
+						output.entryPoint = metafileString(value)
+					case "inputs":
+						forEachMetafileProperty(value, func(path string, value js_ast.Expr) {
+							input := metafileInput{path: path}
+							forEachMetafileProperty(value, func(key string, value js_ast.Expr) {
+								switch key {
+								case "bytesInOutput":
+									input.bytes = metafileInt(value)
+								case "includedBy":
+									if array, ok := value.Data.(*js_ast.EArray); ok {
+										for _, item := range array.Items {
+											input.includedBy = append(input.includedBy, metafileString(item))
+										}
+									}
+								}
+							})
+							output.inputs = append(output.inputs, input)
+						})
+					}
+				})
+
+				// Source maps don't have any inputs, so they are omitted
+				if len(output.inputs) > 0 {
+					outputs = append(outputs, output)
+				}
+			})
+		}
+	}
+
+	return
+}
+
+func analyzeRowsForOutput(output metafileOutput, importers map[string][]string, verbose bool) []analyzeRow {
+	// Group the input files by package
+	var entries []*analyzeEntry
+	packages := make(map[string]*analyzeEntry)
+	for _, input := range output.inputs {
+		if pkg := packagePathForAnalyze(input.path); pkg != "" {
+			entry := packages[pkg]
+			if entry == nil {
+				entry = &analyzeEntry{path: pkg, isGroup: true}
+				packages[pkg] = entry
+				entries = append(entries, entry)
+			}
+			entry.files = append(entry.files, input)
+			entry.bytes += input.bytes
+		} else {
+			entries = append(entries, &analyzeEntry{path: input.path, files: []metafileInput{input}, bytes: input.bytes})
+		}
+	}
+
+	// Show the largest contributors first
+	sort.SliceStable(entries, func(i int, j int) bool {
+		a, b := entries[i], entries[j]
+		return a.bytes > b.bytes || (a.bytes == b.bytes && a.path < b.path)
+	})
+	for _, entry := range entries {
+		files := entry.files
+		sort.SliceStable(files, func(i int, j int) bool {
+			a, b := files[i], files[j]
+			return a.bytes > b.bytes || (a.bytes == b.bytes && a.path < b.path)
+		})
+	}
+
+	// Prefer the inclusion reasons recorded by the bundler, but fall back to
+	// the import graph for metafiles that don't have them
+	var includedBy map[string][]string
+	if verbose {
+		includedBy = make(map[string][]string)
+		for _, input := range output.inputs {
+			if len(input.includedBy) > 0 {
+				includedBy[input.path] = input.includedBy
+			} else {
+				includedBy[input.path] = importers[input.path]
+			}
+		}
+	}
+
+	percent := func(bytes int) string {
+		if output.bytes == 0 {
+			return "0.0%"
+		}
+		return fmt.Sprintf("%.1f%%", float64(bytes)*100/float64(output.bytes))
+	}
+
+	rows := []analyzeRow{{prefix: "  ", path: output.path, size: bytesToText(output.bytes), pct: percent(output.bytes), isBold: true}}
+	addFile := func(prefix string, childPrefix string, input metafileInput) {
+		rows = append(rows, analyzeRow{prefix: prefix, path: input.path, size: bytesToText(input.bytes), pct: percent(input.bytes)})
+		if verbose {
+			for i, path := range importChainForAnalyze(input.path, output.entryPoint, includedBy) {
+				rows = append(rows, analyzeRow{prefix: childPrefix + strings.Repeat("   ", i) + "└ ", path: path})
+			}
+		}
+	}
+	for i, entry := range entries {
+		prefix, childPrefix := "   ├ ", "   │  "
+		if i+1 == len(entries) {
+			prefix, childPrefix = "   └ ", "      "
+		}
+		if !entry.isGroup {
+			addFile(prefix, childPrefix, entry.files[0])
+			continue
+		}
+		rows = append(rows, analyzeRow{prefix: prefix, path: entry.path, size: bytesToText(entry.bytes), pct: percent(entry.bytes)})
+		for j, file := range entry.files {
+			if j+1 == len(entry.files) {
+				addFile(childPrefix+"└ ", childPrefix+"   ", file)
+			} else {
+				addFile(childPrefix+"├ ", childPrefix+"│  ", file)
+			}
+		}
+	}
+	return rows
+}
+
+// Returns the shortest chain of importers from this file back to the entry
+// point, not including the file itself
+func importChainForAnalyze(path string, entryPoint string, includedBy map[string][]string) []string {
+	if path == entryPoint {
+		return nil
+	}
+	parents := map[string]string{path: ""}
+	queue := []string{path}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		importers := includedBy[current]
+		if current == entryPoint || (len(importers) == 0 && current != path) {
+			var chain []string
+			for current != path {
+				chain = append(chain, current)
+				current = parents[current]
+			}
+			for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+				chain[i], chain[j] = chain[j], chain[i]
+			}
+			return chain
+		}
+		for _, importer := range importers {
+			if _, ok := parents[importer]; !ok {
+				parents[importer] = current
+				queue = append(queue, importer)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the path of the package containing this file such as
+// "node_modules/@scope/pkg", or "" if the file isn't in a package
+func packagePathForAnalyze(path string) string {
+	i := strings.LastIndex(path, "node_modules/")
+	if i == -1 || (i > 0 && path[i-1] != '/') {
+		return ""
+	}
+	start := i + len("node_modules/")
+	slash := strings.IndexByte(path[start:], '/')
+	if slash == -1 {
+		return ""
+	}
+	if path[start] == '@' {
+		next := strings.IndexByte(path[start+slash+1:], '/')
+		if next == -1 {
+			return ""
+		}
+		slash += 1 + next
+	}
+	return path[:start+slash]
+}
+
+func forEachMetafileProperty(value js_ast.Expr, callback func(key string, value js_ast.Expr)) {
+	if object, ok := value.Data.(*js_ast.EObject); ok {
+		for _, prop := range object.Properties {
+			callback(metafileString(prop.Key), prop.ValueOrNil)
+		}
+	}
+}
+
+func metafileString(value js_ast.Expr) string {
+	if str, ok := value.Data.(*js_ast.EString); ok {
+		return js_lexer.UTF16ToString(str.Value)
+	}
+	return ""
+}
+
+func metafileInt(value js_ast.Expr) int {
+	if number, ok := value.Data.(*js_ast.ENumber); ok {
+		return int(number.Value)
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, contents := range files {
+		absPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(absPath, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPluginResolve(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"entry.js": `
+			import 'dep'
+			import 'ext'
+			import 'pure'
+			import 'by-namespace'
+		`,
+		"node_modules/dep/index.js":      `console.log('dep')`,
+		"node_modules/pure/package.json": `{ "sideEffects": false }`,
+		"node_modules/pure/index.js":     `console.log('pure')`,
+	})
+
+	var mutex sync.Mutex
+	var beforeBuild ResolveResult
+	results := make(map[string]ResolveResult)
+
+	caller := Plugin{
+		Name: "caller",
+		Setup: func(build PluginBuild) {
+			beforeBuild = build.Resolve("dep", ResolveOptions{ResolveDir: dir})
+
+			build.OnResolve(OnResolveOptions{Filter: `^(dep|ext|pure|by-namespace)$`},
+				func(args OnResolveArgs) (OnResolveResult, error) {
+					// This would recurse forever if "Resolve" called this plugin again
+					if args.PluginData == "from-resolve" {
+						return OnResolveResult{Path: "recursed", External: true}, nil
+					}
+					result := build.Resolve(args.Path, ResolveOptions{
+						Importer:   args.Importer,
+						ResolveDir: args.ResolveDir,
+						Kind:       args.Kind,
+						PluginData: "from-resolve",
+					})
+					mutex.Lock()
+					results[args.Path] = result
+					mutex.Unlock()
+					return OnResolveResult{Path: args.Path, External: true}, nil
+				})
+		},
+	}
+
+	other := Plugin{
+		Name: "other",
+		Setup: func(build PluginBuild) {
+			build.OnResolve(OnResolveOptions{Filter: `^ext$`},
+				func(args OnResolveArgs) (OnResolveResult, error) {
+					return OnResolveResult{Path: "ext", External: true}, nil
+				})
+
+			// This only matches if the importer defaults to the "file" namespace
+			build.OnResolve(OnResolveOptions{Filter: `^by-namespace$`, Namespace: "file"},
+				func(args OnResolveArgs) (OnResolveResult, error) {
+					return OnResolveResult{Path: "/by-namespace", Namespace: "other-ns"}, nil
+				})
+		},
+	}
+
+	result := Build(BuildOptions{
+		AbsWorkingDir: dir,
+		EntryPoints:   []string{"entry.js"},
+		Bundle:        true,
+		LogLevel:      LogLevelSilent,
+		Plugins:       []Plugin{caller, other},
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("Unexpected build errors: %v", result.Errors)
+	}
+
+	if len(beforeBuild.Errors) != 1 || beforeBuild.Errors[0].Text != "Cannot call \"Resolve\" before the build has started" {
+		t.Fatalf("Unexpected result before the build: %v", beforeBuild)
+	}
+
+	dep := results["dep"]
+	if len(dep.Errors) != 0 || dep.Path != filepath.Join(dir, "node_modules", "dep", "index.js") || dep.Namespace != "file" || dep.External {
+		t.Fatalf("Unexpected result for \"dep\": %v", dep)
+	}
+
+	ext := results["ext"]
+	if len(ext.Errors) != 0 || ext.Path != "ext" || !ext.External {
+		t.Fatalf("Unexpected result for \"ext\": %v", ext)
+	}
+
+	pure := results["pure"]
+	if len(pure.Errors) != 0 || pure.Path != filepath.Join(dir, "node_modules", "pure", "index.js") || pure.SideEffects != SideEffectsFalse {
+		t.Fatalf("Unexpected result for \"pure\": %v", pure)
+	}
+	if dep.SideEffects != SideEffectsTrue {
+		t.Fatalf("Unexpected side effects for \"dep\": %v", dep)
+	}
+
+	byNamespace := results["by-namespace"]
+	if len(byNamespace.Errors) != 0 || byNamespace.Path != "/by-namespace" || byNamespace.Namespace != "other-ns" {
+		t.Fatalf("Unexpected result for \"by-namespace\": %v", byNamespace)
+	}
+}
+
+func TestPluginResolveError(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"entry.js": `import 'missing'`,
+	})
+
+	var result ResolveResult
+	plugin := Plugin{
+		Name: "caller",
+		Setup: func(build PluginBuild) {
+			build.OnResolve(OnResolveOptions{Filter: `^missing$`},
+				func(args OnResolveArgs) (OnResolveResult, error) {
+					result = build.Resolve(args.Path, ResolveOptions{ResolveDir: args.ResolveDir, Kind: args.Kind})
+					return OnResolveResult{Path: args.Path, External: true}, nil
+				})
+		},
+	}
+
+	Build(BuildOptions{
+		AbsWorkingDir: dir,
+		EntryPoints:   []string{"entry.js"},
+		Bundle:        true,
+		LogLevel:      LogLevelSilent,
+		Plugins:       []Plugin{plugin},
+	})
+
+	if len(result.Errors) != 1 || result.Errors[0].Text != "Could not resolve \"missing\"" || result.Path != "" {
+		t.Fatalf("Unexpected result: %v", result)
+	}
+}
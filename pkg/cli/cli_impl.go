@@ -17,16 +17,22 @@ import (
 
 func newBuildOptions() api.BuildOptions {
 	return api.BuildOptions{
-		Loader: make(map[string]api.Loader),
-		Define: make(map[string]string),
-		Banner: make(map[string]string),
-		Footer: make(map[string]string),
+		Loader:          make(map[string]api.Loader),
+		Define:          make(map[string]string),
+		Banner:          make(map[string]string),
+		Footer:          make(map[string]string),
+		ExternalGlobals: make(map[string]string),
+		Alias:           make(map[string]string),
+		ManualChunks:    make(map[string][]string),
+		SizeBudgets:     make(map[string]api.SizeBudget),
+		LogOverride:     make(map[string]api.LogLevel),
 	}
 }
 
 func newTransformOptions() api.TransformOptions {
 	return api.TransformOptions{
-		Define: make(map[string]string),
+		Define:      make(map[string]string),
+		LogOverride: make(map[string]api.LogLevel),
 	}
 }
 
@@ -81,6 +87,12 @@ func parseOptionsImpl(
 				transformOpts.MinifySyntax = true
 			}
 
+		case arg == "--minify-inline" && buildOpts != nil:
+			buildOpts.MinifyInline = true
+
+		case arg == "--infer-pure-functions" && buildOpts != nil:
+			buildOpts.InferPureFunctions = true
+
 		case arg == "--minify-whitespace":
 			if buildOpts != nil {
 				buildOpts.MinifyWhitespace = true
@@ -118,6 +130,22 @@ func parseOptionsImpl(
 				transformOpts.LegalComments = legalComments
 			}
 
+		case strings.HasPrefix(arg, "--integrity=") && buildOpts != nil:
+			value := arg[len("--integrity="):]
+			switch value {
+			case "sha256":
+				buildOpts.Integrity = api.IntegritySHA256
+			case "sha384":
+				buildOpts.Integrity = api.IntegritySHA384
+			case "sha512":
+				buildOpts.Integrity = api.IntegritySHA512
+			default:
+				return fmt.Errorf("Invalid integrity value: %q (valid: sha256, sha384, sha512)", value), nil
+			}
+
+		case arg == "--integrity-map" && buildOpts != nil:
+			buildOpts.IntegrityMap = true
+
 		case strings.HasPrefix(arg, "--charset="):
 			var value *api.Charset
 			if buildOpts != nil {
@@ -247,6 +275,9 @@ func parseOptionsImpl(
 			buildOpts.Metafile = true
 			metafile = &metafilePath
 
+		case arg == "--manifest" && buildOpts != nil:
+			buildOpts.Manifest = true
+
 		case strings.HasPrefix(arg, "--outfile=") && buildOpts != nil:
 			buildOpts.Outfile = arg[len("--outfile="):]
 
@@ -359,6 +390,15 @@ func parseOptionsImpl(
 				return fmt.Errorf("Invalid platform: %q (valid: browser, node, neutral)", value), nil
 			}
 
+		case strings.HasPrefix(arg, "--packages=") && buildOpts != nil:
+			value := arg[len("--packages="):]
+			switch value {
+			case "external":
+				buildOpts.Packages = api.PackagesExternal
+			default:
+				return fmt.Errorf("Invalid packages: %q (valid: external)", value), nil
+			}
+
 		case strings.HasPrefix(arg, "--format="):
 			value := arg[len("--format="):]
 			switch value {
@@ -380,10 +420,79 @@ func parseOptionsImpl(
 				} else {
 					transformOpts.Format = api.FormatESModule
 				}
+			case "umd":
+				if buildOpts != nil {
+					buildOpts.Format = api.FormatUMD
+				} else {
+					transformOpts.Format = api.FormatUMD
+				}
+			case "system":
+				if buildOpts != nil {
+					buildOpts.Format = api.FormatSystem
+				} else {
+					transformOpts.Format = api.FormatSystem
+				}
 			default:
-				return fmt.Errorf("Invalid format: %q (valid: iife, cjs, esm)", value), nil
+				return fmt.Errorf("Invalid format: %q (valid: iife, cjs, esm, umd, system)", value), nil
 			}
 
+		case strings.HasPrefix(arg, "--external-global:") && buildOpts != nil:
+			value := arg[len("--external-global:"):]
+			equals := strings.IndexByte(value, '=')
+			if equals == -1 {
+				return fmt.Errorf("Missing \"=\": %q", value), nil
+			}
+			buildOpts.ExternalGlobals[value[:equals]] = value[equals+1:]
+
+		case strings.HasPrefix(arg, "--alias:") && buildOpts != nil:
+			value := arg[len("--alias:"):]
+			equals := strings.IndexByte(value, '=')
+			if equals == -1 {
+				return fmt.Errorf("Missing \"=\": %q", value), nil
+			}
+			buildOpts.Alias[value[:equals]] = value[equals+1:]
+
+		case strings.HasPrefix(arg, "--manual-chunk:") && buildOpts != nil:
+			value := arg[len("--manual-chunk:"):]
+			equals := strings.IndexByte(value, '=')
+			if equals == -1 {
+				return fmt.Errorf("Missing \"=\": %q", value), nil
+			}
+			name := value[:equals]
+			buildOpts.ManualChunks[name] = append(buildOpts.ManualChunks[name], strings.Split(value[equals+1:], ",")...)
+
+		case strings.HasPrefix(arg, "--size-budget:") && buildOpts != nil:
+			value := arg[len("--size-budget:"):]
+			equals := strings.LastIndexByte(value, '=')
+			if equals == -1 {
+				return fmt.Errorf("Missing \"=\": %q", value), nil
+			}
+			var budget api.SizeBudget
+			for _, limit := range strings.Split(value[equals+1:], ",") {
+				colon := strings.IndexByte(limit, ':')
+				if colon == -1 {
+					return fmt.Errorf("Invalid size budget: %q (expected \"raw:N\", \"gzip:N\", or \"brotli:N\")", limit), nil
+				}
+				size, err := strconv.Atoi(limit[colon+1:])
+				if err != nil || size < 0 {
+					return fmt.Errorf("Invalid size budget: %q", limit), nil
+				}
+				switch limit[:colon] {
+				case "raw":
+					budget.Raw = size
+				case "gzip":
+					budget.Gzip = size
+				case "brotli":
+					budget.Brotli = size
+				default:
+					return fmt.Errorf("Invalid size budget: %q (expected \"raw:N\", \"gzip:N\", or \"brotli:N\")", limit), nil
+				}
+			}
+			buildOpts.SizeBudgets[value[:equals]] = budget
+
+		case arg == "--size-budget-warnings" && buildOpts != nil:
+			buildOpts.SizeBudgetWarnings = true
+
 		case strings.HasPrefix(arg, "--external:") && buildOpts != nil:
 			buildOpts.External = append(buildOpts.External, arg[len("--external:"):])
 
@@ -445,6 +554,38 @@ func parseOptionsImpl(
 			}
 			buildOpts.Footer[value[:equals]] = value[equals+1:]
 
+		case strings.HasPrefix(arg, "--asset-inline-limit=") && buildOpts != nil:
+			value := arg[len("--asset-inline-limit="):]
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				return fmt.Errorf("Invalid asset inline limit: %q", value), nil
+			}
+			buildOpts.AssetInlineLimit = limit
+
+		case strings.HasPrefix(arg, "--min-chunk-size=") && buildOpts != nil:
+			value := arg[len("--min-chunk-size="):]
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 0 {
+				return fmt.Errorf("Invalid min chunk size: %q", value), nil
+			}
+			buildOpts.MinChunkSize = size
+
+		case strings.HasPrefix(arg, "--max-chunk-size=") && buildOpts != nil:
+			value := arg[len("--max-chunk-size="):]
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 0 {
+				return fmt.Errorf("Invalid max chunk size: %q", value), nil
+			}
+			buildOpts.MaxChunkSize = size
+
+		case strings.HasPrefix(arg, "--chunk-merge-budget=") && buildOpts != nil:
+			value := arg[len("--chunk-merge-budget="):]
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 0 {
+				return fmt.Errorf("Invalid chunk merge budget: %q", value), nil
+			}
+			buildOpts.ChunkMergeBudget = size
+
 		case strings.HasPrefix(arg, "--log-limit="):
 			value := arg[len("--log-limit="):]
 			limit, err := strconv.Atoi(value)
@@ -477,23 +618,9 @@ func parseOptionsImpl(
 
 		// Make sure this stays in sync with "PrintErrorToStderr"
 		case strings.HasPrefix(arg, "--log-level="):
-			value := arg[len("--log-level="):]
-			var logLevel api.LogLevel
-			switch value {
-			case "verbose":
-				logLevel = api.LogLevelVerbose
-			case "debug":
-				logLevel = api.LogLevelDebug
-			case "info":
-				logLevel = api.LogLevelInfo
-			case "warning":
-				logLevel = api.LogLevelWarning
-			case "error":
-				logLevel = api.LogLevelError
-			case "silent":
-				logLevel = api.LogLevelSilent
-			default:
-				return fmt.Errorf("Invalid log level: %q (valid: verbose, debug, info, warning, error, silent)", arg), nil
+			logLevel, err := parseLogLevel(arg[len("--log-level="):], arg)
+			if err != nil {
+				return err, nil
 			}
 			if buildOpts != nil {
 				buildOpts.LogLevel = logLevel
@@ -501,6 +628,38 @@ func parseOptionsImpl(
 				transformOpts.LogLevel = logLevel
 			}
 
+		case strings.HasPrefix(arg, "--log-format="):
+			var logFormat api.LogFormat
+			switch value := arg[len("--log-format="):]; value {
+			case "text":
+				logFormat = api.LogFormatText
+			case "json":
+				logFormat = api.LogFormatJSON
+			default:
+				return fmt.Errorf("Invalid log format: %q (valid: text, json)", value), nil
+			}
+			if buildOpts != nil {
+				buildOpts.LogFormat = logFormat
+			} else {
+				transformOpts.LogFormat = logFormat
+			}
+
+		case strings.HasPrefix(arg, "--log-override:"):
+			value := arg[len("--log-override:"):]
+			equals := strings.LastIndexByte(value, '=')
+			if equals == -1 {
+				return fmt.Errorf("Missing \"=\": %q", value), nil
+			}
+			logLevel, err := parseLogLevel(value[equals+1:], arg)
+			if err != nil {
+				return err, nil
+			}
+			if buildOpts != nil {
+				buildOpts.LogOverride[value[:equals]] = logLevel
+			} else {
+				transformOpts.LogOverride[value[:equals]] = logLevel
+			}
+
 		case strings.HasPrefix(arg, "'--"):
 			return fmt.Errorf("Unexpected single quote character before flag (use \\\" to escape double quotes): %s", arg), nil
 
@@ -530,6 +689,12 @@ func parseOptionsImpl(
 		buildOpts.Sourcemap = api.SourceMapInline
 	}
 
+	// Chunks can't be both merged and split
+	if buildOpts != nil && buildOpts.MaxChunkSize != 0 && buildOpts.MinChunkSize > buildOpts.MaxChunkSize {
+		return fmt.Errorf("The min chunk size (%d) must not be larger than the max chunk size (%d)",
+			buildOpts.MinChunkSize, buildOpts.MaxChunkSize), nil
+	}
+
 	return
 }
 
@@ -622,7 +787,112 @@ func parseOptionsForRun(osArgs []string) (*api.BuildOptions, *string, *api.Trans
 	return nil, nil, &options, nil
 }
 
+// This applies settings from the environment, reads from stdin if there are
+// no entry points, and validates the metafile path ahead of time. The returned
+// function writes out the metafile and is nil if there is no metafile.
+func setUpBuildForRun(osArgs []string, buildOptions *api.BuildOptions, metafile *string) (func(string), bool) {
+	for _, key := range os.Environ() {
+		// Read the "NODE_PATH" from the environment. This is part of node's
+		// module resolution algorithm. Documentation for this can be found here:
+		// https://nodejs.org/api/modules.html#modules_loading_from_the_global_folders
+		if strings.HasPrefix(key, "NODE_PATH=") {
+			value := key[len("NODE_PATH="):]
+			separator := ":"
+			if fs.CheckIfWindows() {
+				// On Windows, NODE_PATH is delimited by semicolons instead of colons
+				separator = ";"
+			}
+			buildOptions.NodePaths = strings.Split(value, separator)
+			break
+		}
+
+		// Read "NO_COLOR" from the environment. This is a convention that some
+		// software follows. See https://no-color.org/ for more information.
+		if buildOptions.Color == api.ColorIfTerminal && strings.HasPrefix(key, "NO_COLOR=") {
+			buildOptions.Color = api.ColorNever
+		}
+	}
+
+	// Read from stdin when there are no entry points
+	if len(buildOptions.EntryPoints)+len(buildOptions.EntryPointsAdvanced) == 0 {
+		if buildOptions.Stdin == nil {
+			buildOptions.Stdin = &api.StdinOptions{}
+		}
+		bytes, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
+				"Could not read from stdin: %s", err.Error()))
+			return nil, false
+		}
+		buildOptions.Stdin.Contents = string(bytes)
+		buildOptions.Stdin.ResolveDir, _ = os.Getwd()
+	} else if buildOptions.Stdin != nil {
+		if buildOptions.Stdin.Sourcefile != "" {
+			logger.PrintErrorToStderr(osArgs,
+				"\"sourcefile\" only applies when reading from stdin")
+		} else {
+			logger.PrintErrorToStderr(osArgs,
+				"\"loader\" without extension only applies when reading from stdin")
+		}
+		return nil, false
+	}
+
+	// Validate the metafile absolute path and directory ahead of time so we
+	// don't write any output files if it's incorrect. That makes this API
+	// option consistent with how we handle all other API options.
+	var writeMetafile func(string)
+	if metafile != nil {
+		var metafileAbsPath string
+		var metafileAbsDir string
+
+		if buildOptions.Outfile == "" && buildOptions.Outdir == "" {
+			// Cannot use "metafile" when writing to stdout
+			logger.PrintErrorToStderr(osArgs, "Cannot use \"metafile\" without an output path")
+			return nil, false
+		}
+		realFS, realFSErr := fs.RealFS(fs.RealFSOptions{AbsWorkingDir: buildOptions.AbsWorkingDir})
+		if realFSErr == nil {
+			absPath, ok := realFS.Abs(*metafile)
+			if !ok {
+				logger.PrintErrorToStderr(osArgs, fmt.Sprintf("Invalid metafile path: %s", *metafile))
+				return nil, false
+			}
+			metafileAbsPath = absPath
+			metafileAbsDir = realFS.Dir(absPath)
+		} else {
+			// Don't fail in this case since the error will be reported by "api.Build"
+		}
+
+		writeMetafile = func(json string) {
+			if json == "" || realFSErr != nil {
+				return // Don't write out the metafile on build errors
+			}
+			fs.BeforeFileOpen()
+			defer fs.AfterFileClose()
+			if err := fs.MkdirAll(realFS, metafileAbsDir, 0755); err != nil {
+				logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
+					"Failed to create output directory: %s", err.Error()))
+			} else {
+				if err := ioutil.WriteFile(metafileAbsPath, []byte(json), 0644); err != nil {
+					logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
+						"Failed to write to output file: %s", err.Error()))
+				}
+			}
+		}
+
+		// Write out the metafile whenever we rebuild
+		if buildOptions.Watch != nil {
+			buildOptions.Watch.OnRebuild = func(result api.BuildResult) {
+				writeMetafile(result.Metafile)
+			}
+		}
+	}
+
+	return writeMetafile, true
+}
+
 func runImpl(osArgs []string) int {
+	var configPath *string
 	end := 0
 
 	for _, arg := range osArgs {
@@ -635,116 +905,31 @@ func runImpl(osArgs []string) int {
 			return 0
 		}
 
+		// Special-case reading options from a config file
+		if strings.HasPrefix(arg, "--config=") {
+			path := arg[len("--config="):]
+			configPath = &path
+			continue
+		}
+
 		osArgs[end] = arg
 		end++
 	}
 	osArgs = osArgs[:end]
 
+	if configPath != nil {
+		return runConfigFile(*configPath, osArgs)
+	}
+
 	buildOptions, metafile, transformOptions, err := parseOptionsForRun(osArgs)
 
 	switch {
 	case buildOptions != nil:
-		for _, key := range os.Environ() {
-			// Read the "NODE_PATH" from the environment. This is part of node's
-			// module resolution algorithm. Documentation for this can be found here:
-			// https://nodejs.org/api/modules.html#modules_loading_from_the_global_folders
-			if strings.HasPrefix(key, "NODE_PATH=") {
-				value := key[len("NODE_PATH="):]
-				separator := ":"
-				if fs.CheckIfWindows() {
-					// On Windows, NODE_PATH is delimited by semicolons instead of colons
-					separator = ";"
-				}
-				buildOptions.NodePaths = strings.Split(value, separator)
-				break
-			}
-
-			// Read "NO_COLOR" from the environment. This is a convention that some
-			// software follows. See https://no-color.org/ for more information.
-			if buildOptions.Color == api.ColorIfTerminal && strings.HasPrefix(key, "NO_COLOR=") {
-				buildOptions.Color = api.ColorNever
-			}
-		}
-
-		// Read from stdin when there are no entry points
-		if len(buildOptions.EntryPoints)+len(buildOptions.EntryPointsAdvanced) == 0 {
-			if buildOptions.Stdin == nil {
-				buildOptions.Stdin = &api.StdinOptions{}
-			}
-			bytes, err := ioutil.ReadAll(os.Stdin)
-			if err != nil {
-				logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
-					"Could not read from stdin: %s", err.Error()))
-				return 1
-			}
-			buildOptions.Stdin.Contents = string(bytes)
-			buildOptions.Stdin.ResolveDir, _ = os.Getwd()
-		} else if buildOptions.Stdin != nil {
-			if buildOptions.Stdin.Sourcefile != "" {
-				logger.PrintErrorToStderr(osArgs,
-					"\"sourcefile\" only applies when reading from stdin")
-			} else {
-				logger.PrintErrorToStderr(osArgs,
-					"\"loader\" without extension only applies when reading from stdin")
-			}
+		writeMetafile, ok := setUpBuildForRun(osArgs, buildOptions, metafile)
+		if !ok {
 			return 1
 		}
 
-		// Validate the metafile absolute path and directory ahead of time so we
-		// don't write any output files if it's incorrect. That makes this API
-		// option consistent with how we handle all other API options.
-		var writeMetafile func(string)
-		if metafile != nil {
-			var metafileAbsPath string
-			var metafileAbsDir string
-
-			if buildOptions.Outfile == "" && buildOptions.Outdir == "" {
-				// Cannot use "metafile" when writing to stdout
-				logger.PrintErrorToStderr(osArgs, "Cannot use \"metafile\" without an output path")
-				return 1
-			}
-			realFS, realFSErr := fs.RealFS(fs.RealFSOptions{AbsWorkingDir: buildOptions.AbsWorkingDir})
-			if realFSErr == nil {
-				absPath, ok := realFS.Abs(*metafile)
-				if !ok {
-					logger.PrintErrorToStderr(osArgs, fmt.Sprintf("Invalid metafile path: %s", *metafile))
-					return 1
-				}
-				metafileAbsPath = absPath
-				metafileAbsDir = realFS.Dir(absPath)
-			} else {
-				// Don't fail in this case since the error will be reported by "api.Build"
-			}
-
-			writeMetafile = func(json string) {
-				if json == "" || realFSErr != nil {
-					return // Don't write out the metafile on build errors
-				}
-				if err != nil {
-					// This should already have been checked above
-					panic(err.Error())
-				}
-				fs.BeforeFileOpen()
-				defer fs.AfterFileClose()
-				if err := fs.MkdirAll(realFS, metafileAbsDir, 0755); err != nil {
-					logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
-						"Failed to create output directory: %s", err.Error()))
-				} else {
-					if err := ioutil.WriteFile(metafileAbsPath, []byte(json), 0644); err != nil {
-						logger.PrintErrorToStderr(osArgs, fmt.Sprintf(
-							"Failed to write to output file: %s", err.Error()))
-					}
-				}
-			}
-
-			// Write out the metafile whenever we rebuild
-			if buildOptions.Watch != nil {
-				buildOptions.Watch.OnRebuild = func(result api.BuildResult) {
-					writeMetafile(result.Metafile)
-				}
-			}
-		}
-
 		// Run the build
 		result := api.Build(*buildOptions)
 
@@ -917,3 +1102,22 @@ func serveImpl(osArgs []string) error {
 	})
 	return result.Wait()
 }
+
+func parseLogLevel(value string, arg string) (api.LogLevel, error) {
+	switch value {
+	case "verbose":
+		return api.LogLevelVerbose, nil
+	case "debug":
+		return api.LogLevelDebug, nil
+	case "info":
+		return api.LogLevelInfo, nil
+	case "warning":
+		return api.LogLevelWarning, nil
+	case "error":
+		return api.LogLevelError, nil
+	case "silent":
+		return api.LogLevelSilent, nil
+	default:
+		return api.LogLevelSilent, fmt.Errorf("Invalid log level: %q (valid: verbose, debug, info, warning, error, silent)", arg)
+	}
+}
@@ -0,0 +1,467 @@
+package cli
+
+// This implements "--config=esbuild.json". The keys in the config file mirror
+// the fields in "api.BuildOptions" using the same names as the JavaScript API.
+// Each key is converted into the equivalent command-line flag so that config
+// files go through exactly the same validation as command-line flags, and so
+// that flags on the actual command line naturally override the config file.
+// Flags that can be repeated such as "--external:" and "--define:" add to the
+// values from the config file instead of replacing them, although a "--define:"
+// for a name that the config file also defines still replaces that name.
+//
+// A config file can also contain a "builds" object that declares multiple
+// named builds. The other top-level keys are shared by all named builds. Named
+// builds run in parallel and share a cache.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/js_parser"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+type configFlag struct {
+	text string
+	r    logger.Range
+}
+
+type configBuild struct {
+	name  string
+	flags []configFlag
+}
+
+// Boolean options that turn on a flag when they are true
+var configBoolFlags = map[string]string{
+	"allowOverwrite":     "--allow-overwrite",
+	"bundle":             "--bundle",
+	"inferPureFunctions": "--infer-pure-functions",
+	"integrityMap":       "--integrity-map",
+	"keepNames":          "--keep-names",
+	"manifest":           "--manifest",
+	"minify":             "--minify",
+	"minifyIdentifiers":  "--minify-identifiers",
+	"minifyInline":       "--minify-inline",
+	"minifySyntax":       "--minify-syntax",
+	"minifyWhitespace":   "--minify-whitespace",
+	"preserveSymlinks":   "--preserve-symlinks",
+	"sizeBudgetWarnings": "--size-budget-warnings",
+	"splitting":          "--splitting",
+	"watch":              "--watch",
+}
+
+// Boolean options where both values are passed to the flag
+var configBoolValueFlags = map[string]string{
+	"color":          "--color=",
+	"sourcesContent": "--sources-content=",
+}
+
+// String and number options
+var configValueFlags = map[string]string{
+	"assetInlineLimit": "--asset-inline-limit=",
+	"assetNames":       "--asset-names=",
+	"charset":          "--charset=",
+	"chunkMergeBudget": "--chunk-merge-budget=",
+	"chunkNames":       "--chunk-names=",
+	"entryNames":       "--entry-names=",
+	"format":           "--format=",
+	"globalName":       "--global-name=",
+	"integrity":        "--integrity=",
+	"jsx":              "--jsx=",
+	"jsxFactory":       "--jsx-factory=",
+	"jsxFragment":      "--jsx-fragment=",
+	"legalComments":    "--legal-comments=",
+	"logFormat":        "--log-format=",
+	"logLevel":         "--log-level=",
+	"logLimit":         "--log-limit=",
+	"maxChunkSize":     "--max-chunk-size=",
+	"metafile":         "--metafile=",
+	"minChunkSize":     "--min-chunk-size=",
+	"outbase":          "--outbase=",
+	"outdir":           "--outdir=",
+	"outfile":          "--outfile=",
+	"packages":         "--packages=",
+	"platform":         "--platform=",
+	"publicPath":       "--public-path=",
+	"sourceRoot":       "--source-root=",
+	"treeShaking":      "--tree-shaking=",
+	"tsconfig":         "--tsconfig=",
+	"tsconfigRaw":      "--tsconfig-raw=",
+}
+
+// Array options that are passed to the flag as a comma-separated list
+var configListFlags = map[string]string{
+	"conditions":        "--conditions=",
+	"mainFields":        "--main-fields=",
+	"resolveExtensions": "--resolve-extensions=",
+	"target":            "--target=",
+}
+
+// Array options that pass each item to a separate flag
+var configRepeatedFlags = map[string]string{
+	"external": "--external:",
+	"inject":   "--inject:",
+	"pure":     "--pure:",
+}
+
+// Object options that pass each key/value pair to a separate flag
+var configMapFlags = map[string]string{
+	"alias":           "--alias:",
+	"banner":          "--banner:",
+	"define":          "--define:",
+	"externalGlobals": "--external-global:",
+	"footer":          "--footer:",
+	"loader":          "--loader:",
+	"logOverride":     "--log-override:",
+	"outExtension":    "--out-extension:",
+}
+
+type configParser struct {
+	log     logger.Log
+	source  logger.Source
+	tracker logger.LineColumnTracker
+}
+
+func parseConfigFile(log logger.Log, source logger.Source) (shared []configFlag, builds []configBuild, ok bool) {
+	root, ok := js_parser.ParseJSON(log, source, js_parser.JSONOptions{})
+	if !ok {
+		return nil, nil, false
+	}
+
+	p := configParser{
+		log:     log,
+		source:  source,
+		tracker: logger.MakeLineColumnTracker(&source),
+	}
+
+	object, ok := root.Data.(*js_ast.EObject)
+	if !ok {
+		p.log.AddRangeError(&p.tracker, logger.Range{Loc: root.Loc}, "The config file must contain a JSON object")
+		return nil, nil, false
+	}
+
+	for _, prop := range object.Properties {
+		key, keyRange := p.key(prop.Key)
+		if key != "builds" {
+			shared = append(shared, p.validFlagsForOption(key, keyRange, prop.ValueOrNil)...)
+			continue
+		}
+
+		buildsObject, ok := prop.ValueOrNil.Data.(*js_ast.EObject)
+		if !ok {
+			p.log.AddRangeError(&p.tracker, p.valueRange(prop.ValueOrNil), "The \"builds\" option must be an object")
+			continue
+		}
+		for _, buildProp := range buildsObject.Properties {
+			name, _ := p.key(buildProp.Key)
+			buildObject, ok := buildProp.ValueOrNil.Data.(*js_ast.EObject)
+			if !ok {
+				p.log.AddRangeError(&p.tracker, p.valueRange(buildProp.ValueOrNil), fmt.Sprintf("The build %q must be an object", name))
+				continue
+			}
+			build := configBuild{name: name}
+			for _, optionProp := range buildObject.Properties {
+				key, keyRange := p.key(optionProp.Key)
+				if key == "builds" {
+					p.log.AddRangeError(&p.tracker, keyRange, fmt.Sprintf("The build %q cannot contain nested builds", name))
+					continue
+				}
+				build.flags = append(build.flags, p.validFlagsForOption(key, keyRange, optionProp.ValueOrNil)...)
+			}
+			builds = append(builds, build)
+		}
+	}
+
+	return shared, builds, !log.HasErrors()
+}
+
+// Each flag is run through the command-line parser by itself so that invalid
+// values can be reported at the location of the value in the config file
+func (p *configParser) validFlagsForOption(key string, keyRange logger.Range, value js_ast.Expr) []configFlag {
+	flags := p.flagsForOption(key, keyRange, value)
+	for _, flag := range flags {
+		options := newBuildOptions()
+		if err, _ := parseOptionsImpl([]string{flag.text}, &options, nil, kindInternal); err != nil {
+			p.log.AddRangeError(&p.tracker, flag.r, err.Error())
+		}
+	}
+	return flags
+}
+
+func (p *configParser) key(key js_ast.Expr) (string, logger.Range) {
+	text, _ := p.string(key)
+	return text, p.source.RangeOfString(key.Loc)
+}
+
+func (p *configParser) valueRange(value js_ast.Expr) logger.Range {
+	if _, ok := value.Data.(*js_ast.EString); ok {
+		return p.source.RangeOfString(value.Loc)
+	}
+	return logger.Range{Loc: value.Loc}
+}
+
+func (p *configParser) string(value js_ast.Expr) (string, bool) {
+	if str, ok := value.Data.(*js_ast.EString); ok {
+		return js_lexer.UTF16ToString(str.Value), true
+	}
+	return "", false
+}
+
+// Numbers are converted to text so that the flag parser can validate them
+func (p *configParser) stringOrNumber(value js_ast.Expr) (string, bool) {
+	switch e := value.Data.(type) {
+	case *js_ast.EString:
+		return js_lexer.UTF16ToString(e.Value), true
+	case *js_ast.ENumber:
+		return strconv.FormatFloat(e.Value, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+func (p *configParser) stringArray(key string, value js_ast.Expr) ([]string, []logger.Range, bool) {
+	array, ok := value.Data.(*js_ast.EArray)
+	if !ok {
+		p.log.AddRangeError(&p.tracker, p.valueRange(value), fmt.Sprintf("The %q option must be an array of strings", key))
+		return nil, nil, false
+	}
+	items := make([]string, 0, len(array.Items))
+	ranges := make([]logger.Range, 0, len(array.Items))
+	for _, item := range array.Items {
+		text, ok := p.string(item)
+		if !ok {
+			p.log.AddRangeError(&p.tracker, p.valueRange(item), fmt.Sprintf("The %q option must be an array of strings", key))
+			return nil, nil, false
+		}
+		items = append(items, text)
+		ranges = append(ranges, p.valueRange(item))
+	}
+	return items, ranges, true
+}
+
+func (p *configParser) flagsForOption(key string, keyRange logger.Range, value js_ast.Expr) (flags []configFlag) {
+	r := p.valueRange(value)
+
+	if flag, ok := configBoolFlags[key]; ok {
+		if boolean, ok := value.Data.(*js_ast.EBoolean); !ok {
+			p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("The %q option must be a boolean", key))
+		} else if boolean.Value {
+			flags = append(flags, configFlag{text: flag, r: r})
+		}
+		return
+	}
+
+	if flag, ok := configBoolValueFlags[key]; ok {
+		if boolean, ok := value.Data.(*js_ast.EBoolean); !ok {
+			p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("The %q option must be a boolean", key))
+		} else {
+			flags = append(flags, configFlag{text: flag + strconv.FormatBool(boolean.Value), r: r})
+		}
+		return
+	}
+
+	if flag, ok := configValueFlags[key]; ok {
+		if text, ok := p.stringOrNumber(value); !ok {
+			p.log.AddRangeError(&p.tracker, r, fmt.Sprintf("The %q option must be a string or a number", key))
+		} else {
+			flags = append(flags, configFlag{text: flag + text, r: r})
+		}
+		return
+	}
+
+	if flag, ok := configListFlags[key]; ok {
+		if text, ok := p.string(value); ok {
+			flags = append(flags, configFlag{text: flag + text, r: r})
+		} else if items, _, ok := p.stringArray(key, value); ok {
+			flags = append(flags, configFlag{text: flag + strings.Join(items, ","), r: r})
+		}
+		return
+	}
+
+	if flag, ok := configRepeatedFlags[key]; ok {
+		if items, ranges, ok := p.stringArray(key, value); ok {
+			for i, item := range items {
+				flags = append(flags, configFlag{text: flag + item, r: ranges[i]})
+			}
+		}
+		return
+	}
+
+	if flag, ok := configMapFlags[key]; ok {
+		p.forEachProperty(key, value, func(name string, nameRange logger.Range, value js_ast.Expr) {
+			if text, ok := p.string(value); !ok {
+				p.log.AddRangeError(&p.tracker, p.valueRange(value), fmt.Sprintf("The values in the %q option must be strings", key))
+			} else if strings.ContainsRune(name, '=') {
+				p.log.AddRangeError(&p.tracker, nameRange, fmt.Sprintf("The keys in the %q option cannot contain \"=\"", key))
+			} else {
+				flags = append(flags, configFlag{text: flag + name + "=" + text, r: nameRange})
+			}
+		})
+		return
+	}
+
+	switch key {
+	case "entryPoints":
+		// Entry points are either an array of paths or an object that maps output
+		// paths to input paths, just like the JavaScript API
+		if _, ok := value.Data.(*js_ast.EObject); ok {
+			p.forEachProperty(key, value, func(name string, nameRange logger.Range, value js_ast.Expr) {
+				if text, ok := p.string(value); !ok {
+					p.log.AddRangeError(&p.tracker, p.valueRange(value), "The values in the \"entryPoints\" option must be strings")
+				} else {
+					flags = append(flags, configFlag{text: name + "=" + text, r: nameRange})
+				}
+			})
+		} else if items, ranges, ok := p.stringArray(key, value); ok {
+			for i, item := range items {
+				flags = append(flags, configFlag{text: item, r: ranges[i]})
+			}
+		}
+
+	case "sourcemap":
+		switch e := value.Data.(type) {
+		case *js_ast.EBoolean:
+			if e.Value {
+				flags = append(flags, configFlag{text: "--sourcemap", r: r})
+			}
+		case *js_ast.EString:
+			flags = append(flags, configFlag{text: "--sourcemap=" + js_lexer.UTF16ToString(e.Value), r: r})
+		default:
+			p.log.AddRangeError(&p.tracker, r, "The \"sourcemap\" option must be a boolean or a string")
+		}
+
+	case "manualChunks":
+		p.forEachProperty(key, value, func(name string, nameRange logger.Range, value js_ast.Expr) {
+			if items, _, ok := p.stringArray(key, value); ok {
+				flags = append(flags, configFlag{text: "--manual-chunk:" + name + "=" + strings.Join(items, ","), r: nameRange})
+			}
+		})
+
+	case "sizeBudgets":
+		p.forEachProperty(key, value, func(glob string, globRange logger.Range, value js_ast.Expr) {
+			var limits []string
+			p.forEachProperty(key, value, func(kind string, kindRange logger.Range, value js_ast.Expr) {
+				if size, ok := p.stringOrNumber(value); !ok {
+					p.log.AddRangeError(&p.tracker, p.valueRange(value), "The sizes in the \"sizeBudgets\" option must be numbers")
+				} else {
+					limits = append(limits, kind+":"+size)
+				}
+			})
+			if len(limits) > 0 {
+				flags = append(flags, configFlag{text: "--size-budget:" + glob + "=" + strings.Join(limits, ","), r: globRange})
+			}
+		})
+
+	default:
+		p.log.AddRangeError(&p.tracker, keyRange, fmt.Sprintf("Invalid config option %q", key))
+	}
+	return
+}
+
+func (p *configParser) forEachProperty(key string, value js_ast.Expr, callback func(name string, nameRange logger.Range, value js_ast.Expr)) {
+	object, ok := value.Data.(*js_ast.EObject)
+	if !ok {
+		p.log.AddRangeError(&p.tracker, p.valueRange(value), fmt.Sprintf("The %q option must be an object", key))
+		return
+	}
+	for _, prop := range object.Properties {
+		name, nameRange := p.key(prop.Key)
+		callback(name, nameRange, prop.ValueOrNil)
+	}
+}
+
+func runConfigFile(configPath string, osArgs []string) int {
+	contents, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		logger.PrintErrorToStderr(osArgs, fmt.Sprintf("Cannot read file %q: %s", configPath, err.Error()))
+		return 1
+	}
+
+	// Parse the config file using the normal log so that errors show the
+	// relevant part of the config file
+	log := logger.NewStderrLog(logger.OutputOptionsForArgs(osArgs))
+	shared, builds, ok := parseConfigFile(log, logger.Source{
+		KeyPath:    logger.Path{Text: configPath, Namespace: "file"},
+		PrettyPath: configPath,
+		Contents:   string(contents),
+	})
+	log.Done()
+	if !ok {
+		return 1
+	}
+	if len(builds) == 0 {
+		builds = []configBuild{{}}
+	}
+
+	// Command-line flags come last so that they override the config file
+	options := make([]api.BuildOptions, len(builds))
+	writeMetafiles := make([]func(string), len(builds))
+	isWatch := false
+	for i, build := range builds {
+		args := make([]string, 0, len(shared)+len(build.flags)+len(osArgs))
+		for _, flag := range shared {
+			args = append(args, flag.text)
+		}
+		for _, flag := range build.flags {
+			args = append(args, flag.text)
+		}
+		args = append(args, osArgs...)
+
+		// Apply defaults appropriate for the CLI
+		buildOptions := &options[i]
+		*buildOptions = newBuildOptions()
+		buildOptions.LogLimit = 10
+		buildOptions.LogLevel = api.LogLevelInfo
+		buildOptions.Write = true
+
+		err, metafile := parseOptionsImpl(args, buildOptions, nil, kindInternal)
+		if err != nil {
+			logger.PrintErrorToStderr(osArgs, err.Error())
+			return 1
+		}
+
+		// Only one build can read from stdin
+		if len(builds) > 1 && len(buildOptions.EntryPoints)+len(buildOptions.EntryPointsAdvanced) == 0 {
+			logger.PrintErrorToStderr(osArgs, fmt.Sprintf("The build %q has no entry points", build.name))
+			return 1
+		}
+
+		writeMetafile, ok := setUpBuildForRun(osArgs, buildOptions, metafile)
+		if !ok {
+			return 1
+		}
+		writeMetafiles[i] = writeMetafile
+		if buildOptions.Watch != nil {
+			isWatch = true
+		}
+	}
+
+	// Run the builds
+	results := api.BuildParallel(options)
+
+	// Write the metafiles to the file system
+	hasErrors := false
+	for i, result := range results {
+		if writeMetafiles[i] != nil {
+			writeMetafiles[i](result.Metafile)
+		}
+		if len(result.Errors) > 0 {
+			hasErrors = true
+		}
+	}
+
+	// Do not exit if we're in watch mode
+	if isWatch {
+		<-make(chan bool)
+	}
+
+	// Stop if there were errors
+	if hasErrors {
+		return 1
+	}
+	return 0
+}
@@ -47,6 +47,93 @@
     }),
   )
 
+  // Tests for "--config"
+  tests.push(
+    // Command-line flags override the config file
+    test(['--config=esbuild.json', '--outfile=node.js', '--define:x=2'], {
+      'esbuild.json': `{ "entryPoints": ["in.js"], "outfile": "wrong.js", "define": { "x": "1", "y": "3" } }`,
+      'in.js': `if (x !== 2 || y !== 3) throw 'fail'`,
+    }),
+
+    // Repeated flags add to the values in the config file
+    test(['--config=esbuild.json', '--external:b', '--outfile=node.js'], {
+      'esbuild.json': `{ "entryPoints": ["in.js"], "bundle": true, "format": "cjs", "external": ["a"] }`,
+      'in.js': `
+        try { require('a') } catch { }
+        try { require('b') } catch { }
+      `,
+    }),
+
+    // Named builds inherit the shared options, and builds that share a cache
+    // must still parse the same file with their own options
+    test(['--config=esbuild.json'], {
+      'esbuild.json': `{
+        "bundle": true,
+        "format": "cjs",
+        "define": { "y": "\\"shared\\"" },
+        "builds": {
+          "a": { "entryPoints": ["a.js"], "outfile": "node.js", "define": { "x": "1" }, "external": ["./b.js"] },
+          "b": { "entryPoints": ["b-in.js"], "outfile": "b.js", "define": { "x": "2" } }
+        }
+      }`,
+      'shared.js': `module.exports = expected => { if (x !== expected || y !== 'shared') throw 'fail' }`,
+      'a.js': `require('./shared.js')(1); require('./b.js')`,
+      'b-in.js': `require('./shared.js')(2)`,
+    }),
+
+    // Errors point into the config file
+    test(['--config=esbuild.json', 'in.js'], {
+      'esbuild.json': `{"minify": "yes"}`,
+      'in.js': ``,
+    }, {
+      expectedStderr: ` > esbuild.json:1:11: error: The "minify" option must be a boolean
+    1 │ {"minify": "yes"}
+      ╵            ~~~~~
+
+`,
+    }),
+    test(['--config=esbuild.json', 'in.js'], {
+      'esbuild.json': `{"external": ["a", 1]}`,
+      'in.js': ``,
+    }, {
+      expectedStderr: ` > esbuild.json:1:19: error: The "external" option must be an array of strings
+    1 │ {"external": ["a", 1]}
+      ╵                    ^
+
+`,
+    }),
+    test(['--config=esbuild.json', 'in.js'], {
+      'esbuild.json': `{"minify": true,}`,
+      'in.js': ``,
+    }, {
+      expectedStderr: ` > esbuild.json:1:15: error: JSON does not support trailing commas
+    1 │ {"minify": true,}
+      ╵                ^
+
+`,
+    }),
+    test(['--config=esbuild.json', 'in.js'], {
+      'esbuild.json': `{"bundel": true}`,
+      'in.js': ``,
+    }, {
+      expectedStderr: ` > esbuild.json:1:1: error: Invalid config option "bundel"
+    1 │ {"bundel": true}
+      ╵  ~~~~~~~~
+
+`,
+    }),
+    test(['--config=esbuild.json', 'in.js'], {
+      'esbuild.json': `{"builds": {"a": {"builds": {}}}}`,
+      'in.js': ``,
+    }, {
+      expectedStderr: ` > esbuild.json:1:18: error: The build "a" cannot contain nested builds
+    1 │ {"builds": {"a": {"builds": {}}}}
+      ╵                   ~~~~~~~~
+
+`,
+    }),
+  )
+
   // Test recursive directory creation
   tests.push(
     test(['entry.js', '--outfile=a/b/c/d/index.js'], {
@@ -2749,6 +2836,30 @@
 
   // Class lowering tests
   tests.push(
+    test(['in.js', '--outfile=node.js', '--target=es2020'], {
+      'in.js': `
+        class Foo {
+          accessor a = 1
+          static accessor b = 2
+        }
+        const foo = new Foo
+        foo.a = 5
+        Foo.b = 6
+        if (foo.a !== 5 || Foo.b !== 6) throw 'fail'
+      `,
+    }),
+    test(['in.js', '--outfile=node.js', '--target=es2020', '--bundle'], {
+      'in.js': `
+        class Foo {
+          accessor a = 1
+          static accessor b = 2
+        }
+        const foo = new Foo
+        foo.a = 5
+        Foo.b = 6
+        if (foo.a !== 5 || Foo.b !== 6) throw 'fail'
+      `,
+    }),
     test(['in.js', '--outfile=node.js', '--target=es6'], {
       'in.js': `
         class Foo {
@@ -4375,6 +4486,76 @@
     )
   }
 
+  // Tests for "esbuild analyze"
+  {
+    const metafile = JSON.stringify({
+      inputs: {
+        'src/entry.js': { bytes: 100, imports: [{ path: 'node_modules/pkg/index.js' }] },
+        'node_modules/pkg/index.js': { bytes: 10, imports: [] },
+      },
+      outputs: {
+        'out.js.map': { bytes: 500, inputs: {}, imports: [], exports: [] },
+        'out.js': {
+          bytes: 400,
+          entryPoint: 'src/entry.js',
+          inputs: {
+            'src/entry.js': { bytesInOutput: 100 },
+            'node_modules/pkg/index.js': { bytesInOutput: 300 },
+          },
+        },
+      },
+    })
+    tests.push(
+      testAnalyze(['meta.json'], { 'meta.json': metafile }, {
+        expectedStdout: `
+  out.js                           400b  100.0%
+   ├ node_modules/pkg              300b   75.0%
+   │  └ node_modules/pkg/index.js  300b   75.0%
+   └ src/entry.js                  100b   25.0%
+
+`,
+      }),
+      testAnalyze(['meta.json', '--verbose'], { 'meta.json': metafile }, {
+        expectedStdout: `
+  out.js                           400b  100.0%
+   ├ node_modules/pkg              300b   75.0%
+   │  └ node_modules/pkg/index.js  300b   75.0%
+   │     └ src/entry.js
+   └ src/entry.js                  100b   25.0%
+
+`,
+      }),
+      testAnalyze(['meta.json'], { 'meta.json': '{' }, {
+        expectedStatus: 1,
+        expectedStderr: ` > error: The metafile is not valid or does not contain any outputs
+
+1 error
+`,
+      }),
+      testAnalyze(['meta.json'], { 'meta.json': '{"outputs": {}}' }, {
+        expectedStatus: 1,
+        expectedStderr: ` > error: The metafile is not valid or does not contain any outputs
+
+1 error
+`,
+      }),
+      testAnalyze(['missing.json'], {}, {
+        expectedStatus: 1,
+        expectedStderr: ` > error: Could not read metafile: open missing.json: ${process.platform === 'win32' ? 'The system cannot find the file specified.' : 'no such file or directory'}
+
+1 error
+`,
+      }),
+      testAnalyze(['a.json', 'b.json'], {}, {
+        expectedStatus: 1,
+        expectedStderr: ` > error: Only one metafile can be analyzed at a time
+
+1 error
+`,
+      }),
+    )
+  }
+
   // Test a special-case error message for people trying to use "'--" on Windows
   tests.push(
     test(['in.js', `'--define:process.env.NODE_ENV="production"'`], {
@@ -4941,6 +5122,41 @@
     }
   }
 
+  // This runs "esbuild analyze" and checks the exit status and both outputs
+  function testAnalyze(args, files, { expectedStatus = 0, expectedStdout = '', expectedStderr = '' }) {
+    return async () => {
+      const thisTestDir = path.join(testDir, '' + testCount++)
+
+      try {
+        await fs.mkdir(thisTestDir, { recursive: true })
+        for (const file in files) {
+          await fs.writeFile(path.join(thisTestDir, file), files[file])
+        }
+
+        let status = 0, stdout, stderr
+        try {
+          ({ stdout, stderr } = await execFileAsync(esbuildPath, ['analyze'].concat(args), { cwd: thisTestDir, stdio: 'pipe' }))
+        } catch (e) {
+          ({ stdout, stderr } = e)
+          status = e.code
+        }
+        assert.strictEqual(status, expectedStatus)
+        assert.strictEqual(stdout, expectedStdout)
+        assert.strictEqual(stderr, expectedStderr)
+
+        // Clean up test output
+        removeRecursiveSync(thisTestDir)
+      } catch (e) {
+        console.error(`❌ test failed: ${e && e.message || e}
+  dir: ${path.relative(dirname, thisTestDir)}
+  args: analyze ${args.join(' ')}`)
+        return false
+      }
+
+      return true
+    }
+  }
+
   // Create a fresh test directory
   removeRecursiveSync(testDir)
   await fs.mkdir(testDir, { recursive: true })
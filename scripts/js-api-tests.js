@@ -780,8 +780,8 @@ body {
     // Check inputs
     assert.deepStrictEqual(json.inputs[makePath(entry)].bytes, 144)
     assert.deepStrictEqual(json.inputs[makePath(entry)].imports, [
-      { path: makePath(imported), kind: 'import-statement' },
-      { path: makePath(css), kind: 'import-statement' },
+      { path: makePath(imported), kind: 'import-statement', uses: ['default'] },
+      { path: makePath(css), kind: 'import-statement', uses: ['*'] },
       { path: makePath(text), kind: 'require-call' },
     ])
     assert.deepStrictEqual(json.inputs[makePath(imported)].bytes, 18)
@@ -855,8 +855,8 @@ body {
     const outEntry2 = makeOutPath(path.basename(entry2));
     const outChunk = makeOutPath(chunk);
 
-    assert.deepStrictEqual(json.inputs[inEntry1], { bytes: 94, imports: [{ path: inImported, kind: 'import-statement' }] })
-    assert.deepStrictEqual(json.inputs[inEntry2], { bytes: 107, imports: [{ path: inImported, kind: 'import-statement' }] })
+    assert.deepStrictEqual(json.inputs[inEntry1], { bytes: 94, imports: [{ path: inImported, kind: 'import-statement', uses: ['default', 'f1'] }] })
+    assert.deepStrictEqual(json.inputs[inEntry2], { bytes: 107, imports: [{ path: inImported, kind: 'import-statement', uses: ['default', 'f2'] }] })
     assert.deepStrictEqual(json.inputs[inImported], { bytes: 118, imports: [] })
 
     assert.deepStrictEqual(json.outputs[outEntry1].imports, [{ path: makeOutPath(chunk), kind: 'import-statement' }])
@@ -869,7 +869,7 @@ body {
 
     assert.deepStrictEqual(json.outputs[outEntry1].inputs, { [inEntry1]: { bytesInOutput: 40 } })
     assert.deepStrictEqual(json.outputs[outEntry2].inputs, { [inEntry2]: { bytesInOutput: 48 } })
-    assert.deepStrictEqual(json.outputs[outChunk].inputs, { [inImported]: { bytesInOutput: 87 } })
+    assert.deepStrictEqual(json.outputs[outChunk].inputs, { [inImported]: { bytesInOutput: 87, includedBy: [inEntry1, inEntry2] } })
   },
 
   async metafileSplittingPublicPath({ esbuild, testDir }) {
@@ -919,8 +919,8 @@ body {
     const outEntry2 = makeOutPath(path.basename(entry2));
     const outChunk = makeOutPath(chunk);
 
-    assert.deepStrictEqual(json.inputs[inEntry1], { bytes: 94, imports: [{ path: inImported, kind: 'import-statement' }] })
-    assert.deepStrictEqual(json.inputs[inEntry2], { bytes: 107, imports: [{ path: inImported, kind: 'import-statement' }] })
+    assert.deepStrictEqual(json.inputs[inEntry1], { bytes: 94, imports: [{ path: inImported, kind: 'import-statement', uses: ['default', 'f1'] }] })
+    assert.deepStrictEqual(json.inputs[inEntry2], { bytes: 107, imports: [{ path: inImported, kind: 'import-statement', uses: ['default', 'f2'] }] })
     assert.deepStrictEqual(json.inputs[inImported], { bytes: 118, imports: [] })
 
     assert.deepStrictEqual(json.outputs[outEntry1].imports, [{ path: makeOutPath(chunk), kind: 'import-statement' }])
@@ -933,7 +933,7 @@ body {
 
     assert.deepStrictEqual(json.outputs[outEntry1].inputs, { [inEntry1]: { bytesInOutput: 40 } })
     assert.deepStrictEqual(json.outputs[outEntry2].inputs, { [inEntry2]: { bytesInOutput: 48 } })
-    assert.deepStrictEqual(json.outputs[outChunk].inputs, { [inImported]: { bytesInOutput: 87 } })
+    assert.deepStrictEqual(json.outputs[outChunk].inputs, { [inImported]: { bytesInOutput: 87, includedBy: [inEntry1, inEntry2] } })
   },
 
   async metafileSplittingDoubleDynamicImport({ esbuild, testDir }) {
@@ -1032,7 +1032,63 @@ body {
     assert.deepStrictEqual(json.outputs[outEntry].inputs, { [inEntry]: { bytesInOutput: 74 } })
     assert.deepStrictEqual(json.outputs[outImport1].inputs, { [inImport1]: { bytesInOutput: 0 } })
     assert.deepStrictEqual(json.outputs[outImport2].inputs, { [inImport2]: { bytesInOutput: 0 } })
-    assert.deepStrictEqual(json.outputs[outChunk].inputs, { [inShared]: { bytesInOutput: 28 } })
+    assert.deepStrictEqual(json.outputs[outChunk].inputs, { [inShared]: { bytesInOutput: 28, includedBy: [inEntry, inImport1, inImport2] } })
+  },
+
+  async metafileChunkSizeDecisions({ esbuild, testDir }) {
+    const files = {
+      'a.js': `import { ab } from './ab.js'; import { abc } from './abc.js'; console.log(ab, abc)`,
+      'b.js': `import { ab } from './ab.js'; import { abc } from './abc.js'; import { bc } from './bc.js'; console.log(ab, abc, bc)`,
+      'c.js': `import { abc } from './abc.js'; import { bc } from './bc.js'; console.log(abc, bc)`,
+      'ab.js': `import { abc } from './abc.js'; export function ab() { return abc }`,
+      'abc.js': `export let abc = 3`,
+      'bc.js': `import { abc } from './abc.js'; export let bc = abc; console.log('bc has side effects')`,
+    }
+    for (const name in files) await writeFileAsync(path.join(testDir, name), files[name])
+    const result = await esbuild.build({
+      entryPoints: ['a.js', 'b.js', 'c.js'].map(name => path.join(testDir, name)),
+      bundle: true,
+      outdir: path.join(testDir, 'out'),
+      absWorkingDir: testDir,
+      format: 'esm',
+      splitting: true,
+      minChunkSize: 50,
+      chunkMergeBudget: 100,
+      metafile: true,
+      write: false,
+    })
+
+    // The chunk for "abc.js" is too small, so it's merged into the chunk for "ab.js"
+    const merged = Object.values(result.metafile.outputs).filter(output => output.chunkSizeDecisions)
+    assert.strictEqual(merged.length, 1)
+    assert.deepStrictEqual(Object.keys(merged[0].inputs).sort(), ['ab.js', 'abc.js'])
+    assert.strictEqual(merged[0].chunkSizeDecisions.length, 1)
+    const { kind, inputs, estimatedBytes, addedBytes } = merged[0].chunkSizeDecisions[0]
+    assert.strictEqual(kind, 'merged')
+    assert.deepStrictEqual(inputs, ['abc.js'])
+    assert.strictEqual(typeof estimatedBytes, 'number')
+    assert.strictEqual(typeof addedBytes, 'number')
+
+    // An entry point over the maximum size is split into a piece per module
+    const result2 = await esbuild.build({
+      entryPoints: [path.join(testDir, 'a.js')],
+      bundle: true,
+      outdir: path.join(testDir, 'out'),
+      absWorkingDir: testDir,
+      format: 'esm',
+      splitting: true,
+      maxChunkSize: 10,
+      metafile: true,
+      write: false,
+    })
+    const pieces = Object.values(result2.metafile.outputs)
+      .map(output => output.chunkSizeDecisions.map(({ kind, piece, pieceCount }) => ({ kind, piece, pieceCount })))
+      .sort((a, b) => a[0].piece - b[0].piece)
+    assert.deepStrictEqual(pieces, [
+      [{ kind: 'split', piece: 1, pieceCount: 3 }],
+      [{ kind: 'split', piece: 2, pieceCount: 3 }],
+      [{ kind: 'split', piece: 3, pieceCount: 3 }],
+    ])
   },
 
   async metafileCJSInFormatIIFE({ esbuild, testDir }) {
@@ -1178,11 +1234,11 @@ body {
     const makePath = pathname => path.relative(cwd, pathname).split(path.sep).join('/')
     const json = result.metafile
     assert.deepStrictEqual(json.inputs[makePath(entry)].imports, [
-      { path: makePath(nested1), kind: 'import-statement' },
-      { path: makePath(nested2), kind: 'import-statement' },
+      { path: makePath(nested1), kind: 'import-statement', uses: ['nested1'] },
+      { path: makePath(nested2), kind: 'import-statement', uses: ['*'] },
     ])
     assert.deepStrictEqual(json.inputs[makePath(nested1)].imports, [
-      { path: makePath(nested3), kind: 'import-statement' },
+      { path: makePath(nested3), kind: 'import-statement', uses: ['nested3'] },
     ])
     assert.deepStrictEqual(json.inputs[makePath(nested2)].imports, [])
     assert.deepStrictEqual(json.inputs[makePath(nested3)].imports, [])
@@ -1222,7 +1278,13 @@ body {
     // Check inputs
     assert.deepStrictEqual(json, {
       inputs: {
-        [makePath(entry)]: { bytes: 98, imports: [{ path: makePath(imported), kind: 'import-rule' }] },
+        [makePath(entry)]: {
+          bytes: 98,
+          imports: [
+            { path: makePath(imported), kind: 'import-rule' },
+            { path: 'https://example.com/external.png', kind: 'url-token', external: true },
+          ],
+        },
         [makePath(image)]: { bytes: 8, imports: [] },
         [makePath(imported)]: { bytes: 48, imports: [{ path: makePath(image), kind: 'url-token' }] },
       },
@@ -1233,7 +1295,7 @@ body {
           imports: [],
           inputs: {
             [makePath(entry)]: { bytesInOutput: 62 },
-            [makePath(imported)]: { bytesInOutput: 61 },
+            [makePath(imported)]: { bytesInOutput: 61, includedBy: [makePath(entry)] },
           },
         },
       },
@@ -2399,6 +2461,197 @@ require("/assets/file.png");
     assert.strictEqual(outputFiles[0].path, path.join(testDir, 'entry', 'out', 'MYINLEYF-1.js'))
     assert.strictEqual(outputFiles[1].path, path.join(testDir, 'entry', 'out', 'R2MEQS4G-2.js'))
   },
+
+  async sizeBudgetRaw({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.js')
+    await writeFileAsync(input, `console.log(${JSON.stringify('x'.repeat(100))})`)
+    try {
+      await esbuild.build({
+        entryPoints: [input],
+        outdir: path.join(testDir, 'out'),
+        absWorkingDir: testDir,
+        sizeBudgets: { '*.js': { raw: 50 } },
+        logLevel: 'silent',
+        write: false,
+      })
+      throw new Error('Expected build failure');
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || e.errors[0].text !==
+        'Output file "out/in.js" is 117b, which is over the size budget of 50b for "*.js"') {
+        throw e;
+      }
+      assert.deepStrictEqual(e.errors[0].notes.map(note => note.text), ['The input file "in.js" contributes 117b (100.0%)'])
+    }
+
+    // The output is under budget
+    const result = await esbuild.build({
+      entryPoints: [input],
+      outdir: path.join(testDir, 'out'),
+      absWorkingDir: testDir,
+      sizeBudgets: { '*.js': { raw: 200 } },
+      logLevel: 'silent',
+      write: false,
+    })
+    assert.strictEqual(result.errors.length, 0)
+    assert.strictEqual(result.warnings.length, 0)
+  },
+
+  async sizeBudgetGzip({ esbuild, testDir }) {
+    const compressible = path.join(testDir, 'compressible.js')
+    const random = path.join(testDir, 'random.js')
+    let text = ''
+    for (let i = 0; i < 200; i++) text += Math.random().toString(36).slice(2)
+    await writeFileAsync(compressible, `console.log(${JSON.stringify('x'.repeat(2000))})`)
+    await writeFileAsync(random, `console.log(${JSON.stringify(text)})`)
+    try {
+      await esbuild.build({
+        entryPoints: [compressible, random],
+        outdir: path.join(testDir, 'out'),
+        absWorkingDir: testDir,
+        sizeBudgets: { '*.js': { gzip: 500 } },
+        logLevel: 'silent',
+        write: false,
+      })
+      throw new Error('Expected build failure');
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || !e.errors[0].text.startsWith('Output file "out/random.js" is ') ||
+        !e.errors[0].text.endsWith(' after gzip compression, which is over the gzip size budget of 500b for "*.js"')) {
+        throw e;
+      }
+    }
+  },
+
+  async sizeBudgetWarnings({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.js')
+    await writeFileAsync(input, `console.log(${JSON.stringify('x'.repeat(100))})`)
+    const result = await esbuild.build({
+      entryPoints: [input],
+      outdir: path.join(testDir, 'out'),
+      absWorkingDir: testDir,
+      sizeBudgets: { '*.js': { raw: 50 } },
+      sizeBudgetWarnings: true,
+      logLevel: 'silent',
+      write: false,
+    })
+    assert.strictEqual(result.errors.length, 0)
+    assert.strictEqual(result.outputFiles.length, 1)
+    assert.deepStrictEqual(result.warnings.map(msg => msg.text), [
+      'Output file "out/in.js" is 117b, which is over the size budget of 50b for "*.js"',
+    ])
+  },
+
+  async sizeBudgetGlobRelativeToOutdir({ esbuild, testDir }) {
+    const a = path.join(testDir, 'a.js')
+    const b = path.join(testDir, 'sub', 'b.js')
+    await mkdirAsync(path.join(testDir, 'sub'))
+    await writeFileAsync(a, `console.log('a')`)
+    await writeFileAsync(b, `console.log('b')`)
+    const result = await esbuild.build({
+      entryPoints: [a, b],
+      outdir: path.join(testDir, 'out'),
+      absWorkingDir: testDir,
+      sizeBudgets: {
+        'sub/*.js': { raw: 1 }, // Matches "out/sub/b.js"
+        'out/*.js': { raw: 1 }, // Doesn't match since globs are relative to the output directory
+        './b.js': { raw: 1 }, // Doesn't match since "./" anchors to the output directory
+      },
+      sizeBudgetWarnings: true,
+      logLevel: 'silent',
+      write: false,
+    })
+    assert.deepStrictEqual(result.warnings.map(msg => msg.text), [
+      'Output file "out/sub/b.js" is 18b, which is over the size budget of 1b for "sub/*.js"',
+    ])
+  },
+
+  async sizeBudgetBrotli({ esbuild, testDir }) {
+    const compressible = path.join(testDir, 'compressible.js')
+    const random = path.join(testDir, 'random.js')
+    let text = ''
+    for (let i = 0; i < 200; i++) text += Math.random().toString(36).slice(2)
+    await writeFileAsync(compressible, `console.log(${JSON.stringify('x'.repeat(2000))})`)
+    await writeFileAsync(random, `console.log(${JSON.stringify(text)})`)
+    try {
+      await esbuild.build({
+        entryPoints: [compressible, random],
+        outdir: path.join(testDir, 'out'),
+        absWorkingDir: testDir,
+        sizeBudgets: { '*.js': { brotli: 500 } },
+        logLevel: 'silent',
+        write: false,
+      })
+      throw new Error('Expected build failure');
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || !e.errors[0].text.startsWith('Output file "out/random.js" is ') ||
+        !e.errors[0].text.endsWith(' after brotli compression, which is over the brotli size budget of 500b for "*.js"')) {
+        throw e;
+      }
+    }
+
+    // The brotli size is measured separately from the gzip size
+    const result = await esbuild.build({
+      entryPoints: [compressible],
+      outdir: path.join(testDir, 'out'),
+      absWorkingDir: testDir,
+      sizeBudgets: { '*.js': { gzip: 20, brotli: 40 } },
+      sizeBudgetWarnings: true,
+      logLevel: 'silent',
+      write: false,
+    })
+    assert.deepStrictEqual(result.warnings.map(msg => msg.text.replace(/ is \d+b after/, ' is Nb after')), [
+      'Output file "out/compressible.js" is Nb after gzip compression, which is over the gzip size budget of 20b for "*.js"',
+    ])
+  },
+
+  async manualChunkInvalidName({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.js')
+    await writeFileAsync(input, `console.log('in')`)
+    try {
+      await esbuild.build({
+        entryPoints: [input],
+        outdir: path.join(testDir, 'out'),
+        bundle: true,
+        splitting: true,
+        format: 'esm',
+        manualChunks: { 'a/b': ['in.js'] },
+        logLevel: 'silent',
+        write: false,
+      })
+      throw new Error('Expected build failure');
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || e.errors[0].id !== 'invalid-manual-chunk-name' || e.errors[0].text !== 'Invalid manual chunk name: "a/b"') {
+        throw e;
+      }
+    }
+  },
+
+  async manualChunkCycle({ esbuild, testDir }) {
+    const a = path.join(testDir, 'a.js')
+    const b = path.join(testDir, 'b.js')
+    const lib = path.join(testDir, 'lib.js')
+    await writeFileAsync(a, `import {lib} from "./lib.js"; console.log(lib)`)
+    await writeFileAsync(b, `import {lib} from "./lib.js"; export let b = lib`)
+    await writeFileAsync(lib, `import {b} from "./b.js"; export function lib() { return b }`)
+    try {
+      await esbuild.build({
+        entryPoints: [a, b],
+        outdir: path.join(testDir, 'out'),
+        bundle: true,
+        splitting: true,
+        format: 'esm',
+        manualChunks: { lib: ['./lib.js'] },
+        absWorkingDir: testDir,
+        logLevel: 'silent',
+        write: false,
+      })
+      throw new Error('Expected build failure');
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || e.errors[0].id !== 'manual-chunk-cycle' ||
+        e.errors[0].text !== 'The manual chunk "lib" cannot be generated because it is part of a circular import between chunks') {
+        throw e;
+      }
+    }
+  },
 }
 
 function fetch(host, port, path) {
@@ -3797,6 +4050,54 @@ let transformTests = {
     }
   },
 
+  async logOverridePromote({ esbuild }) {
+    try {
+      await esbuild.transform(`x == NaN`, { logOverride: { 'equals-nan': 'error' } })
+      throw new Error('Expected a transform failure')
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || e.errors[0].text !== 'Comparison with NaN using the "==" operator here is always false') {
+        throw e
+      }
+    }
+  },
+
+  async logOverrideDemote({ esbuild }) {
+    const { warnings } = await esbuild.transform(`x == NaN`, { logOverride: { 'equals-nan': 'verbose' } })
+    assert.deepStrictEqual(warnings, [])
+  },
+
+  async logOverrideSilence({ esbuild }) {
+    const { warnings } = await esbuild.transform(`x == NaN; y == NaN`, { logOverride: { 'equals-nan': 'silent' } })
+    assert.deepStrictEqual(warnings, [])
+  },
+
+  async logOverrideOtherMessages({ esbuild }) {
+    const { warnings } = await esbuild.transform(`x == NaN; x == -0`, { logOverride: { 'equals-nan': 'silent' } })
+    assert.deepStrictEqual(warnings.map(msg => msg.text), ['Comparison with -0 using the "==" operator will also match 0'])
+  },
+
+  async logOverrideCannotDemoteErrors({ esbuild }) {
+    try {
+      await esbuild.transform(`x ==`, { logOverride: { 'js-syntax-error': 'silent' } })
+      throw new Error('Expected a transform failure')
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || e.errors[0].text !== 'Unexpected end of file') {
+        throw e
+      }
+    }
+  },
+
+  async logOverrideUnknownID({ esbuild }) {
+    try {
+      await esbuild.transform(`x == NaN`, { logOverride: { 'not-a-real-id': 'error', 'equals-nan': 'silent' } })
+      throw new Error('Expected a transform failure')
+    } catch (e) {
+      if (!e.errors || e.errors.length !== 1 || e.errors[0].text !== 'Invalid log override: "not-a-real-id" is not a known message ID') {
+        throw e
+      }
+    }
+  },
+
   // Future syntax
   forAwait: ({ esbuild }) => futureSyntax(esbuild, 'async function foo() { for await (let x of y) {} }', 'es2017', 'es2018'),
   bigInt: ({ esbuild }) => futureSyntax(esbuild, '123n', 'es2019', 'es2020'),
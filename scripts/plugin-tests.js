@@ -1227,6 +1227,7 @@ let pluginTests = {
     } catch (e) {
       assert.deepStrictEqual(e.warnings, [])
       assert.deepStrictEqual(e.errors, [{
+        id: 'js-syntax-error',
         pluginName: '',
         text: 'Expected ";" but found "y"',
         location: {
@@ -1247,6 +1248,7 @@ let pluginTests = {
   async transformUndefinedDetailForWarning({ esbuild }) {
     const result = await esbuild.transform('typeof x == "null"')
     assert.deepStrictEqual(result.warnings, [{
+      id: 'impossible-typeof',
       pluginName: '',
       text: 'The "typeof" operator will never evaluate to "null"',
       location: {
@@ -1274,6 +1276,7 @@ let pluginTests = {
     } catch (e) {
       assert.deepStrictEqual(e.warnings, [])
       assert.deepStrictEqual(e.errors, [{
+        id: 'js-syntax-error',
         pluginName: '',
         text: 'Expected ";" but found "y"',
         location: {
@@ -1298,6 +1301,7 @@ let pluginTests = {
       logLevel: 'silent',
     })
     assert.deepStrictEqual(result.warnings, [{
+      id: 'impossible-typeof',
       pluginName: '',
       text: 'The "typeof" operator will never evaluate to "null"',
       location: {
@@ -1414,6 +1418,7 @@ let pluginTests = {
       assert.strictEqual(e.warnings.length, 0)
       assert.strictEqual(e.errors.length, 1)
       assert.deepStrictEqual(e.errors[0], {
+        id: '',
         pluginName: 'the-plugin',
         text: 'some error',
         location: {
@@ -1487,6 +1492,7 @@ let pluginTests = {
     })
     assert.strictEqual(result.warnings.length, 1)
     assert.deepStrictEqual(result.warnings[0], {
+      id: '',
       pluginName: 'other-plugin',
       text: 'some warning',
       location: {
@@ -1562,6 +1568,7 @@ let pluginTests = {
       assert.strictEqual(e.warnings.length, 0)
       assert.strictEqual(e.errors.length, 1)
       assert.deepStrictEqual(e.errors[0], {
+        id: '',
         pluginName: 'the-plugin',
         text: 'some error',
         location: {
@@ -1634,6 +1641,7 @@ let pluginTests = {
     })
     assert.strictEqual(result.warnings.length, 1)
     assert.deepStrictEqual(result.warnings[0], {
+      id: '',
       pluginName: 'the-plugin',
       text: 'some warning',
       location: {